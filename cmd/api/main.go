@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Timezone names must resolve in minimal containers
 
 	"github.com/yourusername/todo-api/internal/config"
+	"github.com/yourusername/todo-api/internal/db"
+	"github.com/yourusername/todo-api/internal/repository"
 	"github.com/yourusername/todo-api/internal/router"
 )
 
@@ -19,6 +23,27 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Connect to the database, waiting for it to become ready
+	log.Printf("Connecting to %s using the %s driver", cfg.DBConfig.MaskConnString(), cfg.DBConfig.Driver)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBConfig.ConnectTimeout)
+	if cfg.DBConfig.Driver == config.DriverPgx {
+		cfg.Pool, err = config.ConnectPoolWithRetry(ctx, cfg.DBConfig, cfg.DBConfig.ConnectAttempts, cfg.DBConfig.ConnectBackoff)
+	} else {
+		cfg.DB, err = config.ConnectWithRetry(ctx, cfg.DBConfig, cfg.DBConfig.ConnectAttempts, cfg.DBConfig.ConnectBackoff)
+	}
+	cancel()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// pgx prepares and caches statements itself; lib/pq needs them up front
+	if cfg.DB != nil {
+		cfg.Statements, err = db.Prepare(context.Background(), cfg.DB, repository.PreparedQueries()...)
+		if err != nil {
+			log.Printf("Failed to prepare statements, running queries unprepared: %v", err)
+		}
+	}
+
 	// Initialize router
 	r := router.SetupRouter(cfg)
 
@@ -44,4 +69,17 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+
+	// Let in-flight requests finish before closing the statements they use
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
+
+	if cfg.Statements != nil {
+		if err := cfg.Statements.Close(); err != nil {
+			log.Printf("Failed to close prepared statements: %v", err)
+		}
+	}
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/yourusername/todo-api/internal/config"
+	"github.com/yourusername/todo-api/internal/migrate"
+)
+
+const usage = `Usage: migrate [-dir migrations] <command>
+
+Commands:
+  up             apply all pending migrations
+  down N         roll back the last N migrations
+  status         list migrations and whether they are applied
+  create <name>  add a new numbered up/down migration pair
+`
+
+func main() {
+	dir := flag.String("dir", "migrations", "directory containing the migration scripts")
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// create only touches the filesystem, so it works without a database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		upPath, downPath, err := migrate.Create(*dir, args[1])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Printf("Created %s\nCreated %s\n", upPath, downPath)
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	log.Printf("Connecting to %s", cfg.DBConfig.MaskConnString())
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBConfig.ConnectTimeout)
+	db, err := config.ConnectWithRetry(ctx, cfg.DBConfig, cfg.DBConfig.ConnectAttempts, cfg.DBConfig.ConnectBackoff)
+	cancel()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	migrator := migrate.NewMigrator(db, *dir)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("Applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+
+	case "down":
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid number of migrations to roll back: %q", args[1])
+		}
+		rolledBack, err := migrator.Down(n)
+		for _, m := range rolledBack {
+			fmt.Printf("Rolled back %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MIGRATION\tHASH\tSTATUS")
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Changed {
+				state += " (changed since applied)"
+			}
+			fmt.Fprintf(w, "%03d_%s\t%s\t%s\n", s.Version, s.Name, s.Hash[:12], state)
+		}
+		w.Flush()
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -1,26 +1,128 @@
 package config
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/yourusername/todo-api/internal/db"
+)
+
+// Supported values for DB_DRIVER
+const (
+	DriverPQ  = "pq"
+	DriverPgx = "pgx"
 )
 
 type Config struct {
 	Port     string
 	DB       *sql.DB
+	Pool     *pgxpool.Pool
 	DBConfig DBConfig
+
+	// Statements are the repository queries prepared on DB at startup
+	Statements *db.PreparedStatements
+
+	// MaxTodosPerUser caps the number of todos that can be created. The
+	// API has a single implicit user, so this applies to the whole list.
+	MaxTodosPerUser int
+
+	// ReadOnly rejects every write request and does not start the
+	// background workers that write, e.g. during maintenance
+	ReadOnly bool
+
+	// CORSAllowedOrigins are the browser origins allowed to call the API;
+	// "*" allows any origin
+	CORSAllowedOrigins []string
+
+	// LogSlowQueries logs a warning for repository calls slower than
+	// SlowQueryThreshold
+	LogSlowQueries     bool
+	SlowQueryThreshold time.Duration
+
+	// LogRequests logs every request with its headers and JSON body, with
+	// sensitive fields redacted
+	LogRequests bool
+
+	// ServerTiming sends a Server-Timing header with database and
+	// serialization times on the busiest reads
+	ServerTiming bool
+
+	// AdminToken is the bearer token the admin routes require. They are
+	// turned off while it is empty.
+	AdminToken string
+
+	Notify NotifyConfig
+}
+
+// NotifyConfig configures outgoing email and Slack notifications
+type NotifyConfig struct {
+	// Overdue enables the overdue todo reminder worker
+	Overdue bool
+	// OverdueInterval is how often the worker checks for overdue todos
+	OverdueInterval time.Duration
+	// DueSoon enables the due-soon todo reminder worker
+	DueSoon bool
+	// DueSoonInterval is how often the worker checks for todos due soon
+	DueSoonInterval time.Duration
+	// DueSoonWindow is how far ahead of its due date a todo is reminded
+	// about
+	DueSoonWindow time.Duration
+	// To is the address reminders are sent to
+	To string
+
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+
+	// SlackWebhookURL is a Slack incoming webhook reminders are posted to
+	SlackWebhookURL string
+	// PublicURL is the externally visible address of the web UI, used for
+	// links in notifications
+	PublicURL string
+}
+
+// EmailEnabled reports whether reminders should be sent by email
+func (c NotifyConfig) EmailEnabled() bool {
+	return c.To != ""
+}
+
+// SlackEnabled reports whether reminders should be posted to Slack
+func (c NotifyConfig) SlackEnabled() bool {
+	return c.SlackWebhookURL != ""
 }
 
 type DBConfig struct {
+	Driver   string
 	Host     string
 	Port     string
 	User     string
 	Password string
 	DBName   string
+
+	// SSLMode is the libpq sslmode, e.g. "disable", "require" or "verify-full"
+	SSLMode string
+	// SSLRootCert is the path to the CA certificate used to verify the server
+	SSLRootCert string
+
+	// ConnectAttempts is the number of times to try connecting at startup
+	ConnectAttempts int
+	// ConnectBackoff is the wait between connection attempts
+	ConnectBackoff time.Duration
+	// ConnectTimeout is the overall deadline for connecting at startup
+	ConnectTimeout time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -36,44 +138,184 @@ func Load() (*Config, error) {
 
 	// Database configuration
 	dbConfig := DBConfig{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres"),
-		DBName:   getEnv("DB_NAME", "todo_db"),
+		Driver:          getEnv("DB_DRIVER", DriverPQ),
+		Host:            getEnv("DB_HOST", "localhost"),
+		Port:            getEnv("DB_PORT", "5432"),
+		User:            getEnv("DB_USER", "postgres"),
+		Password:        getEnv("DB_PASSWORD", "postgres"),
+		DBName:          getEnv("DB_NAME", "todo_db"),
+		SSLMode:         getEnv("DB_SSLMODE", "disable"),
+		SSLRootCert:     os.Getenv("DB_SSLROOTCERT"),
+		ConnectAttempts: getEnvInt("DB_CONNECT_ATTEMPTS", 10),
+		ConnectBackoff:  getEnvDuration("DB_CONNECT_BACKOFF", 2*time.Second),
+		ConnectTimeout:  getEnvDuration("DB_CONNECT_TIMEOUT", 60*time.Second),
 	}
 
-	// Connect to database
-	db, err := connectDB(dbConfig)
-	if err != nil {
-		return nil, err
+	if dbConfig.Driver != DriverPQ && dbConfig.Driver != DriverPgx {
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q (expected %q or %q)", dbConfig.Driver, DriverPQ, DriverPgx)
+	}
+
+	notifyConfig := NotifyConfig{
+		Overdue:         getEnvBool("NOTIFY_OVERDUE", false),
+		OverdueInterval: getEnvDuration("NOTIFY_OVERDUE_INTERVAL", 15*time.Minute),
+		DueSoon:         getEnvBool("NOTIFY_DUE_SOON", false),
+		DueSoonInterval: getEnvDuration("NOTIFY_DUE_SOON_INTERVAL", time.Minute),
+		DueSoonWindow:   getEnvDuration("NOTIFY_DUE_SOON_WINDOW", 5*time.Minute),
+		To:              os.Getenv("NOTIFY_EMAIL_TO"),
+		From:            getEnv("SMTP_FROM", "todo-api@localhost"),
+		SMTPHost:        os.Getenv("SMTP_HOST"),
+		SMTPPort:        getEnv("SMTP_PORT", "587"),
+		SMTPUser:        os.Getenv("SMTP_USER"),
+		SMTPPassword:    os.Getenv("SMTP_PASSWORD"),
+		SlackWebhookURL: os.Getenv("SLACK_WEBHOOK_URL"),
+		PublicURL:       getEnv("PUBLIC_URL", "http://localhost:"+port),
+	}
+
+	if notifyConfig.Overdue || notifyConfig.DueSoon {
+		if !notifyConfig.EmailEnabled() && !notifyConfig.SlackEnabled() {
+			return nil, fmt.Errorf("NOTIFY_OVERDUE and NOTIFY_DUE_SOON require NOTIFY_EMAIL_TO or SLACK_WEBHOOK_URL")
+		}
+		if notifyConfig.EmailEnabled() && notifyConfig.SMTPHost == "" {
+			return nil, fmt.Errorf("NOTIFY_EMAIL_TO requires SMTP_HOST")
+		}
 	}
 
 	return &Config{
-		Port:     port,
-		DB:       db,
-		DBConfig: dbConfig,
+		Port:               port,
+		DBConfig:           dbConfig,
+		MaxTodosPerUser:    getEnvInt("TODO_MAX_PER_USER", 10000),
+		ReadOnly:           getEnvBool("TODO_READ_ONLY", false),
+		CORSAllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		LogSlowQueries:     getEnvBool("LOG_SLOW_QUERIES", false),
+		SlowQueryThreshold: getEnvDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		LogRequests:        getEnvBool("LOG_REQUESTS", false),
+		ServerTiming:       getEnvBool("SERVER_TIMING", false),
+		AdminToken:         os.Getenv("TODO_ADMIN_TOKEN"),
+		Notify:             notifyConfig,
 	}, nil
 }
 
-// connectDB establishes a connection to the database
-func connectDB(config DBConfig) (*sql.DB, error) {
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		config.Host, config.Port, config.User, config.Password, config.DBName)
+// ConnectWithRetry connects to the database, retrying until it is reachable,
+// maxAttempts is exhausted or ctx is done
+func ConnectWithRetry(ctx context.Context, cfg DBConfig, maxAttempts int, backoff time.Duration) (*sql.DB, error) {
+	var db *sql.DB
+	err := retry(ctx, maxAttempts, backoff, func() error {
+		var err error
+		db, err = connectDB(ctx, cfg)
+		return err
+	})
+	return db, err
+}
+
+// ConnectPoolWithRetry creates a pgx connection pool, retrying until the
+// database is reachable, maxAttempts is exhausted or ctx is done
+func ConnectPoolWithRetry(ctx context.Context, cfg DBConfig, maxAttempts int, backoff time.Duration) (*pgxpool.Pool, error) {
+	var pool *pgxpool.Pool
+	err := retry(ctx, maxAttempts, backoff, func() error {
+		var err error
+		pool, err = connectPool(ctx, cfg)
+		return err
+	})
+	return pool, err
+}
+
+// retry calls connect until it succeeds, logging each failed attempt. It
+// always makes at least one attempt, even if maxAttempts is below 1.
+func retry(ctx context.Context, maxAttempts int, backoff time.Duration, connect func() error) error {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
 
-	db, err := sql.Open("postgres", psqlInfo)
+	var err error
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = connect()
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Database connection attempt %d/%d failed: %v", attempt, maxAttempts, err)
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connecting to database: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+
+	return fmt.Errorf("connecting to database after %d attempts: %w", maxAttempts, err)
+}
+
+// connectDB establishes a connection to the database
+func connectDB(ctx context.Context, config DBConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", config.ConnString())
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping()
+	err = db.PingContext(ctx)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
 
+// connectPool establishes a pgx connection pool to the database
+func connectPool(ctx context.Context, config DBConfig) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, config.ConnString())
+	if err != nil {
+		return nil, err
+	}
+
+	err = pool.Ping(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// ConnString builds a postgres:// connection URL understood by both
+// drivers. The user name and password are escaped, so they may contain any
+// character.
+func (c DBConfig) ConnString() string {
+	return c.connURL(url.UserPassword(c.User, c.Password))
+}
+
+// MaskConnString returns ConnString with the password replaced by "***",
+// for use in logs
+func (c DBConfig) MaskConnString() string {
+	// url.UserPassword would escape the asterisks, so splice them in after
+	// the (already escaped) user name
+	return strings.Replace(c.connURL(url.User(c.User)), "@", ":***@", 1)
+}
+
+// connURL builds the connection URL with the given credentials
+func (c DBConfig) connURL(user *url.Userinfo) string {
+	query := url.Values{}
+	query.Set("sslmode", c.SSLMode)
+	if c.SSLRootCert != "" {
+		query.Set("sslrootcert", c.SSLRootCert)
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     user,
+		Host:     net.JoinHostPort(c.Host, c.Port),
+		Path:     "/" + c.DBName,
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
+}
+
 // getEnv gets the value of an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -82,3 +324,47 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInt gets an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool gets a boolean environment variable or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList gets a comma-separated environment variable or returns a
+// default value
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "2s") or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
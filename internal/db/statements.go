@@ -0,0 +1,54 @@
+// Package db prepares SQL statements once at startup, so Postgres does not
+// parse and plan them again on every request
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// PreparedStatements holds statements prepared on a connection pool, keyed
+// by their SQL text
+type PreparedStatements struct {
+	stmts map[string]*sql.Stmt
+}
+
+// Prepare prepares each of queries on conn. If any fails to prepare, the
+// ones already prepared are closed and the error is returned.
+func Prepare(ctx context.Context, conn *sql.DB, queries ...string) (*PreparedStatements, error) {
+	p := &PreparedStatements{stmts: make(map[string]*sql.Stmt, len(queries))}
+
+	for _, query := range queries {
+		stmt, err := conn.PrepareContext(ctx, query)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.stmts[query] = stmt
+	}
+
+	return p, nil
+}
+
+// Lookup returns the statement prepared for query, or nil if it was not
+// prepared. It is safe to call on a nil *PreparedStatements.
+func (p *PreparedStatements) Lookup(query string) *sql.Stmt {
+	if p == nil {
+		return nil
+	}
+	return p.stmts[query]
+}
+
+// Close closes every prepared statement. Call it once no more queries will
+// run, e.g. after the HTTP server has shut down.
+func (p *PreparedStatements) Close() error {
+	var errs []error
+	for query, stmt := range p.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(p.stmts, query)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// defaultVacuumDays is how old completed todos must be to be archived when
+// ?older_than_days= is not given
+const defaultVacuumDays = 365
+
+// VacuumTodos handles POST /admin/vacuum, archiving old completed todos.
+// They are moved in batches of repository.VacuumBatchSize, each in its own
+// transaction rather than one for the whole run.
+func (h *TodoHandler) VacuumTodos(w http.ResponseWriter, r *http.Request) {
+	days := defaultVacuumDays
+	if raw := r.URL.Query().Get("older_than_days"); raw != "" {
+		var err error
+		days, err = strconv.Atoi(raw)
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.param.invalid", "older_than_days")
+			return
+		}
+	}
+
+	archived, err := h.service.VacuumCompleted(days)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]int64{"archived": archived})
+}
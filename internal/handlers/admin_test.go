@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestVacuumTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	longAgo := time.Now().AddDate(-2, 0, 0)
+	old, _ := repo.Create(&models.CreateTodoRequest{Title: "Old", Priority: models.PriorityMedium, Completed: true, CompletedAt: &longAgo})
+	recent := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+	open := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/admin/vacuum?older_than_days=365", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var got map[string]int64
+	testhelpers.DecodeJSON(t, resp, &got)
+	if got["archived"] != 1 {
+		t.Errorf("archived = %d, want 1", got["archived"])
+	}
+
+	if repo.Archived(old.ID) == nil {
+		t.Errorf("todo %d was not archived", old.ID)
+	}
+
+	// A vacuumed todo was archived, not deleted, so it is not gone
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d", old.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+
+	for _, todo := range []*models.Todo{recent, open} {
+		if kept, _ := repo.GetByID(todo.ID); kept == nil {
+			t.Errorf("todo %d was removed, want it kept", todo.ID)
+		}
+	}
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/admin/vacuum?older_than_days=0", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
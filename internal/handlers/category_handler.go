@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/service"
+)
+
+// CategoryHandler handles HTTP requests for category operations
+type CategoryHandler struct {
+	service *service.TodoService
+}
+
+// NewCategoryHandler creates a new CategoryHandler
+func NewCategoryHandler(service *service.TodoService) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+	}
+}
+
+// GetAllCategories handles GET /categories
+func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.ListCategories()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, categories)
+}
+
+// GetCategoryTree handles GET /categories/tree
+func (h *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.CategoryTree()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tree)
+}
+
+// CreateCategory handles POST /categories
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	category, err := h.service.CreateCategory(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, category)
+}
+
+// DuplicateCategory handles POST /categories/{id}/duplicate
+func (h *CategoryHandler) DuplicateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.category_id.invalid")
+		return
+	}
+
+	category, err := h.service.DuplicateCategory(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, category)
+}
+
+// MoveTodos handles POST /categories/{id}/todos/move
+func (h *CategoryHandler) MoveTodos(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.category_id.invalid")
+		return
+	}
+
+	h.moveTodos(w, r, &id)
+}
+
+// CompleteAllTodos handles POST /categories/{id}/complete-all
+func (h *CategoryHandler) CompleteAllTodos(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.category_id.invalid")
+		return
+	}
+
+	result, err := h.service.CompleteAllInCategory(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// UncategorizeTodos handles POST /categories/uncategorized/todos/move
+func (h *CategoryHandler) UncategorizeTodos(w http.ResponseWriter, r *http.Request) {
+	h.moveTodos(w, r, nil)
+}
+
+// moveTodos moves the todos listed in the request body into categoryID
+func (h *CategoryHandler) moveTodos(w http.ResponseWriter, r *http.Request, categoryID *int64) {
+	var req models.MoveTodosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	result, err := h.service.MoveTodos(categoryID, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,246 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestMoveTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	first := testhelpers.InsertTodo(t, repo)
+	second := testhelpers.InsertTodo(t, repo)
+
+	category, err := repo.CreateCategory("Work", nil)
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/categories/1/todos/move", models.MoveTodosRequest{
+		TodoIDs: []int64{first.ID, second.ID, 999},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var result models.MoveTodosResult
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Moved != 2 {
+		t.Errorf("moved = %d, want 2", result.Moved)
+	}
+
+	moved, _ := repo.GetByID(first.ID)
+	if moved.CategoryID == nil || *moved.CategoryID != category.ID {
+		t.Errorf("category_id = %v, want %d", moved.CategoryID, category.ID)
+	}
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/categories/uncategorized/todos/move", models.MoveTodosRequest{
+		TodoIDs: []int64{first.ID},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	moved, _ = repo.GetByID(first.ID)
+	if moved.CategoryID != nil {
+		t.Errorf("category_id = %d, want null", *moved.CategoryID)
+	}
+}
+
+func TestMoveTodosUnknownCategory(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/categories/42/todos/move", models.MoveTodosRequest{
+		TodoIDs: []int64{todo.ID},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestCompleteAllTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	sprint, err := repo.CreateCategory("Sprint 12", nil)
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+	open := testhelpers.InsertTodo(t, repo)
+	done := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+	blocked := testhelpers.InsertTodo(t, repo)
+	other := testhelpers.InsertTodo(t, repo)
+	archived := testhelpers.InsertTodo(t, repo)
+	if _, err := repo.MoveTodos([]int64{open.ID, done.ID, blocked.ID, archived.ID}, &sprint.ID); err != nil {
+		t.Fatalf("Failed to move todos: %v", err)
+	}
+	if err := repo.AddDependency(blocked.ID, other.ID); err != nil {
+		t.Fatalf("Failed to add dependency: %v", err)
+	}
+	if _, _, err := repo.SetArchived([]int64{archived.ID}, true); err != nil {
+		t.Fatalf("Failed to archive todo: %v", err)
+	}
+
+	resp := testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/categories/%d/complete-all", sprint.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var result models.CompleteAllResult
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Completed != 1 {
+		t.Errorf("completed = %d, want 1", result.Completed)
+	}
+	if len(result.Blocked) != 1 || result.Blocked[0] != blocked.ID {
+		t.Errorf("blocked = %v, want [%d]", result.Blocked, blocked.ID)
+	}
+
+	if completed, _ := repo.GetByID(open.ID); !completed.Completed || completed.CompletedAt == nil {
+		t.Errorf("todo in the category = %+v, want it completed", completed)
+	}
+	if untouched, _ := repo.GetByID(other.ID); untouched.Completed {
+		t.Error("todo outside the category was completed")
+	}
+	if untouched, _ := repo.GetByID(archived.ID); untouched.Completed {
+		t.Error("archived todo was completed")
+	}
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/categories/42/complete-all", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestCategoryTree(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	var parent models.Category
+	resp := testhelpers.MustPost(t, srv, "/api/v1/categories", models.CreateCategoryRequest{Name: "Home"})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+	testhelpers.DecodeJSON(t, resp, &parent)
+
+	// Nest down to the maximum depth, then one level too far
+	id := parent.ID
+	for depth := 2; depth <= models.MaxCategoryDepth+1; depth++ {
+		resp = testhelpers.MustPost(t, srv, "/api/v1/categories", models.CreateCategoryRequest{Name: "Level", ParentID: &id})
+		if depth > models.MaxCategoryDepth {
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+			break
+		}
+		testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+		var child models.Category
+		testhelpers.DecodeJSON(t, resp, &child)
+		id = child.ID
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/categories/tree")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var tree []*models.CategoryNode
+	testhelpers.DecodeJSON(t, resp, &tree)
+
+	if len(tree) != 1 || tree[0].ID != parent.ID {
+		t.Fatalf("tree roots = %+v, want only %d", tree, parent.ID)
+	}
+
+	depth := 0
+	for node := tree[0]; node != nil; depth++ {
+		if len(node.Children) == 0 {
+			node = nil
+		} else {
+			node = node.Children[0]
+		}
+	}
+	if depth != models.MaxCategoryDepth {
+		t.Errorf("tree depth = %d, want %d", depth, models.MaxCategoryDepth)
+	}
+}
+
+func TestGetAllTodosIncludesDescendantCategories(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	parent, _ := repo.CreateCategory("Work", nil)
+	child, _ := repo.CreateCategory("Reports", &parent.ID)
+
+	inChild := testhelpers.InsertTodo(t, repo)
+	if _, err := repo.MoveTodos([]int64{inChild.ID}, &child.ID); err != nil {
+		t.Fatalf("Failed to move todo: %v", err)
+	}
+	testhelpers.InsertTodo(t, repo)
+
+	var list models.ListResponse[models.Todo]
+	resp := testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos?category_id=%d", parent.ID))
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+	if len(todos) != 0 {
+		t.Errorf("without descendants got %d todos, want 0", len(todos))
+	}
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos?category_id=%d&include_descendants=true", parent.ID))
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos = list.Data
+	if len(todos) != 1 || todos[0].ID != inChild.ID {
+		t.Errorf("with descendants got %+v, want only todo %d", todos, inChild.ID)
+	}
+}
+
+func TestDuplicateCategory(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	category, err := repo.CreateCategory("Weekly sprint", nil)
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+
+	planning := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Sprint planning"), testhelpers.WithPriority(models.PriorityHigh), testhelpers.WithCompleted(true))
+	retro := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Retrospective"))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Not in the sprint"))
+	if _, err := repo.MoveTodos([]int64{planning.ID, retro.ID}, &category.ID); err != nil {
+		t.Fatalf("Failed to move todos: %v", err)
+	}
+	if _, err := repo.SetTags(planning.ID, []string{"meeting"}); err != nil {
+		t.Fatalf("Failed to tag todo: %v", err)
+	}
+
+	resp := testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/categories/%d/duplicate", category.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var copied models.CategoryWithCount
+	testhelpers.DecodeJSON(t, resp, &copied)
+
+	if copied.ID == category.ID || copied.Name != "Copy of Weekly sprint" {
+		t.Errorf("copy = %+v, want a new category named %q", copied, "Copy of Weekly sprint")
+	}
+	if copied.TodoCount != 2 {
+		t.Errorf("todo_count = %d, want 2", copied.TodoCount)
+	}
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos?category_id=%d", copied.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+	if len(todos) != 2 {
+		t.Fatalf("copied category has %d todos, want 2", len(todos))
+	}
+	for _, todo := range todos {
+		if todo.ID == planning.ID || todo.ID == retro.ID {
+			t.Errorf("todo %d was moved instead of copied", todo.ID)
+		}
+		if todo.Completed {
+			t.Errorf("copied todo %q is completed, want incomplete", todo.Title)
+		}
+		if todo.Title == "Sprint planning" && (todo.Priority != models.PriorityHigh || len(todo.Tags) != 1 || todo.Tags[0] != "meeting") {
+			t.Errorf("copied todo = %+v, want priority and tags preserved", todo)
+		}
+	}
+}
+
+func TestDuplicateCategoryNotFound(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/categories/42/duplicate", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// listCacheMaxAge is how long clients may reuse a GET /todos response
+const listCacheMaxAge = 10 * time.Second
+
+// setCacheHeaders lets clients cache the response for maxAge. Private
+// responses may only be kept by the client itself, not shared caches, and
+// vary by Authorization. Every response varies by Accept, which can ask for
+// timestamps in the user's timezone.
+func setCacheHeaders(w http.ResponseWriter, private bool, maxAge time.Duration) {
+	scope := "public"
+	if private {
+		scope = "private"
+		w.Header().Add("Vary", "Authorization")
+	}
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int(maxAge.Seconds())))
+}
+
+// checkNotModified sets Last-Modified from modified, and ETag to etag unless
+// it is empty, and reports whether the request's If-None-Match or
+// If-Modified-Since shows the client already has this version. If-None-Match
+// takes precedence, as in RFC 9110. When it returns true a 304 has been
+// written and the caller must not write a body.
+func checkNotModified(w http.ResponseWriter, r *http.Request, modified time.Time, etag string) bool {
+	if !modified.IsZero() {
+		// HTTP dates only carry whole seconds
+		modified = modified.UTC().Truncate(time.Second)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+		if match := r.Header.Get("If-None-Match"); match != "" {
+			if !etagMatches(match, etag) {
+				return false
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	if modified.IsZero() {
+		return false
+	}
+
+	raw := r.Header.Get("If-Modified-Since")
+	if raw == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(raw)
+	if err != nil {
+		return false
+	}
+
+	if modified.After(since) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether the If-None-Match header value lists etag, or
+// is "*". Tags are compared weakly, ignoring any W/ prefix.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// GetDependencies handles GET /todos/{id}/dependencies
+func (h *TodoHandler) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	blockers, err := h.service.Dependencies(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, blockers)
+}
+
+// AddDependency handles POST /todos/{id}/dependencies. It responds with the
+// todos the todo depends on, including the one just added.
+func (h *TodoHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	var req models.AddDependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	blockers, err := h.service.AddDependency(id, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, blockers)
+}
+
+// RemoveDependency handles DELETE /todos/{id}/dependencies/{blocking_id}
+func (h *TodoHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+	blockingID, err := strconv.ParseInt(vars["blocking_id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.blocking_todo_id.invalid")
+		return
+	}
+
+	if err := h.service.RemoveDependency(id, blockingID); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestTodoDependencies(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Ship release"))
+	tests := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Run tests"))
+	review := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Review"), testhelpers.WithCompleted(true))
+	path := fmt.Sprintf("/api/v1/todos/%d/dependencies", todo.ID)
+
+	for _, blocking := range []int64{tests.ID, review.ID, tests.ID} {
+		resp := testhelpers.MustPost(t, srv, path, models.AddDependencyRequest{BlockingTodoID: blocking})
+		testhelpers.AssertStatus(t, resp, http.StatusCreated)
+	}
+
+	resp := testhelpers.MustGet(t, srv, path)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var blockers []models.Blocker
+	testhelpers.DecodeJSON(t, resp, &blockers)
+	want := []models.Blocker{{ID: tests.ID, Title: "Run tests"}, {ID: review.ID, Title: "Review", Completed: true}}
+	if len(blockers) != 2 || blockers[0] != want[0] || blockers[1] != want[1] {
+		t.Errorf("blockers = %+v, want %+v", blockers, want)
+	}
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d?expand=dependencies", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var expanded struct {
+		ID        int64             `json:"id"`
+		BlockedBy []models.Blocker  `json:"blocked_by"`
+		Reactions []models.Reaction `json:"reactions"`
+	}
+	testhelpers.DecodeJSON(t, resp, &expanded)
+	if expanded.ID != todo.ID || len(expanded.BlockedBy) != 2 || expanded.Reactions != nil {
+		t.Errorf("expanded todo = %+v, want todo %d blocked by 2 todos and no reactions", expanded, todo.ID)
+	}
+
+	resp = testhelpers.MustDelete(t, srv, fmt.Sprintf("%s/%d", path, tests.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusNoContent)
+
+	resp = testhelpers.MustGet(t, srv, path)
+	testhelpers.DecodeJSON(t, resp, &blockers)
+	if len(blockers) != 1 || blockers[0] != want[1] {
+		t.Errorf("blockers after delete = %+v, want [%+v]", blockers, want[1])
+	}
+}
+
+func TestTodoDependenciesValidation(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	first := testhelpers.InsertTodo(t, repo)
+	second := testhelpers.InsertTodo(t, repo)
+	third := testhelpers.InsertTodo(t, repo)
+
+	add := func(todoID, blockingID int64) *http.Response {
+		return testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/dependencies", todoID), models.AddDependencyRequest{BlockingTodoID: blockingID})
+	}
+
+	testhelpers.AssertStatus(t, add(first.ID, first.ID), http.StatusBadRequest)
+	testhelpers.AssertStatus(t, add(first.ID, 0), http.StatusBadRequest)
+	testhelpers.AssertStatus(t, add(first.ID, 999), http.StatusNotFound)
+	testhelpers.AssertStatus(t, add(999, first.ID), http.StatusNotFound)
+
+	// first waits on second, which waits on third: third cannot wait on first
+	testhelpers.AssertStatus(t, add(first.ID, second.ID), http.StatusCreated)
+	testhelpers.AssertStatus(t, add(second.ID, third.ID), http.StatusCreated)
+	testhelpers.AssertStatus(t, add(third.ID, first.ID), http.StatusConflict)
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/999/dependencies")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestBlockedTodoCannotBeCompleted(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithExternalID("sync-1"))
+	blocking := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/dependencies", todo.ID), models.AddDependencyRequest{BlockingTodoID: blocking.ID})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	completed := true
+	resp = testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/complete", todo.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusConflict)
+	resp = testhelpers.MustPut(t, srv, fmt.Sprintf("/api/v1/todos/%d", todo.ID), models.UpdateTodoRequest{Completed: &completed})
+	testhelpers.AssertStatus(t, resp, http.StatusConflict)
+
+	// Upserting an existing todo never changes whether it is completed
+	resp = testhelpers.MustPut(t, srv, "/api/v1/todos/external/sync-1", models.CreateTodoRequest{Title: todo.Title, Completed: true})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	var upserted models.Todo
+	testhelpers.DecodeJSON(t, resp, &upserted)
+	if upserted.Completed {
+		t.Error("upsert completed a blocked todo")
+	}
+
+	resp = testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/complete", blocking.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	resp = testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/complete", todo.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+}
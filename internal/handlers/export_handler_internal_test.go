@@ -0,0 +1,19 @@
+package handlers
+
+import "testing"
+
+// Every field ?columns= accepts must have a CSV formatter, or exporting
+// that column would call a nil func
+func TestCSVColumnValuesCoverTodoFields(t *testing.T) {
+	for _, field := range todoFields {
+		if csvColumnValues[field] == nil {
+			t.Errorf("todoFields has %q but csvColumnValues has no column for it", field)
+		}
+	}
+
+	for field := range csvColumnValues {
+		if !isTodoField(field) {
+			t.Errorf("csvColumnValues has %q, which is not in todoFields", field)
+		}
+	}
+}
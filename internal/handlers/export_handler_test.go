@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestExportTodosCSV(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk, eggs"))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Write tests"), testhelpers.WithPinned(true))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=csv")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	if got := resp.Header.Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("got %d CSV rows, want header and 2 todos", len(records))
+	}
+	if records[0][1] != "title" {
+		t.Errorf("header title column = %q, want %q", records[0][1], "title")
+	}
+	if records[1][1] != "Write tests" || records[2][1] != "Buy milk, eggs" {
+		t.Errorf("titles = %q, %q, want pinned todo first", records[1][1], records[2][1])
+	}
+}
+
+func TestExportTodosCSVColumns(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk, eggs"), testhelpers.WithPriority(3))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=csv&columns=title,id,priority")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	want := [][]string{
+		{"title", "id", "priority"},
+		{"Buy milk, eggs", strconv.FormatInt(todo.ID, 10), "3"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=csv&columns=title&header=false")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	records, _ = csv.NewReader(resp.Body).ReadAll()
+	resp.Body.Close()
+	if want := [][]string{{"Buy milk, eggs"}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("records without header = %q, want %q", records, want)
+	}
+
+	// Every sparse fieldset name is a valid column
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=csv&columns=id,title,description,completed,created_at,updated_at,completed_at,notes,priority,pinned,external_id,category_id,due_at,snooze_count,position,tags")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	resp.Body.Close()
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=csv&columns=title,secret")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "valid fields: id, title") {
+		t.Errorf("error = %q, want the valid column names", body)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// todoFields is the whitelist of field names accepted by the ?fields= parameter
+var todoFields = []string{
+	"id",
+	"title",
+	"description",
+	"completed",
+	"created_at",
+	"updated_at",
+	"completed_at",
+	"notes",
+	"priority",
+	"pinned",
+	"external_id",
+	"category_id",
+	"due_at",
+	"snooze_count",
+	"position",
+	"tags",
+}
+
+// parseFields parses a comma-separated field list, rejecting unknown names.
+// An empty string yields a nil slice, meaning all fields.
+func parseFields(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if !isTodoField(name) {
+			return nil, invalid("err.field.unknown", name, strings.Join(todoFields, ", "))
+		}
+		fields = append(fields, name)
+	}
+
+	return fields, nil
+}
+
+// isTodoField reports whether name is in the todoFields whitelist
+func isTodoField(name string) bool {
+	for _, field := range todoFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFields serializes each todo, or each item that embeds one, and keeps
+// only the requested fields
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	result := make([]map[string]json.RawMessage, 0, len(items))
+
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		sparse := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				sparse[field] = value
+			}
+		}
+
+		result = append(result, sparse)
+	}
+
+	return result, nil
+}
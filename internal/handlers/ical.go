@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// icalUTCFormat is the iCalendar form of a UTC date-time (RFC 5545 section
+// 3.3.5)
+const icalUTCFormat = "20060102T150405Z"
+
+// icalSerialization folds lines at 75 octets and ends them with CRLF, as
+// RFC 5545 requires
+var icalSerialization = &ics.SerializationConfiguration{
+	MaxLength:         75,
+	PropertyMaxLength: 75,
+	NewLine:           "\r\n",
+}
+
+// ImportICalTodos handles POST /todos/import/ical
+//
+// The request is multipart/form-data with an .ics file in the "file" field.
+// Each VTODO becomes a todo; other components such as VEVENT are ignored.
+// VTODOs that cannot be imported are skipped and reported, and the rest are
+// stored together.
+func (h *TodoHandler) ImportICalTodos(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.multipart.invalid")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.file.required")
+		return
+	}
+	defer file.Close()
+
+	todos, failed, err := parseICalTodos(file)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	report, err := h.service.ImportValid(todos, failed)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, importResult(w, r, report))
+}
+
+// parseICalTodos reads the VTODO components of an iCalendar file. A VTODO
+// whose values cannot be read is left nil, with its error in failed. Todos
+// are validated by the service.
+func parseICalTodos(body io.Reader) ([]*models.CreateTodoRequest, map[int]error, error) {
+	cal, err := ics.ParseCalendar(body)
+	if err != nil {
+		return nil, nil, invalid("err.ical.invalid")
+	}
+
+	components := cal.Todos()
+	todos := make([]*models.CreateTodoRequest, len(components))
+	failed := make(map[int]error)
+	for i, component := range components {
+		todo, err := icalTodo(component)
+		if err != nil {
+			failed[i] = err
+			continue
+		}
+		todos[i] = todo
+	}
+
+	return todos, failed, nil
+}
+
+// icalTodo maps SUMMARY, DESCRIPTION, DUE, STATUS and COMPLETED onto a new
+// todo
+func icalTodo(component *ics.VTodo) (*models.CreateTodoRequest, error) {
+	todo := &models.CreateTodoRequest{}
+
+	if p := component.GetProperty(ics.ComponentPropertySummary); p != nil {
+		todo.Title = p.Value
+	}
+	if p := component.GetProperty(ics.ComponentPropertyDescription); p != nil {
+		todo.Description = p.Value
+	}
+
+	if p := component.GetProperty(ics.ComponentPropertyDue); p != nil {
+		dueAt, err := component.GetDueAt()
+		if err != nil {
+			return nil, invalid("err.ical.due", p.Value)
+		}
+		todo.DueAt = &dueAt
+	}
+
+	if p := component.GetProperty(ics.ComponentPropertyStatus); p != nil && p.Value == string(ics.ObjectStatusCompleted) {
+		todo.Completed = true
+
+		if p := component.GetProperty(ics.ComponentPropertyCompleted); p != nil {
+			completedAt, err := time.Parse(icalUTCFormat, p.Value)
+			if err != nil {
+				return nil, invalid("err.ical.completed", p.Value)
+			}
+			todo.CompletedAt = &completedAt
+		}
+	}
+
+	return todo, nil
+}
+
+// exportICal streams the todos matching filter as an iCalendar file, oldest
+// first so repeated exports line up
+func (h *TodoHandler) exportICal(w http.ResponseWriter, r *http.Request, filter repository.TodoFilter) {
+	filter.Sort = []repository.SortParam{{Field: "created_at"}}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="todos.ics"`)
+
+	_, err := io.WriteString(w, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//todo-api//EN\r\n")
+	if err == nil {
+		err = h.service.Stream(r.Context(), filter, func(todo *models.Todo) error {
+			return icalComponent(todo).SerializeTo(w, icalSerialization)
+		})
+	}
+	if err == nil {
+		_, err = io.WriteString(w, "END:VCALENDAR\r\n")
+	}
+
+	// The status has already been sent, so a failure can only be logged
+	if err != nil {
+		log.Printf("iCal export failed: %v", err)
+	}
+}
+
+// icalComponent maps a todo onto a VTODO, the reverse of icalTodo
+func icalComponent(todo *models.Todo) *ics.VTodo {
+	component := ics.NewTodo(fmt.Sprintf("todo-%d", todo.ID))
+	component.SetDtStampTime(todo.UpdatedAt)
+	component.SetCreatedTime(todo.CreatedAt)
+	component.SetSummary(todo.Title)
+	if todo.Description != "" {
+		component.SetDescription(todo.Description)
+	}
+	if todo.DueAt != nil {
+		component.SetDueAt(*todo.DueAt)
+	}
+
+	if todo.Completed {
+		component.SetStatus(ics.ObjectStatusCompleted)
+		if todo.CompletedAt != nil {
+			component.SetCompletedAt(*todo.CompletedAt)
+		}
+	} else {
+		component.SetStatus(ics.ObjectStatusNeedsAction)
+	}
+
+	return component
+}
@@ -0,0 +1,171 @@
+package handlers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	ics "github.com/arran4/golang-ical"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+const importCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VEVENT
+UID:event-1
+DTSTART:20240115T090000Z
+SUMMARY:Team meeting
+END:VEVENT
+BEGIN:VTODO
+UID:todo-1
+SUMMARY:File taxes
+DESCRIPTION:Before the deadline\, with receipts
+DUE:20240415T170000Z
+END:VTODO
+BEGIN:VTODO
+UID:todo-2
+SUMMARY:Renew passport
+STATUS:COMPLETED
+COMPLETED:20240110T120000Z
+END:VTODO
+BEGIN:VTODO
+UID:todo-3
+DESCRIPTION:No summary
+END:VTODO
+BEGIN:VTODO
+UID:todo-4
+SUMMARY:Bad due date
+DUE:next week
+END:VTODO
+END:VCALENDAR
+`
+
+// postICal uploads calendar to POST /todos/import/ical as a multipart file
+func postICal(t *testing.T, url, calendar string) *http.Response {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "todos.ics")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write([]byte(strings.ReplaceAll(calendar, "\n", "\r\n")))
+	mw.Close()
+
+	resp, err := http.Post(url+"/api/v1/todos/import/ical", mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestImportICalTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	resp := postICal(t, srv.URL, importCalendar)
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var result models.ImportResult
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Imported != 2 || result.Skipped != 2 || len(result.Errors) != 2 {
+		t.Fatalf("result = %+v, want 2 imported and 2 skipped", result)
+	}
+	if !strings.HasPrefix(result.Errors[0], "item 3:") || !strings.HasPrefix(result.Errors[1], "item 4:") {
+		t.Errorf("errors = %q, want items 3 and 4", result.Errors)
+	}
+
+	todos, _, _ := repo.GetAll(repository.TodoFilter{})
+	byTitle := make(map[string]*models.Todo)
+	for _, todo := range todos {
+		byTitle[todo.Title] = todo
+	}
+
+	taxes := byTitle["File taxes"]
+	if taxes == nil || taxes.Description != "Before the deadline, with receipts" || taxes.Completed {
+		t.Fatalf("File taxes = %+v", taxes)
+	}
+	if want := time.Date(2024, 4, 15, 17, 0, 0, 0, time.UTC); taxes.DueAt == nil || !taxes.DueAt.Equal(want) {
+		t.Errorf("due_at = %v, want %v", taxes.DueAt, want)
+	}
+
+	passport := byTitle["Renew passport"]
+	if passport == nil || !passport.Completed {
+		t.Fatalf("Renew passport = %+v, want completed", passport)
+	}
+	if want := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC); passport.CompletedAt == nil || !passport.CompletedAt.Equal(want) {
+		t.Errorf("completed_at = %v, want %v", passport.CompletedAt, want)
+	}
+}
+
+func TestImportICalTodosRejectsInvalidFile(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	resp := postICal(t, srv.URL, "not a calendar")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/todos/import/ical", map[string]string{"title": "x"})
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestExportTodosICal(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	dueAt := time.Date(2024, 4, 15, 17, 0, 0, 0, time.UTC)
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("File taxes"), testhelpers.WithDescription("With receipts, all of them"))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Renew passport"), testhelpers.WithCompleted(true))
+	due, _ := repo.Create(&models.CreateTodoRequest{Title: "Book flights", Priority: models.PriorityMedium, DueAt: &dueAt})
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=ical")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/calendar") {
+		t.Errorf("Content-Type = %q, want text/calendar", got)
+	}
+	if got := resp.Header.Get("Content-Disposition"); got != `attachment; filename="todos.ics"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	cal, err := ics.ParseCalendar(resp.Body)
+	if err != nil {
+		t.Fatalf("export is not valid iCalendar: %v", err)
+	}
+
+	components := cal.Todos()
+	if len(components) != 3 {
+		t.Fatalf("got %d VTODOs, want 3", len(components))
+	}
+
+	var titles []string
+	for _, c := range components {
+		titles = append(titles, c.GetProperty(ics.ComponentPropertySummary).Value)
+	}
+	if want := []string{"File taxes", "Renew passport", "Book flights"}; !slices.Equal(titles, want) {
+		t.Errorf("SUMMARY order = %q, want %q", titles, want)
+	}
+
+	if got := components[0].GetProperty(ics.ComponentPropertyDescription).Value; got != "With receipts, all of them" {
+		t.Errorf("DESCRIPTION = %q", got)
+	}
+	if got := components[1].GetProperty(ics.ComponentPropertyStatus).Value; got != "COMPLETED" {
+		t.Errorf("STATUS = %q, want COMPLETED", got)
+	}
+	if components[1].GetProperty(ics.ComponentPropertyCompleted) == nil {
+		t.Error("completed todo has no COMPLETED")
+	}
+	if got, err := components[2].GetDueAt(); err != nil || !got.Equal(*due.DueAt) {
+		t.Errorf("DUE = %v, %v, want %v", got, err, dueAt)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/todo-api/internal/i18n"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/service"
+)
+
+// maxImportSize is the largest CSV body accepted by ImportTodos
+const maxImportSize = 32 << 20 // 32 MB
+
+// ImportTodos handles POST /todos/import
+//
+// The request body is CSV with a header row containing a "title" column and
+// an optional "description" column.
+func (h *TodoHandler) ImportTodos(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	todos, err := parseImportCSV(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	imported, err := h.service.Import(todos)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, map[string]int64{"imported": imported})
+}
+
+// parseImportCSV reads todos from CSV. Rows are validated by the service.
+func parseImportCSV(body io.Reader) ([]*models.CreateTodoRequest, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, invalid("err.csv.empty")
+		}
+		return nil, invalid("err.csv.invalid", err)
+	}
+
+	titleCol, descriptionCol := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "title":
+			titleCol = i
+		case "description":
+			descriptionCol = i
+		}
+	}
+	if titleCol == -1 {
+		return nil, invalid("err.csv.title")
+	}
+
+	var todos []*models.CreateTodoRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, invalid("err.csv.invalid", err)
+		}
+
+		todo := &models.CreateTodoRequest{}
+		if titleCol < len(record) {
+			todo.Title = record[titleCol]
+		}
+		if descriptionCol != -1 && descriptionCol < len(record) {
+			todo.Description = record[descriptionCol]
+		}
+
+		todos = append(todos, todo)
+	}
+
+	return todos, nil
+}
+
+// importResult describes report to the client, with the errors of skipped
+// records in the language negotiated from the request
+func importResult(w http.ResponseWriter, r *http.Request, report *service.ImportReport) models.ImportResult {
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+
+	result := models.ImportResult{
+		Imported:          report.Imported,
+		Skipped:           len(report.Skipped),
+		Errors:            []string{},
+		CategoriesCreated: report.CategoriesCreated,
+	}
+	for _, err := range report.Skipped {
+		var validationErr *service.ValidationError
+		if errors.As(err, &validationErr) {
+			result.Errors = append(result.Errors, validationErr.Localize(lang))
+		} else {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	return result
+}
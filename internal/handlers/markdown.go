@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// markdownTask matches a GitHub-flavoured Markdown task list item, such as
+// "- [ ] Buy milk" or "  * [x] Write tests", at any indentation
+var markdownTask = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.+)$`)
+
+// markdownHeading matches an ATX heading and captures its level and text
+var markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// ImportMarkdownTodos handles POST /todos/import/markdown
+//
+// The body is a Markdown document, sent as is or as the "file" field of a
+// multipart/form-data upload. Each task list item becomes a todo, completed
+// when checked. Items under a "## Name" heading are filed in the top-level
+// category of that name, which is created if needed.
+func (h *TodoHandler) ImportMarkdownTodos(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	defer r.Body.Close()
+
+	var body io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxImportSize); err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.multipart.invalid")
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.file.required")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	groups, err := parseMarkdownTasks(body)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.markdown.invalid")
+		return
+	}
+
+	report, err := h.service.ImportGroups(groups)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, importResult(w, r, report))
+}
+
+// parseMarkdownTasks reads the task list items of a Markdown document line
+// by line, grouped by the level 2 heading they follow. A level 1 heading
+// ends the current category, and fenced code blocks are skipped.
+func parseMarkdownTasks(body io.Reader) ([]models.ImportGroup, error) {
+	var groups []models.ImportGroup
+	category := ""
+	inFence := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			switch len(m[1]) {
+			case 1:
+				category = ""
+			case 2:
+				category = m[2]
+			}
+			continue
+		}
+
+		m := markdownTask.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		todo := &models.CreateTodoRequest{
+			Title:     m[2],
+			Completed: m[1] != " ",
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Category != category {
+			groups = append(groups, models.ImportGroup{Category: category})
+		}
+		last := &groups[len(groups)-1]
+		last.Todos = append(last.Todos, todo)
+	}
+
+	return groups, scanner.Err()
+}
+
+// exportMarkdown writes the todos matching filter as a GitHub-flavoured
+// Markdown task list. Uncategorized todos come first, then one "## Name"
+// section per category in name order. ?include_completed=false leaves out
+// completed todos.
+func (h *TodoHandler) exportMarkdown(w http.ResponseWriter, r *http.Request, filter repository.TodoFilter) {
+	includeCompleted := true
+	if raw := r.URL.Query().Get("include_completed"); raw != "" {
+		var err error
+		includeCompleted, err = strconv.ParseBool(raw)
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.flag.invalid", "include_completed")
+			return
+		}
+	}
+
+	loc, err := h.service.Timezone()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	categories, err := h.service.ListCategories()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var uncategorized []*models.Todo
+	byCategory := make(map[int64][]*models.Todo)
+	err = h.service.Stream(r.Context(), filter, func(todo *models.Todo) error {
+		if todo.Completed && !includeCompleted {
+			return nil
+		}
+		if todo.CategoryID == nil {
+			uncategorized = append(uncategorized, todo)
+		} else {
+			byCategory[*todo.CategoryID] = append(byCategory[*todo.CategoryID], todo)
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var sb strings.Builder
+	for _, todo := range uncategorized {
+		sb.WriteString(markdownTaskLine(todo, loc))
+	}
+	for _, category := range categories {
+		todos := byCategory[category.ID]
+		if len(todos) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "## %s\n\n", category.Name)
+		for _, todo := range todos {
+			sb.WriteString(markdownTaskLine(todo, loc))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="todos.md"`)
+	io.WriteString(w, sb.String())
+}
+
+// markdownTaskLine formats a todo as a task list item, with completed titles
+// struck through, the due date in loc and tags as #tag
+func markdownTaskLine(todo *models.Todo, loc *time.Location) string {
+	var sb strings.Builder
+
+	if todo.Completed {
+		fmt.Fprintf(&sb, "- [x] ~~%s~~", todo.Title)
+	} else {
+		fmt.Fprintf(&sb, "- [ ] %s", todo.Title)
+	}
+	if todo.DueAt != nil {
+		fmt.Fprintf(&sb, " (due: %s)", todo.DueAt.In(loc).Format(time.DateOnly))
+	}
+	for _, tag := range todo.Tags {
+		sb.WriteString(" #" + tag)
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+const importMarkdown = "# My list\n" +
+	"\n" +
+	"- [ ] Buy milk\n" +
+	"\n" +
+	"## Work\n" +
+	"- [x] Write tests\n" +
+	"  - [ ] Review PR\n" +
+	"- Not a task\n" +
+	"\n" +
+	"```\n" +
+	"- [ ] Inside a code block\n" +
+	"```\n" +
+	"\n" +
+	"## Home\n" +
+	"* [ ] Water plants\n"
+
+func TestImportMarkdownTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	work, err := repo.CreateCategory("Work", nil)
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+
+	resp, err := http.Post(srv.URL+"/api/v1/todos/import/markdown", "text/markdown", strings.NewReader(importMarkdown))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer resp.Body.Close()
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var result models.ImportResult
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Imported != 4 || result.Skipped != 0 {
+		t.Errorf("result = %+v, want 4 imported", result)
+	}
+	if len(result.CategoriesCreated) != 1 || result.CategoriesCreated[0] != "Home" {
+		t.Errorf("categories_created = %q, want [Home]", result.CategoriesCreated)
+	}
+
+	todos, _, _ := repo.GetAll(repository.TodoFilter{})
+	byTitle := make(map[string]*models.Todo)
+	for _, todo := range todos {
+		byTitle[todo.Title] = todo
+	}
+	if len(byTitle) != 4 {
+		t.Fatalf("got todos %v, want 4", byTitle)
+	}
+
+	if milk := byTitle["Buy milk"]; milk.CategoryID != nil || milk.Completed {
+		t.Errorf("Buy milk = %+v, want uncategorized and open", milk)
+	}
+	if tests := byTitle["Write tests"]; !tests.Completed || tests.CategoryID == nil || *tests.CategoryID != work.ID {
+		t.Errorf("Write tests = %+v, want completed in Work", tests)
+	}
+	if review := byTitle["Review PR"]; review.CategoryID == nil || *review.CategoryID != work.ID {
+		t.Errorf("Review PR category = %v, want Work", review.CategoryID)
+	}
+	if plants := byTitle["Water plants"]; plants.CategoryID == nil || *plants.CategoryID == work.ID {
+		t.Errorf("Water plants category = %v, want the new Home category", plants.CategoryID)
+	}
+}
+
+func TestExportTodosMarkdown(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	work, err := repo.CreateCategory("Work", nil)
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+
+	dueAt := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	if _, err := repo.Create(&models.CreateTodoRequest{Title: "Buy milk", Priority: models.PriorityMedium, DueAt: &dueAt}); err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	tests, err := repo.Create(&models.CreateTodoRequest{Title: "Write tests", Priority: models.PriorityMedium, CategoryID: &work.ID})
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	repo.SetTags(tests.ID, []string{"go"})
+	repo.SetCompleted(tests.ID, true)
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=markdown&sort=created_at")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/markdown") {
+		t.Errorf("Content-Type = %q, want text/markdown", got)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	want := "- [ ] Buy milk (due: 2024-01-15)\n" +
+		"\n" +
+		"## Work\n" +
+		"\n" +
+		"- [x] ~~Write tests~~ #go\n"
+	if string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=markdown&include_completed=false")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if want := "- [ ] Buy milk (due: 2024-01-15)\n"; string(body) != want {
+		t.Errorf("body without completed = %q, want %q", body, want)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/export?format=markdown&include_completed=maybe")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
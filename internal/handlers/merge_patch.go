@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// mergePatchContentType is the media type for JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// HandleMergePatch handles PATCH /todos/{id} with a JSON Merge Patch body.
+// Fields set to null are cleared: "completed": null un-completes the todo,
+// "due_at": null removes the due date, "description": null empties it and
+// "priority": null restores the default.
+func (h *TodoHandler) HandleMergePatch(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != mergePatchContentType {
+		respondWithMessage(w, r, http.StatusUnsupportedMediaType, "err.content_type.unsupported", mergePatchContentType)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	req, err := mergePatchToUpdate(patch)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	todo, err := h.service.Update(id, req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+// mergePatchToUpdate walks a merge patch document and builds the equivalent
+// update request, mapping null to each field's cleared value
+func mergePatchToUpdate(patch map[string]json.RawMessage) (*models.UpdateTodoRequest, error) {
+	var req models.UpdateTodoRequest
+
+	for field, raw := range patch {
+		isNull := string(raw) == "null"
+
+		var err error
+		switch field {
+		case "title":
+			if isNull {
+				return nil, invalid("err.patch.title_null")
+			}
+			err = json.Unmarshal(raw, &req.Title)
+		case "description":
+			if isNull {
+				empty := ""
+				req.Description = &empty
+			} else {
+				err = json.Unmarshal(raw, &req.Description)
+			}
+		case "completed":
+			if isNull {
+				completed := false
+				req.Completed = &completed
+			} else {
+				err = json.Unmarshal(raw, &req.Completed)
+			}
+		case "priority":
+			if isNull {
+				priority := models.PriorityMedium
+				req.Priority = &priority
+			} else {
+				err = json.Unmarshal(raw, &req.Priority)
+			}
+		case "due_at":
+			if isNull {
+				req.ClearDueAt = true
+			} else {
+				var dueAt time.Time
+				err = json.Unmarshal(raw, &dueAt)
+				req.DueAt = &dueAt
+			}
+		default:
+			return nil, invalid("err.patch.field", field)
+		}
+
+		if err != nil {
+			return nil, invalid("err.patch.value", field)
+		}
+	}
+
+	return &req, nil
+}
@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+// mergePatch sends body to url as application/merge-patch+json, or with
+// contentType if it is not empty
+func mergePatch(t *testing.T, url, body, contentType string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPatch, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if contentType == "" {
+		contentType = "application/merge-patch+json"
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PATCH %s failed: %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestMergePatchClearsNullFields(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	dueAt := time.Now().Add(24 * time.Hour)
+	todo, err := repo.Create(&models.CreateTodoRequest{Title: "Pay rent", Priority: models.PriorityHigh, DueAt: &dueAt})
+	if err != nil {
+		t.Fatalf("Failed to create todo: %v", err)
+	}
+	url := fmt.Sprintf("%s/api/v1/todos/%d", srv.URL, todo.ID)
+
+	resp := mergePatch(t, url, `{"completed": true}`, "")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	resp = mergePatch(t, url, `{"completed": null, "due_at": null, "title": "Pay rent today"}`, "")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var got models.Todo
+	testhelpers.DecodeJSON(t, resp, &got)
+
+	if got.Completed || got.CompletedAt != nil {
+		t.Errorf("completed = %v, completed_at = %v, want un-completed", got.Completed, got.CompletedAt)
+	}
+	if got.DueAt != nil {
+		t.Errorf("due_at = %v, want cleared", got.DueAt)
+	}
+	if got.Title != "Pay rent today" || got.Priority != models.PriorityHigh {
+		t.Errorf("title = %q, priority = %d, want the other fields untouched", got.Title, got.Priority)
+	}
+}
+
+func TestMergePatchRequiresContentType(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	resp := mergePatch(t, fmt.Sprintf("%s/api/v1/todos/%d", srv.URL, todo.ID), `{"title": "x"}`, "application/json")
+	testhelpers.AssertStatus(t, resp, http.StatusUnsupportedMediaType)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// listMeta describes the page of a list filter selected out of total
+// matches. An unlimited list is page 1.
+func listMeta(filter repository.TodoFilter, total int64) models.ListMeta {
+	page := 1
+	if filter.Limit > 0 {
+		page = filter.Offset/filter.Limit + 1
+	}
+	return models.ListMeta{Total: total, Page: page}
+}
+
+// setPaginationHeaders describes the page of results to clients that read
+// headers rather than the body: X-Total-Count is the number of matching todos
+// across all pages, X-Page-Count how many pages of filter.Limit they fill,
+// and Link points at the next page when there is one (RFC 8288)
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, filter repository.TodoFilter, returned int, total int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+
+	// An unlimited list is a single page
+	pages := int64(1)
+	if total == 0 {
+		pages = 0
+	} else if filter.Limit > 0 {
+		limit := int64(filter.Limit)
+		pages = (total + limit - 1) / limit
+	}
+	w.Header().Set("X-Page-Count", strconv.FormatInt(pages, 10))
+
+	next := filter.Offset + returned
+	if filter.Limit <= 0 || int64(next) >= total {
+		return
+	}
+
+	u := *r.URL
+	query := u.Query()
+	query.Set("offset", strconv.Itoa(next))
+	u.RawQuery = query.Encode()
+	w.Header().Set("Link", "<"+u.String()+`>; rel="next"`)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/olebedev/when"
+	"github.com/olebedev/when/rules"
+	"github.com/olebedev/when/rules/common"
+	"github.com/olebedev/when/rules/en"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// ParseTodoRequest is the payload for POST /todos/parse
+type ParseTodoRequest struct {
+	Text string `json:"text"`
+}
+
+// ParseTodo handles POST /todos/parse. It reads a todo written as a
+// sentence and responds with the create request it describes, without
+// creating anything; the client shows the fields for confirmation and then
+// sends them to POST /todos.
+func (h *TodoHandler) ParseTodo(w http.ResponseWriter, r *http.Request) {
+	var req ParseTodoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	if strings.TrimSpace(req.Text) == "" {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.text.required")
+		return
+	}
+
+	// Read "tomorrow" and "5pm" in the user's timezone
+	loc, err := h.service.Timezone()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	todo, err := parseTodoText(req.Text, time.Now().In(loc))
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.text.due")
+		return
+	}
+
+	if todo.Title == "" {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.text.no_title")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+var (
+	// priorityPhrase matches "high priority" or "priority: high"
+	priorityPhrase = regexp.MustCompile(`(?i)\b(?:(high|medium|low)\s+priority|priority\s*:?\s*(high|medium|low))\b`)
+
+	// isoDate matches a date written as 2026-11-01, which the date parser
+	// would otherwise read as a time
+	isoDate = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`)
+
+	// trailingConnector matches the word that introduced a due date, as in
+	// "Pay rent by friday"
+	trailingConnector = regexp.MustCompile(`(?i)\s+(?:at|by|on|due)\s*$`)
+)
+
+// priorityByLabel maps the words priorityPhrase accepts to priorities
+var priorityByLabel = map[string]int{
+	"low":    models.PriorityLow,
+	"medium": models.PriorityMedium,
+	"high":   models.PriorityHigh,
+}
+
+// parseTodoText splits text into a priority keyword, a due date relative to
+// now and the title that is left. A date without a time of day is due at
+// the end of that day in now's location.
+func parseTodoText(text string, now time.Time) (*models.CreateTodoRequest, error) {
+	var todo models.CreateTodoRequest
+
+	if m := priorityPhrase.FindStringSubmatchIndex(text); m != nil {
+		// One of the two groups matched, depending on the word order
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[4], m[5]
+		}
+		label := text[start:end]
+		todo.Priority = priorityByLabel[strings.ToLower(label)]
+		text = text[:m[0]] + " " + text[m[1]:]
+	}
+
+	if m := isoDate.FindStringIndex(text); m != nil {
+		if day, err := time.ParseInLocation("2006-01-02", text[m[0]:m[1]], now.Location()); err == nil {
+			dueAt := endOfDay(day)
+			todo.DueAt = &dueAt
+			text = stripDue(text, m[0], m[1])
+		}
+	}
+
+	if todo.DueAt == nil {
+		found, clock, err := parseDue(text, now)
+		if err != nil {
+			return nil, err
+		}
+		if found != nil {
+			// The parser keeps now's clock when the text names no time, and
+			// now's fraction of a second when it does
+			dueAt := found.Time
+			if clock {
+				dueAt = dueAt.Truncate(time.Second)
+			} else {
+				dueAt = endOfDay(dueAt)
+			}
+			todo.DueAt = &dueAt
+			text = stripDue(text, found.Index, found.Index+len(found.Text))
+		}
+	}
+
+	todo.Title = text
+	todo.Normalize()
+	return &todo, nil
+}
+
+// dueRules are the rules of when.EN
+var dueRules = slices.Concat(en.All, common.All)
+
+// parseDue finds a date in text relative to now, and reports whether the
+// text named a time of day, as in "at 5pm" or "in 2 hours", rather than
+// only a day. when.Result does not say which rules matched, so each rule
+// is wrapped to capture the context it fills in.
+func parseDue(text string, now time.Time) (*when.Result, bool, error) {
+	var ctx *rules.Context
+
+	parser := when.New(nil)
+	for _, rule := range dueRules {
+		parser.Add(contextRule{rule: rule, ctx: &ctx})
+	}
+
+	found, err := parser.Parse(text, now)
+	if err != nil || found == nil || ctx == nil {
+		return found, false, err
+	}
+
+	clock := ctx.Hour != nil || ctx.Minute != nil || ctx.Second != nil || ctx.Duration%(24*time.Hour) != 0
+	return found, clock, nil
+}
+
+// contextRule is a date rule that stores the context its matches are
+// applied to in ctx
+type contextRule struct {
+	rule rules.Rule
+	ctx  **rules.Context
+}
+
+// Find finds the wrapped rule's match in text
+func (r contextRule) Find(text string) *rules.Match {
+	match := r.rule.Find(text)
+	if match == nil {
+		return nil
+	}
+
+	apply := match.Applier
+	match.Applier = func(m *rules.Match, c *rules.Context, o *rules.Options, ref time.Time) (bool, error) {
+		*r.ctx = c
+		return apply(m, c, o, ref)
+	}
+	return match
+}
+
+// stripDue removes the due date at text[start:end], along with the word
+// that introduced it
+func stripDue(text string, start, end int) string {
+	return trailingConnector.ReplaceAllString(text[:start], "") + " " + text[end:]
+}
+
+// endOfDay returns the last minute of t's day in t's location
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 0, 0, t.Location())
+}
@@ -0,0 +1,107 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/handlers"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestParseTodo(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	if err := repo.SetTimezone("America/New_York"); err != nil {
+		t.Fatalf("Failed to set timezone: %v", err)
+	}
+	loc, _ := time.LoadLocation("America/New_York")
+	tomorrow := time.Now().In(loc).AddDate(0, 0, 1)
+
+	tests := []struct {
+		text     string
+		title    string
+		priority int
+		dueAt    time.Time
+	}{
+		{
+			text:     "Buy milk tomorrow at 5pm high priority",
+			title:    "Buy milk",
+			priority: models.PriorityHigh,
+			dueAt:    time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 17, 0, 0, 0, loc),
+		},
+		{
+			text:  "Pay rent by tomorrow",
+			title: "Pay rent",
+			dueAt: time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 23, 59, 0, 0, loc),
+		},
+		{
+			text:     "File taxes on 2027-04-15 priority: low",
+			title:    "File taxes",
+			priority: models.PriorityLow,
+			dueAt:    time.Date(2027, 4, 15, 23, 59, 0, 0, loc),
+		},
+		{
+			text:  "Read a book",
+			title: "Read a book",
+		},
+	}
+
+	for _, tt := range tests {
+		resp := testhelpers.MustPost(t, srv, "/api/v1/todos/parse", handlers.ParseTodoRequest{Text: tt.text})
+		testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+		var parsed models.CreateTodoRequest
+		testhelpers.DecodeJSON(t, resp, &parsed)
+		if parsed.Title != tt.title || parsed.Priority != tt.priority {
+			t.Errorf("%q parsed as %q priority %d, want %q priority %d", tt.text, parsed.Title, parsed.Priority, tt.title, tt.priority)
+		}
+		switch {
+		case tt.dueAt.IsZero() && parsed.DueAt != nil:
+			t.Errorf("%q parsed due_at %v, want none", tt.text, parsed.DueAt)
+		case !tt.dueAt.IsZero() && (parsed.DueAt == nil || !parsed.DueAt.Equal(tt.dueAt)):
+			t.Errorf("%q parsed due_at %v, want %v", tt.text, parsed.DueAt, tt.dueAt)
+		}
+	}
+
+	// Parsing creates nothing
+	if todos, _, _ := repo.GetAll(repository.TodoFilter{}); len(todos) != 0 {
+		t.Errorf("found %d todos after parsing, want 0", len(todos))
+	}
+}
+
+func TestParseTodoKeepsClockOfRelativeTimes(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	before := time.Now().Truncate(time.Second)
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos/parse", handlers.ParseTodoRequest{Text: "Call back in 2 hours"})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var parsed models.CreateTodoRequest
+	testhelpers.DecodeJSON(t, resp, &parsed)
+	if parsed.Title != "Call back" || parsed.DueAt == nil {
+		t.Fatalf("parsed %q due %v, want Call back with a due date", parsed.Title, parsed.DueAt)
+	}
+	if due := parsed.DueAt.Sub(before); due < 2*time.Hour || due > 2*time.Hour+time.Minute {
+		t.Errorf("due_at = %v, want 2 hours after %v", parsed.DueAt, before)
+	}
+}
+
+func TestParseTodoRejectsUnreadableDueDate(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos/parse", handlers.ParseTodoRequest{Text: "Renew passport in 99999999999999999999 days"})
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestParseTodoWithoutTitle(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	for _, text := range []string{"", "tomorrow at 5pm high priority"} {
+		resp := testhelpers.MustPost(t, srv, "/api/v1/todos/parse", handlers.ParseTodoRequest{Text: text})
+		testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestExportPersonalData(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("File taxes"))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Renew passport"), testhelpers.WithCompleted(true))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/me/export")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Content-Type = %q, want application/zip", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="export-`) {
+		t.Errorf("Content-Disposition = %q, want an export-<date>.zip attachment", cd)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Response is not a ZIP archive: %v", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	for _, name := range []string{"todos.json", "categories.json", "profile.json"} {
+		if files[name] == nil {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+
+	if f := files["todos.json"]; f != nil {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open todos.json: %v", err)
+		}
+		defer rc.Close()
+
+		var todos []models.Todo
+		if err := json.NewDecoder(rc).Decode(&todos); err != nil {
+			t.Fatalf("Failed to decode todos.json: %v", err)
+		}
+		if len(todos) != 2 {
+			t.Errorf("todos.json has %d todos, want 2", len(todos))
+		}
+	}
+
+	// A second export within the hour is rate limited
+	resp = testhelpers.MustGet(t, srv, "/api/v1/me/export")
+	testhelpers.AssertStatus(t, resp, http.StatusTooManyRequests)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// GetProfile handles GET /me
+func (h *TodoHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.service.Profile()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+// GetPreferences handles GET /me/preferences
+func (h *TodoHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs, err := h.service.Preferences()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+// UpdatePreferences handles PUT /me/preferences
+func (h *TodoHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdatePreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validateListDefaults(req.DefaultSort, req.DefaultFilter); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	prefs, err := h.service.SetPreferences(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+// validateListDefaults checks a default sort and filter against the rules
+// for saved searches, so the frontend can always apply them to GET /todos.
+// The sort is kept apart from the filter.
+func validateListDefaults(sort string, filter models.SearchParams) error {
+	if _, ok := filter["sort"]; ok {
+		return invalid("err.default_filter.sort")
+	}
+
+	params := models.SearchParams{}
+	for key, values := range filter {
+		params[key] = values
+	}
+	if sort != "" {
+		params["sort"] = []string{sort}
+	}
+
+	return validateSavedSearchParams(params)
+}
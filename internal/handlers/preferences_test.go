@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestGetPreferencesDefaults(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/me/preferences")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var prefs models.Preferences
+	testhelpers.DecodeJSON(t, resp, &prefs)
+	if prefs.TodosPerPage != models.DefaultTodosPerPage || prefs.Theme != models.ThemeSystem || prefs.Timezone != "UTC" {
+		t.Errorf("preferences = %+v, want the defaults", prefs)
+	}
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	want := models.Preferences{
+		DefaultSort:   "priority:desc",
+		DefaultFilter: models.SearchParams{"due": {"today"}},
+		TodosPerPage:  25,
+		Theme:         models.ThemeDark,
+		Timezone:      "Europe/Berlin",
+	}
+	resp := testhelpers.MustPut(t, srv, "/api/v1/me/preferences", want)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/me")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var profile models.Profile
+	testhelpers.DecodeJSON(t, resp, &profile)
+	if profile.Preferences == nil || !reflect.DeepEqual(*profile.Preferences, want) {
+		t.Errorf("profile preferences = %+v, want %+v", profile.Preferences, want)
+	}
+	if profile.Timezone != "Europe/Berlin" {
+		t.Errorf("profile timezone = %q, want Europe/Berlin", profile.Timezone)
+	}
+}
+
+func TestUpdatePreferencesRejectsInvalid(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{"todos_per_page": 50, "theme": "light", "timezone": "UTC"}
+	}
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+	}{
+		{"page too small", "todos_per_page", 5},
+		{"page too large", "todos_per_page", 500},
+		{"unknown theme", "theme", "solarized"},
+		{"unknown sort", "default_sort", "secret"},
+		{"unknown filter", "default_filter", map[string]string{"limit": "10"}},
+		{"invalid filter value", "default_filter", map[string]string{"due": "someday"}},
+		{"sort in filter", "default_filter", map[string]string{"sort": "title"}},
+		{"unknown timezone", "timezone", "Mars/Olympus_Mons"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := valid()
+			body[tt.key] = tt.value
+
+			resp := testhelpers.MustPut(t, srv, "/api/v1/me/preferences", body)
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+		})
+	}
+}
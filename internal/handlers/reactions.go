@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// ?expand= values of GET /todos/{id}
+const (
+	expandReactions    = "reactions"
+	expandDependencies = "dependencies"
+)
+
+// expansions is what the ?expand= parameter of GET /todos/{id} asked to add
+// to the todo
+type expansions struct {
+	reactions    bool
+	dependencies bool
+}
+
+// any reports whether anything was asked for
+func (e expansions) any() bool {
+	return e.reactions || e.dependencies
+}
+
+// parseExpand reads the ?expand= parameter of GET /todos/{id}
+func parseExpand(raw string) (expansions, error) {
+	var expand expansions
+	for _, name := range strings.Split(raw, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case expandReactions:
+			expand.reactions = true
+		case expandDependencies:
+			expand.dependencies = true
+		default:
+			return expansions{}, invalid("err.expand.unknown", name, expandReactions, expandDependencies)
+		}
+	}
+	return expand, nil
+}
+
+// ReactToTodo handles POST /todos/{id}/react
+func (h *TodoHandler) ReactToTodo(w http.ResponseWriter, r *http.Request) {
+	h.react(w, r, h.service.React)
+}
+
+// UnreactToTodo handles DELETE /todos/{id}/react
+func (h *TodoHandler) UnreactToTodo(w http.ResponseWriter, r *http.Request) {
+	h.react(w, r, h.service.Unreact)
+}
+
+// react applies the reaction in the request body with change and responds
+// with the todo's reactions
+func (h *TodoHandler) react(w http.ResponseWriter, r *http.Request, change func(int64, *models.ReactionRequest) ([]models.Reaction, error)) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	reactions, err := change(id, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, reactions)
+}
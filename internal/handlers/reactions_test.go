@@ -0,0 +1,83 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestReactToTodo(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+	path := fmt.Sprintf("/api/v1/todos/%d/react", todo.ID)
+
+	for _, emoji := range []string{"\U0001F44D", "\U0001F389", "\U0001F44D"} {
+		resp := testhelpers.MustPost(t, srv, path, models.ReactionRequest{Emoji: emoji})
+		testhelpers.AssertStatus(t, resp, http.StatusOK)
+	}
+
+	resp := testhelpers.MustDo(t, srv, http.MethodDelete, path, models.ReactionRequest{Emoji: "\U0001F389"})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var reactions []models.Reaction
+	testhelpers.DecodeJSON(t, resp, &reactions)
+	want := models.Reaction{Emoji: "\U0001F44D", Count: 1, ReactedByMe: true}
+	if len(reactions) != 1 || reactions[0] != want {
+		t.Errorf("reactions = %+v, want [%+v]", reactions, want)
+	}
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d?expand=reactions", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var expanded struct {
+		ID        int64             `json:"id"`
+		Reactions []models.Reaction `json:"reactions"`
+	}
+	testhelpers.DecodeJSON(t, resp, &expanded)
+	if expanded.ID != todo.ID || len(expanded.Reactions) != 1 || expanded.Reactions[0] != want {
+		t.Errorf("expanded todo = %+v, want todo %d with [%+v]", expanded, todo.ID, want)
+	}
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d?expand=notes", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestReactToTodoValidation(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+	path := fmt.Sprintf("/api/v1/todos/%d/react", todo.ID)
+
+	for _, emoji := range []string{"", "ok", "\U0001F44D\U0001F44D"} {
+		resp := testhelpers.MustPost(t, srv, path, models.ReactionRequest{Emoji: emoji})
+		testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+	}
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos/999/react", models.ReactionRequest{Emoji: "\U0001F44D"})
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestReactToTodoLimit(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+	path := fmt.Sprintf("/api/v1/todos/%d/react", todo.ID)
+
+	// MaxReactionsPerTodo distinct emoji, starting at the mouse face
+	for i := 0; i < repository.MaxReactionsPerTodo; i++ {
+		resp := testhelpers.MustPost(t, srv, path, models.ReactionRequest{Emoji: string(rune(0x1F42D + i))})
+		testhelpers.AssertStatus(t, resp, http.StatusOK)
+	}
+
+	resp := testhelpers.MustPost(t, srv, path, models.ReactionRequest{Emoji: "\U0001F44D"})
+	testhelpers.AssertStatus(t, resp, http.StatusConflict)
+
+	// Reacting again with an existing emoji is still fine
+	resp = testhelpers.MustPost(t, srv, path, models.ReactionRequest{Emoji: string(rune(0x1F42D))})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/i18n"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/service"
+)
+
+// savedSearchParams are the GET /todos query parameters a saved search may
+// hold. Paging is left to each request.
+var savedSearchParams = []string{
+	"category_id", "due", "include_descendants", "instant", "pinned", "q", "sort", "tag", "tagged",
+}
+
+// SavedSearchHandler handles HTTP requests for saved searches
+type SavedSearchHandler struct {
+	service *service.TodoService
+}
+
+// NewSavedSearchHandler creates a new SavedSearchHandler
+func NewSavedSearchHandler(service *service.TodoService) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		service: service,
+	}
+}
+
+// GetAllSavedSearches handles GET /search/saved
+func (h *SavedSearchHandler) GetAllSavedSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.service.ListSavedSearches()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, searches)
+}
+
+// GetSavedSearch handles GET /search/saved/{name}
+func (h *SavedSearchHandler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, err := h.service.GetSavedSearch(mux.Vars(r)["name"])
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, search)
+}
+
+// CreateSavedSearch handles POST /search/saved
+func (h *SavedSearchHandler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validateSavedSearchParams(req.Params); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	search, err := h.service.CreateSavedSearch(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, search)
+}
+
+// UpdateSavedSearch handles PUT /search/saved/{name}
+func (h *SavedSearchHandler) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateSavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validateSavedSearchParams(req.Params); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	search, err := h.service.UpdateSavedSearch(mux.Vars(r)["name"], &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, search)
+}
+
+// DeleteSavedSearch handles DELETE /search/saved/{name}
+func (h *SavedSearchHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteSavedSearch(mux.Vars(r)["name"]); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateSavedSearchParams checks params against the same rules as the
+// query parameters of GET /todos, so a saved search is always usable
+func validateSavedSearchParams(params models.SearchParams) error {
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		if !slices.Contains(savedSearchParams, key) {
+			return invalid("err.saved_search.param", key)
+		}
+	}
+
+	_, err := parseTodoFilter(url.Values(params))
+	return err
+}
+
+// withSavedSearch returns query with the saved search's params filled in
+// beneath it. A parameter given in the request replaces the saved one.
+func withSavedSearch(query url.Values, saved models.SearchParams) url.Values {
+	merged := url.Values{}
+	for key, values := range saved {
+		merged[key] = values
+	}
+	for key, values := range query {
+		if key != "saved_search" {
+			merged[key] = values
+		}
+	}
+	return merged
+}
+
+// setWarningHeaders adds a Warning header (RFC 7234 section 5.5) for each
+// warning, in the language negotiated from the request
+func setWarningHeaders(w http.ResponseWriter, r *http.Request, warnings []service.Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+	for _, warning := range warnings {
+		w.Header().Add("Warning", "299 - "+strconv.Quote(warning.Localize(lang)))
+	}
+}
@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestSavedSearchCRUD(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/search/saved", map[string]interface{}{
+		"name":   "weekly-review",
+		"params": map[string]interface{}{"due": "overdue", "tag": []string{"work"}},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var created models.SavedSearch
+	testhelpers.DecodeJSON(t, resp, &created)
+	if got := created.Params["due"]; len(got) != 1 || got[0] != "overdue" {
+		t.Errorf("params[due] = %v, want [overdue]", got)
+	}
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/search/saved", map[string]interface{}{
+		"name": "weekly-review",
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusConflict)
+
+	resp = testhelpers.MustPut(t, srv, "/api/v1/search/saved/weekly-review", map[string]interface{}{
+		"params": map[string]interface{}{"pinned": "true"},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/search/saved/weekly-review")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var updated models.SavedSearch
+	testhelpers.DecodeJSON(t, resp, &updated)
+	if _, ok := updated.Params["due"]; ok || len(updated.Params["pinned"]) != 1 {
+		t.Errorf("params = %v, want only pinned", updated.Params)
+	}
+
+	resp = testhelpers.MustDelete(t, srv, "/api/v1/search/saved/weekly-review")
+	testhelpers.AssertStatus(t, resp, http.StatusNoContent)
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/search/saved/weekly-review")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+
+	resp = testhelpers.MustPut(t, srv, "/api/v1/search/saved/weekly-review", map[string]interface{}{
+		"params": map[string]interface{}{"pinned": "true"},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+
+	resp = testhelpers.MustDelete(t, srv, "/api/v1/search/saved/weekly-review")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestCreateSavedSearchValidatesParams(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+	}{
+		{"invalid value", map[string]interface{}{"due": "tomorrow"}},
+		{"invalid sort", map[string]interface{}{"sort": "colour:asc"}},
+		{"paging", map[string]interface{}{"limit": "10"}},
+		{"unknown parameter", map[string]interface{}{"saved_search": "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := testhelpers.MustPost(t, srv, "/api/v1/search/saved", map[string]interface{}{
+				"name":   "review",
+				"params": tt.params,
+			})
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+		})
+	}
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/search/saved", map[string]interface{}{
+		"name": "Weekly Review",
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestGetAllTodosWithSavedSearch(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	pinned := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Pinned"), testhelpers.WithPinned(true))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Unpinned"))
+	if _, err := repo.SetTags(pinned.ID, []string{"work"}); err != nil {
+		t.Fatalf("Failed to set tags: %v", err)
+	}
+
+	if _, err := repo.CreateSavedSearch("pinned-work", models.SearchParams{
+		"pinned": {"true"},
+		"tag":    {"work"},
+	}); err != nil {
+		t.Fatalf("Failed to create saved search: %v", err)
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?saved_search=pinned-work")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+	if len(todos) != 1 || todos[0].ID != pinned.ID {
+		t.Errorf("got %d todos, want only the pinned one", len(todos))
+	}
+
+	// Query parameters override the saved ones
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos?saved_search=pinned-work&pinned=false")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos = list.Data
+	if len(todos) != 0 {
+		t.Errorf("got %d todos, want none", len(todos))
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos?saved_search=missing")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestGetAllTodosWithSavedSearchMissingReferences(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo)
+	testhelpers.InsertTodo(t, repo)
+
+	if _, err := repo.CreateSavedSearch("old-project", models.SearchParams{
+		"category_id": {"42"},
+		"tag":         {"archived"},
+	}); err != nil {
+		t.Fatalf("Failed to create saved search: %v", err)
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?saved_search=old-project")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	if warnings := resp.Header.Values("Warning"); len(warnings) != 2 {
+		t.Errorf("Warning = %q, want one for the category and one for the tag", warnings)
+	}
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+	if len(todos) != 2 {
+		t.Errorf("got %d todos, want 2", len(todos))
+	}
+}
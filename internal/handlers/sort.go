@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// relevanceSort is the ?sort= value that orders search results best match
+// first. It is not a column, so ParseSortParams does not accept it.
+const relevanceSort = "relevance"
+
+// ParseSortParams parses a ?sort= value such as "priority:desc,due_at:asc"
+// into at most repository.MaxSortParams sort fields. The direction defaults
+// to ascending. An empty string yields nil, meaning the default order.
+func ParseSortParams(raw string) ([]repository.SortParam, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	terms := strings.Split(raw, ",")
+	if len(terms) > repository.MaxSortParams {
+		return nil, invalid("err.sort.max", repository.MaxSortParams)
+	}
+
+	params := make([]repository.SortParam, 0, len(terms))
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		field, dir, _ := strings.Cut(strings.TrimSpace(term), ":")
+		if !repository.ValidSortField(field) {
+			return nil, invalid("err.sort.unknown", field)
+		}
+		if seen[field] {
+			return nil, invalid("err.sort.repeated", field)
+		}
+		seen[field] = true
+
+		param := repository.SortParam{Field: field}
+		switch strings.ToLower(dir) {
+		case "", "asc":
+		case "desc":
+			param.Descending = true
+		default:
+			return nil, invalid("err.sort.direction", dir)
+		}
+		params = append(params, param)
+	}
+
+	return params, nil
+}
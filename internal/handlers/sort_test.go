@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/handlers"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestParseSortParams(t *testing.T) {
+	got, err := handlers.ParseSortParams("priority:desc, due_at")
+	if err != nil {
+		t.Fatalf("ParseSortParams returned error: %v", err)
+	}
+
+	want := []repository.SortParam{
+		{Field: "priority", Descending: true},
+		{Field: "due_at"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSortParams = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseSortParamsRejectsInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"id; DROP TABLE todos",
+		"priority:sideways",
+		"priority,priority",
+		"priority,due_at,title,created_at",
+	} {
+		if _, err := handlers.ParseSortParams(raw); err == nil {
+			t.Errorf("ParseSortParams(%q) returned no error", raw)
+		}
+	}
+}
+
+func TestGetAllTodosSortsByMultipleFields(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	low := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("b"), testhelpers.WithPriority(models.PriorityLow))
+	highB := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("b"), testhelpers.WithPriority(models.PriorityHigh))
+	highA := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("a"), testhelpers.WithPriority(models.PriorityHigh))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?sort=priority:desc,title:asc")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+
+	want := []int64{highA.ID, highB.ID, low.ID}
+	if len(todos) != len(want) {
+		t.Fatalf("got %d todos, want %d", len(todos), len(want))
+	}
+	for i, id := range want {
+		if todos[i].ID != id {
+			t.Errorf("todos[%d].ID = %d, want %d", i, todos[i].ID, id)
+		}
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos?sort=secret")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestGetAllTodosSortsByRelevance(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	once := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Review report"))
+	twice := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Report"), testhelpers.WithDescription("Send the report to finance"))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk"))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?q=report&sort=relevance")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+
+	if len(todos) != 2 || todos[0].ID != twice.ID || todos[1].ID != once.ID {
+		t.Errorf("got %+v, want todo %d then %d", todos, twice.ID, once.ID)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos?sort=relevance")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos?q=report&sort=relevance,title")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// defaultTrendDays is the window used when ?days= is not given
+const defaultTrendDays = 30
+
+// countCacheMaxAge is how long clients may reuse a GET /todos/count
+// response. The count takes no parameters, so it can be kept longer than a
+// list page.
+const countCacheMaxAge = time.Minute
+
+// GetStats handles GET /stats
+func (h *TodoHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.Stats()
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+// GetTodoCount handles GET /todos/count
+func (h *TodoHandler) GetTodoCount(w http.ResponseWriter, r *http.Request) {
+	count, err := h.service.Count()
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	setCacheHeaders(w, true, countCacheMaxAge)
+	respondWithJSON(w, http.StatusOK, count)
+}
+
+// GetCompletionTrend handles GET /stats/trend?period=day&days=30
+func (h *TodoHandler) GetCompletionTrend(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.PeriodDay
+	}
+
+	days := defaultTrendDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		var err error
+		days, err = strconv.Atoi(raw)
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.param.invalid", "days")
+			return
+		}
+	}
+
+	trend, err := h.service.CompletionTrend(period, days)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, trend)
+}
+
+// GetDigest handles GET /todos/digest, previewing the daily or weekly digest
+func (h *TodoHandler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.DigestWeekly
+	}
+
+	digest, err := h.service.Digest(period, time.Now())
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, digest)
+}
+
+// GetCompletionStreak handles GET /stats/streak
+func (h *TodoHandler) GetCompletionStreak(w http.ResponseWriter, r *http.Request) {
+	streak, err := h.service.CompletionStreak()
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, streak)
+}
@@ -0,0 +1,162 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestGetStats(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityHigh))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityHigh))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityHigh), testhelpers.WithCompleted(true))
+	testhelpers.InsertTodo(t, repo)
+	archived := testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityHigh))
+	if _, _, err := repo.SetArchived([]int64{archived.ID}, true); err != nil {
+		t.Fatalf("SetArchived returned error: %v", err)
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/stats")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var stats models.Stats
+	testhelpers.DecodeJSON(t, resp, &stats)
+
+	want := map[int]int64{models.PriorityLow: 0, models.PriorityMedium: 1, models.PriorityHigh: 2}
+	for priority, count := range want {
+		if stats.OpenByPriority[priority] != count {
+			t.Errorf("open_by_priority[%d] = %d, want %d", priority, stats.OpenByPriority[priority], count)
+		}
+	}
+	if len(stats.OpenByPriority) != len(want) {
+		t.Errorf("open_by_priority = %v, want only priorities 1-3", stats.OpenByPriority)
+	}
+}
+
+func TestGetTodoCount(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo)
+	testhelpers.InsertTodo(t, repo)
+	testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+	archived := testhelpers.InsertTodo(t, repo)
+	if _, _, err := repo.SetArchived([]int64{archived.ID}, true); err != nil {
+		t.Fatalf("SetArchived returned error: %v", err)
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/count")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	if got := resp.Header.Get("Cache-Control"); got != "private, max-age=60" {
+		t.Errorf("Cache-Control = %q, want private, max-age=60", got)
+	}
+
+	var count models.TodoCount
+	testhelpers.DecodeJSON(t, resp, &count)
+	if want := (models.TodoCount{Total: 3, Completed: 1, Pending: 2}); count != want {
+		t.Errorf("count = %+v, want %+v", count, want)
+	}
+}
+
+func TestGetCompletionTrend(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo)
+	testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/stats/trend?period=day&days=7")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var trend []models.TrendPoint
+	testhelpers.DecodeJSON(t, resp, &trend)
+
+	if len(trend) != 7 {
+		t.Fatalf("got %d points, want 7", len(trend))
+	}
+
+	today := trend[len(trend)-1]
+	if today.Date != time.Now().Format("2006-01-02") {
+		t.Errorf("last point date = %s, want today", today.Date)
+	}
+	if today.Created != 2 || today.Completed != 1 {
+		t.Errorf("today = %+v, want 2 created and 1 completed", today)
+	}
+}
+
+func TestGetCompletionTrendRejectsBadPeriod(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/stats/trend?period=month")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestGetDigest(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	done := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+	upcoming := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(time.Now().AddDate(0, 0, 7)))
+	late := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(time.Now().Add(-time.Hour)))
+	testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/digest?period=weekly")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var digest models.Digest
+	testhelpers.DecodeJSON(t, resp, &digest)
+
+	if digest.Period != models.DigestWeekly {
+		t.Errorf("period = %q, want %q", digest.Period, models.DigestWeekly)
+	}
+	if len(digest.Completed) != 1 || digest.Completed[0].ID != done.ID {
+		t.Errorf("completed = %+v, want just %d", digest.Completed, done.ID)
+	}
+	if len(digest.Due) != 1 || digest.Due[0].ID != upcoming.ID {
+		t.Errorf("due = %+v, want just %d", digest.Due, upcoming.ID)
+	}
+	if len(digest.Overdue) != 1 || digest.Overdue[0].ID != late.ID {
+		t.Errorf("overdue = %+v, want just %d", digest.Overdue, late.ID)
+	}
+}
+
+func TestGetDigestRejectsBadPeriod(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/digest?period=monthly")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestGetCompletionStreak(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	// A finished three-day run, a gap, then yesterday and today
+	now := time.Now().UTC()
+	for _, daysAgo := range []int{10, 9, 8, 1, 0, 0} {
+		completedAt := now.AddDate(0, 0, -daysAgo)
+		if _, err := repo.Create(&models.CreateTodoRequest{Title: "Done", Completed: true, CompletedAt: &completedAt}); err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/stats/streak")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var streak models.Streak
+	testhelpers.DecodeJSON(t, resp, &streak)
+
+	if streak.Current != 2 || streak.Longest != 3 {
+		t.Errorf("streak = %d current, %d longest, want 2 and 3", streak.Current, streak.Longest)
+	}
+	if streak.LastCompletionDate == nil || *streak.LastCompletionDate != now.Format("2006-01-02") {
+		t.Errorf("last_completion_date = %v, want today", streak.LastCompletionDate)
+	}
+}
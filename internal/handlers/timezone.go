@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// TimezoneRequest is the payload for PUT /me/timezone
+type TimezoneRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+// SetTimezone handles PUT /me/timezone
+func (h *TodoHandler) SetTimezone(w http.ResponseWriter, r *http.Request) {
+	var req TimezoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.service.SetTimezone(req.Timezone); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, req)
+}
+
+// wantsUserTimezone reports whether the Accept header asks for timestamps
+// in the user's timezone with application/json;tz=user
+func wantsUserTimezone(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err == nil && mediaType == "application/json" && params["tz"] == "user" {
+			return true
+		}
+	}
+	return false
+}
+
+// localizeTodos converts the timestamps of todos to loc in place
+func localizeTodos(todos []*models.Todo, loc *time.Location) {
+	for _, todo := range todos {
+		todo.CreatedAt = todo.CreatedAt.In(loc)
+		todo.UpdatedAt = todo.UpdatedAt.In(loc)
+		if todo.CompletedAt != nil {
+			completedAt := todo.CompletedAt.In(loc)
+			todo.CompletedAt = &completedAt
+		}
+		if todo.DueAt != nil {
+			dueAt := todo.DueAt.In(loc)
+			todo.DueAt = &dueAt
+		}
+		for i := range todo.Notes {
+			todo.Notes[i].CreatedAt = todo.Notes[i].CreatedAt.In(loc)
+		}
+	}
+}
+
+// requestTimezone returns the user's timezone if the request asked for
+// timestamps in it, or nil if it did not
+func (h *TodoHandler) requestTimezone(r *http.Request) (*time.Location, error) {
+	if !wantsUserTimezone(r) {
+		return nil, nil
+	}
+	return h.service.Timezone()
+}
+
+// localizeForRequest converts todos to the user's timezone if the request
+// asked for it
+func (h *TodoHandler) localizeForRequest(r *http.Request, todos ...*models.Todo) error {
+	loc, err := h.requestTimezone(r)
+	if err != nil || loc == nil {
+		return err
+	}
+
+	localizeTodos(todos, loc)
+	return nil
+}
@@ -0,0 +1,53 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/handlers"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestSetTimezoneRejectsUnknownZone(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustPut(t, srv, "/api/v1/me/timezone", handlers.TimezoneRequest{Timezone: "Mars/Olympus_Mons"})
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+
+	resp = testhelpers.MustPut(t, srv, "/api/v1/me/timezone", handlers.TimezoneRequest{Timezone: "Asia/Tokyo"})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+}
+
+func TestGetTodoInUserTimezone(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	if err := repo.SetTimezone("Asia/Tokyo"); err != nil {
+		t.Fatalf("Failed to set timezone: %v", err)
+	}
+	todo := testhelpers.InsertTodo(t, repo)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/todos/%d", srv.URL, todo.ID), nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json;tz=user")
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var got models.Todo
+	testhelpers.DecodeJSON(t, resp, &got)
+
+	if _, offset := got.CreatedAt.Zone(); offset != 9*60*60 {
+		t.Errorf("created_at offset = %ds, want +09:00", offset)
+	}
+	if !got.CreatedAt.Equal(todo.CreatedAt) {
+		t.Errorf("created_at = %v, want the same instant as %v", got.CreatedAt, todo.CreatedAt)
+	}
+}
@@ -1,36 +1,201 @@
 package handlers
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/i18n"
+	"github.com/yourusername/todo-api/internal/middleware"
 	"github.com/yourusername/todo-api/internal/models"
 	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/service"
 )
 
 // TodoHandler handles HTTP requests for todo operations
 type TodoHandler struct {
-	repo *repository.TodoRepository
+	service *service.TodoService
 }
 
 // NewTodoHandler creates a new TodoHandler
-func NewTodoHandler(repo *repository.TodoRepository) *TodoHandler {
+func NewTodoHandler(service *service.TodoService) *TodoHandler {
 	return &TodoHandler{
-		repo: repo,
+		service: service,
 	}
 }
 
 // GetAllTodos handles GET /todos
 func (h *TodoHandler) GetAllTodos(w http.ResponseWriter, r *http.Request) {
-	todos, err := h.repo.GetAll()
+	fields, err := parseFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	var saved *models.SavedSearch
+	if name := query.Get("saved_search"); name != "" {
+		saved, err = h.service.GetSavedSearch(name)
+		if err != nil {
+			respondWithServiceError(w, r, err)
+			return
+		}
+		query = withSavedSearch(query, saved.Params)
+	}
+
+	filter, err := parseTodoFilter(query)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	if saved != nil {
+		warnings, err := h.service.DropMissingReferences(&filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		setWarningHeaders(w, r, warnings)
+	}
+
+	hlTag, err := parseHighlightTag(r.URL.Query().Get("hl_tag"))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	loc, err := h.requestTimezone(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, todos)
+	middleware.StartTimer(r.Context(), "db")
+	todos, total, err := h.service.List(filter)
+	if err != nil {
+		middleware.EndTimer(r.Context(), "db")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	modified, err := h.service.LastModified()
+	middleware.EndTimer(r.Context(), "db")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setPaginationHeaders(w, r, filter, len(todos), total)
+	meta := listMeta(filter, total)
+	meta.CollectionVersion = h.service.CollectionVersion(todos, meta, saved, loc)
+
+	// The list belongs to the user, so only their own client may cache it.
+	// The ETag is weak since the body also depends on Accept. Last-Modified
+	// is the latest write to any todo, deletes included, since removing a
+	// todo changes the list without moving any updated_at.
+	setCacheHeaders(w, true, listCacheMaxAge)
+	if checkNotModified(w, r, modified, `W/"`+meta.CollectionVersion+`"`) {
+		return
+	}
+
+	if loc != nil {
+		localizeTodos(todos, loc)
+	}
+
+	if filter.SearchQuery != nil {
+		highlighted, err := h.service.Highlight(todos, *filter.SearchQuery, hlTag)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if fields != nil {
+			fields = append(fields, "highlight")
+		}
+		respondWithTodos(w, r, highlighted, fields, meta)
+		return
+	}
+
+	respondWithTodos(w, r, todos, fields, meta)
+}
+
+// highlightTags are the elements ?hl_tag= may wrap search matches in
+var highlightTags = []string{"mark", "b", "strong", "em"}
+
+// parseHighlightTag returns the element search matches are wrapped in,
+// <mark> unless ?hl_tag= names another one from highlightTags
+func parseHighlightTag(raw string) (string, error) {
+	if raw == "" {
+		return "mark", nil
+	}
+	if !slices.Contains(highlightTags, raw) {
+		return "", invalid("err.hl_tag.oneof", strings.Join(highlightTags, ", "))
+	}
+	return raw, nil
+}
+
+// respondWithTodos writes items in a list envelope with meta, keeping only
+// fields when the request asked for a sparse fieldset. A nil fields writes
+// every field.
+func respondWithTodos[T any](w http.ResponseWriter, r *http.Request, items []T, fields []string, meta models.ListMeta) {
+	if fields == nil {
+		respondWithTimedJSON(w, r, http.StatusOK, models.ListResponse[T]{Data: items, Meta: meta})
+		return
+	}
+
+	sparse, err := selectFields(items, fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithTimedJSON(w, r, http.StatusOK, models.ListResponse[map[string]json.RawMessage]{Data: sparse, Meta: meta})
+}
+
+// GetAllTodoIDs handles GET /todos/ids
+func (h *TodoHandler) GetAllTodoIDs(w http.ResponseWriter, r *http.Request) {
+	// Write each ID as its row arrives, so large lists are neither loaded
+	// nor encoded whole before being sent. The status goes out with the
+	// first ID, so a query that fails before then still gets a 500.
+	bw := bufio.NewWriter(w)
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		bw.WriteString(`{"ids":[`)
+		started = true
+	}
+
+	err := h.service.StreamIDs(r.Context(), func(id int64) error {
+		if started {
+			bw.WriteByte(',')
+		} else {
+			start()
+		}
+		_, err := bw.WriteString(strconv.FormatInt(id, 10))
+		return err
+	})
+	if err != nil && !started {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		// The status has already been sent, so a failure can only be logged
+		log.Printf("Streaming todo IDs failed: %v", err)
+		bw.Flush()
+		return
+	}
+
+	if !started {
+		start()
+	}
+	bw.WriteString("]}")
+	bw.Flush()
 }
 
 // GetTodo handles GET /todos/{id}
@@ -38,46 +203,119 @@ func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
 		return
 	}
 
-	todo, err := h.repo.GetByID(id)
+	expand, err := parseExpand(r.URL.Query().Get("expand"))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	middleware.StartTimer(r.Context(), "db")
+	todo, err := h.service.Get(id)
+	middleware.EndTimer(r.Context(), "db")
+	if errors.Is(err, repository.ErrNotFound) {
+		// Tell sync clients a deleted todo apart from one that never existed
+		deletedAt, err := h.service.DeletedAt(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if deletedAt != nil {
+			respondWithJSON(w, http.StatusGone, models.DeletedTodo{ID: id, DeletedAt: *deletedAt})
+			return
+		}
+
+		respondWithServiceError(w, r, repository.ErrNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if todo == nil {
-		http.Error(w, "Todo not found", http.StatusNotFound)
+	// Reacting and adding dependencies do not touch updated_at, and neither
+	// does completing a blocker, so an expanded todo cannot be answered from
+	// the client's cache
+	if !expand.any() && checkNotModified(w, r, todo.UpdatedAt, "") {
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, todo)
+	if err := h.localizeForRequest(r, todo); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if expand.any() {
+		expanded := models.ExpandedTodo{Todo: todo}
+		if expand.reactions {
+			reactions, err := h.service.Reactions(id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			expanded.Reactions = &reactions
+		}
+		if expand.dependencies {
+			blockers, err := h.service.Dependencies(id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			expanded.BlockedBy = &blockers
+		}
+		respondWithJSON(w, http.StatusOK, expanded)
+		return
+	}
+
+	respondWithTimedJSON(w, r, http.StatusOK, todo)
 }
 
 // CreateTodo handles POST /todos
 func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateTodoRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
 		return
 	}
 	defer r.Body.Close()
 
-	// Validate request
-	if req.Title == "" {
-		http.Error(w, "Title is required", http.StatusBadRequest)
+	todo, err := h.service.Create(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, todo)
+}
+
+// UpsertTodo handles PUT /todos/external/{external_id}
+func (h *TodoHandler) UpsertTodo(w http.ResponseWriter, r *http.Request) {
+	externalID := mux.Vars(r)["external_id"]
+
+	var req models.CreateTodoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
 		return
 	}
+	defer r.Body.Close()
+
+	req.ExternalID = &externalID
 
-	todo, err := h.repo.Create(&req)
+	todo, inserted, err := h.service.Upsert(&req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithServiceError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, todo)
+	status := http.StatusOK
+	if inserted {
+		status = http.StatusCreated
+	}
+
+	respondWithJSON(w, status, todo)
 }
 
 // UpdateTodo handles PUT /todos/{id}
@@ -85,46 +323,595 @@ func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
 		return
 	}
 
 	var req models.UpdateTodoRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	todo, err := h.service.Update(id, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+// DeleteTodo handles DELETE /todos/{id}, responding with the deleted todo,
+// or with no content when ?no_body=true
+func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	noBody := false
+	if raw := r.URL.Query().Get("no_body"); raw != "" {
+		noBody, err = strconv.ParseBool(raw)
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.flag.invalid", "no_body")
+			return
+		}
+	}
+
+	todo, err := h.service.Delete(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	if noBody {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := h.localizeForRequest(r, todo); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+// BatchUpdateTodos handles PATCH /todos/batch
+func (h *TodoHandler) BatchUpdateTodos(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	result, err := h.service.BatchUpdate(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// BulkArchiveTodos handles POST /todos/bulk-archive
+func (h *TodoHandler) BulkArchiveTodos(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkArchiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
 		return
 	}
 	defer r.Body.Close()
 
-	todo, err := h.repo.Update(id, &req)
+	result, err := h.service.BulkArchive(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// ShiftDueDates handles PATCH /todos/bulk-shift-due
+func (h *TodoHandler) ShiftDueDates(w http.ResponseWriter, r *http.Request) {
+	var req models.ShiftDueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	result, err := h.service.ShiftDueDates(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// ReorderTodos handles PATCH /todos/reorder
+func (h *TodoHandler) ReorderTodos(w http.ResponseWriter, r *http.Request) {
+	var req models.ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	result, err := h.service.Reorder(&req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// DeleteCompletedTodos handles DELETE /todos?completed=true
+func (h *TodoHandler) DeleteCompletedTodos(w http.ResponseWriter, r *http.Request) {
+	// Require the filter explicitly so a bare DELETE /todos never
+	// removes more than the caller intended
+	if r.URL.Query().Get("completed") != "true" {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.bulk_delete.completed")
+		return
+	}
+
+	deleted, err := h.service.DeleteAllCompleted()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if todo == nil {
-		http.Error(w, "Todo not found", http.StatusNotFound)
+	respondWithJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
+// CompleteTodo handles POST /todos/{id}/complete
+func (h *TodoHandler) CompleteTodo(w http.ResponseWriter, r *http.Request) {
+	h.setCompleted(w, r, true)
+}
+
+// UncompleteTodo handles POST /todos/{id}/uncomplete
+func (h *TodoHandler) UncompleteTodo(w http.ResponseWriter, r *http.Request) {
+	h.setCompleted(w, r, false)
+}
+
+// setCompleted updates the completion state of the todo identified in the URL
+func (h *TodoHandler) setCompleted(w http.ResponseWriter, r *http.Request, completed bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	todo, err := h.service.SetCompleted(id, completed)
+	if err != nil {
+		respondWithServiceError(w, r, err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, todo)
 }
 
-// DeleteTodo handles DELETE /todos/{id}
-func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
+// PinTodo handles POST /todos/{id}/pin
+func (h *TodoHandler) PinTodo(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, true)
+}
+
+// UnpinTodo handles DELETE /todos/{id}/pin
+func (h *TodoHandler) UnpinTodo(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, false)
+}
+
+// setPinned updates the pinned state of the todo identified in the URL
+func (h *TodoHandler) setPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	todo, err := h.service.SetPinned(id, pinned)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+// SetTodoTags handles PUT /todos/{id}/tags
+func (h *TodoHandler) SetTodoTags(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	var req models.SetTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	todo, err := h.service.SetTags(id, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+// SnoozeTodo handles POST /todos/{id}/snooze?days=1
+func (h *TodoHandler) SnoozeTodo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	days := 1
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		days, err = strconv.Atoi(raw)
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.param.invalid", "days")
+			return
+		}
+	}
+
+	todo, err := h.service.Snooze(id, days)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
+
+// defaultRelatedLimit is how many related todos are suggested without ?limit=
+const defaultRelatedLimit = 5
+
+// GetRelatedTodos handles GET /todos/{id}/related?limit=5
+func (h *TodoHandler) GetRelatedTodos(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	limit := defaultRelatedLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.param.invalid", "limit")
+			return
+		}
+	}
+
+	todos, err := h.service.Related(id, limit)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todos)
+}
+
+// GetGroupedTodos handles GET /todos/grouped?group_by=category
+func (h *TodoHandler) GetGroupedTodos(w http.ResponseWriter, r *http.Request) {
+	var groups interface{}
+	var todos []*models.Todo
+
+	switch r.URL.Query().Get("group_by") {
+	case "", models.GroupByCategory:
+		byCategory, err := h.service.GroupedByCategory()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, group := range byCategory {
+			todos = append(todos, group.Todos...)
+		}
+		groups = byCategory
+	case models.GroupByPriority:
+		byPriority, err := h.service.GroupedByPriority()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, group := range byPriority {
+			todos = append(todos, group.Todos...)
+		}
+		groups = byPriority
+	default:
+		respondWithMessage(w, r, http.StatusBadRequest, "err.group_by.oneof", models.GroupByCategory, models.GroupByPriority)
+		return
+	}
+
+	if err := h.localizeForRequest(r, todos...); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	respondWithJSON(w, http.StatusOK, groups)
+}
+
+// GetTodoTimeline handles GET /todos/{id}/timeline
+func (h *TodoHandler) GetTodoTimeline(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	events, err := h.service.Timeline(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, events)
+}
+
+// GetActivity handles GET /activity
+func (h *TodoHandler) GetActivity(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := models.DefaultActivityLimit
+	if raw := query.Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.param.invalid", "limit")
+			return
+		}
+	}
+
+	var cursor int64
+	if raw := query.Get("cursor"); raw != "" {
+		var err error
+		cursor, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || cursor < 1 {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.param.invalid", "cursor")
+			return
+		}
+	}
+
+	feed, err := h.service.Activity(cursor, limit)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, feed)
+}
+
+// GetTodoBreadcrumbs handles GET /todos/{id}/breadcrumbs
+func (h *TodoHandler) GetTodoBreadcrumbs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	crumbs, err := h.service.Breadcrumbs(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.BreadcrumbsResponse{Breadcrumbs: crumbs})
+}
+
+// ConvertToProject handles POST /todos/{id}/convert-to-project
+func (h *TodoHandler) ConvertToProject(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	conversion, err := h.service.ConvertToProject(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, conversion)
+}
+
+// AddNote handles POST /todos/{id}/notes
+func (h *TodoHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	var req models.AddNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.payload.invalid")
+		return
+	}
+	defer r.Body.Close()
+
+	todo, err := h.service.AddNote(id, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, todo)
+}
+
+// errorResponse is the JSON body written by respondWithErrorCode
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// respondWithErrorCode writes a JSON error with a machine-readable code
+func respondWithErrorCode(w http.ResponseWriter, status int, code, message string) {
+	respondWithJSON(w, status, errorResponse{Code: code, Message: message})
+}
+
+// parseTodoFilter builds a list filter from the query parameters of a
+// request or a saved search
+func parseTodoFilter(query url.Values) (repository.TodoFilter, error) {
+	var filter repository.TodoFilter
+
+	if raw := query.Get("pinned"); raw != "" {
+		pinned, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, invalid("err.filter.invalid", "pinned")
+		}
+		filter.Pinned = &pinned
+	}
+
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		filter.SearchQuery = &q
+	}
+
+	if raw := query.Get("instant"); raw != "" {
+		instant, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, invalid("err.flag.invalid", "instant")
+		}
+		filter.SearchLive = instant
+	}
+
+	for _, tag := range query["tag"] {
+		filter.Tags = append(filter.Tags, strings.ToLower(strings.TrimSpace(tag)))
+	}
+
+	if raw := query.Get("tagged"); raw != "" {
+		tagged, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, invalid("err.filter.invalid", "tagged")
+		}
+		filter.Tagged = &tagged
+	}
+
+	if raw := query.Get("category_id"); raw != "" {
+		categoryID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, invalid("err.filter.invalid", "category_id")
+		}
+		filter.CategoryID = &categoryID
+	}
+
+	if raw := query.Get("include_descendants"); raw != "" {
+		include, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, invalid("err.filter.invalid", "include_descendants")
+		}
+		filter.IncludeDescendants = include
+	}
+
+	if raw := query.Get("sort"); raw == relevanceSort {
+		if filter.SearchQuery == nil {
+			return filter, invalid("err.sort.relevance")
+		}
+		filter.SortByRelevance = true
+	} else {
+		sort, err := ParseSortParams(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.Sort = sort
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return filter, invalid("err.limit.positive")
+		}
+		filter.Limit = limit
+	}
+
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return filter, invalid("err.offset.non_negative")
+		}
+		filter.Offset = offset
+	}
+
+	switch due := query.Get("due"); due {
+	case "", repository.DueToday, repository.DueOverdue:
+		filter.Due = due
+	default:
+		return filter, invalid("err.filter.due", repository.DueToday, repository.DueOverdue)
+	}
+
+	return filter, nil
+}
+
+// respondWithServiceError maps an error returned by the service layer to an
+// HTTP response, with the message in the language negotiated from the
+// request's Accept-Language header
+func respondWithServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *service.ValidationError
+
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, validationErr.Localize(lang), http.StatusBadRequest)
+	case errors.Is(err, repository.ErrTodoLimitExceeded):
+		respondWithErrorCode(w, http.StatusTooManyRequests, "ERR_LIMIT_EXCEEDED", i18n.Message(lang, "err.todo.limit_exceeded"))
+	case errors.Is(err, repository.ErrPinLimitReached):
+		http.Error(w, i18n.Message(lang, "err.pin.limit", repository.MaxPinnedTodos), http.StatusConflict)
+	case errors.Is(err, repository.ErrReactionLimitReached):
+		http.Error(w, i18n.Message(lang, "err.reaction.limit", repository.MaxReactionsPerTodo), http.StatusConflict)
+	case errors.Is(err, repository.ErrBlocked):
+		http.Error(w, i18n.Message(lang, "err.todo.blocked"), http.StatusConflict)
+	case errors.Is(err, repository.ErrDependencyCycle):
+		http.Error(w, i18n.Message(lang, "err.dependency.cycle"), http.StatusConflict)
+	case errors.Is(err, repository.ErrNotFound):
+		http.Error(w, i18n.Message(lang, "err.todo.not_found"), http.StatusNotFound)
+	case errors.Is(err, repository.ErrSavedSearchExists):
+		http.Error(w, i18n.Message(lang, "err.saved_search.exists"), http.StatusConflict)
+	case errors.Is(err, repository.ErrSavedSearchNotFound):
+		http.Error(w, i18n.Message(lang, "err.saved_search.not_found"), http.StatusNotFound)
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		http.Error(w, i18n.Message(lang, "err.category.not_found"), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// respondWithMessage writes the message for key with status, in the language
+// negotiated from the request's Accept-Language header. It is for errors
+// the handlers find themselves, such as a malformed ID or query parameter.
+func respondWithMessage(w http.ResponseWriter, r *http.Request, status int, key string, args ...interface{}) {
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+	http.Error(w, i18n.Message(lang, key, args...), status)
+}
+
+// invalid creates a ValidationError for the message key, for the parsers
+// of query parameters and bodies. respondWithServiceError answers it with
+// 400 in the request's language.
+func invalid(key string, args ...interface{}) error {
+	return &service.ValidationError{Key: key, Args: args}
 }
 
 // respondWithJSON writes the response as JSON
@@ -133,3 +920,20 @@ func respondWithJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
+
+// respondWithTimedJSON is respondWithJSON for the busiest reads. It encodes
+// data before writing the status, so the encoding time can be reported as
+// "serialize" in the Server-Timing header.
+func respondWithTimedJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	middleware.StartTimer(r.Context(), "serialize")
+	body, err := json.Marshal(data)
+	middleware.EndTimer(r.Context(), "serialize")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
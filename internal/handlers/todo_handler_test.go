@@ -0,0 +1,1279 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestCreateTodo(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos", models.CreateTodoRequest{
+		Title:       "Buy milk",
+		Description: "Semi-skimmed",
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var todo models.Todo
+	testhelpers.DecodeJSON(t, resp, &todo)
+
+	if todo.ID == 0 {
+		t.Error("ID was not assigned")
+	}
+	if todo.Title != "Buy milk" {
+		t.Errorf("Title = %q, want %q", todo.Title, "Buy milk")
+	}
+	if todo.Priority != models.PriorityMedium {
+		t.Errorf("Priority = %d, want default %d", todo.Priority, models.PriorityMedium)
+	}
+}
+
+func TestCreateTodoValidation(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	tests := []struct {
+		name string
+		req  models.CreateTodoRequest
+	}{
+		{"missing title", models.CreateTodoRequest{Description: "No title"}},
+		{"whitespace-only title", models.CreateTodoRequest{Title: " \t "}},
+		{"priority too high", models.CreateTodoRequest{Title: "Urgent", Priority: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := testhelpers.MustPost(t, srv, "/api/v1/todos", tt.req)
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestCreateTodoNormalizesWhitespace(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos", models.CreateTodoRequest{
+		Title:       "  Buy   milk  ",
+		Description: "\n Semi-skimmed \n",
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var created models.Todo
+	testhelpers.DecodeJSON(t, resp, &created)
+
+	if created.Title != "Buy milk" {
+		t.Errorf("title = %q, want %q", created.Title, "Buy milk")
+	}
+	if created.Description != "Semi-skimmed" {
+		t.Errorf("description = %q, want %q", created.Description, "Semi-skimmed")
+	}
+
+	title := "   "
+	resp = testhelpers.MustPut(t, srv, fmt.Sprintf("/api/v1/todos/%d", created.ID), models.UpdateTodoRequest{Title: &title})
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestCreateTodoValidationLocalized(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"es-MX,es;q=0.9,en;q=0.8", "Se requiere el título"},
+		{"fr", "Title is required"},
+		{"", "Title is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/todos", strings.NewReader(`{"title": ""}`))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+
+			body, _ := io.ReadAll(resp.Body)
+			if got := strings.TrimSpace(string(body)); got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTodoNotFound(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/42")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestGetTodoConditional(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	lastModified := resp.Header.Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("expected Last-Modified header")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/todos/%d", srv.URL, todo.ID), nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("If-Modified-Since", lastModified)
+
+	resp, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	testhelpers.AssertStatus(t, resp, http.StatusNotModified)
+}
+
+func TestGetAllTodosSetsCacheHeaders(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	if got := resp.Header.Get("Cache-Control"); got != "private, max-age=10" {
+		t.Errorf("Cache-Control = %q, want %q", got, "private, max-age=10")
+	}
+	if got := resp.Header.Values("Vary"); len(got) != 2 || got[0] != "Authorization" || got[1] != "Accept" {
+		t.Errorf("Vary = %v, want [Authorization Accept]", got)
+	}
+	if got, want := resp.Header.Get("Last-Modified"), todo.UpdatedAt.UTC().Format(http.TimeFormat); got != want {
+		t.Errorf("Last-Modified = %q, want %q", got, want)
+	}
+}
+
+func TestGetAllTodosCollectionVersion(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	first := testhelpers.InsertTodo(t, repo)
+	second := testhelpers.InsertTodo(t, repo)
+	shelved := testhelpers.InsertTodo(t, repo)
+	if _, _, err := repo.SetArchived([]int64{shelved.ID}, true); err != nil {
+		t.Fatalf("SetArchived returned error: %v", err)
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	version := list.Meta.CollectionVersion
+	if version == "" {
+		t.Fatal("expected meta.collection_version")
+	}
+	etag := resp.Header.Get("ETag")
+	if etag != `W/"`+version+`"` {
+		t.Errorf("ETag = %q, want W/%q", etag, version)
+	}
+
+	get := func(ifNoneMatch string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/todos", nil)
+		if err != nil {
+			t.Fatalf("Failed to build request: %v", err)
+		}
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	testhelpers.AssertStatus(t, get(etag), http.StatusNotModified)
+
+	// Deleting a todo leaves the latest updated_at alone but changes the version
+	testhelpers.MustDelete(t, srv, fmt.Sprintf("/api/v1/todos/%d", first.ID))
+
+	resp = get(etag)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	list = models.ListResponse[models.Todo]{}
+	testhelpers.DecodeJSON(t, resp, &list)
+	if list.Meta.CollectionVersion == version {
+		t.Errorf("collection_version stayed %q after a delete", version)
+	}
+
+	// Deleting one todo and unarchiving another leaves the count alone but
+	// still changes the version
+	version = list.Meta.CollectionVersion
+	testhelpers.MustDelete(t, srv, fmt.Sprintf("/api/v1/todos/%d", second.ID))
+	if _, _, err := repo.SetArchived([]int64{shelved.ID}, false); err != nil {
+		t.Fatalf("SetArchived returned error: %v", err)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos")
+	list = models.ListResponse[models.Todo]{}
+	testhelpers.DecodeJSON(t, resp, &list)
+	if list.Meta.Total != 1 || list.Meta.CollectionVersion == version {
+		t.Errorf("total = %d, collection_version = %q after a delete and an unarchive, want 1 and a new version", list.Meta.Total, list.Meta.CollectionVersion)
+	}
+}
+
+func TestGetAllTodosCollectionVersionCoversTheRequest(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	testhelpers.InsertTodo(t, repo)
+	testhelpers.InsertTodo(t, repo)
+
+	versionOf := func(path, accept string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatalf("Failed to build request: %v", err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		testhelpers.AssertStatus(t, resp, http.StatusOK)
+		var list models.ListResponse[models.Todo]
+		testhelpers.DecodeJSON(t, resp, &list)
+		return list.Meta.CollectionVersion
+	}
+
+	all := versionOf("/api/v1/todos", "")
+	if first := versionOf("/api/v1/todos?limit=1", ""); first == all {
+		t.Errorf("first page has the version of the whole list, %q", all)
+	}
+	if versionOf("/api/v1/todos?limit=1", "") == versionOf("/api/v1/todos?limit=1&offset=1", "") {
+		t.Error("both pages have the same version")
+	}
+	if local := versionOf("/api/v1/todos", "application/json;tz=user"); local == all {
+		t.Errorf("version in the user's timezone is the UTC one, %q", all)
+	}
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/search/saved", map[string]interface{}{
+		"name":   "open",
+		"params": map[string]interface{}{"pinned": "false"},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+	saved := versionOf("/api/v1/todos?saved_search=open", "")
+	if saved == versionOf("/api/v1/todos?pinned=false", "") {
+		t.Errorf("version through a saved search is the plain one, %q", saved)
+	}
+
+	// Saving the same params again lists the same todos, but the version
+	// still moves with the saved search
+	resp = testhelpers.MustPut(t, srv, "/api/v1/search/saved/open", map[string]interface{}{
+		"params": map[string]interface{}{"pinned": "false"},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	if versionOf("/api/v1/todos?saved_search=open", "") == saved {
+		t.Error("version stayed the same after the saved search was updated")
+	}
+}
+
+func TestGetAllTodosEmpty(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+	if want := `{"data":[],"meta":{"total":0,"page":1,"collection_version":"af5de2c1"}}`; strings.TrimSpace(string(body)) != want {
+		t.Errorf("body = %s, want %s", body, want)
+	}
+}
+
+func TestGetAllTodosSetsPaginationHeaders(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	for i := 0; i < 5; i++ {
+		testhelpers.InsertTodo(t, repo)
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?limit=2&offset=2")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+	if len(todos) != 2 {
+		t.Errorf("got %d todos, want 2", len(todos))
+	}
+	if list.Meta.Total != 5 || list.Meta.Page != 2 {
+		t.Errorf("meta = %+v, want total 5 on page 2", list.Meta)
+	}
+
+	if got := resp.Header.Get("X-Total-Count"); got != "5" {
+		t.Errorf("X-Total-Count = %q, want %q", got, "5")
+	}
+	if got := resp.Header.Get("X-Page-Count"); got != "3" {
+		t.Errorf("X-Page-Count = %q, want %q", got, "3")
+	}
+	want := `</api/v1/todos?limit=2&offset=4>; rel="next"`
+	if got := resp.Header.Get("Link"); got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+
+	// The last page has no next link
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos?limit=2&offset=4")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	if got := resp.Header.Get("Link"); got != "" {
+		t.Errorf("Link = %q on the last page, want none", got)
+	}
+}
+
+func TestRequestValidationLocalized(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	tests := []struct {
+		path           string
+		acceptLanguage string
+		lang           string
+		want           string
+	}{
+		{"/api/v1/activity?cursor=x", "es", "es", "cursor no válido"},
+		{"/api/v1/todos/grouped?group_by=color", "", "en", "Invalid group_by (valid values: category, priority)"},
+		{"/api/v1/todos?pinned=maybe", "es-MX", "es", "Filtro pinned no válido"},
+		{"/api/v1/todos?sort=color", "es", "es", `Campo de orden desconocido "color"`},
+		{"/api/v1/todos?due=someday", "fr", "en", "Invalid due filter (expected today or overdue)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.acceptLanguage, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+
+			body, _ := io.ReadAll(resp.Body)
+			if got := strings.TrimSpace(string(body)); got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+			if got := resp.Header.Get("Content-Language"); got != tt.lang {
+				t.Errorf("Content-Language = %q, want %q", got, tt.lang)
+			}
+		})
+	}
+}
+
+func TestGetAllTodosInvalidLimit(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?limit=0")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestUpdateTodoCompletes(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	created := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Write tests"))
+
+	completed := true
+	resp := testhelpers.MustPut(t, srv, fmt.Sprintf("/api/v1/todos/%d", created.ID), models.UpdateTodoRequest{Completed: &completed})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var todo models.Todo
+	testhelpers.DecodeJSON(t, resp, &todo)
+
+	if todo.ID != created.ID {
+		t.Errorf("ID = %d, want %d", todo.ID, created.ID)
+	}
+	if !todo.Completed || todo.CompletedAt == nil {
+		t.Errorf("todo was not completed: completed=%v completed_at=%v", todo.Completed, todo.CompletedAt)
+	}
+}
+
+func TestDeleteTodo(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Doomed"))
+	path := fmt.Sprintf("/api/v1/todos/%d", todo.ID)
+
+	resp := testhelpers.MustDelete(t, srv, path)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var removed models.Todo
+	testhelpers.DecodeJSON(t, resp, &removed)
+	if removed.ID != todo.ID || removed.Title != "Doomed" {
+		t.Errorf("removed = %+v, want the deleted todo", removed)
+	}
+
+	// A deleted todo is gone rather than never having existed
+	resp = testhelpers.MustGet(t, srv, path)
+	testhelpers.AssertStatus(t, resp, http.StatusGone)
+
+	var deleted models.DeletedTodo
+	testhelpers.DecodeJSON(t, resp, &deleted)
+	if deleted.ID != todo.ID || deleted.DeletedAt.IsZero() {
+		t.Errorf("deleted = %+v, want ID %d and a deleted_at", deleted, todo.ID)
+	}
+}
+
+func TestDeleteTodoWithoutBody(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustDelete(t, srv, fmt.Sprintf("/api/v1/todos/%d?no_body=true", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusNoContent)
+
+	resp = testhelpers.MustDelete(t, srv, fmt.Sprintf("/api/v1/todos/%d?no_body=maybe", testhelpers.InsertTodo(t, repo).ID))
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestDeleteTodoNotFound(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustDelete(t, srv, "/api/v1/todos/42")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestSingleTodoActionsNotFound(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	tests := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodPost, "/api/v1/todos/42/complete", nil},
+		{http.MethodPost, "/api/v1/todos/42/uncomplete", nil},
+		{http.MethodPost, "/api/v1/todos/42/pin", nil},
+		{http.MethodDelete, "/api/v1/todos/42/pin", nil},
+		{http.MethodPut, "/api/v1/todos/42/tags", models.SetTagsRequest{Tags: []string{"home"}}},
+		{http.MethodPost, "/api/v1/todos/42/snooze", nil},
+		{http.MethodPost, "/api/v1/todos/42/notes", models.AddNoteRequest{Body: "Called"}},
+		{http.MethodPost, "/api/v1/todos/42/convert-to-project", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			resp := testhelpers.MustDo(t, srv, tt.method, tt.path, tt.body)
+			testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+		})
+	}
+}
+
+func TestDeleteCompletedTodosRequiresFilter(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustDelete(t, srv, "/api/v1/todos")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestDeleteCompletedTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+	pending := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustDelete(t, srv, "/api/v1/todos?completed=true")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var result map[string]int64
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result["deleted"] != 2 {
+		t.Errorf("deleted = %d, want 2", result["deleted"])
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/ids")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var remaining struct {
+		IDs []int64 `json:"ids"`
+	}
+	testhelpers.DecodeJSON(t, resp, &remaining)
+	if len(remaining.IDs) != 1 || remaining.IDs[0] != pending.ID {
+		t.Errorf("remaining IDs = %v, want [%d]", remaining.IDs, pending.ID)
+	}
+}
+
+func TestGetAllTodoIDsWithNoTodos(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/ids")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	body, _ := io.ReadAll(resp.Body)
+	if got := string(body); got != `{"ids":[]}` {
+		t.Errorf("body = %s, want {\"ids\":[]}", got)
+	}
+}
+
+// brokenIDsRepository fails to read todo IDs before finding any
+type brokenIDsRepository struct {
+	*testhelpers.MemoryRepository
+}
+
+func (r brokenIDsRepository) StreamAllIDs(ctx context.Context, fn func(int64) error) error {
+	return errors.New("connection reset")
+}
+
+func TestGetAllTodoIDsFailsBeforeFirstID(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, brokenIDsRepository{testhelpers.NewMemoryRepository()})
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/ids")
+	testhelpers.AssertStatus(t, resp, http.StatusInternalServerError)
+}
+
+func TestTodoResponseShape(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	todo := testhelpers.InsertTodo(t, repo,
+		testhelpers.WithTitle("Buy milk"),
+		testhelpers.WithDescription("Semi-skimmed"),
+		testhelpers.WithExternalID("ext-1"),
+		testhelpers.WithCompleted(true),
+	)
+	if _, err := repo.AppendNote(todo.ID, "Shop was closed"); err != nil {
+		t.Fatalf("AppendNote returned error: %v", err)
+	}
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Write tests"), testhelpers.WithPinned(true))
+
+	tests := []struct {
+		golden string
+		path   string
+	}{
+		{"get_todo", fmt.Sprintf("/api/v1/todos/%d", todo.ID)},
+		{"list_todos", "/api/v1/todos"},
+		{"list_todos_sparse", "/api/v1/todos?fields=id,title,completed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			resp := testhelpers.MustGet(t, srv, tt.path)
+			testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Failed to read response body: %v", err)
+			}
+
+			testhelpers.AssertGolden(t, tt.golden, body)
+		})
+	}
+}
+
+func TestSnoozeTodo(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+	todo := testhelpers.InsertTodo(t, repo)
+	path := fmt.Sprintf("/api/v1/todos/%d/snooze", todo.ID)
+
+	resp := testhelpers.MustPost(t, srv, path+"?days=31", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+
+	var snoozed models.Todo
+	for i := 0; i < models.SnoozeFlagThreshold; i++ {
+		resp = testhelpers.MustPost(t, srv, path+"?days=2", nil)
+		testhelpers.AssertStatus(t, resp, http.StatusOK)
+		testhelpers.DecodeJSON(t, resp, &snoozed)
+
+		if snoozed.Flagged != (i == models.SnoozeFlagThreshold-1) {
+			t.Errorf("after %d snoozes flagged = %v", i+1, snoozed.Flagged)
+		}
+	}
+
+	if snoozed.SnoozeCount != models.SnoozeFlagThreshold {
+		t.Errorf("snooze_count = %d, want %d", snoozed.SnoozeCount, models.SnoozeFlagThreshold)
+	}
+	if snoozed.DueAt == nil || snoozed.DueAt.Before(time.Now().AddDate(0, 0, 9)) {
+		t.Errorf("due_at = %v, want about 10 days from now", snoozed.DueAt)
+	}
+}
+
+func TestGetAllTodosSearchAndTag(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	groceries := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Shopping for groceries"))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Shopping for a new laptop"))
+	errand := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Post office"))
+
+	for _, todo := range []*models.Todo{groceries, errand} {
+		resp := testhelpers.MustPut(t, srv, fmt.Sprintf("/api/v1/todos/%d/tags", todo.ID), models.SetTagsRequest{Tags: []string{" Errands "}})
+		testhelpers.AssertStatus(t, resp, http.StatusOK)
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?q=shopping&tag=errands")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+
+	if len(todos) != 1 || todos[0].ID != groceries.ID {
+		t.Fatalf("got %+v, want only todo %d", todos, groceries.ID)
+	}
+	if len(todos[0].Tags) != 1 || todos[0].Tags[0] != "errands" {
+		t.Errorf("tags = %v, want [errands]", todos[0].Tags)
+	}
+}
+
+func TestGetAllTodosFiltersByTagged(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	tagged := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Tagged"))
+	untagged := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Inbox"))
+	if _, err := repo.SetTags(tagged.ID, []string{"work"}); err != nil {
+		t.Fatalf("SetTags returned error: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  int64
+	}{
+		{"tagged=false", untagged.ID},
+		{"tagged=true", tagged.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resp := testhelpers.MustGet(t, srv, "/api/v1/todos?"+tt.query)
+			testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+			var list models.ListResponse[models.Todo]
+			testhelpers.DecodeJSON(t, resp, &list)
+			if len(list.Data) != 1 || list.Data[0].ID != tt.want {
+				t.Errorf("got %+v, want only todo %d", list.Data, tt.want)
+			}
+		})
+	}
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?tagged=maybe")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestGetAllTodosInvalidInstantFlag(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?q=shopping&instant=soon")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestPreflight(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustDo(t, srv, http.MethodOptions, "/api/v1/todos/1", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusNoContent)
+
+	if got := resp.Header.Get("Access-Control-Allow-Methods"); got != "GET, PUT, PATCH, DELETE, OPTIONS" {
+		t.Errorf("Access-Control-Allow-Methods = %q, want the methods of /todos/{id}", got)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Content-Type") || !strings.Contains(got, "If-None-Match") {
+		t.Errorf("Access-Control-Allow-Headers = %q, want Content-Type and If-None-Match allowed", got)
+	}
+
+	resp = testhelpers.MustDo(t, srv, http.MethodOptions, "/api/v1/nothing-here", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestReorderTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	first := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("First"))
+	second := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Second"))
+
+	resp := testhelpers.MustDo(t, srv, http.MethodPatch, "/api/v1/todos/reorder", models.ReorderRequest{
+		Order: []models.TodoPosition{{ID: first.ID, Position: 1}, {ID: second.ID, Position: 2}},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var result models.ReorderResult
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Updated != 2 {
+		t.Errorf("updated = %d, want 2", result.Updated)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos")
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+	if len(todos) != 2 || todos[0].ID != first.ID || todos[1].ID != second.ID {
+		t.Errorf("todos are not in the new order: %+v", todos)
+	}
+}
+
+func TestReorderTodosRejectsInvalidOrder(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	first := testhelpers.InsertTodo(t, repo)
+	second := testhelpers.InsertTodo(t, repo)
+
+	tests := []struct {
+		name  string
+		order []models.TodoPosition
+		want  int
+	}{
+		{"duplicate position", []models.TodoPosition{{ID: first.ID, Position: 1}, {ID: second.ID, Position: 1}}, http.StatusBadRequest},
+		{"duplicate id", []models.TodoPosition{{ID: first.ID, Position: 1}, {ID: first.ID, Position: 2}}, http.StatusBadRequest},
+		{"empty", nil, http.StatusBadRequest},
+		{"missing todo", []models.TodoPosition{{ID: first.ID, Position: 1}, {ID: 999, Position: 2}}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := testhelpers.MustDo(t, srv, http.MethodPatch, "/api/v1/todos/reorder", models.ReorderRequest{Order: tt.order})
+			testhelpers.AssertStatus(t, resp, tt.want)
+		})
+	}
+
+	// A rejected order changes nothing
+	stored, _ := repo.GetByID(first.ID)
+	if stored.Position != nil {
+		t.Errorf("position = %d, want unset", *stored.Position)
+	}
+}
+
+func TestBulkArchiveTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	done := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+	open := testhelpers.InsertTodo(t, repo)
+	earlier := testhelpers.InsertTodo(t, repo)
+	if _, _, err := repo.SetArchived([]int64{earlier.ID}, true); err != nil {
+		t.Fatalf("SetArchived returned error: %v", err)
+	}
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos/bulk-archive", models.BulkArchiveRequest{
+		IDs: []int64{done.ID, earlier.ID, 999},
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var result models.BulkArchiveResult
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Updated != 1 {
+		t.Errorf("updated = %d, want 1", result.Updated)
+	}
+	if len(result.AlreadyArchived) != 1 || result.AlreadyArchived[0] != earlier.ID {
+		t.Errorf("already_archived = %v, want [%d]", result.AlreadyArchived, earlier.ID)
+	}
+	if len(result.NotFound) != 1 || result.NotFound[0] != 999 {
+		t.Errorf("not_found = %v, want [999]", result.NotFound)
+	}
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d", done.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	var archived models.Todo
+	testhelpers.DecodeJSON(t, resp, &archived)
+	if archived.ArchivedAt == nil {
+		t.Errorf("todo %d was not archived", done.ID)
+	}
+	if kept, _ := repo.GetByID(open.ID); kept.ArchivedAt != nil {
+		t.Errorf("todo %d was archived, want it kept", open.ID)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	var list models.ListResponse[models.Todo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	if len(list.Data) != 1 || list.Data[0].ID != open.ID {
+		t.Errorf("listed %d todos, want only todo %d", len(list.Data), open.ID)
+	}
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/todos/bulk-archive", models.BulkArchiveRequest{
+		IDs:    []int64{done.ID, open.ID},
+		Action: models.BulkUnarchive,
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	result = models.BulkArchiveResult{}
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Updated != 1 || len(result.NotArchived) != 1 || result.NotArchived[0] != open.ID {
+		t.Errorf("unarchive result = %+v, want 1 updated and todo %d not archived", result, open.ID)
+	}
+	if restored, _ := repo.GetByID(done.ID); restored.ArchivedAt != nil {
+		t.Errorf("todo %d was not unarchived", done.ID)
+	}
+}
+
+func TestBulkArchiveTodosRejectsInvalidRequest(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	tooMany := make([]int64, models.MaxBulkIDs+1)
+	for i := range tooMany {
+		tooMany[i] = int64(i + 1)
+	}
+
+	tests := []struct {
+		name string
+		req  models.BulkArchiveRequest
+	}{
+		{"no ids", models.BulkArchiveRequest{}},
+		{"too many ids", models.BulkArchiveRequest{IDs: tooMany}},
+		{"unknown action", models.BulkArchiveRequest{IDs: []int64{1}, Action: "shred"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := testhelpers.MustPost(t, srv, "/api/v1/todos/bulk-archive", tt.req)
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestShiftDueDates(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	due := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	dated := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(due))
+	undated := testhelpers.InsertTodo(t, repo)
+
+	resp := testhelpers.MustDo(t, srv, http.MethodPatch, "/api/v1/todos/bulk-shift-due", models.ShiftDueRequest{
+		IDs:       []int64{dated.ID, undated.ID, 999},
+		ShiftDays: 7,
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var result models.ShiftDueResult
+	testhelpers.DecodeJSON(t, resp, &result)
+	if result.Updated != 1 || result.SkippedNoDueDate != 1 {
+		t.Errorf("result = %+v, want 1 updated and 1 skipped", result)
+	}
+	if len(result.NotFound) != 1 || result.NotFound[0] != 999 {
+		t.Errorf("not_found = %v, want [999]", result.NotFound)
+	}
+
+	shifted, _ := repo.GetByID(dated.ID)
+	if want := due.AddDate(0, 0, 7); shifted.DueAt == nil || !shifted.DueAt.Equal(want) {
+		t.Errorf("due_at = %v, want %v", shifted.DueAt, want)
+	}
+	if kept, _ := repo.GetByID(undated.ID); kept.DueAt != nil {
+		t.Errorf("due_at = %v, want it left unset", kept.DueAt)
+	}
+}
+
+func TestShiftDueDatesRejectsInvalidRequest(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	tests := []struct {
+		name string
+		req  models.ShiftDueRequest
+	}{
+		{"no ids", models.ShiftDueRequest{ShiftDays: 7}},
+		{"no shift", models.ShiftDueRequest{IDs: []int64{1}}},
+		{"too far", models.ShiftDueRequest{IDs: []int64{1}, ShiftDays: models.MaxShiftDays + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := testhelpers.MustDo(t, srv, http.MethodPatch, "/api/v1/todos/bulk-shift-due", tt.req)
+			testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestCreateCompletedTodo(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos", models.CreateTodoRequest{Title: "Already done", Completed: true})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var todo models.Todo
+	testhelpers.DecodeJSON(t, resp, &todo)
+	if !todo.Completed || todo.CompletedAt == nil {
+		t.Errorf("todo was not created completed: completed=%v completed_at=%v", todo.Completed, todo.CompletedAt)
+	}
+}
+
+func TestGetAllTodosHighlightsSearchMatches(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy <fresh> milk"), testhelpers.WithDescription("Semi-skimmed"))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos?q=milk&hl_tag=b")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var list models.ListResponse[models.HighlightedTodo]
+	testhelpers.DecodeJSON(t, resp, &list)
+	todos := list.Data
+
+	if len(todos) != 1 {
+		t.Fatalf("got %d todos, want 1", len(todos))
+	}
+	if got, want := todos[0].Highlight["title"], "Buy &lt;fresh&gt; <b>milk</b>"; got != want {
+		t.Errorf("title highlight = %q, want %q", got, want)
+	}
+	if got := todos[0].Highlight["description"]; got != "" {
+		t.Errorf("description highlight = %q, want empty", got)
+	}
+
+	// Only searches are highlighted
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos")
+	body, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(body), "highlight") {
+		t.Errorf("list without q has highlights: %s", body)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos?q=milk&hl_tag=script")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestGetRelatedTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	source := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk and eggs"))
+	both := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Eggs"), testhelpers.WithDescription("and milk"))
+	one := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Oat milk"))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Milk the cows"), testhelpers.WithCompleted(true))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Walk the dog"))
+
+	resp := testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d/related?limit=5", source.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var related []models.Todo
+	testhelpers.DecodeJSON(t, resp, &related)
+
+	if len(related) != 2 || related[0].ID != both.ID || related[1].ID != one.ID {
+		t.Errorf("related = %+v, want todos %d then %d", related, both.ID, one.ID)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/999/related")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d/related?limit=100", source.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+func TestGetGroupedTodos(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	work, _ := repo.CreateCategory("Work", nil)
+	repo.CreateCategory("Home", nil)
+	report, _ := repo.Create(&models.CreateTodoRequest{Title: "Write report", Priority: models.PriorityHigh, CategoryID: &work.ID})
+	review, _ := repo.Create(&models.CreateTodoRequest{Title: "Review PR", Priority: models.PriorityLow, CategoryID: &work.ID})
+	milk := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk"), testhelpers.WithPriority(models.PriorityHigh))
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/grouped")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var byCategory []models.CategoryGroup
+	testhelpers.DecodeJSON(t, resp, &byCategory)
+	if len(byCategory) != 3 {
+		t.Fatalf("got %d groups, want Work, Home and uncategorized", len(byCategory))
+	}
+	if c := byCategory[0].Category; c == nil || c.Name != "Work" || len(byCategory[0].Todos) != 2 {
+		t.Errorf("groups[0] = %+v, want Work with 2 todos", byCategory[0])
+	}
+	if byCategory[0].Todos[0].ID != report.ID || byCategory[0].Todos[1].ID != review.ID {
+		t.Errorf("Work todos = %v, want oldest first", byCategory[0].Todos)
+	}
+	if c := byCategory[1].Category; c == nil || c.Name != "Home" || len(byCategory[1].Todos) != 0 {
+		t.Errorf("groups[1] = %+v, want empty Home", byCategory[1])
+	}
+	if byCategory[2].Category != nil || len(byCategory[2].Todos) != 1 || byCategory[2].Todos[0].ID != milk.ID {
+		t.Errorf("groups[2] = %+v, want uncategorized Buy milk", byCategory[2])
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/grouped?group_by=priority")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var byPriority []models.PriorityGroup
+	testhelpers.DecodeJSON(t, resp, &byPriority)
+	if len(byPriority) != 3 {
+		t.Fatalf("got %d groups, want one per priority", len(byPriority))
+	}
+	wantCounts := []int{1, 0, 2}
+	for i, group := range byPriority {
+		if group.Priority != i+1 || group.Label != models.PriorityLabels[i+1] || len(group.Todos) != wantCounts[i] {
+			t.Errorf("groups[%d] = priority %d %q with %d todos, want priority %d with %d", i, group.Priority, group.Label, len(group.Todos), i+1, wantCounts[i])
+		}
+	}
+	if high := byPriority[2].Todos; high[0].ID != report.ID || high[1].ID != milk.ID {
+		t.Errorf("high priority todos = %v, want categorized first", high)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/grouped?group_by=tag")
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+}
+
+// vanishingCategoriesRepository lists no categories, as if they were all
+// removed right after the todos in them were read
+type vanishingCategoriesRepository struct {
+	*testhelpers.MemoryRepository
+}
+
+func (r vanishingCategoriesRepository) GetAllCategories() ([]*models.Category, error) {
+	return nil, nil
+}
+
+func TestGetGroupedTodosWithoutTheirCategory(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, vanishingCategoriesRepository{repo})
+
+	work, _ := repo.CreateCategory("Work", nil)
+	todo, _ := repo.Create(&models.CreateTodoRequest{Title: "Write report", Priority: models.PriorityMedium, CategoryID: &work.ID})
+
+	resp := testhelpers.MustGet(t, srv, "/api/v1/todos/grouped")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var groups []models.CategoryGroup
+	testhelpers.DecodeJSON(t, resp, &groups)
+	if len(groups) != 1 || groups[0].Category != nil || len(groups[0].Todos) != 1 || groups[0].Todos[0].ID != todo.ID {
+		t.Errorf("groups = %+v, want the todo in the uncategorized group", groups)
+	}
+}
+
+func TestGetTodoBreadcrumbs(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	project, _ := repo.CreateCategory("Project X", nil)
+	sprint, _ := repo.CreateCategory("Sprint 1", &project.ID)
+	todo, _ := repo.Create(&models.CreateTodoRequest{Title: "Write tests", Priority: models.PriorityMedium, CategoryID: &sprint.ID})
+	loose := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk"))
+
+	resp := testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d/breadcrumbs", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var got models.BreadcrumbsResponse
+	testhelpers.DecodeJSON(t, resp, &got)
+	want := []models.Breadcrumb{
+		{ID: project.ID, Title: "Project X", Type: models.BreadcrumbCategory},
+		{ID: sprint.ID, Title: "Sprint 1", Type: models.BreadcrumbCategory},
+		{ID: todo.ID, Title: "Write tests", Type: models.BreadcrumbTodo},
+	}
+	if !reflect.DeepEqual(got.Breadcrumbs, want) {
+		t.Errorf("breadcrumbs = %+v, want %+v", got.Breadcrumbs, want)
+	}
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d/breadcrumbs", loose.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	testhelpers.DecodeJSON(t, resp, &got)
+	if len(got.Breadcrumbs) != 1 || got.Breadcrumbs[0].ID != loose.ID {
+		t.Errorf("uncategorized breadcrumbs = %+v, want just the todo", got.Breadcrumbs)
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/999/breadcrumbs")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestConvertToProject(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	area, _ := repo.CreateCategory("Home", nil)
+	todo, _ := repo.Create(&models.CreateTodoRequest{Title: "Renovate kitchen", Priority: models.PriorityMedium, CategoryID: &area.ID})
+
+	resp := testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/convert-to-project", todo.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var got models.ProjectConversion
+	testhelpers.DecodeJSON(t, resp, &got)
+	if got.Category == nil || got.Category.Name != "Renovate kitchen" {
+		t.Fatalf("category = %+v, want one named after the todo", got.Category)
+	}
+	if got.Category.ParentID == nil || *got.Category.ParentID != area.ID {
+		t.Errorf("parent = %v, want %d", got.Category.ParentID, area.ID)
+	}
+	if got.MigratedTodos != 0 {
+		t.Errorf("migrated_todos = %d, want 0", got.MigratedTodos)
+	}
+
+	if archived, _ := repo.GetByID(todo.ID); archived.ArchivedAt == nil {
+		t.Errorf("todo %d was not archived", todo.ID)
+	}
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/todos/999/convert-to-project", nil)
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestConvertToProjectTooDeep(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	var parentID *int64
+	for i := 0; i < models.MaxCategoryDepth; i++ {
+		category, _ := repo.CreateCategory(fmt.Sprintf("Level %d", i+1), parentID)
+		parentID = &category.ID
+	}
+	todo, _ := repo.Create(&models.CreateTodoRequest{Title: "Too deep", Priority: models.PriorityMedium, CategoryID: parentID})
+
+	resp := testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/convert-to-project", todo.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+}
+
+func TestGetTodoTimeline(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityMedium))
+
+	resp := testhelpers.MustPut(t, srv, fmt.Sprintf("/api/v1/todos/%d", todo.ID), map[string]interface{}{
+		"priority": models.PriorityHigh,
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	resp = testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/complete", todo.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	resp = testhelpers.MustGet(t, srv, fmt.Sprintf("/api/v1/todos/%d/timeline", todo.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var events []map[string]interface{}
+	testhelpers.DecodeJSON(t, resp, &events)
+
+	want := []string{models.EventCreated, models.EventPriorityChanged, models.EventCompleted}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(events), len(want), events)
+	}
+	for i, event := range events {
+		if event["event"] != want[i] {
+			t.Errorf("events[%d] = %v, want %s", i, event["event"], want[i])
+		}
+	}
+	if events[1]["from"] != float64(models.PriorityMedium) || events[1]["to"] != float64(models.PriorityHigh) {
+		t.Errorf("priority change = %v -> %v, want %d -> %d", events[1]["from"], events[1]["to"], models.PriorityMedium, models.PriorityHigh)
+	}
+	if _, ok := events[0]["from"]; ok {
+		t.Error("created event has a from value")
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/todos/999/timeline")
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
+
+func TestGetActivity(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	kept := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Kept"))
+	deleted := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Deleted"))
+
+	resp := testhelpers.MustPost(t, srv, fmt.Sprintf("/api/v1/todos/%d/complete", kept.ID), nil)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+	resp = testhelpers.MustDelete(t, srv, fmt.Sprintf("/api/v1/todos/%d", deleted.ID))
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/activity?limit=3")
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var feed models.ActivityFeed
+	testhelpers.DecodeJSON(t, resp, &feed)
+
+	want := []struct {
+		todoID int64
+		event  string
+	}{{deleted.ID, models.EventDeleted}, {kept.ID, models.EventCompleted}, {deleted.ID, models.EventCreated}}
+	if len(feed.Data) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(feed.Data), len(want))
+	}
+	for i, entry := range feed.Data {
+		if entry.TodoID != want[i].todoID || entry.Event != want[i].event {
+			t.Errorf("entry %d = todo %d %s, want todo %d %s", i, entry.TodoID, entry.Event, want[i].todoID, want[i].event)
+		}
+	}
+	if feed.Data[0].TodoTitle != nil {
+		t.Errorf("deleted todo title = %q, want null", *feed.Data[0].TodoTitle)
+	}
+	if title := feed.Data[1].TodoTitle; title == nil || *title != "Kept" {
+		t.Errorf("todo title = %v, want Kept", title)
+	}
+	if feed.NextCursor == "" {
+		t.Fatal("next_cursor is empty, want a cursor to the older entry")
+	}
+
+	resp = testhelpers.MustGet(t, srv, "/api/v1/activity?limit=3&cursor="+feed.NextCursor)
+	testhelpers.AssertStatus(t, resp, http.StatusOK)
+
+	var older models.ActivityFeed
+	testhelpers.DecodeJSON(t, resp, &older)
+	if len(older.Data) != 1 || older.Data[0].TodoID != kept.ID || older.Data[0].Event != models.EventCreated {
+		t.Errorf("older entries = %+v, want only the creation of todo %d", older.Data, kept.ID)
+	}
+	if older.NextCursor != "" {
+		t.Errorf("next_cursor on the last page = %q, want none", older.NextCursor)
+	}
+}
+
+func TestGetActivityRejectsInvalidParameters(t *testing.T) {
+	srv := testhelpers.NewTestServer(t, testhelpers.NewMemoryRepository())
+
+	for _, query := range []string{"limit=0", "limit=201", "limit=ten", "cursor=0", "cursor=abc"} {
+		resp := testhelpers.MustGet(t, srv, "/api/v1/activity?"+query)
+		testhelpers.AssertStatus(t, resp, http.StatusBadRequest)
+	}
+}
+
+func TestCreateTodoInCategory(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	srv := testhelpers.NewTestServer(t, repo)
+
+	category, err := repo.CreateCategory("Work", nil)
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+
+	resp := testhelpers.MustPost(t, srv, "/api/v1/todos", map[string]interface{}{
+		"title":       "Write report",
+		"category_id": category.ID,
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusCreated)
+
+	var todo models.Todo
+	testhelpers.DecodeJSON(t, resp, &todo)
+	if todo.CategoryID == nil || *todo.CategoryID != category.ID {
+		t.Errorf("category_id = %v, want %d", todo.CategoryID, category.ID)
+	}
+
+	resp = testhelpers.MustPost(t, srv, "/api/v1/todos", map[string]interface{}{
+		"title":       "Write report",
+		"category_id": 999,
+	})
+	testhelpers.AssertStatus(t, resp, http.StatusNotFound)
+}
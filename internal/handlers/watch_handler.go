@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/service"
+	"github.com/yourusername/todo-api/internal/watch"
+)
+
+// Bounds and default for the ?timeout= parameter of WatchTodo, in seconds
+const (
+	defaultWatchTimeout = 30
+	maxWatchTimeout     = 120
+)
+
+// WatchHandler handles long-poll requests waiting for a todo to change
+type WatchHandler struct {
+	service *service.TodoService
+	broker  *watch.Broker
+}
+
+// NewWatchHandler creates a new WatchHandler
+func NewWatchHandler(service *service.TodoService, broker *watch.Broker) *WatchHandler {
+	return &WatchHandler{
+		service: service,
+		broker:  broker,
+	}
+}
+
+// WatchTodo handles GET /todos/{id}/watch
+//
+// It waits up to ?timeout= seconds for the todo to change and returns its
+// new state, or 304 Not Modified if nothing changed in time.
+func (h *WatchHandler) WatchTodo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	timeout := defaultWatchTimeout
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		timeout, err = strconv.Atoi(raw)
+		if err != nil || timeout < 1 || timeout > maxWatchTimeout {
+			respondWithMessage(w, r, http.StatusBadRequest, "err.timeout.range", maxWatchTimeout)
+			return
+		}
+	}
+
+	// Subscribe before checking the todo exists so no change is missed
+	changed, cancel := h.broker.Subscribe(id)
+	defer cancel()
+
+	todo, err := h.service.Get(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	// Allow the response to outlive the server's write timeout
+	wait := time.Duration(timeout) * time.Second
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-changed:
+	case <-timer.C:
+		w.WriteHeader(http.StatusNotModified)
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	todo, err = h.service.Get(id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, todo)
+}
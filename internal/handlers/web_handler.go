@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/service"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// WebHandler serves a server-rendered HTML view of the todo list
+type WebHandler struct {
+	service *service.TodoService
+}
+
+// NewWebHandler creates a new WebHandler
+func NewWebHandler(service *service.TodoService) *WebHandler {
+	return &WebHandler{
+		service: service,
+	}
+}
+
+// Index handles GET /
+func (h *WebHandler) Index(w http.ResponseWriter, r *http.Request) {
+	todos, _, err := h.service.List(repository.TodoFilter{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Todos []*models.Todo
+	}{
+		Todos: todos,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "index.html", data); err != nil {
+		log.Printf("Failed to render index: %v", err)
+	}
+}
+
+// ToggleTodo handles POST /todos/{id}/toggle from the HTML form
+func (h *WebHandler) ToggleTodo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		respondWithMessage(w, r, http.StatusBadRequest, "err.todo_id.invalid")
+		return
+	}
+
+	if _, err := h.service.Toggle(id); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
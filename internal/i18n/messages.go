@@ -0,0 +1,282 @@
+// Package i18n translates user-facing error messages. Messages are looked up
+// by key, so adding a language only means adding an entry to messages.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when a request accepts none of the supported
+// languages, and for keys a language does not translate
+const DefaultLanguage = "en"
+
+// messages maps a language tag to message keys and their format strings
+var messages = map[string]map[string]string{
+	"en": {
+		"err.action.oneof":                   "Action must be one of: %s",
+		"err.activity.limit":                 "Limit must be between 1 and %d",
+		"err.batch.one_field":                "Exactly one of priority and category_id must be given",
+		"err.blocking_todo_id.invalid":       "Invalid blocking todo ID",
+		"err.blocking_todo_id.required":      "Blocking todo ID is required",
+		"err.body.required":                  "Body is required",
+		"err.bulk_delete.completed":          "Only completed todos can be deleted in bulk; pass ?completed=true",
+		"err.category.not_found":             "Category not found",
+		"err.category.parent_not_found":      "Parent category not found",
+		"err.category.too_deep":              "Categories can be nested at most %d levels deep",
+		"err.category_id.invalid":            "Invalid category ID",
+		"err.content_type.unsupported":       "Content-Type must be %s",
+		"err.csv.empty":                      "CSV is empty",
+		"err.csv.invalid":                    "Invalid CSV: %v",
+		"err.csv.title":                      "CSV header must contain a title column",
+		"err.default_filter.sort":            "Set the default sort with default_sort, not in default_filter",
+		"err.default_sort.max":               "Default sort must be at most %s characters",
+		"err.dependency.cycle":               "The blocking todo already depends on this todo",
+		"err.dependency.self":                "A todo cannot depend on itself",
+		"err.description.max":                "Description must be at most %s characters",
+		"err.digest.period":                  "Period must be %q or %q",
+		"err.emoji.emoji":                    "Emoji must be a single emoji",
+		"err.emoji.required":                 "Emoji is required",
+		"err.expand.unknown":                 "Unknown expand %q (valid values: %s, %s)",
+		"err.export.format":                  "Unsupported export format",
+		"err.external_id.required":           "External ID is required",
+		"err.field.unknown":                  "Unknown field %q (valid fields: %s)",
+		"err.file.required":                  "Missing file",
+		"err.filter.due":                     "Invalid due filter (expected %s or %s)",
+		"err.filter.invalid":                 "Invalid %s filter",
+		"err.flag.invalid":                   "Invalid %s flag",
+		"err.group_by.oneof":                 "Invalid group_by (valid values: %s, %s)",
+		"err.hl_tag.oneof":                   "Invalid hl_tag (expected one of %s)",
+		"err.ical.completed":                 "Invalid COMPLETED %q",
+		"err.ical.due":                       "Invalid DUE %q",
+		"err.ical.invalid":                   "Invalid iCalendar file",
+		"err.id.required":                    "ID is required",
+		"err.ids.max":                        "At most %d IDs may be given",
+		"err.ids.min":                        "IDs are required",
+		"err.import.item":                    "item %d: %v",
+		"err.import.row":                     "row %d: %v",
+		"err.limit.positive":                 "Invalid limit (expected a positive integer)",
+		"err.markdown.invalid":               "Invalid Markdown body",
+		"err.multipart.invalid":              "Invalid multipart form",
+		"err.name.max":                       "Name must be at most %s characters",
+		"err.name.required":                  "Name is required",
+		"err.offset.non_negative":            "Invalid offset (expected a non-negative integer)",
+		"err.order.max":                      "At most %s todos can be reordered at once",
+		"err.order.min":                      "Order is required",
+		"err.param.invalid":                  "Invalid %s",
+		"err.patch.field":                    "Field %q cannot be patched",
+		"err.patch.title_null":               "Title cannot be null",
+		"err.patch.value":                    "Invalid value for %q",
+		"err.payload.invalid":                "Invalid request payload",
+		"err.pin.limit":                      "At most %d todos can be pinned",
+		"err.position.min":                   "Position must be at least %s",
+		"err.priority.max":                   "Priority must be at most %s",
+		"err.priority.min":                   "Priority must be at least %s",
+		"err.priority.required":              "Priority is required",
+		"err.reaction.limit":                 "A todo can have at most %d different reactions",
+		"err.related.limit":                  "Limit must be between 1 and %d",
+		"err.reorder.duplicate_id":           "Todo %d appears more than once",
+		"err.reorder.duplicate_position":     "Position %d is used more than once",
+		"err.saved_search.exists":            "A saved search with this name already exists",
+		"err.saved_search.name":              "Name must be lowercase letters and digits separated by hyphens",
+		"err.saved_search.not_found":         "Saved search not found",
+		"err.saved_search.param":             "Unsupported saved search parameter %q",
+		"err.shift_days.range":               "Shift days must be between -%d and %d",
+		"err.shift_days.required":            "Shift days is required and cannot be 0",
+		"err.snooze.days":                    "Days must be between 1 and %d",
+		"err.sort.direction":                 "Invalid sort direction %q (expected asc or desc)",
+		"err.sort.max":                       "At most %d sort fields are allowed",
+		"err.sort.relevance":                 "Sorting by relevance requires a search query (q)",
+		"err.sort.repeated":                  "Sort field %q is repeated",
+		"err.sort.unknown":                   "Unknown sort field %q",
+		"err.tags.empty":                     "Tags cannot be empty",
+		"err.tags.too_long":                  "Tags must be at most %d characters",
+		"err.text.due":                       "Text has a due date that cannot be read",
+		"err.text.no_title":                  "Text has no title left once the due date and priority are taken out",
+		"err.text.required":                  "Text is required",
+		"err.theme.oneof":                    "Theme must be one of: %s",
+		"err.theme.required":                 "Theme is required",
+		"err.timeout.range":                  "Timeout must be between 1 and %d seconds",
+		"err.timezone.required":              "Timezone is required",
+		"err.timezone.unknown":               "Unknown timezone %q",
+		"err.title.max":                      "Title must be at most %s characters",
+		"err.title.min":                      "Title is too short (minimum %s characters)",
+		"err.title.required":                 "Title is required",
+		"err.todo.blocked":                   "Todo is blocked by todos that are not completed yet",
+		"err.todo.limit_exceeded":            "Maximum number of todos reached",
+		"err.todo.not_found":                 "Todo not found",
+		"err.todo_id.invalid":                "Invalid todo ID",
+		"err.todo_ids.min":                   "Todo IDs are required",
+		"err.todos_per_page.max":             "Todos per page must be at most %s",
+		"err.todos_per_page.min":             "Todos per page must be at least %s",
+		"err.todos_per_page.required":        "Todos per page is required",
+		"err.trend.days":                     "Days must be between 1 and %d",
+		"err.trend.period":                   "Period must be %q or %q",
+		"err.vacuum.days":                    "older_than_days must be at least 1",
+		"warn.saved_search.category_missing": "Category %d no longer exists and was left out of the search",
+		"warn.saved_search.tag_missing":      "Tag %q no longer exists and was left out of the search",
+	},
+	"es": {
+		"err.action.oneof":                   "La acción debe ser una de: %s",
+		"err.activity.limit":                 "El límite debe estar entre 1 y %d",
+		"err.batch.one_field":                "Se debe indicar exactamente uno de priority y category_id",
+		"err.blocking_todo_id.invalid":       "ID de tarea bloqueante no válido",
+		"err.blocking_todo_id.required":      "Se requiere el ID de la tarea bloqueante",
+		"err.body.required":                  "Se requiere el cuerpo",
+		"err.bulk_delete.completed":          "Solo se pueden eliminar en bloque las tareas completadas; indique ?completed=true",
+		"err.category.not_found":             "No se encontró la categoría",
+		"err.category.parent_not_found":      "No se encontró la categoría padre",
+		"err.category.too_deep":              "Las categorías se pueden anidar como máximo %d niveles",
+		"err.category_id.invalid":            "ID de categoría no válido",
+		"err.content_type.unsupported":       "El Content-Type debe ser %s",
+		"err.csv.empty":                      "El CSV está vacío",
+		"err.csv.invalid":                    "CSV no válido: %v",
+		"err.csv.title":                      "La cabecera del CSV debe contener una columna title",
+		"err.default_filter.sort":            "Indique el orden predeterminado con default_sort, no en default_filter",
+		"err.default_sort.max":               "El orden predeterminado debe tener como máximo %s caracteres",
+		"err.dependency.cycle":               "La tarea bloqueante ya depende de esta tarea",
+		"err.dependency.self":                "Una tarea no puede depender de sí misma",
+		"err.description.max":                "La descripción debe tener como máximo %s caracteres",
+		"err.digest.period":                  "El periodo debe ser %q o %q",
+		"err.emoji.emoji":                    "El emoji debe ser un solo emoji",
+		"err.emoji.required":                 "Se requiere el emoji",
+		"err.expand.unknown":                 "Valor de expand desconocido %q (valores válidos: %s, %s)",
+		"err.export.format":                  "Formato de exportación no admitido",
+		"err.external_id.required":           "Se requiere el ID externo",
+		"err.field.unknown":                  "Campo desconocido %q (campos válidos: %s)",
+		"err.file.required":                  "Falta el archivo",
+		"err.filter.due":                     "Filtro due no válido (se esperaba %s o %s)",
+		"err.filter.invalid":                 "Filtro %s no válido",
+		"err.flag.invalid":                   "Indicador %s no válido",
+		"err.group_by.oneof":                 "group_by no válido (valores válidos: %s, %s)",
+		"err.hl_tag.oneof":                   "hl_tag no válido (se esperaba uno de %s)",
+		"err.ical.completed":                 "COMPLETED no válido %q",
+		"err.ical.due":                       "DUE no válido %q",
+		"err.ical.invalid":                   "Archivo iCalendar no válido",
+		"err.id.required":                    "Se requiere el ID",
+		"err.ids.max":                        "Se pueden indicar como máximo %d IDs",
+		"err.ids.min":                        "Se requieren los IDs",
+		"err.import.item":                    "elemento %d: %v",
+		"err.import.row":                     "fila %d: %v",
+		"err.limit.positive":                 "Límite no válido (se esperaba un entero positivo)",
+		"err.markdown.invalid":               "Cuerpo Markdown no válido",
+		"err.multipart.invalid":              "Formulario multipart no válido",
+		"err.name.max":                       "El nombre debe tener como máximo %s caracteres",
+		"err.name.required":                  "Se requiere el nombre",
+		"err.offset.non_negative":            "Desplazamiento no válido (se esperaba un entero no negativo)",
+		"err.order.max":                      "Se pueden reordenar como máximo %s tareas a la vez",
+		"err.order.min":                      "Se requiere el orden",
+		"err.param.invalid":                  "%s no válido",
+		"err.patch.field":                    "El campo %q no se puede modificar",
+		"err.patch.title_null":               "El título no puede ser null",
+		"err.patch.value":                    "Valor no válido para %q",
+		"err.payload.invalid":                "Cuerpo de la solicitud no válido",
+		"err.pin.limit":                      "Se pueden fijar como máximo %d tareas",
+		"err.position.min":                   "La posición debe ser al menos %s",
+		"err.priority.max":                   "La prioridad debe ser como máximo %s",
+		"err.priority.min":                   "La prioridad debe ser al menos %s",
+		"err.priority.required":              "Se requiere la prioridad",
+		"err.reaction.limit":                 "Una tarea puede tener como máximo %d reacciones distintas",
+		"err.related.limit":                  "El límite debe estar entre 1 y %d",
+		"err.reorder.duplicate_id":           "La tarea %d aparece más de una vez",
+		"err.reorder.duplicate_position":     "La posición %d se usa más de una vez",
+		"err.saved_search.exists":            "Ya existe una búsqueda guardada con este nombre",
+		"err.saved_search.name":              "El nombre debe tener letras minúsculas y dígitos separados por guiones",
+		"err.saved_search.not_found":         "No se encontró la búsqueda guardada",
+		"err.saved_search.param":             "Parámetro de búsqueda guardada no admitido %q",
+		"err.shift_days.range":               "Los días de desplazamiento deben estar entre -%d y %d",
+		"err.shift_days.required":            "Se requieren los días de desplazamiento y no pueden ser 0",
+		"err.snooze.days":                    "Los días deben estar entre 1 y %d",
+		"err.sort.direction":                 "Dirección de orden no válida %q (se esperaba asc o desc)",
+		"err.sort.max":                       "Se permiten como máximo %d campos de orden",
+		"err.sort.relevance":                 "Ordenar por relevancia requiere una búsqueda (q)",
+		"err.sort.repeated":                  "El campo de orden %q está repetido",
+		"err.sort.unknown":                   "Campo de orden desconocido %q",
+		"err.tags.empty":                     "Las etiquetas no pueden estar vacías",
+		"err.tags.too_long":                  "Las etiquetas deben tener como máximo %d caracteres",
+		"err.text.due":                       "El texto tiene una fecha de vencimiento que no se puede leer",
+		"err.text.no_title":                  "Al texto no le queda título una vez quitadas la fecha de vencimiento y la prioridad",
+		"err.text.required":                  "Se requiere el texto",
+		"err.theme.oneof":                    "El tema debe ser uno de: %s",
+		"err.theme.required":                 "El tema es obligatorio",
+		"err.timeout.range":                  "El tiempo de espera debe estar entre 1 y %d segundos",
+		"err.timezone.required":              "Se requiere la zona horaria",
+		"err.timezone.unknown":               "Zona horaria desconocida %q",
+		"err.title.max":                      "El título debe tener como máximo %s caracteres",
+		"err.title.min":                      "El título es demasiado corto (mínimo %s caracteres)",
+		"err.title.required":                 "Se requiere el título",
+		"err.todo.blocked":                   "La tarea está bloqueada por tareas que aún no se han completado",
+		"err.todo.limit_exceeded":            "Se alcanzó el número máximo de tareas",
+		"err.todo.not_found":                 "No se encontró la tarea",
+		"err.todo_id.invalid":                "ID de tarea no válido",
+		"err.todo_ids.min":                   "Se requieren los IDs de las tareas",
+		"err.todos_per_page.max":             "Las tareas por página deben ser como máximo %s",
+		"err.todos_per_page.min":             "Las tareas por página deben ser al menos %s",
+		"err.todos_per_page.required":        "Las tareas por página son obligatorias",
+		"err.trend.days":                     "Los días deben estar entre 1 y %d",
+		"err.trend.period":                   "El periodo debe ser %q o %q",
+		"err.vacuum.days":                    "older_than_days debe ser al menos 1",
+		"warn.saved_search.category_missing": "La categoría %d ya no existe y se omitió de la búsqueda",
+		"warn.saved_search.tag_missing":      "La etiqueta %q ya no existe y se omitió de la búsqueda",
+	},
+}
+
+// Message formats the message for key in lang. It falls back to
+// DefaultLanguage, and then to the key itself.
+func Message(lang, key string, args ...interface{}) string {
+	format, ok := messages[lang][key]
+	if !ok {
+		format, ok = messages[DefaultLanguage][key]
+	}
+	if !ok {
+		format = key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Negotiate picks the supported language that best matches an
+// Accept-Language header, e.g. "es-MX,es;q=0.9,en;q=0.8". Region subtags
+// are ignored.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := messages[lang]; !ok {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+
+	// Keep header order between languages of equal quality
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
@@ -0,0 +1,52 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"es", "es"},
+		{"es-ES", "es"},
+		{"fr-FR,fr;q=0.9", "en"},
+		{"fr,es;q=0.5", "es"},
+		{"en;q=0.4,es;q=0.8", "es"},
+		{"es;q=0", "en"},
+		{"ES", "es"},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestMessageFallsBack(t *testing.T) {
+	if got := Message("es", "err.tags.too_long", 50); got != "Las etiquetas deben tener como máximo 50 caracteres" {
+		t.Errorf("Spanish message = %q", got)
+	}
+	if got := Message("fr", "err.title.required"); got != "Title is required" {
+		t.Errorf("unsupported language message = %q, want English", got)
+	}
+	if got := Message("en", "err.unknown"); got != "err.unknown" {
+		t.Errorf("unknown key message = %q, want the key", got)
+	}
+}
+
+func TestEveryLanguageHasEveryKey(t *testing.T) {
+	for lang, catalogue := range messages {
+		for key := range messages[DefaultLanguage] {
+			if _, ok := catalogue[key]; !ok {
+				t.Errorf("%s is missing %s", lang, key)
+			}
+		}
+		for key := range catalogue {
+			if _, ok := messages[DefaultLanguage][key]; !ok {
+				t.Errorf("%s has %s, which %s does not define", lang, key, DefaultLanguage)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// RequireToken guards the paths under prefixes: a request to one of them
+// must send "Authorization: Bearer <token>", or it gets 401 Unauthorized.
+// With an empty token those paths are turned off and answer 404 Not Found,
+// as if they did not exist. Other paths and OPTIONS preflight requests pass
+// through.
+func RequireToken(token string, prefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || !exempt(r.URL.Path, prefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if token == "" {
+				http.NotFound(w, r)
+				return
+			}
+
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{
+					"code":    "ERR_UNAUTHORIZED",
+					"message": "Missing or invalid admin token",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
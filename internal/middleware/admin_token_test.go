@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+)
+
+func TestRequireToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		header string
+		want   int
+	}{
+		{"disabled", "", http.MethodPost, "/api/v1/admin/vacuum", "Bearer ", http.StatusNotFound},
+		{"missing", "s3cret", http.MethodPost, "/api/v1/admin/vacuum", "", http.StatusUnauthorized},
+		{"wrong", "s3cret", http.MethodPost, "/api/v1/admin/vacuum", "Bearer nope", http.StatusUnauthorized},
+		{"not bearer", "s3cret", http.MethodPost, "/api/v1/admin/vacuum", "s3cret", http.StatusUnauthorized},
+		{"valid", "s3cret", http.MethodPost, "/api/v1/admin/vacuum", "Bearer s3cret", http.StatusOK},
+		{"preflight", "s3cret", http.MethodOptions, "/api/v1/admin/vacuum", "", http.StatusOK},
+		{"other path", "", http.MethodPost, "/api/v1/todos", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		handler := middleware.RequireToken(tt.token, "/api/v1/admin/")(ok)
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// jsonMediaTypes are the request body types accepted for each method that
+// carries one
+var jsonMediaTypes = map[string][]string{
+	http.MethodPost:  {"application/json"},
+	http.MethodPut:   {"application/json"},
+	http.MethodPatch: {"application/json", "application/merge-patch+json"},
+}
+
+// RequireJSON rejects POST, PUT and PATCH requests whose body is not declared
+// as JSON with 415 Unsupported Media Type, so a client that sends JSON as
+// text/plain or form data finds out instead of having it decoded anyway.
+// Requests without a body pass through, as do requests to a path under one
+// of exemptPrefixes, such as imports that take CSV or multipart uploads.
+func RequireJSON(exemptPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accepted, ok := jsonMediaTypes[r.Method]
+			if !ok || r.ContentLength == 0 || exempt(r.URL.Path, exemptPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err == nil {
+				for _, t := range accepted {
+					if mediaType == t {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			json.NewEncoder(w).Encode(map[string]string{
+				"code":    "ERR_UNSUPPORTED_MEDIA_TYPE",
+				"message": "Content-Type must be " + strings.Join(accepted, " or "),
+			})
+		})
+	}
+}
+
+// exempt reports whether path is under one of prefixes
+func exempt(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
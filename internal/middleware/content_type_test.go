@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+)
+
+func TestRequireJSON(t *testing.T) {
+	handler := middleware.RequireJSON("/api/v1/todos/import")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json post", http.MethodPost, "/api/v1/todos", "application/json", `{}`, http.StatusOK},
+		{"json with charset", http.MethodPut, "/api/v1/todos/1", "application/json; charset=utf-8", `{}`, http.StatusOK},
+		{"merge patch", http.MethodPatch, "/api/v1/todos/1", "application/merge-patch+json", `{}`, http.StatusOK},
+		{"text post", http.MethodPost, "/api/v1/todos", "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		{"missing content type", http.MethodPut, "/api/v1/todos/1", "", `{}`, http.StatusUnsupportedMediaType},
+		{"merge patch on put", http.MethodPut, "/api/v1/todos/1", "application/merge-patch+json", `{}`, http.StatusUnsupportedMediaType},
+		{"empty body", http.MethodPost, "/api/v1/todos/1/complete", "", "", http.StatusOK},
+		{"get", http.MethodGet, "/api/v1/todos", "text/plain", "", http.StatusOK},
+		{"import", http.MethodPost, "/api/v1/todos/import/markdown", "text/markdown", "- [ ] Task", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsExposedHeaders are the response headers browser scripts may read
+var corsExposedHeaders = []string{
+	"Content-Disposition",
+	"Content-Language",
+	"ETag",
+	IdempotencyKeyHeader,
+	"Last-Modified",
+	"Link",
+	"Retry-After",
+	"Server-Timing",
+	"Warning",
+	"X-Page-Count",
+	"X-Total-Count",
+}
+
+// CORS sets Access-Control-Allow-Origin on responses to requests from one of
+// allowedOrigins, or from any origin when the list contains "*". Requests
+// from other origins are served without CORS headers, so the browser blocks
+// the response.
+func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			switch {
+			case origin == "":
+			case anyOrigin:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case slices.Contains(allowedOrigins, origin):
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			}
+
+			if w.Header().Get("Access-Control-Allow-Origin") != "" {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyTTL is how long a stored response is replayed for
+const IdempotencyTTL = 24 * time.Hour
+
+// IdempotencyPendingTTL is how long a key stays reserved for a request that
+// never finished, e.g. because the server stopped while handling it. It is
+// well above the server's write timeout.
+const IdempotencyPendingTTL = time.Minute
+
+// maxIdempotencyKeyLength matches the idempotency_keys.key column
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the stored response when a POST, PUT, PATCH or DELETE
+// is repeated with an Idempotency-Key seen within IdempotencyTTL, instead of
+// executing it again. The key is reserved before the handler runs, and a
+// request arriving while another with its key is still running gets 409.
+// Only successful responses are stored: after a 4xx or 5xx the key is
+// released, so the client can fix the request and retry with the same key.
+// Requests without the header are passed through.
+func Idempotency(store repository.IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			stored, err := store.Reserve(key, r.Method, r.URL.Path, IdempotencyTTL, IdempotencyPendingTTL)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set(IdempotencyKeyHeader, key)
+
+			if stored != nil {
+				// Reusing a key for a different request is a client bug
+				if stored.Method != r.Method || stored.Path != r.URL.Path {
+					http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+					return
+				}
+
+				if stored.Pending() {
+					w.Header().Set("Retry-After", "1")
+					http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+					return
+				}
+
+				for name, values := range stored.Header {
+					w.Header()[name] = values
+				}
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+
+			// Free the key if the handler panics, then let Recover answer
+			defer func() {
+				if p := recover(); p != nil {
+					release(store, key)
+					panic(p)
+				}
+			}()
+
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK, before: w.Header().Clone()}
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= http.StatusBadRequest {
+				release(store, key)
+				return
+			}
+
+			err = store.Save(&models.IdempotentResponse{
+				Key:    key,
+				Method: r.Method,
+				Path:   r.URL.Path,
+				Status: rec.status,
+				Header: rec.handlerHeader(),
+				Body:   rec.body.Bytes(),
+			})
+			if err != nil {
+				log.Printf("Failed to store idempotent response: %v", err)
+			}
+		})
+	}
+}
+
+// release frees key, logging a failure since the response is already sent
+func release(store repository.IdempotencyStore, key string) {
+	if err := store.Release(key); err != nil {
+		log.Printf("Failed to release idempotency key: %v", err)
+	}
+}
+
+// ExpireIdempotencyKeys deletes stored responses older than IdempotencyTTL
+// every interval until ctx is done
+func ExpireIdempotencyKeys(ctx context.Context, store repository.IdempotencyStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := store.DeleteExpired(IdempotencyTTL); err != nil {
+				log.Printf("Failed to expire idempotency keys: %v", err)
+			}
+		}
+	}
+}
+
+// isMutating reports whether method can change server state
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// recordingWriter passes a response through while keeping a copy of its
+// status, body and the headers the handler set
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	// before is the header as it was before the handler ran, and after as
+	// the handler wrote the status
+	before, after http.Header
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+		w.after = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// handlerHeader returns the headers the handler added or changed, leaving
+// out those set before it ran, like the CORS headers and Idempotency-Key
+func (w *recordingWriter) handlerHeader() http.Header {
+	after := w.after
+	if after == nil {
+		after = w.Header()
+	}
+
+	header := http.Header{}
+	for name, values := range after {
+		if !slices.Equal(values, w.before[name]) {
+			header[name] = slices.Clone(values)
+		}
+	}
+	return header
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
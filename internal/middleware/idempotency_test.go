@@ -0,0 +1,156 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	calls := 0
+	handler := middleware.Idempotency(testhelpers.NewMemoryIdempotencyStore())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":1}`))
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/todos", strings.NewReader(`{"title":"x"}`))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "3f0c1a52-retry")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusCreated {
+			t.Errorf("request %d: status = %d, want %d", i+1, rec.Code, http.StatusCreated)
+		}
+		if rec.Body.String() != `{"id":1}` {
+			t.Errorf("request %d: body = %q", i+1, rec.Body.String())
+		}
+		if got := rec.Header().Get(middleware.IdempotencyKeyHeader); got != "3f0c1a52-retry" {
+			t.Errorf("request %d: Idempotency-Key header = %q", i+1, got)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyRejectsKeyReuse(t *testing.T) {
+	handler := middleware.Idempotency(testhelpers.NewMemoryIdempotencyStore())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	)
+
+	first := httptest.NewRequest(http.MethodDelete, "/api/v1/todos/1", nil)
+	first.Header.Set(middleware.IdempotencyKeyHeader, "k1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, first)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("first request: status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	second := httptest.NewRequest(http.MethodDelete, "/api/v1/todos/2", nil)
+	second.Header.Set(middleware.IdempotencyKeyHeader, "k1")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, second)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key: status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotencyReplaysHandlerHeaders(t *testing.T) {
+	handler := middleware.Idempotency(testhelpers.NewMemoryIdempotencyStore())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Location", "/api/v1/todos/1")
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/todos", nil)
+		req.Header.Set(middleware.IdempotencyKeyHeader, "k1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Header().Get("Location") != "/api/v1/todos/1" || rec.Header().Get("ETag") != `"v1"` {
+			t.Errorf("request %d: headers = %v, want Location and ETag", i+1, rec.Header())
+		}
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRequest(t *testing.T) {
+	running := make(chan struct{})
+	finish := make(chan struct{})
+	handler := middleware.Idempotency(testhelpers.NewMemoryIdempotencyStore())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(running)
+			<-finish
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/todos", nil)
+		req.Header.Set(middleware.IdempotencyKeyHeader, "k1")
+		return req
+	}
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(first, newRequest())
+	}()
+	<-running
+
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, newRequest())
+	if second.Code != http.StatusConflict {
+		t.Errorf("concurrent request: status = %d, want %d", second.Code, http.StatusConflict)
+	}
+
+	close(finish)
+	<-done
+	if first.Code != http.StatusCreated {
+		t.Errorf("first request: status = %d, want %d", first.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyDoesNotStoreClientErrors(t *testing.T) {
+	calls := 0
+	handler := middleware.Idempotency(testhelpers.NewMemoryIdempotencyStore())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				http.Error(w, "Title is required", http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	for i, want := range []int{http.StatusBadRequest, http.StatusCreated, http.StatusCreated} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/todos", nil)
+		req.Header.Set(middleware.IdempotencyKeyHeader, "k1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxLoggedBody is the most of a request body LogRequests reads for the log
+const maxLoggedBody = 64 << 10
+
+// LogRequests logs every request with its status, duration, headers and
+// JSON body. DefaultSensitiveKeys are redacted from the headers and body
+// first. Bodies that are not JSON objects or arrays, or are larger than
+// maxLoggedBody, are logged by size only, since they cannot be redacted.
+func LogRequests(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			body, err := peekBody(r)
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+
+			rec := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			attrs := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration", time.Since(start),
+				"headers", redactHeaders(r.Header),
+			}
+			var decoded interface{}
+			if len(body) > 0 && len(body) <= maxLoggedBody && json.Unmarshal(body, &decoded) == nil {
+				attrs = append(attrs, "body", redactValue(decoded, DefaultSensitiveKeys))
+			} else if len(body) > 0 {
+				attrs = append(attrs, "body_bytes", len(body))
+			}
+
+			logger.Info("HTTP request", attrs...)
+		})
+	}
+}
+
+// peekBody reads up to one byte more than maxLoggedBody of the request
+// body and puts it back, so the handler still reads the whole body
+func peekBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLoggedBody+1))
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	return body, nil
+}
+
+// redactHeaders returns the request headers with sensitive values redacted
+func redactHeaders(header http.Header) map[string]interface{} {
+	values := make(map[string]interface{}, len(header))
+	for name, value := range header {
+		values[name] = value
+	}
+	return Redact(values, DefaultSensitiveKeys)
+}
+
+// statusWriter passes a response through while keeping its status
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+)
+
+func TestLogRequestsRedacts(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	var received string
+	handler := middleware.LogRequests(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	body := `{"title": "Buy milk", "password": "hunter2"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/todos", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if received != body {
+		t.Errorf("handler read body %q, want %q", received, body)
+	}
+
+	out := logs.String()
+	for _, leaked := range []string{"hunter2", "s3cret"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log contains %q: %s", leaked, out)
+		}
+	}
+	for _, want := range []string{"status=201", "Buy milk", "[REDACTED]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log is missing %q: %s", want, out)
+		}
+	}
+}
+
+func TestLogRequestsOmitsNonJSONBodies(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	handler := middleware.LogRequests(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/todos/import", strings.NewReader("password=hunter2"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := logs.String()
+	if strings.Contains(out, "hunter2") || !strings.Contains(out, "body_bytes=16") {
+		t.Errorf("log = %s, want the body by size only", out)
+	}
+}
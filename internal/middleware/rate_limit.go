@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OncePer lets one request through every interval and rejects the rest
+// with 429 Too Many Requests and a Retry-After header. The API has a single
+// implicit user, so the limit is shared by every caller of the wrapped
+// handler. Only a request answered with 2xx uses up the interval; if the
+// handler fails or panics, the next request is let through straight away.
+func OncePer(interval time.Duration) func(http.Handler) http.Handler {
+	var (
+		mu   sync.Mutex
+		next time.Time
+	)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			now := time.Now()
+			wait := next.Sub(now)
+			previous, claimed := next, now.Add(interval)
+			if wait <= 0 {
+				next = claimed
+			}
+			mu.Unlock()
+
+			if wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]string{
+					"code":    "ERR_RATE_LIMITED",
+					"message": "Too many requests, try again later",
+				})
+				return
+			}
+
+			// Give the slot back unless the request succeeded. Requests
+			// rejected meanwhile still got a 429, since the slot was held.
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			succeeded := false
+			defer func() {
+				if !succeeded {
+					mu.Lock()
+					if next.Equal(claimed) {
+						next = previous
+					}
+					mu.Unlock()
+				}
+			}()
+
+			h.ServeHTTP(sw, r)
+			succeeded = sw.status >= 200 && sw.status < 300
+		})
+	}
+}
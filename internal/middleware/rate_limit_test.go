@@ -0,0 +1,83 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+)
+
+func TestOncePer(t *testing.T) {
+	handler := middleware.OncePer(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if err != nil || retryAfter <= 0 || retryAfter > 3600 {
+		t.Errorf("Retry-After = %q, want up to an hour in seconds", rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestOncePerReleasesSlotAfterFailure(t *testing.T) {
+	status := http.StatusInternalServerError
+	handler := middleware.OncePer(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("failed request: status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	// The failure did not use up the hour
+	status = http.StatusOK
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("retry: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("after success: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestOncePerReleasesSlotAfterPanic(t *testing.T) {
+	panics := true
+	handler := middleware.OncePer(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("export failed")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	func() {
+		defer func() { recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil))
+	}()
+
+	panics = false
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("after panic: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
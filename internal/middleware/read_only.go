@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ReadOnly rejects every request that could modify data with 503 Service
+// Unavailable, letting only GET, HEAD and OPTIONS through. Requests to a
+// path under one of exemptPrefixes pass through too, for POST routes that
+// store nothing, such as parsing quick entry text.
+func ReadOnly(exemptPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if exempt(r.URL.Path, exemptPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{
+				"code":    "ERR_READ_ONLY",
+				"message": "Server is in read-only mode",
+			})
+		})
+	}
+}
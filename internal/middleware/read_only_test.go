@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+)
+
+func TestReadOnly(t *testing.T) {
+	handler := middleware.ReadOnly("/api/v1/todos/parse")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/todos", http.StatusOK},
+		{http.MethodHead, "/api/v1/todos", http.StatusOK},
+		{http.MethodOptions, "/api/v1/todos", http.StatusOK},
+		{http.MethodPost, "/api/v1/todos", http.StatusServiceUnavailable},
+		{http.MethodPut, "/api/v1/todos/1", http.StatusServiceUnavailable},
+		{http.MethodPatch, "/api/v1/todos/1", http.StatusServiceUnavailable},
+		{http.MethodDelete, "/api/v1/todos/1", http.StatusServiceUnavailable},
+		{http.MethodPost, "/api/v1/todos/parse", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panic in a handler into a 500 JSON response and logs it
+// with its stack trace, so one bad request does not drop the connection.
+// http.ErrAbortHandler is panicked again, since a handler raises it to abort
+// the response on purpose.
+func Recover(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				logger.Error("Handler panicked",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"error", err,
+					"stack", string(debug.Stack()),
+				)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{
+					"code":    "ERR_INTERNAL",
+					"message": "Internal server error",
+				})
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import "strings"
+
+// Redacted replaces the value of every sensitive key
+const Redacted = "[REDACTED]"
+
+// DefaultSensitiveKeys are the JSON fields and headers never written to logs
+var DefaultSensitiveKeys = []string{"password", "token", "api_key", "secret", "Authorization"}
+
+// Redact returns a copy of data with the value of every key in
+// sensitiveKeys, matched case-insensitively, replaced by Redacted. Nested
+// objects and arrays are redacted too. data itself is not modified.
+func Redact(data map[string]interface{}, sensitiveKeys []string) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if isSensitive(key, sensitiveKeys) {
+			redacted[key] = Redacted
+		} else {
+			redacted[key] = redactValue(value, sensitiveKeys)
+		}
+	}
+	return redacted
+}
+
+// redactValue redacts the objects inside a decoded JSON value
+func redactValue(value interface{}, sensitiveKeys []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return Redact(v, sensitiveKeys)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactValue(item, sensitiveKeys)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// isSensitive reports whether key is one of sensitiveKeys, ignoring case
+func isSensitive(key string, sensitiveKeys []string) bool {
+	for _, sensitive := range sensitiveKeys {
+		if strings.EqualFold(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package middleware_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+)
+
+func TestRedact(t *testing.T) {
+	data := map[string]interface{}{
+		"title":    "Buy milk",
+		"Password": "hunter2",
+		"account": map[string]interface{}{
+			"api_key": "abc",
+			"name":    "me",
+		},
+		"sessions": []interface{}{
+			map[string]interface{}{"token": "xyz", "id": 1.0},
+			"plain",
+		},
+	}
+
+	got := middleware.Redact(data, middleware.DefaultSensitiveKeys)
+
+	want := map[string]interface{}{
+		"title":    "Buy milk",
+		"Password": middleware.Redacted,
+		"account": map[string]interface{}{
+			"api_key": middleware.Redacted,
+			"name":    "me",
+		},
+		"sessions": []interface{}{
+			map[string]interface{}{"token": middleware.Redacted, "id": 1.0},
+			"plain",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Redact = %v, want %v", got, want)
+	}
+
+	if data["Password"] != "hunter2" {
+		t.Error("Redact modified its input")
+	}
+}
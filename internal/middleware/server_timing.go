@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timingsKey is the context key for a request's timings
+type timingsKey struct{}
+
+// timings accumulates named durations for one request. A name timed more
+// than once, like several queries under "db", reports the total.
+type timings struct {
+	mu      sync.Mutex
+	order   []string
+	started map[string]time.Time
+	total   map[string]time.Duration
+}
+
+// header formats the finished timings as a Server-Timing value, in the order
+// they were first started
+func (t *timings) header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := make([]string, 0, len(t.order))
+	for _, name := range t.order {
+		if d, ok := t.total[name]; ok {
+			metrics = append(metrics, fmt.Sprintf("%s;dur=%.1f", name, float64(d)/float64(time.Millisecond)))
+		}
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// ServerTiming adds a Server-Timing header to every response, listing the
+// durations handlers recorded with StartTimer and EndTimer, e.g.
+// "db;dur=12.3, serialize;dur=0.8". Browsers show it in the Network tab.
+// Only timers ended before the handler writes the status are included.
+func ServerTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := &timings{started: make(map[string]time.Time), total: make(map[string]time.Duration)}
+		tw := &timingWriter{ResponseWriter: w, timings: t}
+		next.ServeHTTP(tw, r.WithContext(context.WithValue(r.Context(), timingsKey{}, t)))
+	})
+}
+
+// StartTimer starts timing name for the request ctx belongs to. It does
+// nothing outside ServerTiming.
+func StartTimer(ctx context.Context, name string) {
+	t, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !slices.Contains(t.order, name) {
+		t.order = append(t.order, name)
+	}
+	t.started[name] = time.Now()
+}
+
+// EndTimer stops timing name and adds the time since StartTimer to its
+// total. It does nothing if name was not started.
+func EndTimer(ctx context.Context, name string) {
+	t, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if start, ok := t.started[name]; ok {
+		t.total[name] += time.Since(start)
+		delete(t.started, name)
+	}
+}
+
+// timingWriter sets the Server-Timing header just before the status is
+// written
+type timingWriter struct {
+	http.ResponseWriter
+	timings     *timings
+	wroteHeader bool
+}
+
+func (w *timingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if value := w.timings.header(); value != "" {
+			w.Header().Set("Server-Timing", value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/middleware"
+)
+
+func TestServerTimingReportsTimers(t *testing.T) {
+	handler := middleware.ServerTiming(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Two queries are reported as one total
+		for i := 0; i < 2; i++ {
+			middleware.StartTimer(r.Context(), "db")
+			time.Sleep(time.Millisecond)
+			middleware.EndTimer(r.Context(), "db")
+		}
+		middleware.StartTimer(r.Context(), "serialize")
+		middleware.EndTimer(r.Context(), "serialize")
+
+		// Never ended, so left out
+		middleware.StartTimer(r.Context(), "render")
+
+		w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/todos", nil))
+
+	got := rec.Header().Get("Server-Timing")
+	want := regexp.MustCompile(`^db;dur=([0-9.]+), serialize;dur=[0-9.]+$`)
+	m := want.FindStringSubmatch(got)
+	if m == nil {
+		t.Fatalf("Server-Timing = %q, want db and serialize durations", got)
+	}
+	if db, _ := strconv.ParseFloat(m[1], 64); db < 2 {
+		t.Errorf("db duration = %s ms, want at least 2", m[1])
+	}
+}
+
+func TestServerTimingWithoutTimers(t *testing.T) {
+	handler := middleware.ServerTiming(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/todos", nil))
+
+	if got := rec.Header().Get("Server-Timing"); got != "" {
+		t.Errorf("Server-Timing = %q, want no header", got)
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestTimersOutsideServerTiming(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/todos", nil)
+
+	// Must not panic without the middleware
+	middleware.StartTimer(req.Context(), "db")
+	middleware.EndTimer(req.Context(), "db")
+}
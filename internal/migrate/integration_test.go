@@ -0,0 +1,82 @@
+//go:build integration
+
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/yourusername/todo-api/internal/migrate"
+)
+
+func TestUpDownRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx,
+		"postgres:14-alpine",
+		postgres.WithDatabase("todo_test"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("postgres"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start Postgres container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			t.Errorf("Failed to terminate Postgres container: %v", err)
+		}
+	})
+
+	connString, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("Failed to get connection string: %v", err)
+	}
+
+	db, err := sql.Open("postgres", connString)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	migrator := migrate.NewMigrator(db, "../../migrations")
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+	if len(applied) == 0 {
+		t.Fatal("Up applied no migrations")
+	}
+
+	again, err := migrator.Up()
+	if err != nil || len(again) != 0 {
+		t.Fatalf("second Up applied %d migrations with error %v, want none", len(again), err)
+	}
+
+	rolledBack, err := migrator.Down(len(applied))
+	if err != nil {
+		t.Fatalf("Down returned error: %v", err)
+	}
+	if len(rolledBack) != len(applied) {
+		t.Fatalf("Down rolled back %d migrations, want %d", len(rolledBack), len(applied))
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			t.Errorf("migration %03d_%s still applied after Down", s.Version, s.Name)
+		}
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up after Down returned error: %v", err)
+	}
+}
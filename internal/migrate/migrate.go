@@ -0,0 +1,287 @@
+// Package migrate applies and rolls back the numbered SQL migrations in the
+// migrations directory, recording applied versions in schema_migrations.
+package migrate
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFile matches NNN_name.up.sql and NNN_name.down.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// migrationName matches the name part of a migration file
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration is a numbered pair of up and down scripts
+type Migration struct {
+	Version  int
+	Name     string
+	UpPath   string
+	DownPath string
+	// Hash is the SHA-256 of the up script, used to spot edited migrations
+	Hash string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Changed is true when the up script no longer matches the hash
+	// recorded when it was applied
+	Changed bool
+}
+
+// Load reads the migrations in dir, ordered by version. Every migration must
+// have both an up and a down script.
+func Load(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if match[3] == "up" {
+			m.UpPath = path
+		} else {
+			m.DownPath = path
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpPath == "" || m.DownPath == "" {
+			return nil, fmt.Errorf("migration %03d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+
+		up, err := os.ReadFile(m.UpPath)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(up)
+		m.Hash = hex.EncodeToString(sum[:])
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Create writes an empty up and down script for a new migration numbered
+// after the last one in dir, returning their paths
+func Create(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !migrationName.MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name %q: use letters, digits and underscores", name)
+	}
+
+	migrations, err := Load(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	version := 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%03d_%s", version, name))
+	upPath, downPath := base+".up.sql", base+".down.sql"
+
+	if err := os.WriteFile(upPath, []byte("-- Write the schema change here\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(downPath, []byte("-- Undo the change made by the up script\n"), 0o644); err != nil {
+		return "", "", err
+	}
+
+	return upPath, downPath, nil
+}
+
+// Migrator applies migrations from a directory to a database
+type Migrator struct {
+	db  *sql.DB
+	dir string
+}
+
+// NewMigrator creates a new Migrator
+func NewMigrator(db *sql.DB, dir string) *Migrator {
+	return &Migrator{
+		db:  db,
+		dir: dir,
+	}
+}
+
+// ensureTable creates schema_migrations if it does not exist yet
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			hash CHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`)
+	return err
+}
+
+// Status lists every migration in the directory and whether it is applied
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query(`SELECT version, hash, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type applied struct {
+		hash string
+		at   time.Time
+	}
+	appliedByVersion := make(map[int]applied)
+	for rows.Next() {
+		var version int
+		var a applied
+		if err := rows.Scan(&version, &a.hash, &a.at); err != nil {
+			return nil, err
+		}
+		appliedByVersion[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, migration := range migrations {
+		statuses[i] = Status{Migration: migration}
+		if a, ok := appliedByVersion[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = a.at
+			statuses[i].Changed = a.hash != migration.Hash
+		}
+	}
+
+	return statuses, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the migrations applied
+func (m *Migrator) Up() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+
+		err := m.run(status.UpPath, func(tx *sql.Tx) error {
+			_, err := tx.Exec(
+				`INSERT INTO schema_migrations (version, name, hash) VALUES ($1, $2, $3)`,
+				status.Version, status.Name, status.Hash,
+			)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("applying %03d_%s: %w", status.Version, status.Name, err)
+		}
+
+		applied = append(applied, status.Migration)
+	}
+
+	return applied, nil
+}
+
+// Down rolls back the last n applied migrations, newest first, and returns
+// the migrations rolled back
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []Migration
+	for i := len(statuses) - 1; i >= 0 && len(rolledBack) < n; i-- {
+		status := statuses[i]
+		if !status.Applied {
+			continue
+		}
+
+		err := m.run(status.DownPath, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, status.Version)
+			return err
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rolling back %03d_%s: %w", status.Version, status.Name, err)
+		}
+
+		rolledBack = append(rolledBack, status.Migration)
+	}
+
+	return rolledBack, nil
+}
+
+// run executes the script at path and then record, in one transaction
+func (m *Migrator) run(path string, record func(tx *sql.Tx) error) error {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(string(script)); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,75 @@
+package migrate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/migrate"
+)
+
+func TestLoadRepositoryMigrations(t *testing.T) {
+	migrations, err := migrate.Load("../../migrations")
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("Load found no migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d, want versions numbered from 1 without gaps", i, m.Version)
+		}
+		if len(m.Hash) != 64 {
+			t.Errorf("migration %d hash = %q, want a SHA-256 hex digest", m.Version, m.Hash)
+		}
+	}
+}
+
+func TestLoadRequiresDownScript(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "001_init.up.sql"), "CREATE TABLE t (id INT);")
+
+	if _, err := migrate.Load(dir); err == nil {
+		t.Fatal("Load returned no error for a migration without a down script")
+	}
+}
+
+func TestCreateNumbersAfterLastMigration(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "001_init.up.sql"), "CREATE TABLE t (id INT);")
+	writeFile(t, filepath.Join(dir, "001_init.down.sql"), "DROP TABLE t;")
+
+	upPath, downPath, err := migrate.Create(dir, "Add Widgets")
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	if filepath.Base(upPath) != "002_add_widgets.up.sql" || filepath.Base(downPath) != "002_add_widgets.down.sql" {
+		t.Errorf("created %s and %s, want 002_add_widgets.up.sql and 002_add_widgets.down.sql", upPath, downPath)
+	}
+
+	migrations, err := migrate.Load(dir)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Errorf("Load found %d migrations, want 2", len(migrations))
+	}
+}
+
+func TestCreateRejectsInvalidName(t *testing.T) {
+	if _, _, err := migrate.Create(t.TempDir(), "drop-everything;"); err == nil || !strings.Contains(err.Error(), "invalid migration name") {
+		t.Errorf("Create returned %v, want an invalid name error", err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Timeline event types, as recorded in activity_logs
+const (
+	EventCreated            = "created"
+	EventCompleted          = "completed"
+	EventUncompleted        = "uncompleted"
+	EventTitleChanged       = "title_changed"
+	EventDescriptionChanged = "description_changed"
+	EventPriorityChanged    = "priority_changed"
+	EventDueChanged         = "due_changed"
+	EventPinned             = "pinned"
+	EventUnpinned           = "unpinned"
+	EventCategoryChanged    = "category_changed"
+	EventArchived           = "archived"
+	EventUnarchived         = "unarchived"
+	EventDeleted            = "deleted"
+)
+
+// DefaultActivityLimit is how many entries GET /activity returns when no
+// limit is given, and MaxActivityLimit the most it returns at once
+const (
+	DefaultActivityLimit = 50
+	MaxActivityLimit     = 200
+)
+
+// TimelineEvent is one change in a todo's history. Events ending in
+// "_changed" carry the old and new value in From and To, which are null when
+// the value was unset; the other events have neither.
+type TimelineEvent struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Event     string          `json:"event"`
+	From      json.RawMessage `json:"from,omitempty"`
+	To        json.RawMessage `json:"to,omitempty"`
+}
+
+// ActivityEntry is a change to any todo in the activity feed. TodoTitle is
+// the todo's current title, or null once the todo has been deleted.
+type ActivityEntry struct {
+	ID        int64   `json:"id"`
+	TodoID    int64   `json:"todo_id"`
+	TodoTitle *string `json:"todo_title"`
+	TimelineEvent
+}
+
+// ActivityFeed is the body of GET /activity: the most recent changes first,
+// with Data always an array. NextCursor is set while older entries remain;
+// pass it back as ?cursor= to read them.
+type ActivityFeed struct {
+	Data       []*ActivityEntry `json:"data"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
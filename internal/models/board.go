@@ -0,0 +1,27 @@
+package models
+
+// Ways GET /todos/grouped can group todos
+const (
+	GroupByCategory = "category"
+	GroupByPriority = "priority"
+)
+
+// CategoryGroup is one category's column of a board
+type CategoryGroup struct {
+	// Category is nil for the uncategorized todos
+	Category *CategoryRef `json:"category"`
+	Todos    []*Todo      `json:"todos"`
+}
+
+// CategoryRef identifies a category in a board
+type CategoryRef struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// PriorityGroup is one priority's column of a board
+type PriorityGroup struct {
+	Priority int     `json:"priority"`
+	Label    string  `json:"label"`
+	Todos    []*Todo `json:"todos"`
+}
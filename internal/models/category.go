@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Category groups related todos
+type Category struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  *int64    `json:"parent_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaxCategoryDepth is the deepest a category can be nested, counting a
+// top-level category as depth 1
+const MaxCategoryDepth = 5
+
+// CategoryNode is a category with its subcategories, as returned by the
+// category tree endpoint
+type CategoryNode struct {
+	ID       int64           `json:"id"`
+	Name     string          `json:"name"`
+	Children []*CategoryNode `json:"children"`
+}
+
+// CreateCategoryRequest represents the request payload for creating a category
+type CreateCategoryRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	ParentID *int64 `json:"parent_id,omitempty"`
+}
+
+// CategoryWithCount is a category along with the number of todos in it
+type CategoryWithCount struct {
+	Category
+	TodoCount int64 `json:"todo_count"`
+}
+
+// ProjectConversion is the result of converting a todo into a category.
+// MigratedTodos counts the subtasks moved into the new category with it.
+// Todos have no parent_id and therefore no subtasks, so it is always 0 for
+// now; it is kept so clients do not need to change once they do.
+type ProjectConversion struct {
+	Category      *Category `json:"category"`
+	MigratedTodos int64     `json:"migrated_todos"`
+}
+
+// Breadcrumb types
+const (
+	BreadcrumbCategory = "category"
+	BreadcrumbTodo     = "todo"
+)
+
+// Breadcrumb is one step of the path from a top-level category down to a
+// todo. Title is the category name for a category.
+type Breadcrumb struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+}
+
+// BreadcrumbsResponse is the body of GET /todos/{id}/breadcrumbs
+type BreadcrumbsResponse struct {
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// Blocker is a todo that another todo depends on, listed in blocked_by
+type Blocker struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+// AddDependencyRequest is the payload for POST /todos/{id}/dependencies
+type AddDependencyRequest struct {
+	BlockingTodoID int64 `json:"blocking_todo_id" validate:"required"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// IdempotentResponse is a stored response to a request sent with an
+// Idempotency-Key header. Status is 0 while the request holding the key is
+// still being handled.
+type IdempotentResponse struct {
+	Key       string
+	Method    string
+	Path      string
+	Status    int
+	Header    http.Header
+	Body      []byte
+	CreatedAt time.Time
+}
+
+// Pending reports whether the request that reserved the key has not
+// finished yet
+func (r *IdempotentResponse) Pending() bool {
+	return r.Status == 0
+}
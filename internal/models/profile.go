@@ -0,0 +1,38 @@
+package models
+
+// Profile holds the settings of the API's single implicit user
+type Profile struct {
+	Timezone    string       `json:"timezone"`
+	Preferences *Preferences `json:"preferences"`
+}
+
+// UI themes
+const (
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+	ThemeSystem = "system"
+)
+
+// DefaultTodosPerPage is the page size used before the user picks one
+const DefaultTodosPerPage = 50
+
+// Preferences are the UI settings the frontend starts from. DefaultSort is a
+// GET /todos ?sort= value and DefaultFilter holds its filter parameters, as
+// in a saved search.
+type Preferences struct {
+	DefaultSort   string       `json:"default_sort"`
+	DefaultFilter SearchParams `json:"default_filter"`
+	TodosPerPage  int          `json:"todos_per_page"`
+	Theme         string       `json:"theme"`
+	Timezone      string       `json:"timezone"`
+}
+
+// UpdatePreferencesRequest represents the request payload for replacing the
+// user's preferences
+type UpdatePreferencesRequest struct {
+	DefaultSort   string       `json:"default_sort" validate:"max=100"`
+	DefaultFilter SearchParams `json:"default_filter"`
+	TodosPerPage  int          `json:"todos_per_page" validate:"required,min=10,max=200"`
+	Theme         string       `json:"theme" validate:"required,oneof=light dark system"`
+	Timezone      string       `json:"timezone"`
+}
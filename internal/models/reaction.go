@@ -0,0 +1,21 @@
+package models
+
+// Reaction summarizes the reactions on a todo with one emoji
+type Reaction struct {
+	Emoji       string `json:"emoji"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
+
+// ReactionRequest is the payload for POST and DELETE /todos/{id}/react
+type ReactionRequest struct {
+	Emoji string `json:"emoji" validate:"required,emoji"`
+}
+
+// ExpandedTodo is a todo returned with ?expand=reactions or
+// ?expand=dependencies. Only the fields that were asked for are included.
+type ExpandedTodo struct {
+	*Todo
+	Reactions *[]Reaction `json:"reactions,omitempty"`
+	BlockedBy *[]Blocker  `json:"blocked_by,omitempty"`
+}
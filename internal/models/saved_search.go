@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SavedSearch is a named set of GET /todos query parameters, applied with
+// ?saved_search=<name>
+type SavedSearch struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	Params    SearchParams `json:"params"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// SearchParams are query parameters keyed by name, as in url.Values
+type SearchParams map[string][]string
+
+// UnmarshalJSON accepts each parameter as a single string or a list of them,
+// so {"due": "today"} and {"due": ["today"]} are the same
+func (p *SearchParams) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	params := make(SearchParams, len(raw))
+	for key, value := range raw {
+		var values []string
+		if err := json.Unmarshal(value, &values); err != nil {
+			var single string
+			if err := json.Unmarshal(value, &single); err != nil {
+				return err
+			}
+			values = []string{single}
+		}
+		params[key] = values
+	}
+
+	*p = params
+	return nil
+}
+
+// CreateSavedSearchRequest represents the request payload for saving a search
+type CreateSavedSearchRequest struct {
+	Name   string       `json:"name" validate:"required,max=100"`
+	Params SearchParams `json:"params"`
+}
+
+// UpdateSavedSearchRequest represents the request payload for replacing the
+// parameters of a saved search
+type UpdateSavedSearchRequest struct {
+	Params SearchParams `json:"params"`
+}
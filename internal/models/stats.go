@@ -0,0 +1,56 @@
+package models
+
+// Trend periods accepted by the completion trend
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// MaxTrendDays is the longest window the completion trend covers
+const MaxTrendDays = 365
+
+// TrendPoint counts the todos created and completed in one period
+type TrendPoint struct {
+	Date      string `json:"date"`
+	Completed int64  `json:"completed"`
+	Created   int64  `json:"created"`
+}
+
+// Streak counts the consecutive days on which at least one todo was completed
+type Streak struct {
+	Current int `json:"current_streak"`
+	Longest int `json:"longest_streak"`
+	// LastCompletionDate is the last day anything was completed, or nil if
+	// nothing has been
+	LastCompletionDate *string `json:"last_completion_date"`
+}
+
+// Stats summarises the todo list for dashboards
+type Stats struct {
+	// OpenByPriority counts incomplete todos keyed by priority
+	OpenByPriority map[int]int64 `json:"open_by_priority"`
+}
+
+// TodoCount is a cheap summary of the todo list by completion state
+type TodoCount struct {
+	Total     int64 `json:"total"`
+	Completed int64 `json:"completed"`
+	Pending   int64 `json:"pending"`
+}
+
+// Digest periods
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Digest summarises recent and upcoming work for one period. A weekly digest
+// covers todos completed so far this week and due next week; a daily one
+// covers yesterday's completions and the rest of today's due todos.
+type Digest struct {
+	Period    string  `json:"period"`
+	Timezone  string  `json:"timezone"`
+	Completed []*Todo `json:"completed"`
+	Due       []*Todo `json:"due"`
+	Overdue   []*Todo `json:"overdue"`
+}
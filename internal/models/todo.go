@@ -1,6 +1,37 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// Todo priorities, from least to most urgent
+const (
+	PriorityLow    = 1
+	PriorityMedium = 2
+	PriorityHigh   = 3
+)
+
+// PriorityLabels names each priority
+var PriorityLabels = map[int]string{
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+}
+
+// Snooze limits
+const (
+	// MaxSnoozeDays is the furthest a todo can be snoozed in one step
+	MaxSnoozeDays = 30
+	// SnoozeFlagThreshold is the snooze count at which a todo is flagged
+	SnoozeFlagThreshold = 5
+)
+
+// MaxRelatedTodos is the most similar todos returned at once
+const MaxRelatedTodos = 20
+
+// MaxTagLength is the longest tag name accepted
+const MaxTagLength = 50
 
 // Todo represents a todo item
 type Todo struct {
@@ -11,17 +42,232 @@ type Todo struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Notes       []Note     `json:"notes"`
+	Priority    int        `json:"priority"`
+	Pinned      bool       `json:"pinned"`
+	ExternalID  *string    `json:"external_id,omitempty"`
+	CategoryID  *int64     `json:"category_id"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	SnoozeCount int        `json:"snooze_count"`
+	Position    *int       `json:"position"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	Flagged     bool       `json:"flagged,omitempty"`
+	Tags        []string   `json:"tags"`
+}
+
+// ListResponse is the body of GET /todos: a page of todos with Data always
+// an array, even when nothing matched
+type ListResponse[T any] struct {
+	Data []T      `json:"data"`
+	Meta ListMeta `json:"meta"`
+}
+
+// ListMeta describes the page in a ListResponse. Total counts the matches
+// across all pages, and Page numbers the page from 1. CollectionVersion
+// changes whenever the page would, so a client holding a page with the same
+// version can skip re-rendering it.
+type ListMeta struct {
+	Total             int64  `json:"total"`
+	Page              int    `json:"page"`
+	CollectionVersion string `json:"collection_version,omitempty"`
+}
+
+// DeletedTodo is returned in place of a todo that has been deleted
+type DeletedTodo struct {
+	ID        int64     `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// HighlightedTodo is a todo in search results, along with its title and
+// description marked up where the search matched
+type HighlightedTodo struct {
+	*Todo
+	Highlight map[string]string `json:"highlight"`
+}
+
+// Note is a timestamped entry in a todo's append-only notes log
+type Note struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // CreateTodoRequest represents the request payload for creating a todo
 type CreateTodoRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title       string     `json:"title" validate:"required,min=1,max=255"`
+	Description string     `json:"description" validate:"max=2000"`
+	Priority    int        `json:"priority,omitempty" validate:"omitempty,min=1,max=3"`
+	ExternalID  *string    `json:"external_id,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+
+	// Completed creates the todo already done, e.g. when importing from
+	// another app. CompletedAt defaults to now and is ignored otherwise.
+	Completed   bool       `json:"completed,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// CategoryID files the new todo under a category, which must exist
+	CategoryID *int64 `json:"category_id,omitempty"`
+}
+
+// Normalize trims surrounding whitespace from the request's strings and
+// collapses runs of whitespace inside the title to a single space
+func (r *CreateTodoRequest) Normalize() {
+	r.Title = normalizeTitle(r.Title)
+	r.Description = strings.TrimSpace(r.Description)
+	if r.ExternalID != nil {
+		externalID := strings.TrimSpace(*r.ExternalID)
+		r.ExternalID = &externalID
+	}
 }
 
 // UpdateTodoRequest represents the request payload for updating a todo
 type UpdateTodoRequest struct {
-	Title       *string `json:"title,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Completed   *bool   `json:"completed,omitempty"`
+	Title       *string    `json:"title,omitempty" validate:"omitnil,min=1,max=255"`
+	Description *string    `json:"description,omitempty" validate:"omitnil,max=2000"`
+	Completed   *bool      `json:"completed,omitempty"`
+	Priority    *int       `json:"priority,omitempty" validate:"omitnil,min=1,max=3"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+
+	// ClearDueAt removes the due date. It is set by merge patches with
+	// "due_at": null.
+	ClearDueAt bool `json:"-"`
+}
+
+// Normalize trims surrounding whitespace from the request's strings and
+// collapses runs of whitespace inside the title to a single space
+func (r *UpdateTodoRequest) Normalize() {
+	if r.Title != nil {
+		title := normalizeTitle(*r.Title)
+		r.Title = &title
+	}
+	if r.Description != nil {
+		description := strings.TrimSpace(*r.Description)
+		r.Description = &description
+	}
+}
+
+// normalizeTitle trims title and collapses internal whitespace
+func normalizeTitle(title string) string {
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// BatchUpdateRequest represents the request payload for updating a single
+// field on several todos at once. Exactly one of Priority and CategoryID
+// must be set.
+type BatchUpdateRequest struct {
+	IDs        []int64 `json:"ids" validate:"min=1"`
+	Priority   *int    `json:"priority,omitempty" validate:"omitnil,min=1,max=3"`
+	CategoryID *int64  `json:"category_id,omitempty"`
+}
+
+// BatchUpdateResult reports the outcome of a batch update
+type BatchUpdateResult struct {
+	Updated  int     `json:"updated"`
+	NotFound []int64 `json:"not_found"`
+}
+
+// MaxBulkIDs is the most todos a single bulk archive or due date shift may
+// name. The service checks it rather than a validate tag, so the limit lives
+// in one place.
+const MaxBulkIDs = 200
+
+// MaxShiftDays is the furthest due dates can be moved in one shift, either way
+const MaxShiftDays = 365
+
+// Bulk archive actions
+const (
+	BulkArchive   = "archive"
+	BulkUnarchive = "unarchive"
+)
+
+// BulkArchiveRequest represents the request payload for archiving several
+// todos, or unarchiving them. Action defaults to BulkArchive.
+type BulkArchiveRequest struct {
+	IDs    []int64 `json:"ids" validate:"min=1"`
+	Action string  `json:"action,omitempty" validate:"omitempty,oneof=archive unarchive"`
+}
+
+// BulkArchiveResult reports the outcome of a bulk archive. AlreadyArchived
+// is only set when archiving and NotArchived only when unarchiving.
+type BulkArchiveResult struct {
+	Updated         int     `json:"updated"`
+	AlreadyArchived []int64 `json:"already_archived,omitempty"`
+	NotArchived     []int64 `json:"not_archived,omitempty"`
+	NotFound        []int64 `json:"not_found"`
+}
+
+// ShiftDueRequest represents the request payload for moving the due dates of
+// several todos by the same number of days. A negative ShiftDays moves them
+// earlier.
+type ShiftDueRequest struct {
+	IDs       []int64 `json:"ids" validate:"min=1"`
+	ShiftDays int     `json:"shift_days" validate:"required"`
+}
+
+// ShiftDueResult reports the outcome of a due date shift
+type ShiftDueResult struct {
+	Updated          int     `json:"updated"`
+	SkippedNoDueDate int     `json:"skipped_no_due_date"`
+	NotFound         []int64 `json:"not_found"`
+}
+
+// SetTagsRequest represents the request payload for replacing a todo's tags
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// AddNoteRequest represents the request payload for appending a note to a todo
+type AddNoteRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+// ReorderRequest represents the request payload for setting the manual order
+// of several todos
+type ReorderRequest struct {
+	Order []TodoPosition `json:"order" validate:"min=1,max=1000,dive"`
+}
+
+// TodoPosition places one todo in the manual order
+type TodoPosition struct {
+	ID       int64 `json:"id" validate:"required"`
+	Position int   `json:"position" validate:"min=1"`
+}
+
+// ReorderResult reports how many todos were reordered
+type ReorderResult struct {
+	Updated int64 `json:"updated"`
+}
+
+// MoveTodosRequest represents the request payload for moving todos into a category
+type MoveTodosRequest struct {
+	TodoIDs []int64 `json:"todo_ids" validate:"min=1"`
+}
+
+// MoveTodosResult reports how many todos were moved
+type MoveTodosResult struct {
+	Moved int64 `json:"moved"`
+}
+
+// CompleteAllResult reports how many todos in a category were completed,
+// and which were left open because an incomplete todo blocks them
+type CompleteAllResult struct {
+	Completed int     `json:"completed"`
+	Blocked   []int64 `json:"blocked"`
+}
+
+// ImportResult reports the outcome of an import that skips the records it
+// cannot use instead of rejecting the whole file
+type ImportResult struct {
+	Imported int64    `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors"`
+
+	// CategoriesCreated names the categories the import had to create
+	CategoriesCreated []string `json:"categories_created,omitempty"`
+}
+
+// ImportGroup is a run of imported todos filed under the same category. An
+// empty Category leaves them uncategorized.
+type ImportGroup struct {
+	Category string
+	Todos    []*CreateTodoRequest
 }
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// DueSoonStore is the subset of the todo repository used by
+// DueSoonNotifier
+type DueSoonStore interface {
+	GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error)
+	ReleaseReminders(ids []int64) error
+}
+
+// DueSoonNotifier periodically sends a single reminder listing every
+// incomplete todo that falls due within the next window. Each todo is
+// included in at most one reminder per due date, even with several
+// instances of the API running.
+type DueSoonNotifier struct {
+	store     DueSoonStore
+	notifiers []Notifier
+	interval  time.Duration
+	window    time.Duration
+	now       func() time.Time
+}
+
+// NewDueSoonNotifier creates a new DueSoonNotifier that checks every
+// interval for todos due within window and sends reminders through every
+// one of notifiers
+func NewDueSoonNotifier(store DueSoonStore, notifiers []Notifier, interval, window time.Duration) *DueSoonNotifier {
+	return &DueSoonNotifier{
+		store:     store,
+		notifiers: notifiers,
+		interval:  interval,
+		window:    window,
+		now:       time.Now,
+	}
+}
+
+// Run checks for todos due soon every interval until ctx is done
+func (n *DueSoonNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := n.NotifyDueSoon(); err != nil {
+			log.Printf("Due-soon notification failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NotifyDueSoon claims the todos due within the window and sends one
+// reminder covering them. Nothing is sent if there are none. If every
+// notifier fails the claims are released, so the next check retries. If
+// only some fail, the todos stay claimed rather than being sent again over
+// the channels that worked, and the errors are returned.
+func (n *DueSoonNotifier) NotifyDueSoon() error {
+	now := n.now()
+	todos, err := n.store.GetDueWithinWindow(now, now.Add(n.window))
+	if err != nil {
+		return err
+	}
+
+	if len(todos) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, notifier := range n.notifiers {
+		if err := notifier.SendDueSoon(todos); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) < len(n.notifiers) {
+		return errors.Join(errs...)
+	}
+
+	ids := make([]int64, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	return errors.Join(append(errs, n.store.ReleaseReminders(ids))...)
+}
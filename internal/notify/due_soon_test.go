@@ -0,0 +1,81 @@
+package notify_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/notify"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+// flakyNotifier fails the first failures reminders and records the todos of
+// the others
+type flakyNotifier struct {
+	failures int
+	sent     [][]*models.Todo
+}
+
+func (n *flakyNotifier) SendOverdue(todos []*models.Todo) error {
+	return n.SendDueSoon(todos)
+}
+
+func (n *flakyNotifier) SendDueSoon(todos []*models.Todo) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("channel down")
+	}
+	n.sent = append(n.sent, todos)
+	return nil
+}
+
+func TestNotifyDueSoonRetriesAfterFailedSend(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	soon := time.Now().Add(2 * time.Minute)
+	later := time.Now().Add(time.Hour)
+
+	due := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Join standup"), testhelpers.WithDueAt(soon))
+	testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Lunch"), testhelpers.WithDueAt(later))
+
+	channel := &flakyNotifier{failures: 1}
+	notifier := notify.NewDueSoonNotifier(repo, []notify.Notifier{channel}, time.Minute, 5*time.Minute)
+
+	if err := notifier.NotifyDueSoon(); err == nil {
+		t.Fatal("NotifyDueSoon returned nil, want the send error")
+	}
+
+	// The failed send released the claim, so the next pass sends it
+	if err := notifier.NotifyDueSoon(); err != nil {
+		t.Fatalf("NotifyDueSoon returned error: %v", err)
+	}
+	if len(channel.sent) != 1 || len(channel.sent[0]) != 1 || channel.sent[0][0].ID != due.ID {
+		t.Fatalf("sent %v, want one reminder for Join standup", channel.sent)
+	}
+
+	if err := notifier.NotifyDueSoon(); err != nil {
+		t.Fatalf("NotifyDueSoon returned error: %v", err)
+	}
+	if len(channel.sent) != 1 {
+		t.Errorf("sent %d reminders after the third pass, want 1", len(channel.sent))
+	}
+}
+
+func TestNotifyDueSoonKeepsClaimWhenOneChannelWorks(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(time.Now().Add(time.Minute)))
+
+	working := &flakyNotifier{}
+	broken := &flakyNotifier{failures: 1}
+	notifier := notify.NewDueSoonNotifier(repo, []notify.Notifier{working, broken}, time.Minute, 5*time.Minute)
+
+	if err := notifier.NotifyDueSoon(); err == nil {
+		t.Fatal("NotifyDueSoon returned nil, want the failed channel's error")
+	}
+	if err := notifier.NotifyDueSoon(); err != nil {
+		t.Fatalf("NotifyDueSoon returned error: %v", err)
+	}
+	if len(working.sent) != 1 {
+		t.Errorf("working channel got %d reminders, want 1", len(working.sent))
+	}
+}
@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var (
+	overdueTemplate = template.Must(template.ParseFS(templateFS, "templates/overdue.html"))
+	dueSoonTemplate = template.Must(template.ParseFS(templateFS, "templates/due_soon.html"))
+)
+
+// Notifier delivers reminders listing todos over one channel
+type Notifier interface {
+	SendOverdue(todos []*models.Todo) error
+	SendDueSoon(todos []*models.Todo) error
+}
+
+// EmailNotifier sends overdue reminders as HTML email
+type EmailNotifier struct {
+	mailer Mailer
+	to     string
+}
+
+// NewEmailNotifier creates a new EmailNotifier that sends to the address to
+func NewEmailNotifier(mailer Mailer, to string) *EmailNotifier {
+	return &EmailNotifier{
+		mailer: mailer,
+		to:     to,
+	}
+}
+
+// SendOverdue emails one message listing todos
+func (n *EmailNotifier) SendOverdue(todos []*models.Todo) error {
+	var body bytes.Buffer
+	if err := overdueTemplate.Execute(&body, todos); err != nil {
+		return err
+	}
+
+	return n.mailer.Send([]string{n.to}, overdueSummary(todos), body.String())
+}
+
+// SendDueSoon emails one message listing todos that are due soon
+func (n *EmailNotifier) SendDueSoon(todos []*models.Todo) error {
+	var body bytes.Buffer
+	if err := dueSoonTemplate.Execute(&body, todos); err != nil {
+		return err
+	}
+
+	return n.mailer.Send([]string{n.to}, dueSoonSummary(todos), body.String())
+}
+
+// overdueSummary is the one-line description of a reminder, e.g.
+// "3 overdue todos"
+func overdueSummary(todos []*models.Todo) string {
+	summary := fmt.Sprintf("%d overdue todo", len(todos))
+	if len(todos) != 1 {
+		summary += "s"
+	}
+	return summary
+}
+
+// dueSoonSummary is the one-line description of a due-soon reminder, e.g.
+// "2 todos due soon"
+func dueSoonSummary(todos []*models.Todo) string {
+	if len(todos) == 1 {
+		return "1 todo due soon"
+	}
+	return fmt.Sprintf("%d todos due soon", len(todos))
+}
+
+// OverdueStore is the subset of the todo repository used by OverdueNotifier
+type OverdueStore interface {
+	GetOverdueUnnotified() ([]*models.Todo, error)
+	MarkOverdueNotified(ids []int64) error
+}
+
+// OverdueNotifier periodically sends a single reminder listing every
+// incomplete todo that has passed its due date. Each todo is included in at
+// most one reminder per due date and channel.
+type OverdueNotifier struct {
+	store     OverdueStore
+	notifiers []Notifier
+	interval  time.Duration
+
+	mu sync.Mutex
+	// pending holds, for each notifier, the todos already marked notified
+	// that it failed to deliver
+	pending [][]*models.Todo
+}
+
+// NewOverdueNotifier creates a new OverdueNotifier that sends reminders
+// through every one of notifiers every interval
+func NewOverdueNotifier(store OverdueStore, notifiers []Notifier, interval time.Duration) *OverdueNotifier {
+	return &OverdueNotifier{
+		store:     store,
+		notifiers: notifiers,
+		interval:  interval,
+		pending:   make([][]*models.Todo, len(notifiers)),
+	}
+}
+
+// Run checks for overdue todos every interval until ctx is done
+func (n *OverdueNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := n.NotifyOverdue(); err != nil {
+			log.Printf("Overdue notification failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// NotifyOverdue sends one reminder covering every overdue todo not yet
+// notified through each notifier, then marks them notified once at least one
+// notifier has delivered it. Nothing is sent if there are none. A notifier
+// that fails keeps the todos it missed and sends them again with the next
+// reminder, without the channels that worked sending them twice. If every
+// notifier fails the todos stay unnotified, so the next check retries them.
+func (n *OverdueNotifier) NotifyOverdue() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	todos, err := n.store.GetOverdueUnnotified()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, notifier := range n.notifiers {
+		batch := mergeTodos(n.pending[i], todos)
+		if len(batch) == 0 {
+			continue
+		}
+		if err := notifier.SendOverdue(batch); err != nil {
+			errs = append(errs, err)
+			n.pending[i] = batch
+			continue
+		}
+		n.pending[i] = nil
+	}
+
+	if len(todos) == 0 || len(errs) == len(n.notifiers) {
+		return errors.Join(errs...)
+	}
+
+	ids := make([]int64, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	return errors.Join(append(errs, n.store.MarkOverdueNotified(ids))...)
+}
+
+// mergeTodos returns pending followed by the todos it does not already hold
+func mergeTodos(pending, todos []*models.Todo) []*models.Todo {
+	if len(pending) == 0 {
+		return todos
+	}
+
+	seen := make(map[int64]bool, len(pending))
+	for _, todo := range pending {
+		seen[todo.ID] = true
+	}
+
+	merged := pending
+	for _, todo := range todos {
+		if !seen[todo.ID] {
+			merged = append(merged, todo)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,110 @@
+package notify_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/notify"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+// recordingMailer keeps every message it is asked to send
+type recordingMailer struct {
+	bodies []string
+}
+
+func (m *recordingMailer) Send(to []string, subject, htmlBody string) error {
+	m.bodies = append(m.bodies, htmlBody)
+	return nil
+}
+
+func TestNotifyOverdueSendsOneReminder(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	for _, req := range []*models.CreateTodoRequest{
+		{Title: "File taxes", DueAt: &past},
+		{Title: "Renew passport", DueAt: &past},
+		{Title: "Plan trip", DueAt: &future},
+	} {
+		if _, err := repo.Create(req); err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+	}
+
+	mailer := &recordingMailer{}
+	notifier := notify.NewOverdueNotifier(repo, []notify.Notifier{notify.NewEmailNotifier(mailer, "me@example.com")}, time.Minute)
+
+	if err := notifier.NotifyOverdue(); err != nil {
+		t.Fatalf("NotifyOverdue returned error: %v", err)
+	}
+	if len(mailer.bodies) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(mailer.bodies))
+	}
+
+	body := mailer.bodies[0]
+	if !strings.Contains(body, "File taxes") || !strings.Contains(body, "Renew passport") {
+		t.Errorf("email body is missing overdue todos:\n%s", body)
+	}
+	if strings.Contains(body, "Plan trip") {
+		t.Errorf("email body includes a todo that is not overdue:\n%s", body)
+	}
+
+	// A second pass has nothing new to report
+	if err := notifier.NotifyOverdue(); err != nil {
+		t.Fatalf("NotifyOverdue returned error: %v", err)
+	}
+	if len(mailer.bodies) != 1 {
+		t.Errorf("sent %d emails after second pass, want 1", len(mailer.bodies))
+	}
+}
+
+func TestNotifyOverdueRetriesOnlyTheFailedChannel(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	first := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(time.Now().Add(-time.Hour)))
+
+	working := &flakyNotifier{}
+	broken := &flakyNotifier{failures: 1}
+	notifier := notify.NewOverdueNotifier(repo, []notify.Notifier{working, broken}, time.Minute)
+
+	if err := notifier.NotifyOverdue(); err == nil {
+		t.Fatal("NotifyOverdue returned nil, want the failed channel's error")
+	}
+
+	second := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(time.Now().Add(-time.Minute)))
+	if err := notifier.NotifyOverdue(); err != nil {
+		t.Fatalf("NotifyOverdue returned error: %v", err)
+	}
+
+	if len(working.sent) != 2 || len(working.sent[0]) != 1 || len(working.sent[1]) != 1 || working.sent[1][0].ID != second.ID {
+		t.Errorf("working channel sent %v, want %d then %d", working.sent, first.ID, second.ID)
+	}
+	if len(broken.sent) != 1 || len(broken.sent[0]) != 2 || broken.sent[0][0].ID != first.ID || broken.sent[0][1].ID != second.ID {
+		t.Errorf("broken channel sent %v, want %d and %d together", broken.sent, first.ID, second.ID)
+	}
+}
+
+func TestNotifyOverdueRetriesWhenEveryChannelFails(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(time.Now().Add(-time.Hour)))
+
+	channel := &flakyNotifier{failures: 1}
+	notifier := notify.NewOverdueNotifier(repo, []notify.Notifier{channel}, time.Minute)
+
+	if err := notifier.NotifyOverdue(); err == nil {
+		t.Fatal("NotifyOverdue returned nil, want the send error")
+	}
+	if err := notifier.NotifyOverdue(); err != nil {
+		t.Fatalf("NotifyOverdue returned error: %v", err)
+	}
+	if err := notifier.NotifyOverdue(); err != nil {
+		t.Fatalf("NotifyOverdue returned error: %v", err)
+	}
+
+	if len(channel.sent) != 1 || len(channel.sent[0]) != 1 {
+		t.Errorf("sent %v, want one reminder for the todo", channel.sent)
+	}
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// maxSlackTodos is how many todos a Slack reminder lists. Slack allows 50
+// blocks per message, and the header and footer use two of them.
+const maxSlackTodos = 48
+
+// SlackNotifier posts reminders to a Slack incoming webhook as a Block Kit
+// message, with a button per todo linking to the web UI
+type SlackNotifier struct {
+	WebhookURL string
+	// BaseURL is where the web UI is served, e.g. "https://todo.example.com"
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewSlackNotifier creates a new SlackNotifier
+func NewSlackNotifier(webhookURL, baseURL string) *SlackNotifier {
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// slackMessage is an incoming webhook payload. Text is the fallback shown
+// in notifications.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type      string        `json:"type"`
+	Text      *slackText    `json:"text,omitempty"`
+	Elements  []slackText   `json:"elements,omitempty"`
+	Accessory *slackElement `json:"accessory,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackElement struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+// SendOverdue posts one message listing todos
+func (n *SlackNotifier) SendOverdue(todos []*models.Todo) error {
+	return n.post(n.message(overdueSummary(todos), todos))
+}
+
+// SendDueSoon posts one message listing todos that are due soon
+func (n *SlackNotifier) SendDueSoon(todos []*models.Todo) error {
+	return n.post(n.message(dueSoonSummary(todos), todos))
+}
+
+// post sends msg to the webhook
+func (n *SlackNotifier) post(msg slackMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.Client.Post(n.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// message builds the Block Kit message listing todos under summary
+func (n *SlackNotifier) message(summary string, todos []*models.Todo) slackMessage {
+	msg := slackMessage{
+		Text: summary,
+		Blocks: []slackBlock{{
+			Type: "header",
+			Text: &slackText{Type: "plain_text", Text: summary},
+		}},
+	}
+
+	for i, todo := range todos {
+		if i == maxSlackTodos {
+			msg.Blocks = append(msg.Blocks, slackBlock{
+				Type:     "context",
+				Elements: []slackText{{Type: "mrkdwn", Text: fmt.Sprintf("…and %d more", len(todos)-maxSlackTodos)}},
+			})
+			break
+		}
+
+		text := "*" + escapeSlack(todo.Title) + "*"
+		if todo.DueAt != nil {
+			text += "\nDue " + todo.DueAt.Format("Mon, 02 Jan 2006 15:04")
+		}
+
+		msg.Blocks = append(msg.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: text},
+			Accessory: &slackElement{
+				Type: "button",
+				Text: slackText{Type: "plain_text", Text: "Open"},
+				URL:  fmt.Sprintf("%s/#todo-%d", n.BaseURL, todo.ID),
+			},
+		})
+	}
+
+	return msg
+}
+
+// escapeSlack escapes the characters Slack treats as control sequences in
+// mrkdwn text
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,87 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/notify"
+)
+
+func TestSlackNotifierPostsBlockKitMessage(t *testing.T) {
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		received, _ = io.ReadAll(r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	due := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	todos := []*models.Todo{
+		{ID: 7, Title: "File taxes", DueAt: &due},
+		{ID: 9, Title: "Fix <script> & styles", DueAt: &due},
+	}
+
+	notifier := notify.NewSlackNotifier(srv.URL, "https://todo.example.com/")
+	if err := notifier.SendOverdue(todos); err != nil {
+		t.Fatalf("SendOverdue returned error: %v", err)
+	}
+
+	var msg struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+			Accessory struct {
+				Type string `json:"type"`
+				URL  string `json:"url"`
+			} `json:"accessory"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(received, &msg); err != nil {
+		t.Fatalf("Failed to decode payload %s: %v", received, err)
+	}
+
+	if msg.Text != "2 overdue todos" {
+		t.Errorf("text = %q, want %q", msg.Text, "2 overdue todos")
+	}
+	if len(msg.Blocks) != 3 || msg.Blocks[0].Type != "header" {
+		t.Fatalf("blocks = %s, want a header and one section per todo", received)
+	}
+
+	first := msg.Blocks[1]
+	if !strings.Contains(first.Text.Text, "*File taxes*") {
+		t.Errorf("first section = %q, want the todo title", first.Text.Text)
+	}
+	if first.Accessory.Type != "button" || first.Accessory.URL != "https://todo.example.com/#todo-7" {
+		t.Errorf("first accessory = %+v, want a button linking to todo 7", first.Accessory)
+	}
+	if got := msg.Blocks[2].Text.Text; !strings.Contains(got, "Fix &lt;script&gt; &amp; styles") {
+		t.Errorf("second section = %q, want the title escaped", got)
+	}
+}
+
+func TestSlackNotifierReportsWebhookErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	notifier := notify.NewSlackNotifier(srv.URL, "http://localhost:8080")
+	if err := notifier.SendOverdue([]*models.Todo{{ID: 1, Title: "File taxes"}}); err == nil {
+		t.Fatal("SendOverdue returned no error for a 400 response")
+	}
+}
@@ -0,0 +1,123 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+func BenchmarkGetAll_100(b *testing.B)   { benchmarkGetAll(b, 100) }
+func BenchmarkGetAll_1000(b *testing.B)  { benchmarkGetAll(b, 1000) }
+func BenchmarkGetAll_10000(b *testing.B) { benchmarkGetAll(b, 10000) }
+
+// benchmarkGetAll measures GetAll over a table seeded with n todos, for
+// each repository implementation
+func benchmarkGetAll(b *testing.B, n int) {
+	seedTodos(b, n)
+
+	repos := map[string]repository.TodoRepositoryInterface{
+		"pq":          repository.NewTodoRepository(testDB, 0, nil),
+		"pq-prepared": repository.NewTodoRepository(testDB, 0, testStatements),
+		"pgx":         repository.NewPgxTodoRepository(testPool, 0),
+	}
+
+	for name, repo := range repos {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				todos, _, err := repo.GetAll(repository.TodoFilter{})
+				if err != nil {
+					b.Fatalf("GetAll returned error: %v", err)
+				}
+				if len(todos) != n {
+					b.Fatalf("GetAll returned %d todos, want %d", len(todos), n)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetByID measures the single-todo lookup, where parsing and
+// planning the query is a large share of the work, with and without
+// prepared statements
+func BenchmarkGetByID(b *testing.B) {
+	seedTodos(b, 100)
+
+	repos := map[string]repository.TodoRepositoryInterface{
+		"pq":          repository.NewTodoRepository(testDB, 0, nil),
+		"pq-prepared": repository.NewTodoRepository(testDB, 0, testStatements),
+	}
+
+	for name, repo := range repos {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				todo, err := repo.GetByID(int64(i%100 + 1))
+				if err != nil || todo == nil {
+					b.Fatalf("GetByID returned %v, %v", todo, err)
+				}
+			}
+		})
+	}
+}
+
+// BulkCreate uses a multi-row INSERT for up to 500 todos and COPY above
+// that, so the first two compare the two paths at the threshold
+func BenchmarkBulkCreate_500(b *testing.B)  { benchmarkBulkCreate(b, 500) }
+func BenchmarkBulkCreate_501(b *testing.B)  { benchmarkBulkCreate(b, 501) }
+func BenchmarkBulkCreate_5000(b *testing.B) { benchmarkBulkCreate(b, 5000) }
+
+// benchmarkBulkCreate measures BulkCreate inserting n todos at a time, for
+// each driver
+func benchmarkBulkCreate(b *testing.B, n int) {
+	todos := make([]*models.CreateTodoRequest, n)
+	for i := range todos {
+		todos[i] = &models.CreateTodoRequest{Title: fmt.Sprintf("Todo %d", i), Description: fmt.Sprintf("Description of todo %d", i)}
+	}
+
+	repos := map[string]repository.TodoRepositoryInterface{
+		"pq":  repository.NewTodoRepository(testDB, 0, nil),
+		"pgx": repository.NewPgxTodoRepository(testPool, 0),
+	}
+
+	for name, repo := range repos {
+		b.Run(name, func(b *testing.B) {
+			resetTodos(b)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				inserted, err := repo.BulkCreate(todos)
+				if err != nil {
+					b.Fatalf("BulkCreate returned error: %v", err)
+				}
+				if inserted != int64(n) {
+					b.Fatalf("BulkCreate inserted %d todos, want %d", inserted, n)
+				}
+			}
+		})
+	}
+}
+
+// seedTodos replaces the contents of the todos table with n generated rows
+func seedTodos(b *testing.B, n int) {
+	b.Helper()
+
+	resetTodos(b)
+
+	_, err := testDB.Exec(`
+		INSERT INTO todos (title, description, created_at, updated_at)
+		SELECT 'Todo ' || i, 'Description of todo ' || i,
+			NOW() - (i || ' seconds')::interval, NOW() - (i || ' seconds')::interval
+		FROM generate_series(1, $1) AS i
+	`, n)
+	if err != nil {
+		b.Fatalf("Failed to seed %d todos: %v", n, err)
+	}
+}
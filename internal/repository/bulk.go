@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// copyThreshold is the batch size above which BulkCreate switches from a
+// multi-row INSERT to COPY FROM STDIN
+const copyThreshold = 500
+
+// copyColumns are the columns written by COPY in BulkCreate, in the order
+// of copyRow
+var copyColumns = []string{"title", "description", "priority", "due_at", "completed", "completed_at", "category_id", "created_at", "updated_at"}
+
+// copyNowQuery reads the time COPY rows are created at from the database,
+// so they agree with the NOW() a multi-row INSERT stamps. The cast keeps
+// the session's wall-clock time, as storing NOW() in a TIMESTAMP column does.
+const copyNowQuery = `SELECT NOW()::timestamp`
+
+// copyRow returns the values COPY writes for todo, created at now. Like
+// Create, a completed todo without a completion time is completed now.
+func copyRow(todo *models.CreateTodoRequest, now time.Time) []interface{} {
+	var completedAt interface{}
+	if todo.Completed {
+		completedAt = now
+		if todo.CompletedAt != nil {
+			completedAt = *todo.CompletedAt
+		}
+	}
+
+	return []interface{}{todo.Title, todo.Description, todo.Priority, todo.DueAt, todo.Completed, completedAt, todo.CategoryID, now, now}
+}
+
+// multiRowInsert builds a single INSERT statement with one VALUES tuple per
+// todo, along with its arguments
+func multiRowInsert(todos []*models.CreateTodoRequest) (string, []interface{}) {
+	var sb strings.Builder
+	args := make([]interface{}, 0, len(todos)*7)
+
+	sb.WriteString("INSERT INTO todos (title, description, priority, due_at, completed, completed_at, category_id, created_at, updated_at) VALUES ")
+	for i, todo := range todos {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&sb, "($%d, $%d, $%d, $%d, $%d, CASE WHEN $%d::boolean THEN COALESCE($%d::timestamp, NOW()) END, $%d, NOW(), NOW())",
+			n+1, n+2, n+3, n+4, n+5, n+5, n+6, n+7)
+		args = append(args, todo.Title, todo.Description, todo.Priority, todo.DueAt, todo.Completed, todo.CompletedAt, todo.CategoryID)
+	}
+
+	return sb.String(), args
+}
+
+// fileUnder sets the category of every todo to categoryID
+func fileUnder(todos []*models.CreateTodoRequest, categoryID int64) {
+	for _, todo := range todos {
+		id := categoryID
+		todo.CategoryID = &id
+	}
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TodoFilter narrows the todos returned by list queries, which never include
+// archived todos. Nil fields are not filtered on.
+type TodoFilter struct {
+	Pinned *bool
+
+	// Due is DueToday or DueOverdue, or empty for no due-date filter
+	Due string
+	// Timezone is the IANA zone that decides which day is "today" for
+	// DueToday. Empty means UTC.
+	Timezone string
+
+	// SearchQuery matches todos whose title or description contain the
+	// words, using full-text search over todo_search_mv
+	SearchQuery *string
+	// SearchLive searches the todos table directly instead of the
+	// materialized view, so writes not yet refreshed are found
+	SearchLive bool
+	// Tags matches todos that have every listed tag
+	Tags []string
+	// Tagged matches todos with at least one tag when true, and todos with
+	// none when false
+	Tagged *bool
+
+	// CategoryID matches todos in the category
+	CategoryID *int64
+	// IncludeDescendants widens CategoryID to its subcategories at any depth
+	IncludeDescendants bool
+
+	// Sort orders the todos by these fields, after pinned todos. Empty
+	// means manual position, then newest first.
+	Sort []SortParam
+	// SortByRelevance orders the todos by how well they match SearchQuery,
+	// best first, after pinned todos. Sort is ignored when it is set.
+	SortByRelevance bool
+
+	// Limit caps the number of todos returned, or zero for no limit
+	Limit int
+	// Offset skips that many matching todos before the first one returned
+	Offset int
+}
+
+// SortParam orders todos by one field
+type SortParam struct {
+	// Field is one of the keys of sortColumns
+	Field      string
+	Descending bool
+}
+
+// MaxSortParams is the most fields a list can be sorted by at once
+const MaxSortParams = 3
+
+// sortColumns maps the fields todos can be sorted by to their columns. Only
+// these names ever reach the ORDER BY clause.
+var sortColumns = map[string]string{
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"due_at":       "due_at",
+	"completed_at": "completed_at",
+	"priority":     "priority",
+	"title":        "title",
+}
+
+// ValidSortField reports whether todos can be sorted by field
+func ValidSortField(field string) bool {
+	_, ok := sortColumns[field]
+	return ok
+}
+
+// Values for TodoFilter.Due
+const (
+	DueToday   = "today"
+	DueOverdue = "overdue"
+)
+
+// where builds the WHERE clause for the filter, numbering placeholders from
+// $1, and returns it with its arguments. Archived todos are always left out.
+func (f TodoFilter) where() (string, []interface{}) {
+	conditions := []string{"todos.archived_at IS NULL"}
+	var args []interface{}
+
+	if f.Pinned != nil {
+		args = append(args, *f.Pinned)
+		conditions = append(conditions, fmt.Sprintf("pinned = $%d", len(args)))
+	}
+
+	switch f.Due {
+	case DueToday:
+		tz := f.Timezone
+		if tz == "" {
+			tz = "UTC"
+		}
+		// due_at is stored as UTC without a zone
+		args = append(args, tz)
+		conditions = append(conditions, fmt.Sprintf(
+			"(due_at AT TIME ZONE 'UTC' AT TIME ZONE $%d)::date = (NOW() AT TIME ZONE $%d)::date",
+			len(args), len(args)))
+	case DueOverdue:
+		conditions = append(conditions, "due_at < NOW() AND completed = false")
+	}
+
+	if f.SearchQuery != nil {
+		args = append(args, *f.SearchQuery)
+		if f.SearchLive {
+			conditions = append(conditions, fmt.Sprintf("%s @@ plainto_tsquery('english', $%d)", searchVector, len(args)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf(
+				"id IN (SELECT id FROM todo_search_mv WHERE document @@ plainto_tsquery('english', $%d))",
+				len(args)))
+		}
+	}
+
+	for _, tag := range f.Tags {
+		args = append(args, tag)
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id WHERE todo_tags.todo_id = todos.id AND tags.name = $%d)",
+			len(args)))
+	}
+
+	if f.Tagged != nil {
+		condition := "EXISTS (SELECT 1 FROM todo_tags WHERE todo_tags.todo_id = todos.id)"
+		if !*f.Tagged {
+			condition = "NOT " + condition
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if f.CategoryID != nil {
+		args = append(args, *f.CategoryID)
+		if f.IncludeDescendants {
+			conditions = append(conditions, fmt.Sprintf(`category_id IN (
+				WITH RECURSIVE descendants AS (
+					SELECT id FROM categories WHERE id = $%d
+					UNION ALL
+					SELECT c.id FROM categories c JOIN descendants d ON c.parent_id = d.id
+				)
+				SELECT id FROM descendants
+			)`, len(args)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("category_id = $%d", len(args)))
+		}
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// page returns the LIMIT and OFFSET clauses for the filter, numbering
+// placeholders after the n arguments already used by the query
+func (f TodoFilter) page(n int) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		clauses = append(clauses, fmt.Sprintf("LIMIT $%d", n+len(args)))
+	}
+
+	if f.Offset > 0 {
+		args = append(args, f.Offset)
+		clauses = append(clauses, fmt.Sprintf("OFFSET $%d", n+len(args)))
+	}
+
+	return strings.Join(clauses, " "), args
+}
+
+// orderBy returns the ORDER BY clause for the filter, numbering placeholders
+// after the n arguments already used by the query. Pinned todos always come
+// first, and todos without a value for a sorted field come last.
+func (f TodoFilter) orderBy(n int) (string, []interface{}) {
+	if f.SortByRelevance && f.SearchQuery != nil {
+		return fmt.Sprintf("ORDER BY pinned DESC, ts_rank(%s, plainto_tsquery('english', $%d)) DESC, id DESC", searchVector, n+1),
+			[]interface{}{*f.SearchQuery}
+	}
+
+	if len(f.Sort) == 0 {
+		return "ORDER BY pinned DESC, position NULLS FIRST, created_at DESC, id DESC", nil
+	}
+
+	terms := []string{"pinned DESC"}
+	for _, s := range f.Sort {
+		dir := "ASC"
+		if s.Descending {
+			dir = "DESC"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s NULLS LAST", sortColumns[s.Field], dir))
+	}
+	terms = append(terms, "id DESC")
+
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// IdempotencyStore persists responses to requests made with an
+// Idempotency-Key so retries can be answered without re-executing them. A
+// key is reserved before its request runs, so two requests with the same
+// key cannot both run.
+type IdempotencyStore interface {
+	// Reserve claims key for a request. It returns nil if the key was free,
+	// or had expired after ttl, or was reserved by a request that has been
+	// pending for longer than pendingTTL. Otherwise it returns what is
+	// stored for the key, which is Pending while that request still runs.
+	Reserve(key, method, path string, ttl, pendingTTL time.Duration) (*models.IdempotentResponse, error)
+	// Save stores the response to the request that reserved its key
+	Save(resp *models.IdempotentResponse) error
+	// Release frees a key whose request is still pending, so it can be
+	// retried with the same key
+	Release(key string) error
+	// DeleteExpired removes responses older than ttl
+	DeleteExpired(ttl time.Duration) (int64, error)
+}
+
+const idempotencyColumns = `key, method, path, response_status, headers, response_body, created_at`
+
+// reserveIdempotencyKeyQuery inserts a pending row for key $1, taking over
+// a row older than $4 seconds, or a pending one older than $5 seconds. It
+// returns no row when the key is taken.
+const reserveIdempotencyKeyQuery = `
+	INSERT INTO idempotency_keys (key, method, path, response_status, headers, response_body, created_at)
+	VALUES ($1, $2, $3, 0, '{}', '', NOW())
+	ON CONFLICT (key) DO UPDATE
+	SET method = EXCLUDED.method, path = EXCLUDED.path, response_status = 0,
+		headers = '{}', response_body = '', created_at = NOW()
+	WHERE idempotency_keys.created_at <= NOW() - $4::float8 * INTERVAL '1 second'
+		OR (idempotency_keys.response_status = 0 AND idempotency_keys.created_at <= NOW() - $5::float8 * INTERVAL '1 second')
+	RETURNING key
+`
+
+// getIdempotencyKeyQuery reads what is stored for key $1
+const getIdempotencyKeyQuery = `SELECT ` + idempotencyColumns + ` FROM idempotency_keys WHERE key = $1`
+
+// saveIdempotentResponseQuery fills in the response for the pending key $1
+const saveIdempotentResponseQuery = `
+	UPDATE idempotency_keys
+	SET response_status = $2, headers = $3, response_body = $4
+	WHERE key = $1 AND response_status = 0
+`
+
+// releaseIdempotencyKeyQuery frees the pending key $1
+const releaseIdempotencyKeyQuery = `DELETE FROM idempotency_keys WHERE key = $1 AND response_status = 0`
+
+// deleteExpiredIdempotencyKeysQuery removes rows older than $1 seconds
+const deleteExpiredIdempotencyKeysQuery = `DELETE FROM idempotency_keys WHERE created_at <= NOW() - $1::float8 * INTERVAL '1 second'`
+
+// scanIdempotentResponse scans a row selected with idempotencyColumns
+func scanIdempotentResponse(row rowScanner) (*models.IdempotentResponse, error) {
+	var resp models.IdempotentResponse
+	var header []byte
+
+	err := row.Scan(&resp.Key, &resp.Method, &resp.Path, &resp.Status, &header, &resp.Body, &resp.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(header, &resp.Header); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// SQLIdempotencyStore is an IdempotencyStore backed by database/sql
+type SQLIdempotencyStore struct {
+	db *sql.DB
+}
+
+// NewSQLIdempotencyStore creates a new SQLIdempotencyStore
+func NewSQLIdempotencyStore(db *sql.DB) *SQLIdempotencyStore {
+	return &SQLIdempotencyStore{
+		db: db,
+	}
+}
+
+// Reserve claims key for a request, or returns what is stored for it
+func (s *SQLIdempotencyStore) Reserve(key, method, path string, ttl, pendingTTL time.Duration) (*models.IdempotentResponse, error) {
+	var reserved string
+	err := s.db.QueryRow(reserveIdempotencyKeyQuery, key, method, path, ttl.Seconds(), pendingTTL.Seconds()).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	resp, err := scanIdempotentResponse(s.db.QueryRow(getIdempotencyKeyQuery, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released since the insert saw it; treat it as still in progress
+		return &models.IdempotentResponse{Key: key, Method: method, Path: path}, nil
+	}
+	return resp, err
+}
+
+// Save stores the response to the request that reserved its key
+func (s *SQLIdempotencyStore) Save(resp *models.IdempotentResponse) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(saveIdempotentResponseQuery, resp.Key, resp.Status, string(header), resp.Body)
+	return err
+}
+
+// Release frees a key whose request is still pending
+func (s *SQLIdempotencyStore) Release(key string) error {
+	_, err := s.db.Exec(releaseIdempotencyKeyQuery, key)
+	return err
+}
+
+// DeleteExpired removes responses older than ttl
+func (s *SQLIdempotencyStore) DeleteExpired(ttl time.Duration) (int64, error) {
+	result, err := s.db.Exec(deleteExpiredIdempotencyKeysQuery, ttl.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// PgxIdempotencyStore is an IdempotencyStore backed by a pgx connection pool
+type PgxIdempotencyStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPgxIdempotencyStore creates a new PgxIdempotencyStore
+func NewPgxIdempotencyStore(pool *pgxpool.Pool) *PgxIdempotencyStore {
+	return &PgxIdempotencyStore{
+		pool: pool,
+	}
+}
+
+// Reserve claims key for a request, or returns what is stored for it
+func (s *PgxIdempotencyStore) Reserve(key, method, path string, ttl, pendingTTL time.Duration) (*models.IdempotentResponse, error) {
+	ctx := context.Background()
+
+	var reserved string
+	err := s.pool.QueryRow(ctx, reserveIdempotencyKeyQuery, key, method, path, ttl.Seconds(), pendingTTL.Seconds()).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	resp, err := scanIdempotentResponse(s.pool.QueryRow(ctx, getIdempotencyKeyQuery, key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Released since the insert saw it; treat it as still in progress
+		return &models.IdempotentResponse{Key: key, Method: method, Path: path}, nil
+	}
+	return resp, err
+}
+
+// Save stores the response to the request that reserved its key
+func (s *PgxIdempotencyStore) Save(resp *models.IdempotentResponse) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pool.Exec(context.Background(), saveIdempotentResponseQuery, resp.Key, resp.Status, string(header), resp.Body)
+	return err
+}
+
+// Release frees a key whose request is still pending
+func (s *PgxIdempotencyStore) Release(key string) error {
+	_, err := s.pool.Exec(context.Background(), releaseIdempotencyKeyQuery, key)
+	return err
+}
+
+// DeleteExpired removes responses older than ttl
+func (s *PgxIdempotencyStore) DeleteExpired(ttl time.Duration) (int64, error) {
+	tag, err := s.pool.Exec(context.Background(), deleteExpiredIdempotencyKeysQuery, ttl.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
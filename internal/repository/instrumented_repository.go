@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// InstrumentedTodoRepository wraps a TodoRepositoryInterface and records the
+// duration of every call in the db_operation_duration_seconds histogram,
+// labelled by operation
+type InstrumentedTodoRepository struct {
+	inner    TodoRepositoryInterface
+	duration *prometheus.HistogramVec
+}
+
+var _ TodoRepositoryInterface = (*InstrumentedTodoRepository)(nil)
+
+// NewInstrumentedTodoRepository creates a new InstrumentedTodoRepository and
+// registers its histogram with reg
+func NewInstrumentedTodoRepository(inner TodoRepositoryInterface, reg prometheus.Registerer) *InstrumentedTodoRepository {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_operation_duration_seconds",
+		Help:    "Duration of todo repository operations.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+	reg.MustRegister(duration)
+
+	return &InstrumentedTodoRepository{
+		inner:    inner,
+		duration: duration,
+	}
+}
+
+// observe records the time elapsed since start for operation
+func (r *InstrumentedTodoRepository) observe(operation string, start time.Time) {
+	r.duration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// Create calls the wrapped repository's Create
+func (r *InstrumentedTodoRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+	defer r.observe("Create", time.Now())
+	return r.inner.Create(todo)
+}
+
+// Upsert calls the wrapped repository's Upsert
+func (r *InstrumentedTodoRepository) Upsert(todo *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	defer r.observe("Upsert", time.Now())
+	return r.inner.Upsert(todo)
+}
+
+// BulkCreate calls the wrapped repository's BulkCreate
+func (r *InstrumentedTodoRepository) BulkCreate(todos []*models.CreateTodoRequest) (int64, error) {
+	defer r.observe("BulkCreate", time.Now())
+	return r.inner.BulkCreate(todos)
+}
+
+// GetAll calls the wrapped repository's GetAll
+func (r *InstrumentedTodoRepository) GetAll(filter TodoFilter) ([]*models.Todo, int64, error) {
+	defer r.observe("GetAll", time.Now())
+	return r.inner.GetAll(filter)
+}
+
+// StreamAll calls the wrapped repository's StreamAll
+func (r *InstrumentedTodoRepository) StreamAll(ctx context.Context, filter TodoFilter, fn func(*models.Todo) error) error {
+	defer r.observe("StreamAll", time.Now())
+	return r.inner.StreamAll(ctx, filter, fn)
+}
+
+// StreamAllIDs calls the wrapped repository's StreamAllIDs
+func (r *InstrumentedTodoRepository) StreamAllIDs(ctx context.Context, fn func(int64) error) error {
+	defer r.observe("StreamAllIDs", time.Now())
+	return r.inner.StreamAllIDs(ctx, fn)
+}
+
+// GetByID calls the wrapped repository's GetByID
+func (r *InstrumentedTodoRepository) GetByID(id int64) (*models.Todo, error) {
+	defer r.observe("GetByID", time.Now())
+	return r.inner.GetByID(id)
+}
+
+// Update calls the wrapped repository's Update
+func (r *InstrumentedTodoRepository) Update(todo *models.Todo) (*models.Todo, error) {
+	defer r.observe("Update", time.Now())
+	return r.inner.Update(todo)
+}
+
+// SetCompleted calls the wrapped repository's SetCompleted
+func (r *InstrumentedTodoRepository) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	defer r.observe("SetCompleted", time.Now())
+	return r.inner.SetCompleted(id, completed)
+}
+
+// AppendNote calls the wrapped repository's AppendNote
+func (r *InstrumentedTodoRepository) AppendNote(id int64, body string) (*models.Todo, error) {
+	defer r.observe("AppendNote", time.Now())
+	return r.inner.AppendNote(id, body)
+}
+
+// BatchUpdatePriority calls the wrapped repository's BatchUpdatePriority
+func (r *InstrumentedTodoRepository) BatchUpdatePriority(ids []int64, priority int) ([]int64, error) {
+	defer r.observe("BatchUpdatePriority", time.Now())
+	return r.inner.BatchUpdatePriority(ids, priority)
+}
+
+// SetPinned calls the wrapped repository's SetPinned
+func (r *InstrumentedTodoRepository) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	defer r.observe("SetPinned", time.Now())
+	return r.inner.SetPinned(id, pinned)
+}
+
+// Delete calls the wrapped repository's Delete
+func (r *InstrumentedTodoRepository) Delete(id int64) (*models.Todo, error) {
+	defer r.observe("Delete", time.Now())
+	return r.inner.Delete(id)
+}
+
+// DeleteAllCompleted calls the wrapped repository's DeleteAllCompleted
+func (r *InstrumentedTodoRepository) DeleteAllCompleted() (int64, error) {
+	defer r.observe("DeleteAllCompleted", time.Now())
+	return r.inner.DeleteAllCompleted()
+}
+
+// CreateCategory calls the wrapped repository's CreateCategory
+func (r *InstrumentedTodoRepository) CreateCategory(name string, parentID *int64) (*models.Category, error) {
+	defer r.observe("CreateCategory", time.Now())
+	return r.inner.CreateCategory(name, parentID)
+}
+
+// GetAllCategories calls the wrapped repository's GetAllCategories
+func (r *InstrumentedTodoRepository) GetAllCategories() ([]*models.Category, error) {
+	defer r.observe("GetAllCategories", time.Now())
+	return r.inner.GetAllCategories()
+}
+
+// GetCategoryByID calls the wrapped repository's GetCategoryByID
+func (r *InstrumentedTodoRepository) GetCategoryByID(id int64) (*models.Category, error) {
+	defer r.observe("GetCategoryByID", time.Now())
+	return r.inner.GetCategoryByID(id)
+}
+
+// MoveTodos calls the wrapped repository's MoveTodos
+func (r *InstrumentedTodoRepository) MoveTodos(ids []int64, categoryID *int64) (int64, error) {
+	defer r.observe("MoveTodos", time.Now())
+	return r.inner.MoveTodos(ids, categoryID)
+}
+
+// GetOverdueUnnotified calls the wrapped repository's GetOverdueUnnotified
+func (r *InstrumentedTodoRepository) GetOverdueUnnotified() ([]*models.Todo, error) {
+	defer r.observe("GetOverdueUnnotified", time.Now())
+	return r.inner.GetOverdueUnnotified()
+}
+
+// MarkOverdueNotified calls the wrapped repository's MarkOverdueNotified
+func (r *InstrumentedTodoRepository) MarkOverdueNotified(ids []int64) error {
+	defer r.observe("MarkOverdueNotified", time.Now())
+	return r.inner.MarkOverdueNotified(ids)
+}
+
+// GetTimezone calls the wrapped repository's GetTimezone
+func (r *InstrumentedTodoRepository) GetTimezone() (string, error) {
+	defer r.observe("GetTimezone", time.Now())
+	return r.inner.GetTimezone()
+}
+
+// SetTimezone calls the wrapped repository's SetTimezone
+func (r *InstrumentedTodoRepository) SetTimezone(tz string) error {
+	defer r.observe("SetTimezone", time.Now())
+	return r.inner.SetTimezone(tz)
+}
+
+// Snooze calls the wrapped repository's Snooze
+func (r *InstrumentedTodoRepository) Snooze(id int64, days int) (*models.Todo, error) {
+	defer r.observe("Snooze", time.Now())
+	return r.inner.Snooze(id, days)
+}
+
+// SetTags calls the wrapped repository's SetTags
+func (r *InstrumentedTodoRepository) SetTags(id int64, tags []string) (*models.Todo, error) {
+	defer r.observe("SetTags", time.Now())
+	return r.inner.SetTags(id, tags)
+}
+
+// GetCategoryTree calls the wrapped repository's GetCategoryTree
+func (r *InstrumentedTodoRepository) GetCategoryTree() ([]*models.Category, error) {
+	defer r.observe("GetCategoryTree", time.Now())
+	return r.inner.GetCategoryTree()
+}
+
+// GetCategoryDepth calls the wrapped repository's GetCategoryDepth
+func (r *InstrumentedTodoRepository) GetCategoryDepth(id int64) (int, error) {
+	defer r.observe("GetCategoryDepth", time.Now())
+	return r.inner.GetCategoryDepth(id)
+}
+
+// GetCompletionTrend calls the wrapped repository's GetCompletionTrend
+func (r *InstrumentedTodoRepository) GetCompletionTrend(period string, days int) ([]*models.TrendPoint, error) {
+	defer r.observe("GetCompletionTrend", time.Now())
+	return r.inner.GetCompletionTrend(period, days)
+}
+
+// CountByPriority calls the wrapped repository's CountByPriority
+func (r *InstrumentedTodoRepository) CountByPriority() (map[int]int64, error) {
+	defer r.observe("CountByPriority", time.Now())
+	return r.inner.CountByPriority()
+}
+
+// DuplicateCategory calls the wrapped repository's DuplicateCategory
+func (r *InstrumentedTodoRepository) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	defer r.observe("DuplicateCategory", time.Now())
+	return r.inner.DuplicateCategory(id)
+}
+
+// Reorder calls the wrapped repository's Reorder
+func (r *InstrumentedTodoRepository) Reorder(order []models.TodoPosition) (int64, error) {
+	defer r.observe("Reorder", time.Now())
+	return r.inner.Reorder(order)
+}
+
+// GetCompletionStreak calls the wrapped repository's GetCompletionStreak
+func (r *InstrumentedTodoRepository) GetCompletionStreak(timezone string) (*models.Streak, error) {
+	defer r.observe("GetCompletionStreak", time.Now())
+	return r.inner.GetCompletionStreak(timezone)
+}
+
+// GetHighlights calls the wrapped repository's GetHighlights
+func (r *InstrumentedTodoRepository) GetHighlights(ids []int64, query string) (map[int64]map[string]string, error) {
+	defer r.observe("GetHighlights", time.Now())
+	return r.inner.GetHighlights(ids, query)
+}
+
+// GetRelated calls the wrapped repository's GetRelated
+func (r *InstrumentedTodoRepository) GetRelated(id int64, limit int) ([]*models.Todo, error) {
+	defer r.observe("GetRelated", time.Now())
+	return r.inner.GetRelated(id, limit)
+}
+
+// ListSavedSearches calls the wrapped repository's ListSavedSearches
+func (r *InstrumentedTodoRepository) ListSavedSearches() ([]*models.SavedSearch, error) {
+	defer r.observe("ListSavedSearches", time.Now())
+	return r.inner.ListSavedSearches()
+}
+
+// GetSavedSearch calls the wrapped repository's GetSavedSearch
+func (r *InstrumentedTodoRepository) GetSavedSearch(name string) (*models.SavedSearch, error) {
+	defer r.observe("GetSavedSearch", time.Now())
+	return r.inner.GetSavedSearch(name)
+}
+
+// CreateSavedSearch calls the wrapped repository's CreateSavedSearch
+func (r *InstrumentedTodoRepository) CreateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	defer r.observe("CreateSavedSearch", time.Now())
+	return r.inner.CreateSavedSearch(name, params)
+}
+
+// UpdateSavedSearch calls the wrapped repository's UpdateSavedSearch
+func (r *InstrumentedTodoRepository) UpdateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	defer r.observe("UpdateSavedSearch", time.Now())
+	return r.inner.UpdateSavedSearch(name, params)
+}
+
+// DeleteSavedSearch calls the wrapped repository's DeleteSavedSearch
+func (r *InstrumentedTodoRepository) DeleteSavedSearch(name string) error {
+	defer r.observe("DeleteSavedSearch", time.Now())
+	return r.inner.DeleteSavedSearch(name)
+}
+
+// ExistingTags calls the wrapped repository's ExistingTags
+func (r *InstrumentedTodoRepository) ExistingTags(names []string) ([]string, error) {
+	defer r.observe("ExistingTags", time.Now())
+	return r.inner.ExistingTags(names)
+}
+
+// GetTimeline calls the wrapped repository's GetTimeline
+func (r *InstrumentedTodoRepository) GetTimeline(todoID int64) ([]*models.TimelineEvent, error) {
+	defer r.observe("GetTimeline", time.Now())
+	return r.inner.GetTimeline(todoID)
+}
+
+// ImportGroups calls the wrapped repository's ImportGroups
+func (r *InstrumentedTodoRepository) ImportGroups(groups []models.ImportGroup) ([]string, int64, error) {
+	defer r.observe("ImportGroups", time.Now())
+	return r.inner.ImportGroups(groups)
+}
+
+// ReleaseReminders calls the wrapped repository's ReleaseReminders
+func (r *InstrumentedTodoRepository) ReleaseReminders(ids []int64) error {
+	defer r.observe("ReleaseReminders", time.Now())
+	return r.inner.ReleaseReminders(ids)
+}
+
+// BatchUpdateCategory calls the wrapped repository's BatchUpdateCategory
+func (r *InstrumentedTodoRepository) BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error) {
+	defer r.observe("BatchUpdateCategory", time.Now())
+	return r.inner.BatchUpdateCategory(ids, categoryID)
+}
+
+// GetDeletedAt calls the wrapped repository's GetDeletedAt
+func (r *InstrumentedTodoRepository) GetDeletedAt(id int64) (*time.Time, error) {
+	defer r.observe("GetDeletedAt", time.Now())
+	return r.inner.GetDeletedAt(id)
+}
+
+// LastModified calls the wrapped repository's LastModified
+func (r *InstrumentedTodoRepository) LastModified() (time.Time, error) {
+	defer r.observe("LastModified", time.Now())
+	return r.inner.LastModified()
+}
+
+// GetDueWithinWindow calls the wrapped repository's GetDueWithinWindow
+func (r *InstrumentedTodoRepository) GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error) {
+	defer r.observe("GetDueWithinWindow", time.Now())
+	return r.inner.GetDueWithinWindow(from, to)
+}
+
+// CountByCompletion calls the wrapped repository's CountByCompletion
+func (r *InstrumentedTodoRepository) CountByCompletion() (*models.TodoCount, error) {
+	defer r.observe("CountByCompletion", time.Now())
+	return r.inner.CountByCompletion()
+}
+
+// GetAllByCategory calls the wrapped repository's GetAllByCategory
+func (r *InstrumentedTodoRepository) GetAllByCategory() ([]*models.Todo, error) {
+	defer r.observe("GetAllByCategory", time.Now())
+	return r.inner.GetAllByCategory()
+}
+
+// GetCategoryAncestors calls the wrapped repository's GetCategoryAncestors
+func (r *InstrumentedTodoRepository) GetCategoryAncestors(id int64) ([]*models.Category, error) {
+	defer r.observe("GetCategoryAncestors", time.Now())
+	return r.inner.GetCategoryAncestors(id)
+}
+
+// ConvertToCategory calls the wrapped repository's ConvertToCategory
+func (r *InstrumentedTodoRepository) ConvertToCategory(id int64) (*models.Category, error) {
+	defer r.observe("ConvertToCategory", time.Now())
+	return r.inner.ConvertToCategory(id)
+}
+
+// GetCompletedBetween calls the wrapped repository's GetCompletedBetween
+func (r *InstrumentedTodoRepository) GetCompletedBetween(from, to time.Time) ([]*models.Todo, error) {
+	defer r.observe("GetCompletedBetween", time.Now())
+	return r.inner.GetCompletedBetween(from, to)
+}
+
+// GetDueBetween calls the wrapped repository's GetDueBetween
+func (r *InstrumentedTodoRepository) GetDueBetween(from, to time.Time) ([]*models.Todo, error) {
+	defer r.observe("GetDueBetween", time.Now())
+	return r.inner.GetDueBetween(from, to)
+}
+
+// GetPreferences calls the wrapped repository's GetPreferences
+func (r *InstrumentedTodoRepository) GetPreferences() (*models.Preferences, error) {
+	defer r.observe("GetPreferences", time.Now())
+	return r.inner.GetPreferences()
+}
+
+// SetPreferences calls the wrapped repository's SetPreferences
+func (r *InstrumentedTodoRepository) SetPreferences(prefs *models.Preferences) error {
+	defer r.observe("SetPreferences", time.Now())
+	return r.inner.SetPreferences(prefs)
+}
+
+// VacuumCompleted calls the wrapped repository's VacuumCompleted
+func (r *InstrumentedTodoRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	defer r.observe("VacuumCompleted", time.Now())
+	return r.inner.VacuumCompleted(olderThanDays)
+}
+
+// UpdateWith calls the wrapped repository's UpdateWith
+func (r *InstrumentedTodoRepository) UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error) {
+	defer r.observe("UpdateWith", time.Now())
+	return r.inner.UpdateWith(id, apply)
+}
+
+// CompleteAllInCategory calls the wrapped repository's CompleteAllInCategory
+func (r *InstrumentedTodoRepository) CompleteAllInCategory(categoryID int64) ([]int64, []int64, error) {
+	defer r.observe("CompleteAllInCategory", time.Now())
+	return r.inner.CompleteAllInCategory(categoryID)
+}
+
+// AddReaction calls the wrapped repository's AddReaction
+func (r *InstrumentedTodoRepository) AddReaction(todoID int64, emoji string) error {
+	defer r.observe("AddReaction", time.Now())
+	return r.inner.AddReaction(todoID, emoji)
+}
+
+// RemoveReaction calls the wrapped repository's RemoveReaction
+func (r *InstrumentedTodoRepository) RemoveReaction(todoID int64, emoji string) error {
+	defer r.observe("RemoveReaction", time.Now())
+	return r.inner.RemoveReaction(todoID, emoji)
+}
+
+// GetReactions calls the wrapped repository's GetReactions
+func (r *InstrumentedTodoRepository) GetReactions(todoID int64) ([]models.Reaction, error) {
+	defer r.observe("GetReactions", time.Now())
+	return r.inner.GetReactions(todoID)
+}
+
+// SetArchived calls the wrapped repository's SetArchived
+func (r *InstrumentedTodoRepository) SetArchived(ids []int64, archived bool) ([]int64, []int64, error) {
+	defer r.observe("SetArchived", time.Now())
+	return r.inner.SetArchived(ids, archived)
+}
+
+// ShiftDueDates calls the wrapped repository's ShiftDueDates
+func (r *InstrumentedTodoRepository) ShiftDueDates(ids []int64, days int) ([]int64, []int64, error) {
+	defer r.observe("ShiftDueDates", time.Now())
+	return r.inner.ShiftDueDates(ids, days)
+}
+
+// AddDependency calls the wrapped repository's AddDependency
+func (r *InstrumentedTodoRepository) AddDependency(todoID, blockingID int64) error {
+	defer r.observe("AddDependency", time.Now())
+	return r.inner.AddDependency(todoID, blockingID)
+}
+
+// RemoveDependency calls the wrapped repository's RemoveDependency
+func (r *InstrumentedTodoRepository) RemoveDependency(todoID, blockingID int64) error {
+	defer r.observe("RemoveDependency", time.Now())
+	return r.inner.RemoveDependency(todoID, blockingID)
+}
+
+// GetBlockers calls the wrapped repository's GetBlockers
+func (r *InstrumentedTodoRepository) GetBlockers(todoID int64) ([]models.Blocker, error) {
+	defer r.observe("GetBlockers", time.Now())
+	return r.inner.GetBlockers(todoID)
+}
+
+// GetActivity calls the wrapped repository's GetActivity
+func (r *InstrumentedTodoRepository) GetActivity(before int64, limit int) ([]*models.ActivityEntry, error) {
+	defer r.observe("GetActivity", time.Now())
+	return r.inner.GetActivity(before, limit)
+}
@@ -0,0 +1,25 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestInstrumentedRepositoryRecordsOperations(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	repo := repository.NewInstrumentedTodoRepository(testhelpers.NewMemoryRepository(), reg)
+
+	testhelpers.InsertTodo(t, repo)
+	if _, _, err := repo.GetAll(repository.TodoFilter{}); err != nil {
+		t.Fatalf("GetAll returned error: %v", err)
+	}
+
+	// One series per operation that has been called
+	if got := testutil.CollectAndCount(reg, "db_operation_duration_seconds"); got != 2 {
+		t.Errorf("got %d series, want 2 (Create and GetAll)", got)
+	}
+}
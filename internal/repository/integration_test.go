@@ -0,0 +1,1973 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/yourusername/todo-api/internal/db"
+	"github.com/yourusername/todo-api/internal/migrate"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/service"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+// Connections to the Postgres container shared by all integration tests
+var (
+	testDB         *sql.DB
+	testPool       *pgxpool.Pool
+	testStatements *db.PreparedStatements
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithPostgres(m))
+}
+
+// runWithPostgres starts a Postgres container with the migrations applied,
+// runs the tests and tears the container down
+func runWithPostgres(m *testing.M) int {
+	ctx := context.Background()
+
+	container, err := postgres.Run(ctx,
+		"postgres:14-alpine",
+		postgres.WithDatabase("todo_test"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("postgres"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		log.Printf("Failed to start Postgres container: %v", err)
+		return 1
+	}
+	defer func() {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			log.Printf("Failed to terminate Postgres container: %v", err)
+		}
+	}()
+
+	connString, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		log.Printf("Failed to get connection string: %v", err)
+		return 1
+	}
+
+	testDB, err = sql.Open("postgres", connString)
+	if err != nil {
+		log.Printf("Failed to open database: %v", err)
+		return 1
+	}
+	defer testDB.Close()
+
+	if _, err := migrate.NewMigrator(testDB, "../../migrations").Up(); err != nil {
+		log.Printf("Failed to apply migrations: %v", err)
+		return 1
+	}
+
+	testStatements, err = db.Prepare(ctx, testDB, repository.PreparedQueries()...)
+	if err != nil {
+		log.Printf("Failed to prepare statements: %v", err)
+		return 1
+	}
+	defer testStatements.Close()
+
+	testPool, err = pgxpool.New(ctx, connString)
+	if err != nil {
+		log.Printf("Failed to create pgx pool: %v", err)
+		return 1
+	}
+	defer testPool.Close()
+
+	return m.Run()
+}
+
+// forEachRepository runs fn as a subtest against every repository
+// implementation, starting each from an empty todos table
+func forEachRepository(t *testing.T, fn func(t *testing.T, repo repository.TodoRepositoryInterface)) {
+	t.Helper()
+
+	repos := map[string]repository.TodoRepositoryInterface{
+		"pq":          repository.NewTodoRepository(testDB, 0, nil),
+		"pq-prepared": repository.NewTodoRepository(testDB, 0, testStatements),
+		"pgx":         repository.NewPgxTodoRepository(testPool, 0),
+	}
+
+	for name, repo := range repos {
+		t.Run(name, func(t *testing.T) {
+			resetTodos(t)
+			fn(t, repo)
+		})
+	}
+}
+
+// resetTodos removes every todo, including the sample data from the migrations
+func resetTodos(t testing.TB) {
+	t.Helper()
+
+	if _, err := testDB.Exec(`TRUNCATE todos, categories, tags, saved_searches, activity_logs, user_preferences, todos_archive, todo_reactions, todo_dependencies RESTART IDENTITY CASCADE`); err != nil {
+		t.Fatalf("Failed to truncate todos: %v", err)
+	}
+	if _, err := testDB.Exec(`REFRESH MATERIALIZED VIEW todo_search_mv`); err != nil {
+		t.Fatalf("Failed to refresh todo_search_mv: %v", err)
+	}
+}
+
+func TestCreateReturnsStoredFields(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo, err := repo.Create(&models.CreateTodoRequest{
+			Title:       "Buy milk",
+			Description: "Semi-skimmed",
+			Priority:    models.PriorityHigh,
+		})
+		if err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+
+		if todo.ID == 0 {
+			t.Error("ID was not assigned")
+		}
+		if todo.Title != "Buy milk" {
+			t.Errorf("Title = %q, want %q", todo.Title, "Buy milk")
+		}
+		if todo.Description != "Semi-skimmed" {
+			t.Errorf("Description = %q, want %q", todo.Description, "Semi-skimmed")
+		}
+		if todo.Priority != models.PriorityHigh {
+			t.Errorf("Priority = %d, want %d", todo.Priority, models.PriorityHigh)
+		}
+		if todo.Completed {
+			t.Error("new todo is completed")
+		}
+		if todo.CompletedAt != nil {
+			t.Errorf("CompletedAt = %v, want nil", todo.CompletedAt)
+		}
+		if todo.CreatedAt.IsZero() || todo.UpdatedAt.IsZero() {
+			t.Error("timestamps were not set")
+		}
+	})
+}
+
+func TestGetAllOrdersByCreatedAtDesc(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		for i := 1; i <= 3; i++ {
+			testhelpers.InsertTodo(t, repo, testhelpers.WithTitle(fmt.Sprintf("Todo %d", i)))
+		}
+
+		todos, _, err := repo.GetAll(repository.TodoFilter{})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+
+		if len(todos) != 3 {
+			t.Fatalf("GetAll returned %d todos, want 3", len(todos))
+		}
+
+		for i, want := range []string{"Todo 3", "Todo 2", "Todo 1"} {
+			if todos[i].Title != want {
+				t.Errorf("todos[%d].Title = %q, want %q", i, todos[i].Title, want)
+			}
+		}
+	})
+}
+
+func TestGetAllPagesAndCountsTotal(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		for i := 0; i < 3; i++ {
+			testhelpers.InsertTodo(t, repo)
+		}
+
+		todos, total, err := repo.GetAll(repository.TodoFilter{Limit: 2, Offset: 2})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+
+		if len(todos) != 1 {
+			t.Errorf("got %d todos, want 1", len(todos))
+		}
+		if total != 3 {
+			t.Errorf("total = %d, want 3", total)
+		}
+	})
+}
+
+func TestGetAllPagesTodosCreatedTogether(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		var ids []int64
+		for i := 0; i < 3; i++ {
+			ids = append(ids, testhelpers.InsertTodo(t, repo).ID)
+		}
+		if _, err := testDB.Exec(`UPDATE todos SET created_at = '2024-03-01 09:30:00'`); err != nil {
+			t.Fatalf("Failed to set created_at: %v", err)
+		}
+
+		var paged []int64
+		for offset := 0; offset < 3; offset++ {
+			todos, _, err := repo.GetAll(repository.TodoFilter{Limit: 1, Offset: offset})
+			if err != nil {
+				t.Fatalf("GetAll returned error: %v", err)
+			}
+			for _, todo := range todos {
+				paged = append(paged, todo.ID)
+			}
+		}
+
+		slices.Reverse(ids)
+		if !slices.Equal(paged, ids) {
+			t.Errorf("pages = %v, want %v, newest ID first", paged, ids)
+		}
+	})
+}
+
+func TestCreateCompletedTodo(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		completedAt := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+		imported, err := repo.Create(&models.CreateTodoRequest{Title: "Imported", Completed: true, CompletedAt: &completedAt})
+		if err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if !imported.Completed || imported.CompletedAt == nil || !imported.CompletedAt.Equal(completedAt) {
+			t.Errorf("completed = %v, completed_at = %v, want true and %v", imported.Completed, imported.CompletedAt, completedAt)
+		}
+
+		// completed_at defaults to now
+		done, err := repo.Create(&models.CreateTodoRequest{Title: "Done", Completed: true})
+		if err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if done.CompletedAt == nil {
+			t.Error("CompletedAt was not set")
+		}
+	})
+}
+
+func TestBulkCreateStampsDatabaseTime(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		var before time.Time
+		if err := testDB.QueryRow(`SELECT LOCALTIMESTAMP`).Scan(&before); err != nil {
+			t.Fatalf("Failed to read the database time: %v", err)
+		}
+
+		// Enough todos for BulkCreate to use COPY
+		batch := make([]*models.CreateTodoRequest, 501)
+		for i := range batch {
+			batch[i] = &models.CreateTodoRequest{Title: fmt.Sprintf("Todo %d", i), Priority: models.PriorityMedium, Completed: i == 0}
+		}
+		if _, err := repo.BulkCreate(batch); err != nil {
+			t.Fatalf("BulkCreate returned error: %v", err)
+		}
+
+		var after time.Time
+		if err := testDB.QueryRow(`SELECT LOCALTIMESTAMP`).Scan(&after); err != nil {
+			t.Fatalf("Failed to read the database time: %v", err)
+		}
+
+		var stamps int
+		var createdAt, updatedAt, completedAt time.Time
+		err := testDB.QueryRow(`
+			SELECT COUNT(DISTINCT created_at), MIN(created_at), MAX(updated_at), MIN(completed_at)
+			FROM todos`).Scan(&stamps, &createdAt, &updatedAt, &completedAt)
+		if err != nil {
+			t.Fatalf("Failed to read the timestamps: %v", err)
+		}
+		if stamps != 1 || createdAt.Before(before) || createdAt.After(after) {
+			t.Errorf("%d created_at values, first %v, want one between %v and %v", stamps, createdAt, before, after)
+		}
+		if !updatedAt.Equal(createdAt) || !completedAt.Equal(createdAt) {
+			t.Errorf("updated_at = %v, completed_at = %v, want %v", updatedAt, completedAt, createdAt)
+		}
+	})
+}
+
+func TestGetAllSortsByMultipleFields(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		low := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("b"), testhelpers.WithPriority(models.PriorityLow))
+		highB := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("b"), testhelpers.WithPriority(models.PriorityHigh))
+		highA := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("a"), testhelpers.WithPriority(models.PriorityHigh))
+
+		todos, _, err := repo.GetAll(repository.TodoFilter{Sort: []repository.SortParam{
+			{Field: "priority", Descending: true},
+			{Field: "title"},
+		}})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+
+		want := []int64{highA.ID, highB.ID, low.ID}
+		if len(todos) != len(want) {
+			t.Fatalf("got %d todos, want %d", len(todos), len(want))
+		}
+		for i, id := range want {
+			if todos[i].ID != id {
+				t.Errorf("todos[%d].ID = %d, want %d", i, todos[i].ID, id)
+			}
+		}
+	})
+}
+
+func TestGetCompletionStreak(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		now := time.Now().UTC()
+		for _, daysAgo := range []int{10, 9, 8, 1, 0} {
+			completedAt := now.AddDate(0, 0, -daysAgo)
+			if _, err := repo.Create(&models.CreateTodoRequest{Title: "Done", Completed: true, CompletedAt: &completedAt}); err != nil {
+				t.Fatalf("Create returned error: %v", err)
+			}
+		}
+
+		streak, err := repo.GetCompletionStreak("UTC")
+		if err != nil {
+			t.Fatalf("GetCompletionStreak returned error: %v", err)
+		}
+		if streak.Current != 2 || streak.Longest != 3 {
+			t.Errorf("streak = %d current, %d longest, want 2 and 3", streak.Current, streak.Longest)
+		}
+		if streak.LastCompletionDate == nil || *streak.LastCompletionDate != now.Format("2006-01-02") {
+			t.Errorf("last_completion_date = %v, want today", streak.LastCompletionDate)
+		}
+	})
+}
+
+func TestUpdateCompletedSetsCompletedAt(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		created := testhelpers.InsertTodo(t, repo)
+		svc := service.NewTodoService(repo)
+
+		completed := true
+		todo, err := svc.Update(created.ID, &models.UpdateTodoRequest{Completed: &completed})
+		if err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+
+		if !todo.Completed {
+			t.Error("todo is not completed")
+		}
+		if todo.CompletedAt == nil {
+			t.Fatal("CompletedAt was not set")
+		}
+
+		stored, err := repo.GetByID(created.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if stored.CompletedAt == nil {
+			t.Error("CompletedAt was not persisted")
+		}
+	})
+}
+
+func TestTimestampTriggerStampsRawUpdates(t *testing.T) {
+	resetTodos(t)
+
+	// Start from timestamps a day old, which the INSERT leaves alone
+	var id int64
+	var updatedAt time.Time
+	err := testDB.QueryRow(`
+		INSERT INTO todos (title, created_at, updated_at)
+		VALUES ('Buy milk', NOW() - INTERVAL '1 day', NOW() - INTERVAL '1 day')
+		RETURNING id, updated_at
+	`).Scan(&id, &updatedAt)
+	if err != nil {
+		t.Fatalf("Failed to insert todo: %v", err)
+	}
+
+	var stamped time.Time
+	var completedAt *time.Time
+	err = testDB.QueryRow(`UPDATE todos SET completed = true WHERE id = $1 RETURNING updated_at, completed_at`, id).Scan(&stamped, &completedAt)
+	if err != nil {
+		t.Fatalf("Failed to update todo: %v", err)
+	}
+	if !stamped.After(updatedAt) {
+		t.Errorf("updated_at = %v after UPDATE, want later than %v", stamped, updatedAt)
+	}
+	if completedAt == nil {
+		t.Error("completed_at was not stamped when completed flipped")
+	}
+
+	// Recording an overdue reminder alone leaves updated_at as it was
+	var notified time.Time
+	err = testDB.QueryRow(`UPDATE todos SET overdue_notified_at = NOW() WHERE id = $1 RETURNING updated_at`, id).Scan(&notified)
+	if err != nil {
+		t.Fatalf("Failed to mark todo notified: %v", err)
+	}
+	if !notified.Equal(stamped) {
+		t.Errorf("updated_at = %v after marking notified, want %v", notified, stamped)
+	}
+
+	err = testDB.QueryRow(`UPDATE todos SET completed = false WHERE id = $1 RETURNING completed_at`, id).Scan(&completedAt)
+	if err != nil {
+		t.Fatalf("Failed to reopen todo: %v", err)
+	}
+	if completedAt != nil {
+		t.Errorf("completed_at = %v after reopening, want NULL", completedAt)
+	}
+}
+
+func TestDeleteRemovesRow(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		created := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Doomed"))
+		if _, err := repo.SetTags(created.ID, []string{"home"}); err != nil {
+			t.Fatalf("SetTags returned error: %v", err)
+		}
+
+		deleted, err := repo.Delete(created.ID)
+		if err != nil {
+			t.Fatalf("Delete returned error: %v", err)
+		}
+		if deleted.ID != created.ID || deleted.Title != "Doomed" || !reflect.DeepEqual(deleted.Tags, []string{"home"}) {
+			t.Errorf("Delete returned %+v, want the todo as it was", deleted)
+		}
+
+		var count int
+		if err := testDB.QueryRow(`SELECT COUNT(*) FROM todos WHERE id = $1`, created.ID).Scan(&count); err != nil {
+			t.Fatalf("Failed to count todos: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("found %d rows after delete, want 0", count)
+		}
+	})
+}
+
+func TestMissingTodoReturnsErrNotFound(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo, err := repo.GetByID(999999)
+		if !errors.Is(err, repository.ErrNotFound) || todo != nil {
+			t.Errorf("GetByID = %+v, %v, want ErrNotFound", todo, err)
+		}
+
+		todo, err = repo.Update(&models.Todo{ID: 999999, Title: "Missing", Priority: models.PriorityMedium})
+		if !errors.Is(err, repository.ErrNotFound) || todo != nil {
+			t.Errorf("Update = %+v, %v, want ErrNotFound", todo, err)
+		}
+
+		todo, err = repo.UpdateWith(999999, func(todo *models.Todo) *models.Todo { return todo })
+		if !errors.Is(err, repository.ErrNotFound) || todo != nil {
+			t.Errorf("UpdateWith = %+v, %v, want ErrNotFound", todo, err)
+		}
+
+		if _, err := repo.Delete(999999); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Delete returned %v, want ErrNotFound", err)
+		}
+	})
+}
+
+// limitedRepositories returns every repository implementation with the
+// todo limit set to maxTodos
+func limitedRepositories(maxTodos int) map[string]repository.TodoRepositoryInterface {
+	return map[string]repository.TodoRepositoryInterface{
+		"pq":  repository.NewTodoRepository(testDB, maxTodos, nil),
+		"pgx": repository.NewPgxTodoRepository(testPool, maxTodos),
+	}
+}
+
+func TestTodoLimitCoversEveryInsert(t *testing.T) {
+	for name, repo := range limitedRepositories(2) {
+		t.Run(name, func(t *testing.T) {
+			resetTodos(t)
+
+			externalID := "ext-1"
+			if _, _, err := repo.Upsert(&models.CreateTodoRequest{Title: "Synced", Priority: models.PriorityMedium, ExternalID: &externalID}); err != nil {
+				t.Fatalf("Upsert returned error: %v", err)
+			}
+
+			batch := []*models.CreateTodoRequest{{Title: "One", Priority: models.PriorityMedium}, {Title: "Two", Priority: models.PriorityMedium}}
+			if _, err := repo.BulkCreate(batch); !errors.Is(err, repository.ErrTodoLimitExceeded) {
+				t.Errorf("BulkCreate over the limit returned %v, want ErrTodoLimitExceeded", err)
+			}
+			if _, err := repo.BulkCreate(batch[:1]); err != nil {
+				t.Fatalf("BulkCreate within the limit returned error: %v", err)
+			}
+
+			// At the limit, updating the synced todo adds nothing and is allowed
+			if _, inserted, err := repo.Upsert(&models.CreateTodoRequest{Title: "Synced again", Priority: models.PriorityMedium, ExternalID: &externalID}); err != nil || inserted {
+				t.Errorf("Upsert of an existing todo at the limit = inserted %v, %v, want an update", inserted, err)
+			}
+			otherID := "ext-2"
+			if _, _, err := repo.Upsert(&models.CreateTodoRequest{Title: "New", Priority: models.PriorityMedium, ExternalID: &otherID}); !errors.Is(err, repository.ErrTodoLimitExceeded) {
+				t.Errorf("Upsert of a new todo at the limit returned %v, want ErrTodoLimitExceeded", err)
+			}
+		})
+	}
+}
+
+func TestTodoLimitCountsArchivedTodos(t *testing.T) {
+	for name, repo := range limitedRepositories(1) {
+		t.Run(name, func(t *testing.T) {
+			resetTodos(t)
+
+			todo := testhelpers.InsertTodo(t, repo)
+			if _, _, err := repo.SetArchived([]int64{todo.ID}, true); err != nil {
+				t.Fatalf("SetArchived returned error: %v", err)
+			}
+
+			// An archived todo still takes up its place under the limit, so
+			// bringing it back can never go over
+			if _, err := repo.Create(&models.CreateTodoRequest{Title: "Another", Priority: models.PriorityMedium}); !errors.Is(err, repository.ErrTodoLimitExceeded) {
+				t.Errorf("Create with an archived todo at the limit returned %v, want ErrTodoLimitExceeded", err)
+			}
+			if changed, _, err := repo.SetArchived([]int64{todo.ID}, false); err != nil || len(changed) != 1 {
+				t.Errorf("SetArchived(false) at the limit = %v, %v, want the todo unarchived", changed, err)
+			}
+		})
+	}
+}
+
+func TestTodoLimitHoldsUnderConcurrentCreates(t *testing.T) {
+	const limit = 5
+
+	for name, repo := range limitedRepositories(limit) {
+		t.Run(name, func(t *testing.T) {
+			resetTodos(t)
+
+			var wg sync.WaitGroup
+			for range 4 * limit {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := repo.Create(&models.CreateTodoRequest{Title: "Racing", Priority: models.PriorityMedium}); err != nil && !errors.Is(err, repository.ErrTodoLimitExceeded) {
+						t.Errorf("Create returned error: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			var count int
+			if err := testDB.QueryRow(`SELECT COUNT(*) FROM todos`).Scan(&count); err != nil {
+				t.Fatalf("Failed to count todos: %v", err)
+			}
+			if count != limit {
+				t.Errorf("%d todos after concurrent creates, want the limit of %d", count, limit)
+			}
+		})
+	}
+}
+
+func TestMoveTodosSetsCategory(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo)
+
+		category, err := repo.CreateCategory("Work", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+
+		moved, err := repo.MoveTodos([]int64{todo.ID, 999999}, &category.ID)
+		if err != nil {
+			t.Fatalf("MoveTodos returned error: %v", err)
+		}
+		if moved != 1 {
+			t.Errorf("MoveTodos moved %d, want 1", moved)
+		}
+
+		got, err := repo.GetByID(todo.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if got.CategoryID == nil || *got.CategoryID != category.ID {
+			t.Errorf("category_id = %v, want %d", got.CategoryID, category.ID)
+		}
+
+		if _, err := repo.MoveTodos([]int64{todo.ID}, nil); err != nil {
+			t.Fatalf("MoveTodos to uncategorized returned error: %v", err)
+		}
+
+		got, _ = repo.GetByID(todo.ID)
+		if got.CategoryID != nil {
+			t.Errorf("category_id = %d, want null", *got.CategoryID)
+		}
+	})
+}
+
+func TestBatchUpdateCategoryReturnsUpdatedIDs(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo)
+
+		category, err := repo.CreateCategory("Work", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+
+		updated, err := repo.BatchUpdateCategory([]int64{todo.ID, todo.ID, 999999}, category.ID)
+		if err != nil {
+			t.Fatalf("BatchUpdateCategory returned error: %v", err)
+		}
+		if !slices.Equal(updated, []int64{todo.ID}) {
+			t.Errorf("BatchUpdateCategory updated %v, want [%d]", updated, todo.ID)
+		}
+
+		got, err := repo.GetByID(todo.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if got.CategoryID == nil || *got.CategoryID != category.ID {
+			t.Errorf("category_id = %v, want %d", got.CategoryID, category.ID)
+		}
+	})
+}
+
+func TestCompleteAllInCategory(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		sprint, err := repo.CreateCategory("Sprint", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+		open := testhelpers.InsertTodo(t, repo)
+		done := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+		blocked := testhelpers.InsertTodo(t, repo)
+		other := testhelpers.InsertTodo(t, repo)
+		if _, err := repo.MoveTodos([]int64{open.ID, done.ID, blocked.ID}, &sprint.ID); err != nil {
+			t.Fatalf("MoveTodos returned error: %v", err)
+		}
+		if err := repo.AddDependency(blocked.ID, other.ID); err != nil {
+			t.Fatalf("AddDependency returned error: %v", err)
+		}
+
+		completed, blockedIDs, err := repo.CompleteAllInCategory(sprint.ID)
+		if err != nil {
+			t.Fatalf("CompleteAllInCategory returned error: %v", err)
+		}
+		if !slices.Equal(completed, []int64{open.ID}) || !slices.Equal(blockedIDs, []int64{blocked.ID}) {
+			t.Errorf("CompleteAllInCategory = %v, %v, want [%d], [%d]", completed, blockedIDs, open.ID, blocked.ID)
+		}
+		if got, _ := repo.GetByID(blocked.ID); got.Completed {
+			t.Error("blocked todo was completed")
+		}
+
+		got, err := repo.GetByID(open.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if !got.Completed || got.CompletedAt == nil {
+			t.Errorf("todo = %+v, want it completed with completed_at", got)
+		}
+		if got, _ := repo.GetByID(other.ID); got.Completed {
+			t.Error("todo outside the category was completed")
+		}
+	})
+}
+
+func TestReactions(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo)
+
+		for i := 0; i < repository.MaxReactionsPerTodo; i++ {
+			if err := repo.AddReaction(todo.ID, string(rune(0x1F42D+i))); err != nil {
+				t.Fatalf("AddReaction returned error: %v", err)
+			}
+		}
+		if err := repo.AddReaction(todo.ID, string(rune(0x1F42D))); err != nil {
+			t.Errorf("repeated AddReaction returned %v, want nil", err)
+		}
+		if err := repo.AddReaction(todo.ID, "\U0001F44D"); !errors.Is(err, repository.ErrReactionLimitReached) {
+			t.Errorf("AddReaction over the limit returned %v, want ErrReactionLimitReached", err)
+		}
+		if err := repo.AddReaction(999999, "\U0001F44D"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("AddReaction on a missing todo returned %v, want ErrNotFound", err)
+		}
+
+		if err := repo.RemoveReaction(todo.ID, string(rune(0x1F42D))); err != nil {
+			t.Fatalf("RemoveReaction returned error: %v", err)
+		}
+		if err := repo.RemoveReaction(999999, "\U0001F44D"); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("RemoveReaction on a missing todo returned %v, want ErrNotFound", err)
+		}
+
+		reactions, err := repo.GetReactions(todo.ID)
+		if err != nil {
+			t.Fatalf("GetReactions returned error: %v", err)
+		}
+		if len(reactions) != repository.MaxReactionsPerTodo-1 {
+			t.Fatalf("GetReactions returned %d reactions, want %d", len(reactions), repository.MaxReactionsPerTodo-1)
+		}
+		want := models.Reaction{Emoji: string(rune(0x1F42E)), Count: 1, ReactedByMe: true}
+		if reactions[0] != want {
+			t.Errorf("first reaction = %+v, want %+v", reactions[0], want)
+		}
+	})
+}
+
+func TestDependenciesBlockCompletion(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithExternalID("sync-1"))
+		blocking := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Blocking"))
+		upstream := testhelpers.InsertTodo(t, repo)
+
+		if err := repo.AddDependency(todo.ID, blocking.ID); err != nil {
+			t.Fatalf("AddDependency returned error: %v", err)
+		}
+		if err := repo.AddDependency(todo.ID, blocking.ID); err != nil {
+			t.Errorf("repeated AddDependency returned %v, want nil", err)
+		}
+		if err := repo.AddDependency(blocking.ID, upstream.ID); err != nil {
+			t.Fatalf("AddDependency returned error: %v", err)
+		}
+		if err := repo.AddDependency(upstream.ID, todo.ID); !errors.Is(err, repository.ErrDependencyCycle) {
+			t.Errorf("AddDependency closing a cycle returned %v, want ErrDependencyCycle", err)
+		}
+		if err := repo.AddDependency(todo.ID, 999999); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("AddDependency on a missing todo returned %v, want ErrNotFound", err)
+		}
+
+		blockers, err := repo.GetBlockers(todo.ID)
+		if err != nil {
+			t.Fatalf("GetBlockers returned error: %v", err)
+		}
+		want := models.Blocker{ID: blocking.ID, Title: "Blocking"}
+		if len(blockers) != 1 || blockers[0] != want {
+			t.Errorf("GetBlockers = %+v, want [%+v]", blockers, want)
+		}
+
+		if _, err := repo.SetCompleted(todo.ID, true); !errors.Is(err, repository.ErrBlocked) {
+			t.Errorf("SetCompleted on a blocked todo returned %v, want ErrBlocked", err)
+		}
+		_, err = repo.UpdateWith(todo.ID, func(current *models.Todo) *models.Todo {
+			current.Completed = true
+			return current
+		})
+		if !errors.Is(err, repository.ErrBlocked) {
+			t.Errorf("UpdateWith completing a blocked todo returned %v, want ErrBlocked", err)
+		}
+		if _, err := repo.SetCompleted(999999, true); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("SetCompleted on a missing todo error = %v, want ErrNotFound", err)
+		}
+		upserted, _, err := repo.Upsert(&models.CreateTodoRequest{Title: "Synced", ExternalID: todo.ExternalID, Completed: true})
+		if err != nil {
+			t.Fatalf("Upsert returned error: %v", err)
+		}
+		if upserted.Completed {
+			t.Error("Upsert completed a blocked todo")
+		}
+
+		if err := repo.RemoveDependency(todo.ID, blocking.ID); err != nil {
+			t.Fatalf("RemoveDependency returned error: %v", err)
+		}
+		if err := repo.RemoveDependency(999999, blocking.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("RemoveDependency on a missing todo returned %v, want ErrNotFound", err)
+		}
+		if completed, err := repo.SetCompleted(todo.ID, true); err != nil || !completed.Completed {
+			t.Errorf("SetCompleted after RemoveDependency = %v, %v, want a completed todo", completed, err)
+		}
+	})
+}
+
+func TestConcurrentDependenciesCannotFormCycle(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		for range 10 {
+			a := testhelpers.InsertTodo(t, repo)
+			b := testhelpers.InsertTodo(t, repo)
+
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i, pair := range [][2]int64{{a.ID, b.ID}, {b.ID, a.ID}} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = repo.AddDependency(pair[0], pair[1])
+				}()
+			}
+			wg.Wait()
+
+			cycles := 0
+			for _, err := range errs {
+				if errors.Is(err, repository.ErrDependencyCycle) {
+					cycles++
+				} else if err != nil {
+					t.Fatalf("AddDependency returned error: %v", err)
+				}
+			}
+			if cycles != 1 {
+				t.Fatalf("AddDependency errors = %v, want exactly one ErrDependencyCycle", errs)
+			}
+		}
+	})
+}
+
+func TestConcurrentPinsStayWithinLimit(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		for range repository.MaxPinnedTodos - 1 {
+			testhelpers.InsertTodo(t, repo, testhelpers.WithPinned(true))
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 5)
+		for i := range errs {
+			todo := testhelpers.InsertTodo(t, repo)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = repo.SetPinned(todo.ID, true)
+			}()
+		}
+		wg.Wait()
+
+		pinned := 0
+		for _, err := range errs {
+			if err == nil {
+				pinned++
+			} else if !errors.Is(err, repository.ErrPinLimitReached) {
+				t.Fatalf("SetPinned returned error: %v", err)
+			}
+		}
+		if pinned != 1 {
+			t.Errorf("SetPinned errors = %v, want exactly one success", errs)
+		}
+	})
+}
+
+func TestConcurrentReactionsStayWithinLimit(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo)
+		for i := range repository.MaxReactionsPerTodo - 1 {
+			if err := repo.AddReaction(todo.ID, fmt.Sprintf("emoji-%d", i)); err != nil {
+				t.Fatalf("AddReaction returned error: %v", err)
+			}
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 5)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = repo.AddReaction(todo.ID, fmt.Sprintf("extra-%d", i))
+			}()
+		}
+		wg.Wait()
+
+		added := 0
+		for _, err := range errs {
+			if err == nil {
+				added++
+			} else if !errors.Is(err, repository.ErrReactionLimitReached) {
+				t.Fatalf("AddReaction returned error: %v", err)
+			}
+		}
+		if added != 1 {
+			t.Errorf("AddReaction errors = %v, want exactly one success", errs)
+		}
+	})
+}
+
+func TestReorderSetsPositions(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		first := testhelpers.InsertTodo(t, repo)
+		second := testhelpers.InsertTodo(t, repo)
+
+		updated, err := repo.Reorder([]models.TodoPosition{{ID: first.ID, Position: 1}, {ID: second.ID, Position: 2}})
+		if err != nil {
+			t.Fatalf("Reorder returned error: %v", err)
+		}
+		if updated != 2 {
+			t.Errorf("updated = %d, want 2", updated)
+		}
+
+		todos, _, err := repo.GetAll(repository.TodoFilter{})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+		if len(todos) != 2 || todos[0].ID != first.ID || todos[1].ID != second.ID {
+			t.Errorf("todos are not in position order")
+		}
+
+		_, err = repo.Reorder([]models.TodoPosition{{ID: first.ID, Position: 2}, {ID: second.ID + 100, Position: 1}})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Reorder with a missing todo returned %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestDuplicateCategoryCopiesTodosAndTags(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		category, err := repo.CreateCategory("Event planning", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Book venue"), testhelpers.WithCompleted(true))
+		if _, err := repo.MoveTodos([]int64{todo.ID}, &category.ID); err != nil {
+			t.Fatalf("MoveTodos returned error: %v", err)
+		}
+		if _, err := repo.SetTags(todo.ID, []string{"venue"}); err != nil {
+			t.Fatalf("SetTags returned error: %v", err)
+		}
+
+		copied, err := repo.DuplicateCategory(category.ID)
+		if err != nil {
+			t.Fatalf("DuplicateCategory returned error: %v", err)
+		}
+		if copied.Name != "Copy of Event planning" || copied.TodoCount != 1 {
+			t.Fatalf("DuplicateCategory returned %+v, want one todo in %q", copied, "Copy of Event planning")
+		}
+
+		todos, _, err := repo.GetAll(repository.TodoFilter{CategoryID: &copied.ID})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+		if len(todos) != 1 || todos[0].ID == todo.ID || todos[0].Completed || len(todos[0].Tags) != 1 {
+			t.Errorf("copied todos = %+v, want one fresh incomplete todo tagged venue", todos)
+		}
+
+		if _, err := repo.DuplicateCategory(9999); !errors.Is(err, repository.ErrCategoryNotFound) {
+			t.Errorf("DuplicateCategory(9999) returned %v, want ErrCategoryNotFound", err)
+		}
+	})
+}
+
+func TestConvertToCategoryReplacesTodo(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		area, err := repo.CreateCategory("Home", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Renovate kitchen"))
+		if _, err := repo.MoveTodos([]int64{todo.ID}, &area.ID); err != nil {
+			t.Fatalf("MoveTodos returned error: %v", err)
+		}
+
+		category, err := repo.ConvertToCategory(todo.ID)
+		if err != nil {
+			t.Fatalf("ConvertToCategory returned error: %v", err)
+		}
+		if category.Name != "Renovate kitchen" || category.ParentID == nil || *category.ParentID != area.ID {
+			t.Fatalf("ConvertToCategory returned %+v, want %q under %d", category, "Renovate kitchen", area.ID)
+		}
+
+		if archived, err := repo.GetByID(todo.ID); err != nil || archived.ArchivedAt == nil {
+			t.Errorf("GetByID after convert = %+v, %v, want the todo archived", archived, err)
+		}
+
+		// The todo is archived, so it can be brought back
+		restored, _, err := repo.SetArchived([]int64{todo.ID}, false)
+		if err != nil || len(restored) != 1 {
+			t.Errorf("SetArchived(false) after convert = %v, %v, want the todo restored", restored, err)
+		}
+
+		var parentID *int64
+		for i := 0; i < models.MaxCategoryDepth; i++ {
+			category, err := repo.CreateCategory(fmt.Sprintf("Level %d", i+1), parentID)
+			if err != nil {
+				t.Fatalf("CreateCategory returned error: %v", err)
+			}
+			parentID = &category.ID
+		}
+		deep := testhelpers.InsertTodo(t, repo)
+		if _, err := repo.MoveTodos([]int64{deep.ID}, parentID); err != nil {
+			t.Fatalf("MoveTodos returned error: %v", err)
+		}
+		if _, err := repo.ConvertToCategory(deep.ID); !errors.Is(err, repository.ErrCategoryTooDeep) {
+			t.Errorf("ConvertToCategory at the depth limit returned %v, want ErrCategoryTooDeep", err)
+		}
+		if _, err := repo.GetByID(deep.ID); err != nil {
+			t.Errorf("GetByID after a refused convert returned %v, want the todo kept", err)
+		}
+
+		if _, err := repo.ConvertToCategory(9999); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("ConvertToCategory(9999) returned %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestGetAllCombinesSearchAndTags(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		groceries := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Shopping for groceries"))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Shopping for a laptop"))
+		errand := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Post office"))
+
+		for _, todo := range []*models.Todo{groceries, errand} {
+			if _, err := repo.SetTags(todo.ID, []string{"errands"}); err != nil {
+				t.Fatalf("SetTags returned error: %v", err)
+			}
+		}
+
+		q := "shopping"
+		todos, _, err := repo.GetAll(repository.TodoFilter{SearchQuery: &q, SearchLive: true, Tags: []string{"errands"}})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+
+		if len(todos) != 1 || todos[0].ID != groceries.ID {
+			t.Fatalf("GetAll returned %d todos, want only %d", len(todos), groceries.ID)
+		}
+		if len(todos[0].Tags) != 1 || todos[0].Tags[0] != "errands" {
+			t.Errorf("tags = %v, want [errands]", todos[0].Tags)
+		}
+	})
+}
+
+func TestGetAllFiltersByTagged(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		tagged := testhelpers.InsertTodo(t, repo)
+		untagged := testhelpers.InsertTodo(t, repo)
+		if _, err := repo.SetTags(tagged.ID, []string{"work"}); err != nil {
+			t.Fatalf("SetTags returned error: %v", err)
+		}
+
+		for _, want := range []*models.Todo{tagged, untagged} {
+			isTagged := want == tagged
+			todos, total, err := repo.GetAll(repository.TodoFilter{Tagged: &isTagged})
+			if err != nil {
+				t.Fatalf("GetAll returned error: %v", err)
+			}
+			if total != 1 || len(todos) != 1 || todos[0].ID != want.ID {
+				t.Errorf("GetAll(tagged=%v) returned %d of %d todos, want only %d", isTagged, len(todos), total, want.ID)
+			}
+		}
+	})
+}
+
+func TestGetAllSortsByRelevance(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		once := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Review report"))
+		twice := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Report"), testhelpers.WithDescription("Send the report to finance"))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk"))
+
+		q := "report"
+		todos, total, err := repo.GetAll(repository.TodoFilter{SearchQuery: &q, SearchLive: true, SortByRelevance: true, Limit: 10})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+
+		if total != 2 || len(todos) != 2 || todos[0].ID != twice.ID || todos[1].ID != once.ID {
+			t.Errorf("GetAll returned %d of %d todos, want %d then %d", len(todos), total, twice.ID, once.ID)
+		}
+	})
+}
+
+func TestPreferencesRoundTrip(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		prefs, err := repo.GetPreferences()
+		if err != nil {
+			t.Fatalf("GetPreferences returned error: %v", err)
+		}
+		if prefs.TodosPerPage != models.DefaultTodosPerPage || prefs.Theme != models.ThemeSystem || prefs.Timezone != "UTC" {
+			t.Errorf("GetPreferences before saving = %+v, want the defaults", prefs)
+		}
+
+		want := &models.Preferences{
+			DefaultSort:   "due_at:asc",
+			DefaultFilter: models.SearchParams{"tag": {"work", "urgent"}},
+			TodosPerPage:  100,
+			Theme:         models.ThemeDark,
+			Timezone:      "Asia/Tokyo",
+		}
+		if err := repo.SetPreferences(want); err != nil {
+			t.Fatalf("SetPreferences returned error: %v", err)
+		}
+
+		got, err := repo.GetPreferences()
+		if err != nil {
+			t.Fatalf("GetPreferences returned error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetPreferences = %+v, want %+v", got, want)
+		}
+
+		tz, err := repo.GetTimezone()
+		if err != nil || tz != "Asia/Tokyo" {
+			t.Errorf("GetTimezone = %q, %v, want Asia/Tokyo", tz, err)
+		}
+	})
+}
+
+func TestVacuumCompletedArchivesOldTodos(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		longAgo := time.Now().AddDate(-2, 0, 0)
+		old, err := repo.Create(&models.CreateTodoRequest{Title: "Old", Priority: models.PriorityMedium, Completed: true, CompletedAt: &longAgo})
+		if err != nil {
+			t.Fatalf("Create returned error: %v", err)
+		}
+		if _, err := repo.SetTags(old.ID, []string{"history"}); err != nil {
+			t.Fatalf("SetTags returned error: %v", err)
+		}
+		recent := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+
+		archived, err := repo.VacuumCompleted(365)
+		if err != nil {
+			t.Fatalf("VacuumCompleted returned error: %v", err)
+		}
+		if archived != 1 {
+			t.Errorf("VacuumCompleted archived %d todos, want 1", archived)
+		}
+
+		if gone, _ := repo.GetByID(old.ID); gone != nil {
+			t.Errorf("todo %d is still in todos", old.ID)
+		}
+		if kept, _ := repo.GetByID(recent.ID); kept == nil {
+			t.Errorf("recently completed todo %d was archived", recent.ID)
+		}
+		if deletedAt, err := repo.GetDeletedAt(old.ID); err != nil || deletedAt != nil {
+			t.Errorf("GetDeletedAt of a vacuumed todo = %v, %v, want nil", deletedAt, err)
+		}
+
+		var title, tag string
+		err = testDB.QueryRow(`
+			SELECT a.title, t.name
+			FROM todos_archive a JOIN todo_tags_archive t ON t.todo_id = a.id
+			WHERE a.id = $1
+		`, old.ID).Scan(&title, &tag)
+		if err != nil || title != "Old" || tag != "history" {
+			t.Errorf("archived row = %q tagged %q, %v, want \"Old\" tagged \"history\"", title, tag, err)
+		}
+	})
+}
+
+func TestSetArchivedStampsArchivedAt(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Ship it"))
+		if _, err := repo.SetTags(todo.ID, []string{"release"}); err != nil {
+			t.Fatalf("SetTags returned error: %v", err)
+		}
+		other := testhelpers.InsertTodo(t, repo)
+
+		changed, unchanged, err := repo.SetArchived([]int64{todo.ID, 999}, true)
+		if err != nil {
+			t.Fatalf("SetArchived returned error: %v", err)
+		}
+		if len(changed) != 1 || changed[0] != todo.ID || len(unchanged) != 0 {
+			t.Errorf("SetArchived = %v, %v, want [%d], []", changed, unchanged, todo.ID)
+		}
+
+		archived, err := repo.GetByID(todo.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if archived.ArchivedAt == nil || len(archived.Tags) != 1 || archived.Tags[0] != "release" {
+			t.Errorf("archived todo = %v tagged %v, want archived_at set and [release] kept", archived.ArchivedAt, archived.Tags)
+		}
+		todos, total, err := repo.GetAll(repository.TodoFilter{})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+		if total != 1 || len(todos) != 1 || todos[0].ID != other.ID {
+			t.Errorf("GetAll = %d todos of %d, want only todo %d", len(todos), total, other.ID)
+		}
+
+		// Archiving is not a deletion, so it does not make the todo gone
+		if deletedAt, err := repo.GetDeletedAt(todo.ID); err != nil || deletedAt != nil {
+			t.Errorf("GetDeletedAt of an archived todo = %v, %v, want nil", deletedAt, err)
+		}
+
+		// Archiving again reports it as already archived
+		changed, unchanged, err = repo.SetArchived([]int64{todo.ID}, true)
+		if err != nil || len(changed) != 0 || len(unchanged) != 1 {
+			t.Errorf("SetArchived again = %v, %v, %v, want [], [%d]", changed, unchanged, err, todo.ID)
+		}
+
+		changed, unchanged, err = repo.SetArchived([]int64{todo.ID, other.ID}, false)
+		if err != nil {
+			t.Fatalf("SetArchived(false) returned error: %v", err)
+		}
+		if len(changed) != 1 || changed[0] != todo.ID || len(unchanged) != 1 || unchanged[0] != other.ID {
+			t.Errorf("SetArchived(false) = %v, %v, want [%d], [%d]", changed, unchanged, todo.ID, other.ID)
+		}
+
+		restored, err := repo.GetByID(todo.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if restored.ArchivedAt != nil {
+			t.Errorf("restored todo archived_at = %v, want unset", restored.ArchivedAt)
+		}
+
+		events, err := repo.GetTimeline(todo.ID)
+		if err != nil {
+			t.Fatalf("GetTimeline returned error: %v", err)
+		}
+		var got []string
+		for _, event := range events {
+			got = append(got, event.Event)
+		}
+		if want := []string{models.EventCreated, models.EventArchived, models.EventUnarchived}; !slices.Equal(got, want) {
+			t.Errorf("timeline = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestSetArchivedKeepsReactionsAndDependencies(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Write report"))
+		blocker := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Gather numbers"))
+		dependent := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Send report"))
+
+		if err := repo.AddReaction(todo.ID, "👍"); err != nil {
+			t.Fatalf("AddReaction returned error: %v", err)
+		}
+		if err := repo.AddDependency(todo.ID, blocker.ID); err != nil {
+			t.Fatalf("AddDependency returned error: %v", err)
+		}
+		if err := repo.AddDependency(dependent.ID, todo.ID); err != nil {
+			t.Fatalf("AddDependency returned error: %v", err)
+		}
+
+		if _, _, err := repo.SetArchived([]int64{todo.ID}, true); err != nil {
+			t.Fatalf("SetArchived returned error: %v", err)
+		}
+
+		// An archived todo no longer blocks anything
+		blockers, err := repo.GetBlockers(dependent.ID)
+		if err != nil {
+			t.Fatalf("GetBlockers returned error: %v", err)
+		}
+		if len(blockers) != 0 {
+			t.Errorf("blockers of %d while archived = %v, want none", dependent.ID, blockers)
+		}
+
+		if _, _, err := repo.SetArchived([]int64{todo.ID}, false); err != nil {
+			t.Fatalf("SetArchived(false) returned error: %v", err)
+		}
+
+		reactions, err := repo.GetReactions(todo.ID)
+		if err != nil {
+			t.Fatalf("GetReactions returned error: %v", err)
+		}
+		if len(reactions) != 1 || reactions[0].Emoji != "👍" {
+			t.Errorf("reactions after unarchive = %v, want 👍", reactions)
+		}
+
+		blockers, err = repo.GetBlockers(todo.ID)
+		if err != nil {
+			t.Fatalf("GetBlockers returned error: %v", err)
+		}
+		if len(blockers) != 1 || blockers[0].ID != blocker.ID {
+			t.Errorf("blockers of %d after unarchive = %v, want %d", todo.ID, blockers, blocker.ID)
+		}
+
+		blockers, err = repo.GetBlockers(dependent.ID)
+		if err != nil {
+			t.Fatalf("GetBlockers returned error: %v", err)
+		}
+		if len(blockers) != 1 || blockers[0].ID != todo.ID {
+			t.Errorf("blockers of %d after unarchive = %v, want %d", dependent.ID, blockers, todo.ID)
+		}
+	})
+}
+
+func TestArchivedTodosAreLeftOut(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		sprint, err := repo.CreateCategory("Sprint", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+		open := testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityHigh))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+		archived := testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityHigh))
+		if _, err := repo.MoveTodos([]int64{open.ID, archived.ID}, &sprint.ID); err != nil {
+			t.Fatalf("MoveTodos returned error: %v", err)
+		}
+		if _, _, err := repo.SetArchived([]int64{archived.ID}, true); err != nil {
+			t.Fatalf("SetArchived returned error: %v", err)
+		}
+
+		count, err := repo.CountByCompletion()
+		if err != nil {
+			t.Fatalf("CountByCompletion returned error: %v", err)
+		}
+		if count.Total != 2 || count.Completed != 1 || count.Pending != 1 {
+			t.Errorf("CountByCompletion = %+v, want 1 completed and 1 pending", count)
+		}
+
+		byPriority, err := repo.CountByPriority()
+		if err != nil {
+			t.Fatalf("CountByPriority returned error: %v", err)
+		}
+		if byPriority[open.Priority] != 1 {
+			t.Errorf("CountByPriority = %v, want 1 at priority %d", byPriority, open.Priority)
+		}
+
+		completed, blocked, err := repo.CompleteAllInCategory(sprint.ID)
+		if err != nil {
+			t.Fatalf("CompleteAllInCategory returned error: %v", err)
+		}
+		if !slices.Equal(completed, []int64{open.ID}) || len(blocked) != 0 {
+			t.Errorf("CompleteAllInCategory = %v, %v, want [%d], []", completed, blocked, open.ID)
+		}
+		if got, _ := repo.GetByID(archived.ID); got.Completed {
+			t.Error("archived todo was completed")
+		}
+
+		// An archived pinned todo does not count toward MaxPinnedTodos
+		var pinned []int64
+		for range repository.MaxPinnedTodos {
+			pinned = append(pinned, testhelpers.InsertTodo(t, repo, testhelpers.WithPinned(true)).ID)
+		}
+		if _, _, err := repo.SetArchived(pinned[:1], true); err != nil {
+			t.Fatalf("SetArchived returned error: %v", err)
+		}
+		todo := testhelpers.InsertTodo(t, repo)
+		if _, err := repo.SetPinned(todo.ID, true); err != nil {
+			t.Errorf("SetPinned with an archived todo pinned returned error: %v", err)
+		}
+	})
+}
+
+func TestShiftDueDatesSkipsUndatedTodos(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		due := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+		dated := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(due))
+		undated := testhelpers.InsertTodo(t, repo)
+
+		shifted, skipped, err := repo.ShiftDueDates([]int64{dated.ID, undated.ID, 999}, -3)
+		if err != nil {
+			t.Fatalf("ShiftDueDates returned error: %v", err)
+		}
+		if len(shifted) != 1 || shifted[0] != dated.ID || len(skipped) != 1 || skipped[0] != undated.ID {
+			t.Errorf("ShiftDueDates = %v, %v, want [%d], [%d]", shifted, skipped, dated.ID, undated.ID)
+		}
+
+		got, err := repo.GetByID(dated.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if want := due.AddDate(0, 0, -3); got.DueAt == nil || !got.DueAt.Equal(want) {
+			t.Errorf("due_at = %v, want %v", got.DueAt, want)
+		}
+	})
+}
+
+func TestGetHighlightsMarksMatches(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk"), testhelpers.WithDescription("From the corner shop"))
+
+		highlights, err := repo.GetHighlights([]int64{todo.ID}, "milk")
+		if err != nil {
+			t.Fatalf("GetHighlights returned error: %v", err)
+		}
+
+		want := "Buy " + repository.HighlightStart + "milk" + repository.HighlightStop
+		if got := highlights[todo.ID]["title"]; got != want {
+			t.Errorf("title = %q, want %q", got, want)
+		}
+		if got := highlights[todo.ID]["description"]; got != "" {
+			t.Errorf("description = %q, want empty", got)
+		}
+	})
+}
+
+func TestGetHighlightsMarksMatchesSplitAcrossFields(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Plan kitchen"), testhelpers.WithDescription("Get renovation quotes"))
+
+		highlights, err := repo.GetHighlights([]int64{todo.ID}, "kitchen renovation")
+		if err != nil {
+			t.Fatalf("GetHighlights returned error: %v", err)
+		}
+
+		want := "Plan " + repository.HighlightStart + "kitchen" + repository.HighlightStop
+		if got := highlights[todo.ID]["title"]; got != want {
+			t.Errorf("title = %q, want %q", got, want)
+		}
+		want = "Get " + repository.HighlightStart + "renovation" + repository.HighlightStop + " quotes"
+		if got := highlights[todo.ID]["description"]; got != want {
+			t.Errorf("description = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestGetRelatedRanksByTitleWords(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		source := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk and eggs"))
+		both := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Eggs"), testhelpers.WithDescription("and milk"))
+		one := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Oat milk"))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Milk the cows"), testhelpers.WithCompleted(true))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Walk the dog"))
+
+		related, err := repo.GetRelated(source.ID, 5)
+		if err != nil {
+			t.Fatalf("GetRelated returned error: %v", err)
+		}
+
+		if len(related) != 2 || related[0].ID != both.ID || related[1].ID != one.ID {
+			t.Errorf("got %d related todos, want %d then %d", len(related), both.ID, one.ID)
+		}
+	})
+}
+
+func TestSavedSearchRoundTrip(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		params := models.SearchParams{"due": {"overdue"}, "tag": {"home", "work"}}
+		if _, err := repo.CreateSavedSearch("weekly-review", params); err != nil {
+			t.Fatalf("CreateSavedSearch returned error: %v", err)
+		}
+
+		if _, err := repo.CreateSavedSearch("weekly-review", params); !errors.Is(err, repository.ErrSavedSearchExists) {
+			t.Errorf("second CreateSavedSearch error = %v, want ErrSavedSearchExists", err)
+		}
+
+		search, err := repo.GetSavedSearch("weekly-review")
+		if err != nil {
+			t.Fatalf("GetSavedSearch returned error: %v", err)
+		}
+		if search == nil || len(search.Params["tag"]) != 2 || search.Params["due"][0] != "overdue" {
+			t.Fatalf("GetSavedSearch = %+v, want the stored params", search)
+		}
+
+		if _, err := repo.UpdateSavedSearch("missing", params); !errors.Is(err, repository.ErrSavedSearchNotFound) {
+			t.Errorf("UpdateSavedSearch(missing) error = %v, want ErrSavedSearchNotFound", err)
+		}
+
+		if err := repo.DeleteSavedSearch("weekly-review"); err != nil {
+			t.Fatalf("DeleteSavedSearch returned error: %v", err)
+		}
+		if _, err := repo.GetSavedSearch("weekly-review"); !errors.Is(err, repository.ErrSavedSearchNotFound) {
+			t.Errorf("GetSavedSearch after DeleteSavedSearch error = %v, want ErrSavedSearchNotFound", err)
+		}
+		if err := repo.DeleteSavedSearch("weekly-review"); !errors.Is(err, repository.ErrSavedSearchNotFound) {
+			t.Errorf("second DeleteSavedSearch error = %v, want ErrSavedSearchNotFound", err)
+		}
+	})
+}
+
+func TestGetTimelineRecordsChanges(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityLow))
+
+		todo.Priority = models.PriorityHigh
+		if _, err := repo.Update(todo); err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+		if _, err := repo.SetCompleted(todo.ID, true); err != nil {
+			t.Fatalf("SetCompleted returned error: %v", err)
+		}
+		if err := repo.MarkOverdueNotified([]int64{todo.ID}); err != nil {
+			t.Fatalf("MarkOverdueNotified returned error: %v", err)
+		}
+
+		events, err := repo.GetTimeline(todo.ID)
+		if err != nil {
+			t.Fatalf("GetTimeline returned error: %v", err)
+		}
+
+		want := []string{models.EventCreated, models.EventPriorityChanged, models.EventCompleted}
+		if len(events) != len(want) {
+			t.Fatalf("got %d events, want %v", len(events), want)
+		}
+		for i, event := range events {
+			if event.Event != want[i] {
+				t.Errorf("events[%d] = %s, want %s", i, event.Event, want[i])
+			}
+		}
+		if string(events[1].From) != "1" || string(events[1].To) != "3" {
+			t.Errorf("priority change = %s -> %s, want 1 -> 3", events[1].From, events[1].To)
+		}
+	})
+}
+
+func TestImportGroupsFilesTodosInOneTransaction(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		work, err := repo.CreateCategory("Work", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+
+		created, imported, err := repo.ImportGroups([]models.ImportGroup{
+			{Category: "Work", Todos: []*models.CreateTodoRequest{{Title: "Report", Priority: models.PriorityMedium}}},
+			{Category: "Home", Todos: []*models.CreateTodoRequest{{Title: "Laundry", Priority: models.PriorityMedium}}},
+			{Todos: []*models.CreateTodoRequest{{Title: "Loose", Priority: models.PriorityMedium}}},
+		})
+		if err != nil {
+			t.Fatalf("ImportGroups returned error: %v", err)
+		}
+		if imported != 3 || !slices.Equal(created, []string{"Home"}) {
+			t.Errorf("ImportGroups = %v, %d, want [Home], 3", created, imported)
+		}
+
+		todos, _, err := repo.GetAll(repository.TodoFilter{})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+		for _, todo := range todos {
+			if todo.Title == "Report" && (todo.CategoryID == nil || *todo.CategoryID != work.ID) {
+				t.Errorf("Report category_id = %v, want the existing Work category %d", todo.CategoryID, work.ID)
+			}
+			if todo.Title == "Loose" && todo.CategoryID != nil {
+				t.Errorf("Loose category_id = %d, want null", *todo.CategoryID)
+			}
+		}
+	})
+
+	for name, repo := range limitedRepositories(1) {
+		t.Run(name+"/over limit", func(t *testing.T) {
+			resetTodos(t)
+
+			_, _, err := repo.ImportGroups([]models.ImportGroup{
+				{Category: "Garden", Todos: []*models.CreateTodoRequest{{Title: "Weed", Priority: models.PriorityMedium}, {Title: "Water", Priority: models.PriorityMedium}}},
+			})
+			if !errors.Is(err, repository.ErrTodoLimitExceeded) {
+				t.Fatalf("ImportGroups over the limit returned %v, want ErrTodoLimitExceeded", err)
+			}
+
+			categories, err := repo.GetAllCategories()
+			if err != nil {
+				t.Fatalf("GetAllCategories returned error: %v", err)
+			}
+			if len(categories) != 0 {
+				t.Errorf("categories = %v, want the failed import to create none", categories)
+			}
+		})
+	}
+}
+
+func TestGetActivityPagesNewestFirst(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		kept := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Kept"))
+		deleted := testhelpers.InsertTodo(t, repo)
+		if _, err := repo.Delete(deleted.ID); err != nil {
+			t.Fatalf("Delete returned error: %v", err)
+		}
+
+		entries, err := repo.GetActivity(0, 2)
+		if err != nil {
+			t.Fatalf("GetActivity returned error: %v", err)
+		}
+		if len(entries) != 2 || entries[0].Event != models.EventDeleted || entries[1].Event != models.EventCreated {
+			t.Fatalf("GetActivity = %+v, want the deletion and then the creation", entries)
+		}
+		if entries[0].TodoID != deleted.ID || entries[0].TodoTitle != nil {
+			t.Errorf("first entry = %+v, want todo %d with no title", entries[0], deleted.ID)
+		}
+
+		older, err := repo.GetActivity(entries[1].ID, 2)
+		if err != nil {
+			t.Fatalf("GetActivity returned error: %v", err)
+		}
+		if len(older) != 1 || older[0].TodoID != kept.ID || older[0].TodoTitle == nil || *older[0].TodoTitle != "Kept" {
+			t.Errorf("older entries = %+v, want the creation of todo %d titled Kept", older, kept.ID)
+		}
+	})
+}
+
+func TestGetDeletedAt(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo)
+
+		deletedAt, err := repo.GetDeletedAt(todo.ID)
+		if err != nil || deletedAt != nil {
+			t.Fatalf("GetDeletedAt before delete = %v, %v, want nil", deletedAt, err)
+		}
+
+		if _, err := repo.Delete(todo.ID); err != nil {
+			t.Fatalf("Delete returned error: %v", err)
+		}
+
+		deletedAt, err = repo.GetDeletedAt(todo.ID)
+		if err != nil || deletedAt == nil {
+			t.Fatalf("GetDeletedAt after delete = %v, %v, want a time", deletedAt, err)
+		}
+	})
+}
+
+func TestLastModifiedCoversDeletes(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo)
+
+		modified, err := repo.LastModified()
+		if err != nil || !modified.Equal(todo.UpdatedAt) {
+			t.Fatalf("LastModified = %v, %v, want %v", modified, err, todo.UpdatedAt)
+		}
+
+		if _, err := repo.Delete(todo.ID); err != nil {
+			t.Fatalf("Delete returned error: %v", err)
+		}
+		deletedAt, err := repo.GetDeletedAt(todo.ID)
+		if err != nil || deletedAt == nil {
+			t.Fatalf("GetDeletedAt after delete = %v, %v, want a time", deletedAt, err)
+		}
+
+		modified, err = repo.LastModified()
+		if err != nil || !modified.Equal(*deletedAt) {
+			t.Errorf("LastModified after delete = %v, %v, want %v", modified, err, *deletedAt)
+		}
+	})
+}
+
+func TestGetCompletedAndDueBetween(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		now := time.Now().UTC().Truncate(time.Second)
+		done := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+		soon := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(now.Add(time.Hour)))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(now.Add(48*time.Hour)))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(now.Add(time.Hour)), testhelpers.WithCompleted(true))
+
+		completed, err := repo.GetCompletedBetween(now.Add(-time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("GetCompletedBetween returned error: %v", err)
+		}
+		if len(completed) != 2 || completed[0].ID != done.ID {
+			t.Errorf("completed = %+v, want both completed todos, %d first", completed, done.ID)
+		}
+
+		due, err := repo.GetDueBetween(now, now.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetDueBetween returned error: %v", err)
+		}
+		if len(due) != 1 || due[0].ID != soon.ID {
+			t.Errorf("due = %+v, want just %d", due, soon.ID)
+		}
+
+		// Listing the due todos does not claim them for a reminder
+		claimed, err := repo.GetDueWithinWindow(now, now.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetDueWithinWindow returned error: %v", err)
+		}
+		if len(claimed) != 1 {
+			t.Errorf("claimed %d todos after GetDueBetween, want 1", len(claimed))
+		}
+	})
+}
+
+func TestGetDueWithinWindowClaimsEachTodoOnce(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		now := time.Now().UTC().Truncate(time.Second)
+		var due []int64
+		for i := 1; i <= 5; i++ {
+			todo := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(now.Add(time.Duration(i)*time.Minute)))
+			due = append(due, todo.ID)
+		}
+		testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(now.Add(time.Hour)))
+		testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(now.Add(time.Minute)), testhelpers.WithCompleted(true))
+
+		// Workers polling at the same time split the todos between them
+		var mu sync.Mutex
+		claimed := make(map[int64]int)
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				todos, err := repo.GetDueWithinWindow(now, now.Add(5*time.Minute))
+				if err != nil {
+					t.Errorf("GetDueWithinWindow returned error: %v", err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, todo := range todos {
+					claimed[todo.ID]++
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(claimed) != len(due) {
+			t.Errorf("claimed %v, want each of %v", claimed, due)
+		}
+		for _, id := range due {
+			if claimed[id] != 1 {
+				t.Errorf("todo %d claimed %d times, want 1", id, claimed[id])
+			}
+		}
+
+		again, err := repo.GetDueWithinWindow(now, now.Add(5*time.Minute))
+		if err != nil || len(again) != 0 {
+			t.Errorf("GetDueWithinWindow after claiming = %d todos, %v, want none", len(again), err)
+		}
+
+		// Moving the due date re-arms the reminder
+		todo, err := repo.GetByID(due[0])
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		moved := now.Add(2 * time.Minute)
+		todo.DueAt = &moved
+		if _, err := repo.Update(todo); err != nil {
+			t.Fatalf("Update returned error: %v", err)
+		}
+		again, err = repo.GetDueWithinWindow(now, now.Add(5*time.Minute))
+		if err != nil || len(again) != 1 || again[0].ID != todo.ID {
+			t.Errorf("GetDueWithinWindow after moving = %v, %v, want todo %d", again, err, todo.ID)
+		}
+
+		// Releasing a claim makes the todo due for a reminder again
+		if err := repo.ReleaseReminders([]int64{due[1]}); err != nil {
+			t.Fatalf("ReleaseReminders returned error: %v", err)
+		}
+		again, err = repo.GetDueWithinWindow(now, now.Add(5*time.Minute))
+		if err != nil || len(again) != 1 || again[0].ID != due[1] {
+			t.Errorf("GetDueWithinWindow after releasing = %v, %v, want todo %d", again, err, due[1])
+		}
+	})
+}
+
+func TestUpdateWithLocksTheTodo(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		svc := service.NewTodoService(repo)
+
+		// race runs other while UpdateWith holds the row lock and reports
+		// whether other finished while the lock was still held
+		race := func(id int64, title string, other func() error) (*models.Todo, bool) {
+			locked := make(chan struct{})
+			otherDone := make(chan error, 1)
+			go func() {
+				<-locked
+				otherDone <- other()
+			}()
+
+			var early bool
+			updated, err := repo.UpdateWith(id, func(todo *models.Todo) *models.Todo {
+				close(locked)
+				time.Sleep(100 * time.Millisecond)
+				early = len(otherDone) > 0
+				todo.Title = title
+				return todo
+			})
+			if err != nil {
+				t.Fatalf("UpdateWith returned error: %v", err)
+			}
+
+			if err := <-otherDone; err != nil {
+				t.Fatalf("concurrent call returned error: %v", err)
+			}
+			return updated, early
+		}
+
+		// A delete waits for the update instead of removing the row under it
+		todo := testhelpers.InsertTodo(t, repo)
+		updated, early := race(todo.ID, "Renamed", func() error {
+			_, err := repo.Delete(todo.ID)
+			return err
+		})
+		if early {
+			t.Error("Delete finished while UpdateWith held the lock")
+		}
+		if updated == nil || updated.Title != "Renamed" {
+			t.Errorf("UpdateWith returned %+v, want the renamed todo", updated)
+		}
+		if gone, _ := repo.GetByID(todo.ID); gone != nil {
+			t.Errorf("todo %d still exists after the delete", todo.ID)
+		}
+
+		// A concurrent update of another field reads the renamed todo
+		// rather than overwriting the rename
+		todo = testhelpers.InsertTodo(t, repo, testhelpers.WithPriority(models.PriorityLow))
+		high := models.PriorityHigh
+		_, early = race(todo.ID, "Renamed", func() error {
+			_, err := svc.Update(todo.ID, &models.UpdateTodoRequest{Priority: &high})
+			return err
+		})
+		if early {
+			t.Error("Update finished while UpdateWith held the lock")
+		}
+		got, err := repo.GetByID(todo.ID)
+		if err != nil {
+			t.Fatalf("GetByID returned error: %v", err)
+		}
+		if got.Title != "Renamed" || got.Priority != models.PriorityHigh {
+			t.Errorf("todo = %+v, want both the rename and the new priority", got)
+		}
+	})
+}
+
+func TestWithIsolationPreventsLostUpdate(t *testing.T) {
+	resetTodos(t)
+	repo := repository.NewTodoRepository(testDB, 0, nil)
+	ctx := context.Background()
+
+	// readModifyWrite raises the priority of a todo read before a
+	// concurrent title change was committed
+	readModifyWrite := func(r *repository.TodoRepository, id int64) error {
+		todo, err := r.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		concurrent, err := repo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		concurrent.Title = "Renamed"
+		if _, err := repo.Update(concurrent); err != nil {
+			return err
+		}
+
+		todo.Priority = models.PriorityHigh
+		_, err = r.Update(todo)
+		return err
+	}
+
+	// At the default READ COMMITTED level the rename is silently lost
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Original"))
+	if err := repo.WithTx(ctx, func(tx *repository.TodoRepository) error {
+		return readModifyWrite(tx, todo.ID)
+	}); err != nil {
+		t.Fatalf("WithTx returned error: %v", err)
+	}
+	if got, _ := repo.GetByID(todo.ID); got.Title != "Original" {
+		t.Fatalf("title under READ COMMITTED = %q, want the lost update to restore %q", got.Title, "Original")
+	}
+
+	// REPEATABLE READ refuses to write over it
+	todo = testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Original"))
+	err := repo.WithIsolation(ctx, sql.LevelRepeatableRead, func(tx *repository.TodoRepository) error {
+		return readModifyWrite(tx, todo.ID)
+	})
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "40001" {
+		t.Fatalf("WithIsolation returned %v, want a serialization failure", err)
+	}
+
+	got, err := repo.GetByID(todo.ID)
+	if err != nil {
+		t.Fatalf("GetByID returned error: %v", err)
+	}
+	if got.Title != "Renamed" || got.Priority == models.PriorityHigh {
+		t.Errorf("todo = %+v, want the rename kept and the stale write rolled back", got)
+	}
+}
+
+func TestWithTxNestsMethodTransactions(t *testing.T) {
+	resetTodos(t)
+	repo := repository.NewTodoRepository(testDB, 1, nil)
+	ctx := context.Background()
+
+	// Create at a limit counts and inserts in its own transaction, which
+	// becomes a savepoint inside WithTx
+	errRollback := errors.New("roll back")
+	err := repo.WithTx(ctx, func(tx *repository.TodoRepository) error {
+		if _, err := tx.Create(&models.CreateTodoRequest{Title: "First", Priority: models.PriorityMedium}); err != nil {
+			return err
+		}
+		if _, err := tx.Create(&models.CreateTodoRequest{Title: "Second", Priority: models.PriorityMedium}); !errors.Is(err, repository.ErrTodoLimitExceeded) {
+			return fmt.Errorf("second Create returned %v, want ErrTodoLimitExceeded", err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithTx returned %v", err)
+	}
+
+	todos, total, err := repo.GetAll(repository.TodoFilter{})
+	if err != nil || total != 0 {
+		t.Errorf("GetAll after rollback = %v, %d, %v, want no todos", todos, total, err)
+	}
+
+	if err := repo.WithTx(ctx, func(tx *repository.TodoRepository) error {
+		return tx.WithIsolation(ctx, sql.LevelSerializable, func(*repository.TodoRepository) error { return nil })
+	}); !errors.Is(err, repository.ErrNestedIsolation) {
+		t.Errorf("nested WithIsolation returned %v, want ErrNestedIsolation", err)
+	}
+}
+
+func TestWithTxReturnsErrNotFound(t *testing.T) {
+	resetTodos(t)
+	repo := repository.NewTodoRepository(testDB, 0, nil)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	// A missing todo inside the transaction rolls back the earlier write
+	// and reaches the caller as ErrNotFound
+	err := repo.WithTx(context.Background(), func(tx *repository.TodoRepository) error {
+		if _, err := tx.Delete(todo.ID); err != nil {
+			return err
+		}
+		_, err := tx.UpdateWith(999999, func(todo *models.Todo) *models.Todo { return todo })
+		return err
+	})
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("WithTx returned %v, want ErrNotFound", err)
+	}
+
+	if _, err := repo.GetByID(todo.ID); err != nil {
+		t.Errorf("GetByID after rollback returned %v, want the todo", err)
+	}
+}
+
+func TestGetAllByCategoryOrdersForGrouping(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		work, err := repo.CreateCategory("Work", nil)
+		if err != nil {
+			t.Fatalf("CreateCategory returned error: %v", err)
+		}
+
+		loose := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Loose"))
+		first := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("First"))
+		second := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Second"))
+		if _, err := repo.MoveTodos([]int64{first.ID, second.ID}, &work.ID); err != nil {
+			t.Fatalf("MoveTodos returned error: %v", err)
+		}
+		if _, err := repo.Reorder([]models.TodoPosition{{ID: second.ID, Position: 1}, {ID: first.ID, Position: 2}}); err != nil {
+			t.Fatalf("Reorder returned error: %v", err)
+		}
+
+		todos, err := repo.GetAllByCategory()
+		if err != nil {
+			t.Fatalf("GetAllByCategory returned error: %v", err)
+		}
+
+		var got []int64
+		for _, todo := range todos {
+			got = append(got, todo.ID)
+		}
+		if want := []int64{second.ID, first.ID, loose.ID}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("order = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestGetCategoryAncestorsRootFirst(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		var parentID *int64
+		var ids []int64
+		for _, name := range []string{"Project X", "Sprint 1", "Week 2"} {
+			category, err := repo.CreateCategory(name, parentID)
+			if err != nil {
+				t.Fatalf("CreateCategory(%q) returned error: %v", name, err)
+			}
+			ids = append(ids, category.ID)
+			parentID = &category.ID
+		}
+
+		ancestors, err := repo.GetCategoryAncestors(ids[2])
+		if err != nil {
+			t.Fatalf("GetCategoryAncestors returned error: %v", err)
+		}
+
+		var got []int64
+		for _, category := range ancestors {
+			got = append(got, category.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(ids) {
+			t.Errorf("ancestors = %v, want %v", got, ids)
+		}
+	})
+}
+
+func TestGetAllSearchesMaterializedView(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, repo repository.TodoRepositoryInterface) {
+		todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Renew passport"))
+
+		q := "passport"
+		todos, _, err := repo.GetAll(repository.TodoFilter{SearchQuery: &q})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+		if len(todos) != 0 {
+			t.Fatalf("GetAll before refresh returned %d todos, want 0", len(todos))
+		}
+
+		if _, err := testDB.Exec(`REFRESH MATERIALIZED VIEW CONCURRENTLY todo_search_mv`); err != nil {
+			t.Fatalf("Failed to refresh todo_search_mv: %v", err)
+		}
+
+		todos, _, err = repo.GetAll(repository.TodoFilter{SearchQuery: &q})
+		if err != nil {
+			t.Fatalf("GetAll returned error: %v", err)
+		}
+		if len(todos) != 1 || todos[0].ID != todo.ID {
+			t.Fatalf("GetAll after refresh returned %d todos, want only %d", len(todos), todo.ID)
+		}
+	})
+}
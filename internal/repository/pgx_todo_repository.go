@@ -0,0 +1,1851 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// PgxTodoRepository handles database operations for todos using a pgx
+// connection pool
+type PgxTodoRepository struct {
+	pool     *pgxpool.Pool
+	maxTodos int
+}
+
+// NewPgxTodoRepository creates a new PgxTodoRepository. Nothing adds todos
+// beyond maxTodos; zero or less means no limit.
+func NewPgxTodoRepository(pool *pgxpool.Pool, maxTodos int) *PgxTodoRepository {
+	return &PgxTodoRepository{
+		pool:     pool,
+		maxTodos: maxTodos,
+	}
+}
+
+// Create adds a new todo to the database
+func (r *PgxTodoRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+	ctx := context.Background()
+	query := `
+		INSERT INTO todos (title, description, priority, external_id, due_at, completed, completed_at, category_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $6::boolean THEN COALESCE($7::timestamp, NOW()) END, $8, NOW(), NOW())
+		RETURNING ` + todoColumns
+
+	if r.maxTodos <= 0 {
+		return scanTodo(r.pool.QueryRow(ctx, query, todo.Title, todo.Description, todo.Priority, todo.ExternalID, todo.DueAt, todo.Completed, todo.CompletedAt, todo.CategoryID))
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.reserveTodos(ctx, tx, 1, nil); err != nil {
+		return nil, err
+	}
+
+	newTodo, err := scanTodo(tx.QueryRow(ctx, query, todo.Title, todo.Description, todo.Priority, todo.ExternalID, todo.DueAt, todo.Completed, todo.CompletedAt, todo.CategoryID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return newTodo, nil
+}
+
+// reserveTodos fails with ErrTodoLimitExceeded unless n more todos fit
+// under the limit, not counting the todo with externalID if it is not nil.
+// It holds todoLimitLockQuery until tx ends, so tx must insert the todos
+// before committing. It does nothing without a limit.
+func (r *PgxTodoRepository) reserveTodos(ctx context.Context, tx pgx.Tx, n int, externalID *string) error {
+	if r.maxTodos <= 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, todoLimitLockQuery); err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, countTodosQuery, externalID).Scan(&count); err != nil {
+		return err
+	}
+	if count+n > r.maxTodos {
+		return ErrTodoLimitExceeded
+	}
+	return nil
+}
+
+// Upsert inserts a todo, or updates the todo with the same external ID if
+// one exists. The completion state and category are only taken from todo on
+// insert. The returned bool is true when a new row was inserted.
+func (r *PgxTodoRepository) Upsert(todo *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	query := `
+		INSERT INTO todos (title, description, priority, external_id, due_at, completed, completed_at, category_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $6::boolean THEN COALESCE($7::timestamp, NOW()) END, $8, NOW(), NOW())
+		ON CONFLICT (external_id) DO UPDATE
+		SET title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			priority = EXCLUDED.priority,
+			due_at = EXCLUDED.due_at,
+			overdue_notified_at = CASE
+				WHEN todos.due_at IS DISTINCT FROM EXCLUDED.due_at THEN NULL
+				ELSE todos.overdue_notified_at
+			END,
+			reminded_at = CASE
+				WHEN todos.due_at IS DISTINCT FROM EXCLUDED.due_at THEN NULL
+				ELSE todos.reminded_at
+			END
+		RETURNING ` + todoColumns + `, (xmax = 0) AS inserted
+	`
+
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.reserveTodos(ctx, tx, 1, todo.ExternalID); err != nil {
+		return nil, false, err
+	}
+
+	var inserted bool
+	newTodo, err := scanTodo(
+		tx.QueryRow(ctx, query, todo.Title, todo.Description, todo.Priority, todo.ExternalID, todo.DueAt, todo.Completed, todo.CompletedAt, todo.CategoryID),
+		&inserted,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+
+	return newTodo, inserted, nil
+}
+
+// BulkCreate inserts many todos at once and returns the number inserted.
+// Batches larger than copyThreshold are loaded with COPY FROM STDIN.
+func (r *PgxTodoRepository) BulkCreate(todos []*models.CreateTodoRequest) (int64, error) {
+	if len(todos) == 0 {
+		return 0, nil
+	}
+
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	inserted, err := r.insertTodos(ctx, tx, todos)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
+// insertTodos reserves room for todos under the limit and inserts them on
+// tx, with COPY for batches larger than copyThreshold. It returns the
+// number inserted.
+func (r *PgxTodoRepository) insertTodos(ctx context.Context, tx pgx.Tx, todos []*models.CreateTodoRequest) (int64, error) {
+	if len(todos) == 0 {
+		return 0, nil
+	}
+
+	if err := r.reserveTodos(ctx, tx, len(todos), nil); err != nil {
+		return 0, err
+	}
+
+	if len(todos) <= copyThreshold {
+		query, args := multiRowInsert(todos)
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return tag.RowsAffected(), nil
+	}
+
+	var now time.Time
+	if err := tx.QueryRow(ctx, copyNowQuery).Scan(&now); err != nil {
+		return 0, err
+	}
+
+	return tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"todos"},
+		copyColumns,
+		pgx.CopyFromSlice(len(todos), func(i int) ([]any, error) {
+			return copyRow(todos[i], now), nil
+		}),
+	)
+}
+
+// ImportGroups files the todos of each group under the top-level category
+// with the group's name, creating the categories that do not exist yet,
+// and inserts all the todos. Everything happens in one transaction, so a
+// failure leaves neither new categories nor todos behind. It returns the
+// names of the categories it created and the number of todos inserted.
+func (r *PgxTodoRepository) ImportGroups(groups []models.ImportGroup) ([]string, int64, error) {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var created []string
+	var todos []*models.CreateTodoRequest
+	categoryIDs := make(map[string]int64)
+	for _, group := range groups {
+		if group.Category != "" && len(group.Todos) > 0 {
+			id, ok := categoryIDs[group.Category]
+			if !ok {
+				var isNew bool
+				if err := tx.QueryRow(ctx, importCategoryQuery, group.Category).Scan(&id, &isNew); err != nil {
+					return nil, 0, err
+				}
+				categoryIDs[group.Category] = id
+				if isNew {
+					created = append(created, group.Category)
+				}
+			}
+			fileUnder(group.Todos, id)
+		}
+		todos = append(todos, group.Todos...)
+	}
+
+	imported, err := r.insertTodos(ctx, tx, todos)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return created, imported, nil
+}
+
+// GetAll retrieves the page of todos matching filter, along with the total
+// number of matching todos across all pages. Pinned todos come first, then
+// the rest in filter.Sort order or, by default, todos never reordered
+// newest first followed by the others by position.
+func (r *PgxTodoRepository) GetAll(filter TodoFilter) ([]*models.Todo, int64, error) {
+	where, args := filter.where()
+	order, orderArgs := filter.orderBy(len(args))
+	page, pageArgs := filter.page(len(args) + len(orderArgs))
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		` + where + `
+		` + order + `
+		` + page
+
+	rows, err := r.pool.Query(context.Background(), query, append(append(args, orderArgs...), pageArgs...)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// An empty page encodes as [] rather than null
+	todos := []*models.Todo{}
+
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// Without paging the page is the whole result
+	if filter.Limit <= 0 && filter.Offset <= 0 {
+		return todos, int64(len(todos)), nil
+	}
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM todos ` + where
+	if err := r.pool.QueryRow(context.Background(), countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return todos, total, nil
+}
+
+// GetHighlights shows where query matched the title and description of each
+// todo in ids, keyed by todo ID. Fields the query does not match are empty.
+func (r *PgxTodoRepository) GetHighlights(ids []int64, query string) (map[int64]map[string]string, error) {
+	rows, err := r.pool.Query(context.Background(), `SELECT `+highlightColumns+` FROM todos WHERE id = ANY($2)`, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	highlights := make(map[int64]map[string]string, len(ids))
+	for rows.Next() {
+		var id int64
+		var title, description string
+		if err := rows.Scan(&id, &title, &description); err != nil {
+			return nil, err
+		}
+		highlights[id] = map[string]string{"title": highlighted(title), "description": highlighted(description)}
+	}
+
+	return highlights, rows.Err()
+}
+
+// GetRelated finds up to limit incomplete todos similar to todo id, ranked
+// by how well their text matches its title
+func (r *PgxTodoRepository) GetRelated(id int64, limit int) ([]*models.Todo, error) {
+	rows, err := r.pool.Query(context.Background(), relatedQuery, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetAllByCategory retrieves every unarchived todo ordered for grouping by
+// category: by category, uncategorized last, then by position and creation
+// time
+func (r *PgxTodoRepository) GetAllByCategory() ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE archived_at IS NULL
+		ORDER BY category_id NULLS LAST, position, created_at, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetTimeline returns the recorded changes to a todo, oldest first
+func (r *PgxTodoRepository) GetTimeline(todoID int64) ([]*models.TimelineEvent, error) {
+	query := `
+		SELECT ` + timelineColumns + `
+		FROM activity_logs
+		WHERE todo_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*models.TimelineEvent{}
+	for rows.Next() {
+		event, err := scanTimelineEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetActivity returns up to limit activity log entries across all todos,
+// newest first. A before of 0 starts from the newest entry; otherwise only
+// entries with a lower ID are returned.
+func (r *PgxTodoRepository) GetActivity(before int64, limit int) ([]*models.ActivityEntry, error) {
+	rows, err := r.pool.Query(context.Background(), getActivityQuery, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.ActivityEntry{}
+	for rows.Next() {
+		entry, err := scanActivityEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetDeletedAt returns when the todo with the given ID was deleted, from its
+// activity log, or nil if no such todo was ever deleted. The vacuum deletes
+// the todos it moves into todos_archive, so those are not counted as deleted.
+func (r *PgxTodoRepository) GetDeletedAt(id int64) (*time.Time, error) {
+	query := `
+		SELECT created_at
+		FROM activity_logs
+		WHERE todo_id = $1 AND event = 'deleted'
+			AND NOT EXISTS (SELECT 1 FROM todos_archive WHERE id = $1)
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var deletedAt time.Time
+	if err := r.pool.QueryRow(context.Background(), query, id).Scan(&deletedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil // Todo never deleted
+		}
+		return nil, err
+	}
+
+	return &deletedAt, nil
+}
+
+// LastModified returns when any todo was last created, changed or deleted:
+// the later of the newest updated_at and the newest deletion in the
+// activity log. It is the zero time if there has been neither.
+func (r *PgxTodoRepository) LastModified() (time.Time, error) {
+	query := `
+		SELECT GREATEST(
+			(SELECT MAX(updated_at) FROM todos),
+			(SELECT MAX(created_at) FROM activity_logs WHERE event = 'deleted')
+		)
+	`
+
+	var modified *time.Time
+	if err := r.pool.QueryRow(context.Background(), query).Scan(&modified); err != nil || modified == nil {
+		return time.Time{}, err
+	}
+
+	return *modified, nil
+}
+
+// StreamAll calls fn for each todo matching filter, in the same order as
+// GetAll, without holding the full result set in memory. Iteration stops at
+// the first error returned by fn, which is returned to the caller.
+func (r *PgxTodoRepository) StreamAll(ctx context.Context, filter TodoFilter, fn func(*models.Todo) error) error {
+	where, args := filter.where()
+	order, orderArgs := filter.orderBy(len(args))
+	args = append(args, orderArgs...)
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		` + where + `
+		` + order + `
+	`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// StreamAllIDs calls fn with the ID of every todo in ascending order as the
+// rows arrive, without loading them all into memory. It stops at the first
+// error from fn and returns it.
+func (r *PgxTodoRepository) StreamAllIDs(ctx context.Context, fn func(int64) error) error {
+	query := `SELECT id FROM todos WHERE archived_at IS NULL ORDER BY id`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetByID retrieves a todo by ID
+func (r *PgxTodoRepository) GetByID(id int64) (*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE id = $1
+	`
+
+	todo, err := scanTodo(r.pool.QueryRow(context.Background(), query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// Update writes the editable fields of a todo to the database. The
+// todos_set_timestamps trigger stamps updated_at and completed_at.
+func (r *PgxTodoRepository) Update(todo *models.Todo) (*models.Todo, error) {
+	updatedTodo, err := scanTodo(r.pool.QueryRow(
+		context.Background(),
+		updateTodoQuery,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		todo.DueAt,
+		todo.ID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return updatedTodo, nil
+}
+
+// UpdateWith reads the todo with the given ID, passes it to apply and
+// saves the todo apply returns, in one transaction. The row is locked
+// between the read and the write, so a concurrent delete or update waits
+// instead of the write silently missing the row or overwriting a change
+// committed in between. It returns ErrNotFound if the todo does not exist,
+// and ErrBlocked if apply completes a todo that depends on an incomplete
+// one.
+func (r *PgxTodoRepository) UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error) {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanTodo(tx.QueryRow(ctx, lockTodoQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	todo := apply(current)
+	if todo.Completed && !current.Completed {
+		var blocked bool
+		if err := tx.QueryRow(ctx, blockedQuery, id).Scan(&blocked); err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrBlocked
+		}
+	}
+
+	updated, err := scanTodo(tx.QueryRow(
+		ctx,
+		updateTodoQuery,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		todo.DueAt,
+		id,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// SetCompleted sets the completion state of a todo with a single targeted UPDATE
+func (r *PgxTodoRepository) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	todo, err := scanTodo(r.pool.QueryRow(context.Background(), setCompletedQuery, completed, id))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+
+		// No row was updated: either the todo does not exist or it is
+		// blocked
+		if _, err := r.GetByID(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrBlocked
+	}
+
+	return todo, nil
+}
+
+// AppendNote appends a timestamped note to a todo's notes log
+func (r *PgxTodoRepository) AppendNote(id int64, body string) (*models.Todo, error) {
+	query := `
+		UPDATE todos
+		SET notes = notes || jsonb_build_array(jsonb_build_object('body', $1::text, 'created_at', NOW()))
+		WHERE id = $2
+		RETURNING ` + todoColumns
+
+	todo, err := scanTodo(r.pool.QueryRow(context.Background(), query, body, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// SetTags replaces the tags of a todo, creating tags that do not exist yet
+func (r *PgxTodoRepository) SetTags(id int64, tags []string) (*models.Todo, error) {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the todo so concurrent calls apply one after the other
+	var exists int
+	err = tx.QueryRow(ctx, `SELECT 1 FROM todos WHERE id = $1 FOR UPDATE`, id).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM todo_tags WHERE todo_id = $1`, id); err != nil {
+		return nil, err
+	}
+
+	if len(tags) > 0 {
+		if _, err := tx.Exec(ctx, `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, tags); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO todo_tags (todo_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`, id, tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// Touch the row so the timestamps trigger records the tag change
+	todo, err := scanTodo(tx.QueryRow(ctx, `UPDATE todos SET updated_at = NOW() WHERE id = $1 RETURNING `+todoColumns, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// ExistingTags returns which of names are tags that exist
+func (r *PgxTodoRepository) ExistingTags(names []string) ([]string, error) {
+	query := `SELECT name FROM tags WHERE name = ANY($1) ORDER BY name`
+
+	rows, err := r.pool.Query(context.Background(), query, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing = append(existing, name)
+	}
+
+	return existing, rows.Err()
+}
+
+// BatchUpdatePriority sets the priority of every unarchived todo in ids with a
+// single UPDATE and returns the IDs that were updated
+func (r *PgxTodoRepository) BatchUpdatePriority(ids []int64, priority int) ([]int64, error) {
+	query := `
+		UPDATE todos
+		SET priority = $1
+		WHERE id = ANY($2) AND archived_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, priority, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// BatchUpdateCategory files every unarchived todo in ids under categoryID with
+// a single UPDATE and returns the IDs that were updated
+func (r *PgxTodoRepository) BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error) {
+	query := `
+		UPDATE todos
+		SET category_id = $1
+		WHERE id = ANY($2) AND archived_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, categoryID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// SetPinned pins or unpins a todo. Pinning fails with ErrPinLimitReached
+// when MaxPinnedTodos other unarchived todos are already pinned.
+func (r *PgxTodoRepository) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, pinLockQuery); err != nil {
+		return nil, err
+	}
+
+	todo, err := scanTodo(tx.QueryRow(ctx, setPinnedQuery, pinned, id, MaxPinnedTodos))
+	if errors.Is(err, pgx.ErrNoRows) {
+		tx.Rollback(ctx)
+
+		// No row was updated: either the todo does not exist or the
+		// pin limit prevented the update
+		if _, err := r.GetByID(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrPinLimitReached
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// AddReaction reacts to a todo with emoji. Reacting again with the same
+// emoji does nothing. It fails with ErrReactionLimitReached when the todo
+// already has MaxReactionsPerTodo other reactions, and with ErrNotFound if
+// the todo does not exist.
+func (r *PgxTodoRepository) AddReaction(todoID int64, emoji string) error {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, reactionLockQuery, todoID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, addReactionQuery, todoID, emoji, MaxReactionsPerTodo)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	// Nothing was inserted: the todo does not exist, already has this
+	// reaction or is at the limit
+	if _, err := r.GetByID(todoID); err != nil {
+		return err
+	}
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, reactionExistsQuery, todoID, emoji).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrReactionLimitReached
+	}
+
+	return nil
+}
+
+// RemoveReaction takes back a reaction to a todo. Removing a reaction that
+// is not there does nothing. It returns ErrNotFound if the todo does not
+// exist.
+func (r *PgxTodoRepository) RemoveReaction(todoID int64, emoji string) error {
+	tag, err := r.pool.Exec(context.Background(), removeReactionQuery, todoID, emoji)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = r.GetByID(todoID)
+	return err
+}
+
+// GetReactions returns the reactions to a todo, one per emoji in the order
+// they were first added. Every reaction is the single user's, so each is
+// reacted_by_me.
+func (r *PgxTodoRepository) GetReactions(todoID int64) ([]models.Reaction, error) {
+	rows, err := r.pool.Query(context.Background(), getReactionsQuery, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := []models.Reaction{}
+	for rows.Next() {
+		reaction := models.Reaction{ReactedByMe: true}
+		if err := rows.Scan(&reaction.Emoji, &reaction.Count); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, rows.Err()
+}
+
+// AddDependency makes a todo depend on blockingID, so it cannot be
+// completed before blockingID is. Adding a dependency again does nothing.
+// It fails with ErrDependencyCycle when blockingID already depends on the
+// todo, and with ErrNotFound if either todo does not exist.
+func (r *PgxTodoRepository) AddDependency(todoID, blockingID int64) error {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, dependencyLockQuery); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, addDependencyQuery, todoID, blockingID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	// Nothing was inserted: a todo does not exist, the dependency is
+	// already there or it would close a cycle
+	for _, id := range []int64{todoID, blockingID} {
+		if _, err := r.GetByID(id); err != nil {
+			return err
+		}
+	}
+
+	var exists bool
+	if err := r.pool.QueryRow(ctx, dependencyExistsQuery, todoID, blockingID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrDependencyCycle
+	}
+
+	return nil
+}
+
+// RemoveDependency stops a todo depending on blockingID. Removing a
+// dependency that is not there does nothing. It returns ErrNotFound if the
+// todo does not exist.
+func (r *PgxTodoRepository) RemoveDependency(todoID, blockingID int64) error {
+	tag, err := r.pool.Exec(context.Background(), removeDependencyQuery, todoID, blockingID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	_, err = r.GetByID(todoID)
+	return err
+}
+
+// GetBlockers returns the todos a todo depends on, in the order the
+// dependencies were added
+func (r *PgxTodoRepository) GetBlockers(todoID int64) ([]models.Blocker, error) {
+	rows, err := r.pool.Query(context.Background(), getBlockersQuery, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blockers := []models.Blocker{}
+	for rows.Next() {
+		var blocker models.Blocker
+		if err := rows.Scan(&blocker.ID, &blocker.Title, &blocker.Completed); err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, blocker)
+	}
+
+	return blockers, rows.Err()
+}
+
+// Snooze pushes a todo's due date back by days, counting from now if it
+// has none, and re-arms its overdue reminder
+func (r *PgxTodoRepository) Snooze(id int64, days int) (*models.Todo, error) {
+	query := `
+		UPDATE todos
+		SET due_at = COALESCE(due_at, NOW() AT TIME ZONE 'UTC') + make_interval(days => $1),
+			overdue_notified_at = NULL,
+			reminded_at = NULL,
+			snooze_count = snooze_count + 1
+		WHERE id = $2
+		RETURNING ` + todoColumns
+
+	todo, err := scanTodo(r.pool.QueryRow(context.Background(), query, days, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// ShiftDueDates moves the due date of every todo in ids by days with a
+// single UPDATE. It returns the IDs it shifted and the IDs of todos without
+// a due date, which are left alone.
+func (r *PgxTodoRepository) ShiftDueDates(ids []int64, days int) ([]int64, []int64, error) {
+	rows, err := r.pool.Query(context.Background(), shiftDueDatesQuery, days, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return splitIDs(rows)
+}
+
+// Delete removes a todo from the database and returns it as it was
+// before the delete
+func (r *PgxTodoRepository) Delete(id int64) (*models.Todo, error) {
+	deleted, err := scanTodo(r.pool.QueryRow(context.Background(), deleteTodoQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// DeleteAllCompleted removes every completed, unarchived todo and returns how
+// many were deleted
+func (r *PgxTodoRepository) DeleteAllCompleted() (int64, error) {
+	query := `DELETE FROM todos WHERE completed = true AND archived_at IS NULL`
+
+	tag, err := r.pool.Exec(context.Background(), query)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// VacuumCompleted moves todos completed more than olderThanDays days ago to
+// todos_archive and returns how many it moved. Each batch of
+// VacuumBatchSize todos is moved in its own transaction, so locks are not
+// held for the whole run; a failure leaves earlier batches archived.
+func (r *PgxTodoRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	var archived int64
+	for {
+		var n int64
+		if err := r.pool.QueryRow(context.Background(), vacuumBatchQuery, olderThanDays, VacuumBatchSize).Scan(&n); err != nil {
+			return archived, err
+		}
+		archived += n
+
+		if n < VacuumBatchSize {
+			return archived, nil
+		}
+	}
+}
+
+// SetArchived stamps archived_at on the todos in ids with a single
+// statement, or clears it when archived is false. It returns the IDs it
+// changed and the IDs that were already archived, or already not archived;
+// IDs of todos that do not exist are in neither list.
+func (r *PgxTodoRepository) SetArchived(ids []int64, archived bool) ([]int64, []int64, error) {
+	rows, err := r.pool.Query(context.Background(), setArchivedQuery(archived), ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return splitIDs(rows)
+}
+
+// CreateCategory adds a new category to the database
+func (r *PgxTodoRepository) CreateCategory(name string, parentID *int64) (*models.Category, error) {
+	query := `
+		INSERT INTO categories (name, parent_id, created_at)
+		VALUES ($1, $2, NOW())
+		RETURNING ` + categoryColumns
+
+	return scanCategory(r.pool.QueryRow(context.Background(), query, name, parentID))
+}
+
+// GetAllCategories retrieves all categories ordered by name
+func (r *PgxTodoRepository) GetAllCategories() ([]*models.Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM categories
+		ORDER BY name, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetCategoryByID retrieves a category by ID, or ErrCategoryNotFound if
+// there is none
+func (r *PgxTodoRepository) GetCategoryByID(id int64) (*models.Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM categories
+		WHERE id = $1
+	`
+
+	category, err := scanCategory(r.pool.QueryRow(context.Background(), query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetCategoryTree retrieves every category ordered so that each parent
+// comes before its children, and siblings are sorted by name
+func (r *PgxTodoRepository) GetCategoryTree() ([]*models.Category, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT ` + categoryColumns + `, 1 AS depth
+			FROM categories
+			WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.id, c.name, c.parent_id, c.created_at, tree.depth + 1
+			FROM categories c
+			JOIN tree ON c.parent_id = tree.id
+		)
+		SELECT ` + categoryColumns + `
+		FROM tree
+		ORDER BY depth, name, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetCategoryDepth returns how deeply a category is nested, 1 for a
+// top-level category, or 0 if it does not exist
+func (r *PgxTodoRepository) GetCategoryDepth(id int64) (int, error) {
+	var depth int
+	err := r.pool.QueryRow(context.Background(), categoryDepthQuery, id).Scan(&depth)
+	return depth, err
+}
+
+// GetCategoryAncestors returns the category with the given ID and its
+// ancestors, root first, following at most MaxBreadcrumbDepth parents
+func (r *PgxTodoRepository) GetCategoryAncestors(id int64) ([]*models.Category, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT ` + categoryColumns + `, 1 AS depth
+			FROM categories
+			WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.parent_id, c.created_at, ancestors.depth + 1
+			FROM categories c
+			JOIN ancestors ON c.id = ancestors.parent_id
+			WHERE ancestors.depth < $2
+		)
+		SELECT ` + categoryColumns + ` FROM ancestors
+		ORDER BY depth DESC
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, id, MaxBreadcrumbDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// MoveTodos sets the category of every unarchived todo in ids with a single
+// UPDATE and returns how many were moved. A nil categoryID leaves them
+// uncategorized.
+func (r *PgxTodoRepository) MoveTodos(ids []int64, categoryID *int64) (int64, error) {
+	query := `
+		UPDATE todos
+		SET category_id = $1
+		WHERE id = ANY($2) AND archived_at IS NULL
+	`
+
+	tag, err := r.pool.Exec(context.Background(), query, categoryID, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// CompleteAllInCategory marks every incomplete, unarchived todo directly in
+// categoryID as completed with a single UPDATE. It returns the IDs it
+// completed and the IDs of todos it left open because they are blocked by an
+// incomplete todo. The todos_set_timestamps trigger stamps completed_at and
+// updated_at.
+func (r *PgxTodoRepository) CompleteAllInCategory(categoryID int64) ([]int64, []int64, error) {
+	rows, err := r.pool.Query(context.Background(), completeAllInCategoryQuery, categoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return splitIDs(rows)
+}
+
+// Reorder sets the manual position of every todo in order with a single
+// UPDATE, in one transaction. It fails with ErrNotFound, changing
+// nothing, if any of the todos does not exist.
+func (r *PgxTodoRepository) Reorder(order []models.TodoPosition) (int64, error) {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]int64, len(order))
+	for i, p := range order {
+		ids[i] = p.ID
+	}
+
+	var found int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (SELECT id FROM todos WHERE id = ANY($1) FOR UPDATE) t
+	`, ids).Scan(&found)
+	if err != nil {
+		return 0, err
+	}
+	if found != len(order) {
+		return 0, ErrNotFound
+	}
+
+	query, args := reorderQuery(order)
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// DuplicateCategory copies a category, named "Copy of <name>" under the same
+// parent, together with the unarchived todos directly in it, in one
+// transaction. The copied todos keep their title, description, priority and
+// tags but start incomplete, unpinned and without a due date. It returns
+// ErrCategoryNotFound if the category does not exist.
+func (r *PgxTodoRepository) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	category, err := scanCategory(tx.QueryRow(ctx, `
+		INSERT INTO categories (name, parent_id, created_at)
+		SELECT LEFT('Copy of ' || name, 255), parent_id, NOW()
+		FROM categories
+		WHERE id = $1
+		RETURNING `+categoryColumns, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `SELECT id FROM todos WHERE category_id = $1 AND archived_at IS NULL ORDER BY id FOR SHARE`, id)
+	if err != nil {
+		return nil, err
+	}
+	var sourceIDs []int64
+	for rows.Next() {
+		var sourceID int64
+		if err := rows.Scan(&sourceID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sourceIDs = append(sourceIDs, sourceID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(sourceIDs) > 0 {
+		if err := r.reserveTodos(ctx, tx, len(sourceIDs), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sourceID := range sourceIDs {
+		var copyID int64
+		err := tx.QueryRow(ctx, `
+			INSERT INTO todos (title, description, priority, category_id, created_at, updated_at)
+			SELECT title, description, priority, $2, NOW(), NOW()
+			FROM todos
+			WHERE id = $1
+			RETURNING id
+		`, sourceID, category.ID).Scan(&copyID)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO todo_tags (todo_id, tag_id) SELECT $1, tag_id FROM todo_tags WHERE todo_id = $2`, copyID, sourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &models.CategoryWithCount{Category: *category, TodoCount: int64(len(sourceIDs))}, nil
+}
+
+// ConvertToCategory archives the todo with the given ID and creates a
+// category named after its title, under the todo's own category,
+// in one transaction. It returns ErrNotFound if the todo does not exist,
+// and ErrCategoryTooDeep if the new category would be nested deeper than
+// models.MaxCategoryDepth.
+func (r *PgxTodoRepository) ConvertToCategory(id int64) (*models.Category, error) {
+	ctx := context.Background()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var title string
+	var parentID *int64
+	err = tx.QueryRow(ctx, `SELECT title, category_id FROM todos WHERE id = $1 FOR UPDATE`, id).Scan(&title, &parentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if parentID != nil {
+		var depth int
+		if err := tx.QueryRow(ctx, categoryDepthQuery, *parentID).Scan(&depth); err != nil {
+			return nil, err
+		}
+		if depth >= models.MaxCategoryDepth {
+			return nil, ErrCategoryTooDeep
+		}
+	}
+
+	if _, err := tx.Exec(ctx, archiveTodosQuery, []int64{id}); err != nil {
+		return nil, err
+	}
+
+	category, err := scanCategory(tx.QueryRow(ctx, `
+		INSERT INTO categories (name, parent_id, created_at)
+		VALUES ($1, $2, NOW())
+		RETURNING `+categoryColumns, title, parentID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetOverdueUnnotified retrieves incomplete todos whose due date has passed
+// and that have not had an overdue reminder sent, oldest due date first
+func (r *PgxTodoRepository) GetOverdueUnnotified() ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE due_at < NOW() AND completed = false AND overdue_notified_at IS NULL AND archived_at IS NULL
+		ORDER BY due_at, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// MarkOverdueNotified records that the overdue reminder for each todo in ids
+// has been sent
+func (r *PgxTodoRepository) MarkOverdueNotified(ids []int64) error {
+	query := `UPDATE todos SET overdue_notified_at = NOW() WHERE id = ANY($1)`
+
+	_, err := r.pool.Exec(context.Background(), query, ids)
+	return err
+}
+
+// GetDueWithinWindow claims the incomplete todos due between from and to
+// whose due-soon reminder has not been sent, and returns them soonest
+// first. Claiming stamps reminded_at in the same statement, and rows locked
+// by another worker are skipped, so concurrent workers never return the
+// same todo. Moving a todo's due date re-arms its reminder.
+func (r *PgxTodoRepository) GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error) {
+	// due_at is stored as UTC without a zone
+	query := `
+		WITH claimed AS (
+			UPDATE todos
+			SET reminded_at = NOW()
+			WHERE id IN (
+				SELECT id
+				FROM todos
+				WHERE due_at BETWEEN $1 AND $2 AND completed = false AND reminded_at IS NULL AND archived_at IS NULL
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + todoColumns + `
+		)
+		SELECT * FROM claimed
+		ORDER BY due_at, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// ReleaseReminders gives up the due-soon reminder claims GetDueWithinWindow
+// made on the todos in ids, so the next call returns them again. The
+// reminder worker calls it when it could not send the reminder.
+func (r *PgxTodoRepository) ReleaseReminders(ids []int64) error {
+	_, err := r.pool.Exec(context.Background(), releaseRemindersQuery, ids)
+	return err
+}
+
+// GetCompletedBetween retrieves the unarchived todos completed from from up to
+// but not including to, in the order they were completed
+func (r *PgxTodoRepository) GetCompletedBetween(from, to time.Time) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE completed = true AND completed_at >= $1 AND completed_at < $2 AND archived_at IS NULL
+		ORDER BY completed_at, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetDueBetween retrieves the incomplete todos due from from up to but not
+// including to, soonest first. Unlike GetDueWithinWindow it does not claim
+// them for a reminder.
+func (r *PgxTodoRepository) GetDueBetween(from, to time.Time) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE completed = false AND due_at >= $1 AND due_at < $2 AND archived_at IS NULL
+		ORDER BY due_at, id
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetTimezone returns the user's IANA timezone name
+func (r *PgxTodoRepository) GetTimezone() (string, error) {
+	query := `SELECT timezone FROM user_preferences WHERE id = 1`
+
+	var tz string
+	err := r.pool.QueryRow(context.Background(), query).Scan(&tz)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "UTC", nil // Preferences not saved yet
+	}
+
+	return tz, err
+}
+
+// SetTimezone stores the user's IANA timezone name
+func (r *PgxTodoRepository) SetTimezone(tz string) error {
+	query := `
+		INSERT INTO user_preferences (id, timezone)
+		VALUES (1, $1)
+		ON CONFLICT (id) DO UPDATE SET timezone = EXCLUDED.timezone
+	`
+
+	_, err := r.pool.Exec(context.Background(), query, tz)
+	return err
+}
+
+// GetPreferences returns the user's UI preferences, or the defaults if none
+// have been saved
+func (r *PgxTodoRepository) GetPreferences() (*models.Preferences, error) {
+	query := `
+		SELECT default_sort, default_filter, todos_per_page, theme, timezone
+		FROM user_preferences
+		WHERE id = 1
+	`
+
+	prefs := models.Preferences{DefaultFilter: models.SearchParams{}, TodosPerPage: models.DefaultTodosPerPage, Theme: models.ThemeSystem, Timezone: "UTC"}
+	var filter []byte
+	err := r.pool.QueryRow(context.Background(), query).Scan(&prefs.DefaultSort, &filter, &prefs.TodosPerPage, &prefs.Theme, &prefs.Timezone)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &prefs, nil // Preferences not saved yet
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(filter, &prefs.DefaultFilter); err != nil {
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+// SetPreferences replaces the user's UI preferences, timezone included
+func (r *PgxTodoRepository) SetPreferences(prefs *models.Preferences) error {
+	filter, err := json.Marshal(prefs.DefaultFilter)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_preferences (id, default_sort, default_filter, todos_per_page, theme, timezone)
+		VALUES (1, $1, $2::jsonb, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			default_sort = EXCLUDED.default_sort,
+			default_filter = EXCLUDED.default_filter,
+			todos_per_page = EXCLUDED.todos_per_page,
+			theme = EXCLUDED.theme,
+			timezone = EXCLUDED.timezone
+	`
+
+	_, err = r.pool.Exec(context.Background(), query, prefs.DefaultSort, string(filter), prefs.TodosPerPage, prefs.Theme, prefs.Timezone)
+	return err
+}
+
+// GetCompletionTrend counts the todos created and completed in each period
+// ("day" or "week") covering the last days days, oldest first. Periods with
+// no activity are included with zero counts.
+func (r *PgxTodoRepository) GetCompletionTrend(period string, days int) ([]*models.TrendPoint, error) {
+	query := `
+		WITH periods AS (
+			SELECT generate_series(
+				date_trunc($1::text, LOCALTIMESTAMP - make_interval(days => $2 - 1)),
+				date_trunc($1::text, LOCALTIMESTAMP),
+				('1 ' || $1::text)::interval
+			) AS period
+		),
+		created AS (
+			SELECT date_trunc($1::text, created_at) AS period, COUNT(*) AS n
+			FROM todos
+			WHERE created_at >= (SELECT MIN(period) FROM periods)
+			GROUP BY 1
+		),
+		completed AS (
+			SELECT date_trunc($1::text, completed_at) AS period, COUNT(*) AS n
+			FROM todos
+			WHERE completed_at >= (SELECT MIN(period) FROM periods)
+			GROUP BY 1
+		)
+		SELECT p.period, COALESCE(d.n, 0), COALESCE(c.n, 0)
+		FROM periods p
+		LEFT JOIN completed d ON d.period = p.period
+		LEFT JOIN created c ON c.period = p.period
+		ORDER BY p.period
+	`
+
+	rows, err := r.pool.Query(context.Background(), query, period, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []*models.TrendPoint{}
+	for rows.Next() {
+		var start time.Time
+		var point models.TrendPoint
+		if err := rows.Scan(&start, &point.Completed, &point.Created); err != nil {
+			return nil, err
+		}
+		point.Date = start.Format("2006-01-02")
+		points = append(points, &point)
+	}
+
+	return points, rows.Err()
+}
+
+// GetCompletionStreak finds the runs of consecutive days, in timezone, on
+// which at least one todo was completed. The current run is still going if
+// it reached today or yesterday.
+func (r *PgxTodoRepository) GetCompletionStreak(timezone string) (*models.Streak, error) {
+	query := `
+		WITH days AS (
+			SELECT DISTINCT (completed_at AT TIME ZONE 'UTC' AT TIME ZONE $1)::date AS day
+			FROM todos
+			WHERE completed_at IS NOT NULL
+		),
+		runs AS (
+			-- A day minus its row number is the same for every day in a run
+			SELECT MAX(day) AS last_day, COUNT(*) AS length
+			FROM (
+				SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::int AS run
+				FROM days
+			) numbered
+			GROUP BY run
+		)
+		SELECT
+			COALESCE(MAX(length) FILTER (WHERE last_day >= (NOW() AT TIME ZONE $1)::date - 1), 0),
+			COALESCE(MAX(length), 0),
+			MAX(last_day)
+		FROM runs
+	`
+
+	var streak models.Streak
+	var lastDay *time.Time
+	if err := r.pool.QueryRow(context.Background(), query, timezone).Scan(&streak.Current, &streak.Longest, &lastDay); err != nil {
+		return nil, err
+	}
+
+	if lastDay != nil {
+		date := lastDay.Format("2006-01-02")
+		streak.LastCompletionDate = &date
+	}
+
+	return &streak, nil
+}
+
+// CountByPriority counts the incomplete, unarchived todos at each priority.
+// Priorities with no such todos are left out.
+func (r *PgxTodoRepository) CountByPriority() (map[int]int64, error) {
+	query := `SELECT priority, COUNT(*) FROM todos WHERE completed = false AND archived_at IS NULL GROUP BY priority`
+
+	rows, err := r.pool.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int64)
+	for rows.Next() {
+		var priority int
+		var count int64
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, err
+		}
+		counts[priority] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// CountByCompletion counts the completed and pending todos, leaving out
+// archived ones like the lists do
+func (r *PgxTodoRepository) CountByCompletion() (*models.TodoCount, error) {
+	query := `SELECT COUNT(*) FILTER (WHERE completed), COUNT(*) FILTER (WHERE NOT completed) FROM todos WHERE archived_at IS NULL`
+
+	var count models.TodoCount
+	if err := r.pool.QueryRow(context.Background(), query).Scan(&count.Completed, &count.Pending); err != nil {
+		return nil, err
+	}
+	count.Total = count.Completed + count.Pending
+
+	return &count, nil
+}
+
+// ListSavedSearches returns all saved searches ordered by name
+func (r *PgxTodoRepository) ListSavedSearches() ([]*models.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM saved_searches
+		ORDER BY name
+	`
+
+	rows, err := r.pool.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := []*models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, search)
+	}
+
+	return searches, rows.Err()
+}
+
+// GetSavedSearch retrieves a saved search by name. It returns
+// ErrSavedSearchNotFound if there is none.
+func (r *PgxTodoRepository) GetSavedSearch(name string) (*models.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM saved_searches
+		WHERE name = $1
+	`
+
+	search, err := scanSavedSearch(r.pool.QueryRow(context.Background(), query, name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSavedSearchNotFound
+	}
+
+	return search, err
+}
+
+// CreateSavedSearch stores params under name. It returns
+// ErrSavedSearchExists if the name is taken.
+func (r *PgxTodoRepository) CreateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO saved_searches (name, params)
+		VALUES ($1, $2::jsonb)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING ` + savedSearchColumns
+
+	search, err := scanSavedSearch(r.pool.QueryRow(context.Background(), query, name, string(encoded)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSavedSearchExists
+	}
+
+	return search, err
+}
+
+// UpdateSavedSearch replaces the params of the saved search called name. It
+// returns ErrSavedSearchNotFound if there is none.
+func (r *PgxTodoRepository) UpdateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE saved_searches
+		SET params = $2::jsonb, updated_at = NOW()
+		WHERE name = $1
+		RETURNING ` + savedSearchColumns
+
+	search, err := scanSavedSearch(r.pool.QueryRow(context.Background(), query, name, string(encoded)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSavedSearchNotFound
+	}
+
+	return search, err
+}
+
+// DeleteSavedSearch removes the saved search called name. It returns
+// ErrSavedSearchNotFound if there is none.
+func (r *PgxTodoRepository) DeleteSavedSearch(name string) error {
+	query := `DELETE FROM saved_searches WHERE name = $1`
+
+	tag, err := r.pool.Exec(context.Background(), query, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSavedSearchNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,731 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// Retry settings used by RetryableRepository
+const (
+	maxRetries     = 3
+	retryBaseDelay = 10 * time.Millisecond
+)
+
+// retryableCodes are the Postgres error codes that are safe to retry
+var retryableCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+}
+
+// isRetryable reports whether err is a Postgres serialization failure or
+// deadlock from either driver
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return retryableCodes[string(pqErr.Code)]
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return retryableCodes[pgErr.Code]
+	}
+
+	return false
+}
+
+// RetryableRepository wraps a TodoRepositoryInterface and retries calls that
+// fail with a transient serialization failure or deadlock, up to maxRetries
+// times with exponential backoff and jitter
+type RetryableRepository struct {
+	inner TodoRepositoryInterface
+}
+
+var _ TodoRepositoryInterface = (*RetryableRepository)(nil)
+
+// NewRetryableRepository creates a new RetryableRepository
+func NewRetryableRepository(inner TodoRepositoryInterface) *RetryableRepository {
+	return &RetryableRepository{
+		inner: inner,
+	}
+}
+
+// do runs op, retrying while it returns a retryable error
+func (r *RetryableRepository) do(op func() error) error {
+	err := op()
+	for attempt := 0; attempt < maxRetries && isRetryable(err); attempt++ {
+		backoff(attempt)
+		err = op()
+	}
+	return err
+}
+
+// backoff sleeps before retry number attempt, for a random time up to an
+// exponentially growing delay
+func backoff(attempt int) {
+	delay := retryBaseDelay << attempt
+	time.Sleep(time.Duration(rand.Int63n(int64(delay))))
+}
+
+// Create calls the wrapped repository's Create, retrying transient errors
+func (r *RetryableRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.Create(todo)
+		return err
+	})
+	return result, err
+}
+
+// Upsert calls the wrapped repository's Upsert, retrying transient errors
+func (r *RetryableRepository) Upsert(todo *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	var result *models.Todo
+	var inserted bool
+	err := r.do(func() (err error) {
+		result, inserted, err = r.inner.Upsert(todo)
+		return err
+	})
+	return result, inserted, err
+}
+
+// BulkCreate calls the wrapped repository's BulkCreate, retrying transient errors
+func (r *RetryableRepository) BulkCreate(todos []*models.CreateTodoRequest) (int64, error) {
+	var result int64
+	err := r.do(func() (err error) {
+		result, err = r.inner.BulkCreate(todos)
+		return err
+	})
+	return result, err
+}
+
+// GetAll calls the wrapped repository's GetAll, retrying transient errors
+func (r *RetryableRepository) GetAll(filter TodoFilter) ([]*models.Todo, int64, error) {
+	var result []*models.Todo
+	var total int64
+	err := r.do(func() (err error) {
+		result, total, err = r.inner.GetAll(filter)
+		return err
+	})
+	return result, total, err
+}
+
+// StreamAll calls the wrapped repository's StreamAll. It is only retried if
+// the failure happened before fn saw any todo, so rows are never repeated.
+func (r *RetryableRepository) StreamAll(ctx context.Context, filter TodoFilter, fn func(*models.Todo) error) error {
+	var started bool
+	stream := func() error {
+		return r.inner.StreamAll(ctx, filter, func(todo *models.Todo) error {
+			started = true
+			return fn(todo)
+		})
+	}
+
+	err := stream()
+	for attempt := 0; attempt < maxRetries && !started && isRetryable(err); attempt++ {
+		backoff(attempt)
+		err = stream()
+	}
+	return err
+}
+
+// StreamAllIDs calls the wrapped repository's StreamAllIDs. Like StreamAll it
+// is only retried if the failure happened before fn saw any ID.
+func (r *RetryableRepository) StreamAllIDs(ctx context.Context, fn func(int64) error) error {
+	var started bool
+	stream := func() error {
+		return r.inner.StreamAllIDs(ctx, func(id int64) error {
+			started = true
+			return fn(id)
+		})
+	}
+
+	err := stream()
+	for attempt := 0; attempt < maxRetries && !started && isRetryable(err); attempt++ {
+		backoff(attempt)
+		err = stream()
+	}
+	return err
+}
+
+// GetByID calls the wrapped repository's GetByID, retrying transient errors
+func (r *RetryableRepository) GetByID(id int64) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetByID(id)
+		return err
+	})
+	return result, err
+}
+
+// Update calls the wrapped repository's Update, retrying transient errors
+func (r *RetryableRepository) Update(todo *models.Todo) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.Update(todo)
+		return err
+	})
+	return result, err
+}
+
+// SetCompleted calls the wrapped repository's SetCompleted, retrying transient errors
+func (r *RetryableRepository) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.SetCompleted(id, completed)
+		return err
+	})
+	return result, err
+}
+
+// AppendNote calls the wrapped repository's AppendNote, retrying transient errors
+func (r *RetryableRepository) AppendNote(id int64, body string) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.AppendNote(id, body)
+		return err
+	})
+	return result, err
+}
+
+// BatchUpdatePriority calls the wrapped repository's BatchUpdatePriority, retrying transient errors
+func (r *RetryableRepository) BatchUpdatePriority(ids []int64, priority int) ([]int64, error) {
+	var result []int64
+	err := r.do(func() (err error) {
+		result, err = r.inner.BatchUpdatePriority(ids, priority)
+		return err
+	})
+	return result, err
+}
+
+// SetPinned calls the wrapped repository's SetPinned, retrying transient errors
+func (r *RetryableRepository) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.SetPinned(id, pinned)
+		return err
+	})
+	return result, err
+}
+
+// Delete calls the wrapped repository's Delete, retrying transient errors
+func (r *RetryableRepository) Delete(id int64) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.Delete(id)
+		return err
+	})
+	return result, err
+}
+
+// DeleteAllCompleted calls the wrapped repository's DeleteAllCompleted, retrying transient errors
+func (r *RetryableRepository) DeleteAllCompleted() (int64, error) {
+	var result int64
+	err := r.do(func() (err error) {
+		result, err = r.inner.DeleteAllCompleted()
+		return err
+	})
+	return result, err
+}
+
+// CreateCategory calls the wrapped repository's CreateCategory, retrying transient errors
+func (r *RetryableRepository) CreateCategory(name string, parentID *int64) (*models.Category, error) {
+	var result *models.Category
+	err := r.do(func() (err error) {
+		result, err = r.inner.CreateCategory(name, parentID)
+		return err
+	})
+	return result, err
+}
+
+// GetAllCategories calls the wrapped repository's GetAllCategories, retrying transient errors
+func (r *RetryableRepository) GetAllCategories() ([]*models.Category, error) {
+	var result []*models.Category
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetAllCategories()
+		return err
+	})
+	return result, err
+}
+
+// GetCategoryByID calls the wrapped repository's GetCategoryByID, retrying transient errors
+func (r *RetryableRepository) GetCategoryByID(id int64) (*models.Category, error) {
+	var result *models.Category
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetCategoryByID(id)
+		return err
+	})
+	return result, err
+}
+
+// MoveTodos calls the wrapped repository's MoveTodos, retrying transient errors
+func (r *RetryableRepository) MoveTodos(ids []int64, categoryID *int64) (int64, error) {
+	var result int64
+	err := r.do(func() (err error) {
+		result, err = r.inner.MoveTodos(ids, categoryID)
+		return err
+	})
+	return result, err
+}
+
+// GetOverdueUnnotified calls the wrapped repository's GetOverdueUnnotified, retrying transient errors
+func (r *RetryableRepository) GetOverdueUnnotified() ([]*models.Todo, error) {
+	var result []*models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetOverdueUnnotified()
+		return err
+	})
+	return result, err
+}
+
+// MarkOverdueNotified calls the wrapped repository's MarkOverdueNotified, retrying transient errors
+func (r *RetryableRepository) MarkOverdueNotified(ids []int64) error {
+	return r.do(func() error {
+		return r.inner.MarkOverdueNotified(ids)
+	})
+}
+
+// GetTimezone calls the wrapped repository's GetTimezone, retrying transient errors
+func (r *RetryableRepository) GetTimezone() (string, error) {
+	var result string
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetTimezone()
+		return err
+	})
+	return result, err
+}
+
+// SetTimezone calls the wrapped repository's SetTimezone, retrying transient errors
+func (r *RetryableRepository) SetTimezone(tz string) error {
+	return r.do(func() error {
+		return r.inner.SetTimezone(tz)
+	})
+}
+
+// Snooze calls the wrapped repository's Snooze, retrying transient errors
+func (r *RetryableRepository) Snooze(id int64, days int) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.Snooze(id, days)
+		return err
+	})
+	return result, err
+}
+
+// SetTags calls the wrapped repository's SetTags, retrying transient errors
+func (r *RetryableRepository) SetTags(id int64, tags []string) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.SetTags(id, tags)
+		return err
+	})
+	return result, err
+}
+
+// GetCategoryTree calls the wrapped repository's GetCategoryTree, retrying transient errors
+func (r *RetryableRepository) GetCategoryTree() ([]*models.Category, error) {
+	var result []*models.Category
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetCategoryTree()
+		return err
+	})
+	return result, err
+}
+
+// GetCategoryDepth calls the wrapped repository's GetCategoryDepth, retrying transient errors
+func (r *RetryableRepository) GetCategoryDepth(id int64) (int, error) {
+	var result int
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetCategoryDepth(id)
+		return err
+	})
+	return result, err
+}
+
+// GetCompletionTrend calls the wrapped repository's GetCompletionTrend, retrying transient errors
+func (r *RetryableRepository) GetCompletionTrend(period string, days int) ([]*models.TrendPoint, error) {
+	var result []*models.TrendPoint
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetCompletionTrend(period, days)
+		return err
+	})
+	return result, err
+}
+
+// CountByPriority calls the wrapped repository's CountByPriority, retrying transient errors
+func (r *RetryableRepository) CountByPriority() (map[int]int64, error) {
+	var result map[int]int64
+	err := r.do(func() (err error) {
+		result, err = r.inner.CountByPriority()
+		return err
+	})
+	return result, err
+}
+
+// DuplicateCategory calls the wrapped repository's DuplicateCategory, retrying transient errors
+func (r *RetryableRepository) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	var result *models.CategoryWithCount
+	err := r.do(func() (err error) {
+		result, err = r.inner.DuplicateCategory(id)
+		return err
+	})
+	return result, err
+}
+
+// Reorder calls the wrapped repository's Reorder, retrying transient errors
+func (r *RetryableRepository) Reorder(order []models.TodoPosition) (int64, error) {
+	var result int64
+	err := r.do(func() (err error) {
+		result, err = r.inner.Reorder(order)
+		return err
+	})
+	return result, err
+}
+
+// GetCompletionStreak calls the wrapped repository's GetCompletionStreak, retrying transient errors
+func (r *RetryableRepository) GetCompletionStreak(timezone string) (*models.Streak, error) {
+	var result *models.Streak
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetCompletionStreak(timezone)
+		return err
+	})
+	return result, err
+}
+
+// GetHighlights calls the wrapped repository's GetHighlights, retrying transient errors
+func (r *RetryableRepository) GetHighlights(ids []int64, query string) (map[int64]map[string]string, error) {
+	var result map[int64]map[string]string
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetHighlights(ids, query)
+		return err
+	})
+	return result, err
+}
+
+// GetRelated calls the wrapped repository's GetRelated, retrying transient errors
+func (r *RetryableRepository) GetRelated(id int64, limit int) ([]*models.Todo, error) {
+	var result []*models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetRelated(id, limit)
+		return err
+	})
+	return result, err
+}
+
+// ListSavedSearches calls the wrapped repository's ListSavedSearches, retrying transient errors
+func (r *RetryableRepository) ListSavedSearches() ([]*models.SavedSearch, error) {
+	var result []*models.SavedSearch
+	err := r.do(func() (err error) {
+		result, err = r.inner.ListSavedSearches()
+		return err
+	})
+	return result, err
+}
+
+// GetSavedSearch calls the wrapped repository's GetSavedSearch, retrying transient errors
+func (r *RetryableRepository) GetSavedSearch(name string) (*models.SavedSearch, error) {
+	var result *models.SavedSearch
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetSavedSearch(name)
+		return err
+	})
+	return result, err
+}
+
+// CreateSavedSearch calls the wrapped repository's CreateSavedSearch, retrying transient errors
+func (r *RetryableRepository) CreateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	var result *models.SavedSearch
+	err := r.do(func() (err error) {
+		result, err = r.inner.CreateSavedSearch(name, params)
+		return err
+	})
+	return result, err
+}
+
+// UpdateSavedSearch calls the wrapped repository's UpdateSavedSearch, retrying transient errors
+func (r *RetryableRepository) UpdateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	var result *models.SavedSearch
+	err := r.do(func() (err error) {
+		result, err = r.inner.UpdateSavedSearch(name, params)
+		return err
+	})
+	return result, err
+}
+
+// DeleteSavedSearch calls the wrapped repository's DeleteSavedSearch, retrying transient errors
+func (r *RetryableRepository) DeleteSavedSearch(name string) error {
+	return r.do(func() error {
+		return r.inner.DeleteSavedSearch(name)
+	})
+}
+
+// ExistingTags calls the wrapped repository's ExistingTags, retrying transient errors
+func (r *RetryableRepository) ExistingTags(names []string) ([]string, error) {
+	var result []string
+	err := r.do(func() (err error) {
+		result, err = r.inner.ExistingTags(names)
+		return err
+	})
+	return result, err
+}
+
+// GetTimeline calls the wrapped repository's GetTimeline, retrying transient errors
+func (r *RetryableRepository) GetTimeline(todoID int64) ([]*models.TimelineEvent, error) {
+	var result []*models.TimelineEvent
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetTimeline(todoID)
+		return err
+	})
+	return result, err
+}
+
+// ImportGroups calls the wrapped repository's ImportGroups, retrying transient errors
+func (r *RetryableRepository) ImportGroups(groups []models.ImportGroup) ([]string, int64, error) {
+	var created []string
+	var imported int64
+	err := r.do(func() (err error) {
+		created, imported, err = r.inner.ImportGroups(groups)
+		return err
+	})
+	return created, imported, err
+}
+
+// ReleaseReminders calls the wrapped repository's ReleaseReminders, retrying transient errors
+func (r *RetryableRepository) ReleaseReminders(ids []int64) error {
+	return r.do(func() error {
+		return r.inner.ReleaseReminders(ids)
+	})
+}
+
+// BatchUpdateCategory calls the wrapped repository's BatchUpdateCategory, retrying transient errors
+func (r *RetryableRepository) BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error) {
+	var result []int64
+	err := r.do(func() (err error) {
+		result, err = r.inner.BatchUpdateCategory(ids, categoryID)
+		return err
+	})
+	return result, err
+}
+
+// GetDeletedAt calls the wrapped repository's GetDeletedAt, retrying transient errors
+func (r *RetryableRepository) GetDeletedAt(id int64) (*time.Time, error) {
+	var result *time.Time
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetDeletedAt(id)
+		return err
+	})
+	return result, err
+}
+
+// LastModified calls the wrapped repository's LastModified, retrying transient errors
+func (r *RetryableRepository) LastModified() (time.Time, error) {
+	var result time.Time
+	err := r.do(func() (err error) {
+		result, err = r.inner.LastModified()
+		return err
+	})
+	return result, err
+}
+
+// GetDueWithinWindow calls the wrapped repository's GetDueWithinWindow, retrying transient errors
+func (r *RetryableRepository) GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error) {
+	var result []*models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetDueWithinWindow(from, to)
+		return err
+	})
+	return result, err
+}
+
+// CountByCompletion calls the wrapped repository's CountByCompletion, retrying transient errors
+func (r *RetryableRepository) CountByCompletion() (*models.TodoCount, error) {
+	var result *models.TodoCount
+	err := r.do(func() (err error) {
+		result, err = r.inner.CountByCompletion()
+		return err
+	})
+	return result, err
+}
+
+// GetAllByCategory calls the wrapped repository's GetAllByCategory, retrying transient errors
+func (r *RetryableRepository) GetAllByCategory() ([]*models.Todo, error) {
+	var result []*models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetAllByCategory()
+		return err
+	})
+	return result, err
+}
+
+// GetCategoryAncestors calls the wrapped repository's GetCategoryAncestors, retrying transient errors
+func (r *RetryableRepository) GetCategoryAncestors(id int64) ([]*models.Category, error) {
+	var result []*models.Category
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetCategoryAncestors(id)
+		return err
+	})
+	return result, err
+}
+
+// ConvertToCategory calls the wrapped repository's ConvertToCategory, retrying transient errors
+func (r *RetryableRepository) ConvertToCategory(id int64) (*models.Category, error) {
+	var result *models.Category
+	err := r.do(func() (err error) {
+		result, err = r.inner.ConvertToCategory(id)
+		return err
+	})
+	return result, err
+}
+
+// GetCompletedBetween calls the wrapped repository's GetCompletedBetween, retrying transient errors
+func (r *RetryableRepository) GetCompletedBetween(from, to time.Time) ([]*models.Todo, error) {
+	var result []*models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetCompletedBetween(from, to)
+		return err
+	})
+	return result, err
+}
+
+// GetDueBetween calls the wrapped repository's GetDueBetween, retrying transient errors
+func (r *RetryableRepository) GetDueBetween(from, to time.Time) ([]*models.Todo, error) {
+	var result []*models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetDueBetween(from, to)
+		return err
+	})
+	return result, err
+}
+
+// GetPreferences calls the wrapped repository's GetPreferences, retrying transient errors
+func (r *RetryableRepository) GetPreferences() (*models.Preferences, error) {
+	var result *models.Preferences
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetPreferences()
+		return err
+	})
+	return result, err
+}
+
+// SetPreferences calls the wrapped repository's SetPreferences, retrying transient errors
+func (r *RetryableRepository) SetPreferences(prefs *models.Preferences) error {
+	return r.do(func() error {
+		return r.inner.SetPreferences(prefs)
+	})
+}
+
+// VacuumCompleted calls the wrapped repository's VacuumCompleted, retrying
+// transient errors. Each batch commits on its own, so a failed attempt may
+// already have archived some todos; the count adds up every attempt, and a
+// retry only finds the todos that are left.
+func (r *RetryableRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	var archived int64
+	err := r.do(func() error {
+		n, err := r.inner.VacuumCompleted(olderThanDays)
+		archived += n
+		return err
+	})
+	return archived, err
+}
+
+// UpdateWith calls the wrapped repository's UpdateWith, retrying transient errors
+func (r *RetryableRepository) UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error) {
+	var result *models.Todo
+	err := r.do(func() (err error) {
+		result, err = r.inner.UpdateWith(id, apply)
+		return err
+	})
+	return result, err
+}
+
+// CompleteAllInCategory calls the wrapped repository's CompleteAllInCategory, retrying transient errors
+func (r *RetryableRepository) CompleteAllInCategory(categoryID int64) ([]int64, []int64, error) {
+	var completed, blocked []int64
+	err := r.do(func() (err error) {
+		completed, blocked, err = r.inner.CompleteAllInCategory(categoryID)
+		return err
+	})
+	return completed, blocked, err
+}
+
+// AddReaction calls the wrapped repository's AddReaction, retrying transient errors
+func (r *RetryableRepository) AddReaction(todoID int64, emoji string) error {
+	return r.do(func() error {
+		return r.inner.AddReaction(todoID, emoji)
+	})
+}
+
+// RemoveReaction calls the wrapped repository's RemoveReaction, retrying transient errors
+func (r *RetryableRepository) RemoveReaction(todoID int64, emoji string) error {
+	return r.do(func() error {
+		return r.inner.RemoveReaction(todoID, emoji)
+	})
+}
+
+// GetReactions calls the wrapped repository's GetReactions, retrying transient errors
+func (r *RetryableRepository) GetReactions(todoID int64) ([]models.Reaction, error) {
+	var result []models.Reaction
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetReactions(todoID)
+		return err
+	})
+	return result, err
+}
+
+// SetArchived calls the wrapped repository's SetArchived, retrying transient errors
+func (r *RetryableRepository) SetArchived(ids []int64, archived bool) ([]int64, []int64, error) {
+	var moved, unchanged []int64
+	err := r.do(func() (err error) {
+		moved, unchanged, err = r.inner.SetArchived(ids, archived)
+		return err
+	})
+	return moved, unchanged, err
+}
+
+// ShiftDueDates calls the wrapped repository's ShiftDueDates, retrying transient errors
+func (r *RetryableRepository) ShiftDueDates(ids []int64, days int) ([]int64, []int64, error) {
+	var shifted, skipped []int64
+	err := r.do(func() (err error) {
+		shifted, skipped, err = r.inner.ShiftDueDates(ids, days)
+		return err
+	})
+	return shifted, skipped, err
+}
+
+// AddDependency calls the wrapped repository's AddDependency, retrying transient errors
+func (r *RetryableRepository) AddDependency(todoID, blockingID int64) error {
+	return r.do(func() error {
+		return r.inner.AddDependency(todoID, blockingID)
+	})
+}
+
+// RemoveDependency calls the wrapped repository's RemoveDependency, retrying transient errors
+func (r *RetryableRepository) RemoveDependency(todoID, blockingID int64) error {
+	return r.do(func() error {
+		return r.inner.RemoveDependency(todoID, blockingID)
+	})
+}
+
+// GetBlockers calls the wrapped repository's GetBlockers, retrying transient errors
+func (r *RetryableRepository) GetBlockers(todoID int64) ([]models.Blocker, error) {
+	var result []models.Blocker
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetBlockers(todoID)
+		return err
+	})
+	return result, err
+}
+
+// GetActivity calls the wrapped repository's GetActivity, retrying transient errors
+func (r *RetryableRepository) GetActivity(before int64, limit int) ([]*models.ActivityEntry, error) {
+	var result []*models.ActivityEntry
+	err := r.do(func() (err error) {
+		result, err = r.inner.GetActivity(before, limit)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+// flakyRepository fails Create with err the first failures times
+type flakyRepository struct {
+	*testhelpers.MemoryRepository
+	err      error
+	failures int
+	calls    int
+}
+
+func (r *flakyRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+	r.calls++
+	if r.calls <= r.failures {
+		return nil, r.err
+	}
+	return r.MemoryRepository.Create(todo)
+}
+
+func TestRetryableRepositoryRetriesSerializationFailure(t *testing.T) {
+	inner := &flakyRepository{
+		MemoryRepository: testhelpers.NewMemoryRepository(),
+		err:              &pq.Error{Code: "40001"},
+		failures:         2,
+	}
+	repo := repository.NewRetryableRepository(inner)
+
+	todo, err := repo.Create(&models.CreateTodoRequest{Title: "Retry me", Priority: models.PriorityMedium})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if todo == nil || inner.calls != 3 {
+		t.Errorf("Create made %d calls, want 3", inner.calls)
+	}
+}
+
+func TestRetryableRepositoryGivesUp(t *testing.T) {
+	inner := &flakyRepository{
+		MemoryRepository: testhelpers.NewMemoryRepository(),
+		err:              &pq.Error{Code: "40P01"},
+		failures:         10,
+	}
+	repo := repository.NewRetryableRepository(inner)
+
+	if _, err := repo.Create(&models.CreateTodoRequest{Title: "Deadlocked"}); err == nil {
+		t.Fatal("Create returned nil error, want deadlock")
+	}
+	if inner.calls != 4 {
+		t.Errorf("Create made %d calls, want 4 (1 + 3 retries)", inner.calls)
+	}
+}
+
+func TestRetryableRepositoryDoesNotRetryOtherErrors(t *testing.T) {
+	inner := &flakyRepository{
+		MemoryRepository: testhelpers.NewMemoryRepository(),
+		err:              errors.New("connection refused"),
+		failures:         1,
+	}
+	repo := repository.NewRetryableRepository(inner)
+
+	if _, err := repo.Create(&models.CreateTodoRequest{Title: "Broken"}); err == nil {
+		t.Fatal("Create returned nil error")
+	}
+	if inner.calls != 1 {
+		t.Errorf("Create made %d calls, want 1", inner.calls)
+	}
+}
+
+// partialVacuumRepository archives a first batch and then fails VacuumCompleted
+// with err once, as a run that commits per batch does mid-way
+type partialVacuumRepository struct {
+	*testhelpers.MemoryRepository
+	err   error
+	calls int
+}
+
+func (r *partialVacuumRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	r.calls++
+	if r.calls == 1 {
+		return repository.VacuumBatchSize, r.err
+	}
+	return 5, nil
+}
+
+func TestRetryableRepositoryCountsVacuumBatchesFromFailedAttempts(t *testing.T) {
+	inner := &partialVacuumRepository{
+		MemoryRepository: testhelpers.NewMemoryRepository(),
+		err:              &pq.Error{Code: "40P01"},
+	}
+	repo := repository.NewRetryableRepository(inner)
+
+	archived, err := repo.VacuumCompleted(30)
+	if err != nil {
+		t.Fatalf("VacuumCompleted returned error: %v", err)
+	}
+	if want := int64(repository.VacuumBatchSize + 5); archived != want {
+		t.Errorf("archived = %d, want %d", archived, want)
+	}
+	if inner.calls != 2 {
+		t.Errorf("VacuumCompleted made %d calls, want 2", inner.calls)
+	}
+}
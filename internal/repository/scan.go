@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// todoColumns is the column list selected for every todo query, in the
+// order expected by scanTodo
+const todoColumns = `id, title, description, completed, created_at, updated_at, completed_at, notes, priority, pinned, external_id, category_id, due_at, snooze_count, position, archived_at, ` + tagsColumn
+
+// tagsColumn selects a todo's tag names as a JSON array, sorted by name
+const tagsColumn = `(
+	SELECT COALESCE(json_agg(tags.name ORDER BY tags.name), '[]')
+	FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id
+	WHERE todo_tags.todo_id = todos.id
+) AS tags`
+
+// searchVector is the expression indexed for full-text search, matching
+// todos_search_idx
+const searchVector = `to_tsvector('english', title || ' ' || COALESCE(description, ''))`
+
+// relatedQuery selects up to $2 incomplete, unarchived todos whose text
+// shares words with the title of todo $1, best match first. plainto_tsquery
+// ANDs the words together, so they are ORed to also find todos matching only
+// some of them.
+const relatedQuery = `
+	WITH source AS (
+		SELECT id AS source_id,
+			replace(plainto_tsquery('english', title)::text, '&', '|')::tsquery AS terms
+		FROM todos
+		WHERE id = $1
+	)
+	SELECT ` + todoColumns + `
+	FROM todos, source
+	WHERE todos.id <> source.source_id
+		AND completed = false
+		AND archived_at IS NULL
+		AND ` + searchVector + ` @@ source.terms
+	ORDER BY ts_rank(` + searchVector + `, source.terms) DESC, todos.id DESC
+	LIMIT $2
+`
+
+// Highlight delimiters around the words GetHighlights found. They are control
+// characters rather than markup, so the caller can escape the text first.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+// highlightColumns selects the title and description of todos with the
+// words of the search query $1 wrapped in HighlightStart and HighlightStop.
+// ts_headline runs on both fields, and highlighted drops a field it marked
+// nothing in, since a todo can match the query without every field doing so.
+const highlightColumns = `id,
+	ts_headline('english', title, plainto_tsquery('english', $1), 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', HighlightAll=true'),
+	ts_headline('english', COALESCE(description, ''), plainto_tsquery('english', $1), 'StartSel=' || chr(2) || ', StopSel=' || chr(3))`
+
+// highlighted returns a field selected by highlightColumns, or an empty
+// string if ts_headline found no words to mark in it
+func highlighted(field string) string {
+	if !strings.Contains(field, HighlightStart) {
+		return ""
+	}
+	return field
+}
+
+// rowScanner is satisfied by *sql.Row, *sql.Rows, pgx.Row and pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTodo scans a row selected with todoColumns into a Todo. Any extra
+// destinations are scanned from the columns following todoColumns.
+func scanTodo(row rowScanner, extra ...interface{}) (*models.Todo, error) {
+	var todo models.Todo
+	var completedAt, dueAt, archivedAt sql.NullTime
+	var notes, tags []byte
+
+	dest := []interface{}{
+		&todo.ID,
+		&todo.Title,
+		&todo.Description,
+		&todo.Completed,
+		&todo.CreatedAt,
+		&todo.UpdatedAt,
+		&completedAt,
+		&notes,
+		&todo.Priority,
+		&todo.Pinned,
+		&todo.ExternalID,
+		&todo.CategoryID,
+		&dueAt,
+		&todo.SnoozeCount,
+		&todo.Position,
+		&archivedAt,
+		&tags,
+	}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(notes, &todo.Notes); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(tags, &todo.Tags); err != nil {
+		return nil, err
+	}
+
+	if completedAt.Valid {
+		todo.CompletedAt = &completedAt.Time
+	}
+
+	if dueAt.Valid {
+		todo.DueAt = &dueAt.Time
+	}
+
+	if archivedAt.Valid {
+		todo.ArchivedAt = &archivedAt.Time
+	}
+
+	todo.Flagged = todo.SnoozeCount >= models.SnoozeFlagThreshold
+
+	return &todo, nil
+}
+
+// categoryColumns is the column list selected for every category query, in
+// the order expected by scanCategory
+const categoryColumns = `id, name, parent_id, created_at`
+
+// scanCategory scans a row selected with categoryColumns into a Category
+func scanCategory(row rowScanner) (*models.Category, error) {
+	var category models.Category
+
+	err := row.Scan(&category.ID, &category.Name, &category.ParentID, &category.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// savedSearchColumns is the column list selected for every saved search
+// query, in the order expected by scanSavedSearch
+const savedSearchColumns = `id, name, params, created_at, updated_at`
+
+// scanSavedSearch scans a row selected with savedSearchColumns into a
+// SavedSearch
+func scanSavedSearch(row rowScanner) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	var params []byte
+
+	err := row.Scan(&search.ID, &search.Name, &params, &search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(params, &search.Params); err != nil {
+		return nil, err
+	}
+
+	return &search, nil
+}
+
+// timelineColumns is the column list selected from activity_logs for a
+// todo's timeline, in the order expected by scanTimelineEvent
+const timelineColumns = `created_at, event, details->'from', details->'to'`
+
+// activityColumns is the column list selected from activity_logs, joined
+// to todos and todos_archive, for the activity feed. It starts with the
+// columns of timelineColumns, in the order expected by scanActivityEntry.
+const activityColumns = `activity_logs.created_at, activity_logs.event, activity_logs.details->'from', activity_logs.details->'to',
+	activity_logs.id, activity_logs.todo_id, COALESCE(todos.title, todos_archive.title)`
+
+// scanActivityEntry scans a row selected with activityColumns into an
+// ActivityEntry
+func scanActivityEntry(row rowScanner) (*models.ActivityEntry, error) {
+	var entry models.ActivityEntry
+	var title sql.NullString
+
+	event, err := scanTimelineEvent(row, &entry.ID, &entry.TodoID, &title)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.TimelineEvent = *event
+	if title.Valid {
+		entry.TodoTitle = &title.String
+	}
+	return &entry, nil
+}
+
+// scanTimelineEvent scans a row selected with timelineColumns into a
+// TimelineEvent. Any extra destinations are scanned from the columns
+// following timelineColumns.
+func scanTimelineEvent(row rowScanner, extra ...interface{}) (*models.TimelineEvent, error) {
+	var event models.TimelineEvent
+	var from, to []byte
+
+	dest := append([]interface{}{&event.Timestamp, &event.Event, &from, &to}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	// A missing key is SQL NULL, while a value that was unset is JSON null
+	if from != nil {
+		event.From = json.RawMessage(from)
+	}
+	if to != nil {
+		event.To = json.RawMessage(to)
+	}
+
+	return &event, nil
+}
+
+// idRows is satisfied by *sql.Rows and pgx.Rows
+type idRows interface {
+	rowScanner
+	Next() bool
+	Err() error
+}
+
+// splitIDs reads rows of an ID and a boolean, and returns the IDs whose
+// boolean is true and the IDs whose boolean is false
+func splitIDs(rows idRows) ([]int64, []int64, error) {
+	var matched, unmatched []int64
+	for rows.Next() {
+		var id int64
+		var ok bool
+		if err := rows.Scan(&id, &ok); err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			matched = append(matched, id)
+		} else {
+			unmatched = append(unmatched, id)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return matched, unmatched, nil
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// refreshSearchQuery rebuilds todo_search_mv without blocking readers
+const refreshSearchQuery = `REFRESH MATERIALIZED VIEW CONCURRENTLY todo_search_mv`
+
+// SearchIndexRefresher refreshes todo_search_mv in the background. Triggers
+// that arrive while a refresh is pending or running are coalesced into the
+// next refresh.
+type SearchIndexRefresher struct {
+	refresh func(ctx context.Context) error
+	pending chan struct{}
+}
+
+// NewSearchIndexRefresher creates a new SearchIndexRefresher that calls
+// refresh to rebuild the index
+func NewSearchIndexRefresher(refresh func(ctx context.Context) error) *SearchIndexRefresher {
+	return &SearchIndexRefresher{
+		refresh: refresh,
+		pending: make(chan struct{}, 1),
+	}
+}
+
+// NewSQLSearchIndexRefresher creates a SearchIndexRefresher using db
+func NewSQLSearchIndexRefresher(db *sql.DB) *SearchIndexRefresher {
+	return NewSearchIndexRefresher(func(ctx context.Context) error {
+		_, err := db.ExecContext(ctx, refreshSearchQuery)
+		return err
+	})
+}
+
+// NewPgxSearchIndexRefresher creates a SearchIndexRefresher using pool
+func NewPgxSearchIndexRefresher(pool *pgxpool.Pool) *SearchIndexRefresher {
+	return NewSearchIndexRefresher(func(ctx context.Context) error {
+		_, err := pool.Exec(ctx, refreshSearchQuery)
+		return err
+	})
+}
+
+// Trigger schedules a refresh without waiting for it
+func (r *SearchIndexRefresher) Trigger() {
+	select {
+	case r.pending <- struct{}{}:
+	default: // A refresh is already pending
+	}
+}
+
+// Run performs scheduled refreshes until ctx is done
+func (r *SearchIndexRefresher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.pending:
+			if err := r.refresh(ctx); err != nil {
+				log.Printf("Failed to refresh search index: %v", err)
+			}
+		}
+	}
+}
+
+// SearchRefreshingRepository wraps a TodoRepositoryInterface and triggers a
+// search index refresh after every write that adds, changes or removes a
+// todo. All other calls go straight to the wrapped repository.
+type SearchRefreshingRepository struct {
+	TodoRepositoryInterface
+	refresher *SearchIndexRefresher
+}
+
+// NewSearchRefreshingRepository creates a new SearchRefreshingRepository
+func NewSearchRefreshingRepository(inner TodoRepositoryInterface, refresher *SearchIndexRefresher) *SearchRefreshingRepository {
+	return &SearchRefreshingRepository{
+		TodoRepositoryInterface: inner,
+		refresher:               refresher,
+	}
+}
+
+// Create creates a todo and schedules a search index refresh
+func (r *SearchRefreshingRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+	created, err := r.TodoRepositoryInterface.Create(todo)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return created, err
+}
+
+// Upsert upserts a todo and schedules a search index refresh
+func (r *SearchRefreshingRepository) Upsert(todo *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	upserted, inserted, err := r.TodoRepositoryInterface.Upsert(todo)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return upserted, inserted, err
+}
+
+// BulkCreate creates todos and schedules a search index refresh
+func (r *SearchRefreshingRepository) BulkCreate(todos []*models.CreateTodoRequest) (int64, error) {
+	count, err := r.TodoRepositoryInterface.BulkCreate(todos)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return count, err
+}
+
+// ImportGroups imports todos into categories and schedules a search index
+// refresh
+func (r *SearchRefreshingRepository) ImportGroups(groups []models.ImportGroup) ([]string, int64, error) {
+	created, imported, err := r.TodoRepositoryInterface.ImportGroups(groups)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return created, imported, err
+}
+
+// Update updates a todo and schedules a search index refresh
+func (r *SearchRefreshingRepository) Update(todo *models.Todo) (*models.Todo, error) {
+	updated, err := r.TodoRepositoryInterface.Update(todo)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return updated, err
+}
+
+// SetCompleted marks a todo complete or incomplete and schedules a search
+// index refresh
+func (r *SearchRefreshingRepository) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	todo, err := r.TodoRepositoryInterface.SetCompleted(id, completed)
+	if err == nil && todo != nil {
+		r.refresher.Trigger()
+	}
+	return todo, err
+}
+
+// AppendNote appends a note to a todo and schedules a search index refresh
+func (r *SearchRefreshingRepository) AppendNote(id int64, body string) (*models.Todo, error) {
+	todo, err := r.TodoRepositoryInterface.AppendNote(id, body)
+	if err == nil && todo != nil {
+		r.refresher.Trigger()
+	}
+	return todo, err
+}
+
+// SetTags replaces a todo's tags and schedules a search index refresh
+func (r *SearchRefreshingRepository) SetTags(id int64, tags []string) (*models.Todo, error) {
+	todo, err := r.TodoRepositoryInterface.SetTags(id, tags)
+	if err == nil && todo != nil {
+		r.refresher.Trigger()
+	}
+	return todo, err
+}
+
+// BatchUpdatePriority sets the priority of todos and schedules a search
+// index refresh
+func (r *SearchRefreshingRepository) BatchUpdatePriority(ids []int64, priority int) ([]int64, error) {
+	updated, err := r.TodoRepositoryInterface.BatchUpdatePriority(ids, priority)
+	if err == nil && len(updated) > 0 {
+		r.refresher.Trigger()
+	}
+	return updated, err
+}
+
+// BatchUpdateCategory files todos under a category and schedules a search
+// index refresh
+func (r *SearchRefreshingRepository) BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error) {
+	updated, err := r.TodoRepositoryInterface.BatchUpdateCategory(ids, categoryID)
+	if err == nil && len(updated) > 0 {
+		r.refresher.Trigger()
+	}
+	return updated, err
+}
+
+// SetPinned pins or unpins a todo and schedules a search index refresh
+func (r *SearchRefreshingRepository) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	todo, err := r.TodoRepositoryInterface.SetPinned(id, pinned)
+	if err == nil && todo != nil {
+		r.refresher.Trigger()
+	}
+	return todo, err
+}
+
+// Snooze pushes back a todo's due date and schedules a search index refresh
+func (r *SearchRefreshingRepository) Snooze(id int64, days int) (*models.Todo, error) {
+	todo, err := r.TodoRepositoryInterface.Snooze(id, days)
+	if err == nil && todo != nil {
+		r.refresher.Trigger()
+	}
+	return todo, err
+}
+
+// ShiftDueDates moves the due dates of todos and, when any moved, schedules
+// a search index refresh
+func (r *SearchRefreshingRepository) ShiftDueDates(ids []int64, days int) ([]int64, []int64, error) {
+	shifted, unchanged, err := r.TodoRepositoryInterface.ShiftDueDates(ids, days)
+	if err == nil && len(shifted) > 0 {
+		r.refresher.Trigger()
+	}
+	return shifted, unchanged, err
+}
+
+// Delete deletes a todo and schedules a search index refresh
+func (r *SearchRefreshingRepository) Delete(id int64) (*models.Todo, error) {
+	deleted, err := r.TodoRepositoryInterface.Delete(id)
+	if err == nil && deleted != nil {
+		r.refresher.Trigger()
+	}
+	return deleted, err
+}
+
+// DeleteAllCompleted deletes every completed todo and, when any were
+// deleted, schedules a search index refresh
+func (r *SearchRefreshingRepository) DeleteAllCompleted() (int64, error) {
+	count, err := r.TodoRepositoryInterface.DeleteAllCompleted()
+	if err == nil && count > 0 {
+		r.refresher.Trigger()
+	}
+	return count, err
+}
+
+// VacuumCompleted deletes old completed todos and, when any were deleted,
+// schedules a search index refresh
+func (r *SearchRefreshingRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	count, err := r.TodoRepositoryInterface.VacuumCompleted(olderThanDays)
+	if err == nil && count > 0 {
+		r.refresher.Trigger()
+	}
+	return count, err
+}
+
+// MoveTodos files todos under a category and, when any moved, schedules a
+// search index refresh
+func (r *SearchRefreshingRepository) MoveTodos(ids []int64, categoryID *int64) (int64, error) {
+	count, err := r.TodoRepositoryInterface.MoveTodos(ids, categoryID)
+	if err == nil && count > 0 {
+		r.refresher.Trigger()
+	}
+	return count, err
+}
+
+// CompleteAllInCategory completes the todos in a category and, when any were
+// completed, schedules a search index refresh
+func (r *SearchRefreshingRepository) CompleteAllInCategory(categoryID int64) ([]int64, []int64, error) {
+	completed, blocked, err := r.TodoRepositoryInterface.CompleteAllInCategory(categoryID)
+	if err == nil && len(completed) > 0 {
+		r.refresher.Trigger()
+	}
+	return completed, blocked, err
+}
+
+// Reorder sets the position of todos and, when any moved, schedules a
+// search index refresh
+func (r *SearchRefreshingRepository) Reorder(order []models.TodoPosition) (int64, error) {
+	count, err := r.TodoRepositoryInterface.Reorder(order)
+	if err == nil && count > 0 {
+		r.refresher.Trigger()
+	}
+	return count, err
+}
+
+// UpdateWith updates a todo in place and schedules a search index refresh
+func (r *SearchRefreshingRepository) UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error) {
+	updated, err := r.TodoRepositoryInterface.UpdateWith(id, apply)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return updated, err
+}
+
+// DuplicateCategory copies a category and its todos and schedules a search
+// index refresh
+func (r *SearchRefreshingRepository) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	copied, err := r.TodoRepositoryInterface.DuplicateCategory(id)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return copied, err
+}
+
+// ConvertToCategory turns a todo into a category and schedules a search
+// index refresh
+func (r *SearchRefreshingRepository) ConvertToCategory(id int64) (*models.Category, error) {
+	category, err := r.TodoRepositoryInterface.ConvertToCategory(id)
+	if err == nil {
+		r.refresher.Trigger()
+	}
+	return category, err
+}
+
+// SetArchived archives or unarchives todos and, when any moved, schedules a
+// search index refresh
+func (r *SearchRefreshingRepository) SetArchived(ids []int64, archived bool) ([]int64, []int64, error) {
+	moved, unchanged, err := r.TodoRepositoryInterface.SetArchived(ids, archived)
+	if err == nil && len(moved) > 0 {
+		r.refresher.Trigger()
+	}
+	return moved, unchanged, err
+}
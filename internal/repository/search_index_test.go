@@ -0,0 +1,85 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestSearchRefreshingRepositoryRefreshesAfterWrites(t *testing.T) {
+	refreshed := make(chan struct{}, 1)
+	refresher := repository.NewSearchIndexRefresher(func(ctx context.Context) error {
+		refreshed <- struct{}{}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go refresher.Run(ctx)
+
+	repo := repository.NewSearchRefreshingRepository(testhelpers.NewMemoryRepository(), refresher)
+
+	todo, err := repo.Create(&models.CreateTodoRequest{Title: "Renew passport"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	waitForRefresh(t, refreshed)
+
+	todo.Title = "Renew driving licence"
+	if _, err := repo.Update(todo); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	waitForRefresh(t, refreshed)
+
+	if _, err := repo.SetTags(todo.ID, []string{"admin"}); err != nil {
+		t.Fatalf("SetTags returned error: %v", err)
+	}
+	waitForRefresh(t, refreshed)
+
+	if _, _, err := repo.SetArchived([]int64{todo.ID}, true); err != nil {
+		t.Fatalf("SetArchived returned error: %v", err)
+	}
+	waitForRefresh(t, refreshed)
+
+	other, err := repo.Create(&models.CreateTodoRequest{Title: "Book dentist"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	waitForRefresh(t, refreshed)
+
+	if _, err := repo.Delete(other.ID); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	waitForRefresh(t, refreshed)
+}
+
+func TestSearchRefreshingRepositorySkipsReads(t *testing.T) {
+	refresher := repository.NewSearchIndexRefresher(func(ctx context.Context) error {
+		t.Error("refresh called after a read")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go refresher.Run(ctx)
+
+	repo := repository.NewSearchRefreshingRepository(testhelpers.NewMemoryRepository(), refresher)
+	if _, _, err := repo.GetAll(repository.TodoFilter{}); err != nil {
+		t.Fatalf("GetAll returned error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+}
+
+func waitForRefresh(t *testing.T, refreshed <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("search index was not refreshed")
+	}
+}
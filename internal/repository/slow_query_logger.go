@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+// SlowQueryLoggerRepository wraps a TodoRepositoryInterface and logs a
+// warning for every call that takes longer than its threshold
+type SlowQueryLoggerRepository struct {
+	inner     TodoRepositoryInterface
+	threshold time.Duration
+	logger    *slog.Logger
+}
+
+var _ TodoRepositoryInterface = (*SlowQueryLoggerRepository)(nil)
+
+// NewSlowQueryLoggerRepository creates a new SlowQueryLoggerRepository that
+// reports calls slower than threshold to logger
+func NewSlowQueryLoggerRepository(inner TodoRepositoryInterface, threshold time.Duration, logger *slog.Logger) *SlowQueryLoggerRepository {
+	return &SlowQueryLoggerRepository{
+		inner:     inner,
+		threshold: threshold,
+		logger:    logger,
+	}
+}
+
+// logSlow logs operation if more than the threshold has elapsed since start.
+// There are no user accounts, so entries carry no user ID.
+func (r *SlowQueryLoggerRepository) logSlow(operation string, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed <= r.threshold {
+		return
+	}
+
+	r.logger.Warn("slow repository call",
+		slog.String("operation", operation),
+		slog.Duration("duration", elapsed),
+		slog.Duration("threshold", r.threshold),
+	)
+}
+
+// Create calls the wrapped repository's Create
+func (r *SlowQueryLoggerRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+	defer r.logSlow("Create", time.Now())
+	return r.inner.Create(todo)
+}
+
+// Upsert calls the wrapped repository's Upsert
+func (r *SlowQueryLoggerRepository) Upsert(todo *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	defer r.logSlow("Upsert", time.Now())
+	return r.inner.Upsert(todo)
+}
+
+// BulkCreate calls the wrapped repository's BulkCreate
+func (r *SlowQueryLoggerRepository) BulkCreate(todos []*models.CreateTodoRequest) (int64, error) {
+	defer r.logSlow("BulkCreate", time.Now())
+	return r.inner.BulkCreate(todos)
+}
+
+// GetAll calls the wrapped repository's GetAll
+func (r *SlowQueryLoggerRepository) GetAll(filter TodoFilter) ([]*models.Todo, int64, error) {
+	defer r.logSlow("GetAll", time.Now())
+	return r.inner.GetAll(filter)
+}
+
+// StreamAll calls the wrapped repository's StreamAll
+func (r *SlowQueryLoggerRepository) StreamAll(ctx context.Context, filter TodoFilter, fn func(*models.Todo) error) error {
+	defer r.logSlow("StreamAll", time.Now())
+	return r.inner.StreamAll(ctx, filter, fn)
+}
+
+// StreamAllIDs calls the wrapped repository's StreamAllIDs
+func (r *SlowQueryLoggerRepository) StreamAllIDs(ctx context.Context, fn func(int64) error) error {
+	defer r.logSlow("StreamAllIDs", time.Now())
+	return r.inner.StreamAllIDs(ctx, fn)
+}
+
+// GetByID calls the wrapped repository's GetByID
+func (r *SlowQueryLoggerRepository) GetByID(id int64) (*models.Todo, error) {
+	defer r.logSlow("GetByID", time.Now())
+	return r.inner.GetByID(id)
+}
+
+// Update calls the wrapped repository's Update
+func (r *SlowQueryLoggerRepository) Update(todo *models.Todo) (*models.Todo, error) {
+	defer r.logSlow("Update", time.Now())
+	return r.inner.Update(todo)
+}
+
+// SetCompleted calls the wrapped repository's SetCompleted
+func (r *SlowQueryLoggerRepository) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	defer r.logSlow("SetCompleted", time.Now())
+	return r.inner.SetCompleted(id, completed)
+}
+
+// AppendNote calls the wrapped repository's AppendNote
+func (r *SlowQueryLoggerRepository) AppendNote(id int64, body string) (*models.Todo, error) {
+	defer r.logSlow("AppendNote", time.Now())
+	return r.inner.AppendNote(id, body)
+}
+
+// BatchUpdatePriority calls the wrapped repository's BatchUpdatePriority
+func (r *SlowQueryLoggerRepository) BatchUpdatePriority(ids []int64, priority int) ([]int64, error) {
+	defer r.logSlow("BatchUpdatePriority", time.Now())
+	return r.inner.BatchUpdatePriority(ids, priority)
+}
+
+// SetPinned calls the wrapped repository's SetPinned
+func (r *SlowQueryLoggerRepository) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	defer r.logSlow("SetPinned", time.Now())
+	return r.inner.SetPinned(id, pinned)
+}
+
+// Delete calls the wrapped repository's Delete
+func (r *SlowQueryLoggerRepository) Delete(id int64) (*models.Todo, error) {
+	defer r.logSlow("Delete", time.Now())
+	return r.inner.Delete(id)
+}
+
+// DeleteAllCompleted calls the wrapped repository's DeleteAllCompleted
+func (r *SlowQueryLoggerRepository) DeleteAllCompleted() (int64, error) {
+	defer r.logSlow("DeleteAllCompleted", time.Now())
+	return r.inner.DeleteAllCompleted()
+}
+
+// CreateCategory calls the wrapped repository's CreateCategory
+func (r *SlowQueryLoggerRepository) CreateCategory(name string, parentID *int64) (*models.Category, error) {
+	defer r.logSlow("CreateCategory", time.Now())
+	return r.inner.CreateCategory(name, parentID)
+}
+
+// GetAllCategories calls the wrapped repository's GetAllCategories
+func (r *SlowQueryLoggerRepository) GetAllCategories() ([]*models.Category, error) {
+	defer r.logSlow("GetAllCategories", time.Now())
+	return r.inner.GetAllCategories()
+}
+
+// GetCategoryByID calls the wrapped repository's GetCategoryByID
+func (r *SlowQueryLoggerRepository) GetCategoryByID(id int64) (*models.Category, error) {
+	defer r.logSlow("GetCategoryByID", time.Now())
+	return r.inner.GetCategoryByID(id)
+}
+
+// MoveTodos calls the wrapped repository's MoveTodos
+func (r *SlowQueryLoggerRepository) MoveTodos(ids []int64, categoryID *int64) (int64, error) {
+	defer r.logSlow("MoveTodos", time.Now())
+	return r.inner.MoveTodos(ids, categoryID)
+}
+
+// GetOverdueUnnotified calls the wrapped repository's GetOverdueUnnotified
+func (r *SlowQueryLoggerRepository) GetOverdueUnnotified() ([]*models.Todo, error) {
+	defer r.logSlow("GetOverdueUnnotified", time.Now())
+	return r.inner.GetOverdueUnnotified()
+}
+
+// MarkOverdueNotified calls the wrapped repository's MarkOverdueNotified
+func (r *SlowQueryLoggerRepository) MarkOverdueNotified(ids []int64) error {
+	defer r.logSlow("MarkOverdueNotified", time.Now())
+	return r.inner.MarkOverdueNotified(ids)
+}
+
+// GetTimezone calls the wrapped repository's GetTimezone
+func (r *SlowQueryLoggerRepository) GetTimezone() (string, error) {
+	defer r.logSlow("GetTimezone", time.Now())
+	return r.inner.GetTimezone()
+}
+
+// SetTimezone calls the wrapped repository's SetTimezone
+func (r *SlowQueryLoggerRepository) SetTimezone(tz string) error {
+	defer r.logSlow("SetTimezone", time.Now())
+	return r.inner.SetTimezone(tz)
+}
+
+// Snooze calls the wrapped repository's Snooze
+func (r *SlowQueryLoggerRepository) Snooze(id int64, days int) (*models.Todo, error) {
+	defer r.logSlow("Snooze", time.Now())
+	return r.inner.Snooze(id, days)
+}
+
+// SetTags calls the wrapped repository's SetTags
+func (r *SlowQueryLoggerRepository) SetTags(id int64, tags []string) (*models.Todo, error) {
+	defer r.logSlow("SetTags", time.Now())
+	return r.inner.SetTags(id, tags)
+}
+
+// GetCategoryTree calls the wrapped repository's GetCategoryTree
+func (r *SlowQueryLoggerRepository) GetCategoryTree() ([]*models.Category, error) {
+	defer r.logSlow("GetCategoryTree", time.Now())
+	return r.inner.GetCategoryTree()
+}
+
+// GetCategoryDepth calls the wrapped repository's GetCategoryDepth
+func (r *SlowQueryLoggerRepository) GetCategoryDepth(id int64) (int, error) {
+	defer r.logSlow("GetCategoryDepth", time.Now())
+	return r.inner.GetCategoryDepth(id)
+}
+
+// GetCompletionTrend calls the wrapped repository's GetCompletionTrend
+func (r *SlowQueryLoggerRepository) GetCompletionTrend(period string, days int) ([]*models.TrendPoint, error) {
+	defer r.logSlow("GetCompletionTrend", time.Now())
+	return r.inner.GetCompletionTrend(period, days)
+}
+
+// CountByPriority calls the wrapped repository's CountByPriority
+func (r *SlowQueryLoggerRepository) CountByPriority() (map[int]int64, error) {
+	defer r.logSlow("CountByPriority", time.Now())
+	return r.inner.CountByPriority()
+}
+
+// DuplicateCategory calls the wrapped repository's DuplicateCategory
+func (r *SlowQueryLoggerRepository) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	defer r.logSlow("DuplicateCategory", time.Now())
+	return r.inner.DuplicateCategory(id)
+}
+
+// Reorder calls the wrapped repository's Reorder
+func (r *SlowQueryLoggerRepository) Reorder(order []models.TodoPosition) (int64, error) {
+	defer r.logSlow("Reorder", time.Now())
+	return r.inner.Reorder(order)
+}
+
+// GetCompletionStreak calls the wrapped repository's GetCompletionStreak
+func (r *SlowQueryLoggerRepository) GetCompletionStreak(timezone string) (*models.Streak, error) {
+	defer r.logSlow("GetCompletionStreak", time.Now())
+	return r.inner.GetCompletionStreak(timezone)
+}
+
+// GetHighlights calls the wrapped repository's GetHighlights
+func (r *SlowQueryLoggerRepository) GetHighlights(ids []int64, query string) (map[int64]map[string]string, error) {
+	defer r.logSlow("GetHighlights", time.Now())
+	return r.inner.GetHighlights(ids, query)
+}
+
+// GetRelated calls the wrapped repository's GetRelated
+func (r *SlowQueryLoggerRepository) GetRelated(id int64, limit int) ([]*models.Todo, error) {
+	defer r.logSlow("GetRelated", time.Now())
+	return r.inner.GetRelated(id, limit)
+}
+
+// ListSavedSearches calls the wrapped repository's ListSavedSearches
+func (r *SlowQueryLoggerRepository) ListSavedSearches() ([]*models.SavedSearch, error) {
+	defer r.logSlow("ListSavedSearches", time.Now())
+	return r.inner.ListSavedSearches()
+}
+
+// GetSavedSearch calls the wrapped repository's GetSavedSearch
+func (r *SlowQueryLoggerRepository) GetSavedSearch(name string) (*models.SavedSearch, error) {
+	defer r.logSlow("GetSavedSearch", time.Now())
+	return r.inner.GetSavedSearch(name)
+}
+
+// CreateSavedSearch calls the wrapped repository's CreateSavedSearch
+func (r *SlowQueryLoggerRepository) CreateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	defer r.logSlow("CreateSavedSearch", time.Now())
+	return r.inner.CreateSavedSearch(name, params)
+}
+
+// UpdateSavedSearch calls the wrapped repository's UpdateSavedSearch
+func (r *SlowQueryLoggerRepository) UpdateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	defer r.logSlow("UpdateSavedSearch", time.Now())
+	return r.inner.UpdateSavedSearch(name, params)
+}
+
+// DeleteSavedSearch calls the wrapped repository's DeleteSavedSearch
+func (r *SlowQueryLoggerRepository) DeleteSavedSearch(name string) error {
+	defer r.logSlow("DeleteSavedSearch", time.Now())
+	return r.inner.DeleteSavedSearch(name)
+}
+
+// ExistingTags calls the wrapped repository's ExistingTags
+func (r *SlowQueryLoggerRepository) ExistingTags(names []string) ([]string, error) {
+	defer r.logSlow("ExistingTags", time.Now())
+	return r.inner.ExistingTags(names)
+}
+
+// GetTimeline calls the wrapped repository's GetTimeline
+func (r *SlowQueryLoggerRepository) GetTimeline(todoID int64) ([]*models.TimelineEvent, error) {
+	defer r.logSlow("GetTimeline", time.Now())
+	return r.inner.GetTimeline(todoID)
+}
+
+// ImportGroups calls the wrapped repository's ImportGroups
+func (r *SlowQueryLoggerRepository) ImportGroups(groups []models.ImportGroup) ([]string, int64, error) {
+	defer r.logSlow("ImportGroups", time.Now())
+	return r.inner.ImportGroups(groups)
+}
+
+// ReleaseReminders calls the wrapped repository's ReleaseReminders
+func (r *SlowQueryLoggerRepository) ReleaseReminders(ids []int64) error {
+	defer r.logSlow("ReleaseReminders", time.Now())
+	return r.inner.ReleaseReminders(ids)
+}
+
+// BatchUpdateCategory calls the wrapped repository's BatchUpdateCategory
+func (r *SlowQueryLoggerRepository) BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error) {
+	defer r.logSlow("BatchUpdateCategory", time.Now())
+	return r.inner.BatchUpdateCategory(ids, categoryID)
+}
+
+// GetDeletedAt calls the wrapped repository's GetDeletedAt
+func (r *SlowQueryLoggerRepository) GetDeletedAt(id int64) (*time.Time, error) {
+	defer r.logSlow("GetDeletedAt", time.Now())
+	return r.inner.GetDeletedAt(id)
+}
+
+// LastModified calls the wrapped repository's LastModified
+func (r *SlowQueryLoggerRepository) LastModified() (time.Time, error) {
+	defer r.logSlow("LastModified", time.Now())
+	return r.inner.LastModified()
+}
+
+// GetDueWithinWindow calls the wrapped repository's GetDueWithinWindow
+func (r *SlowQueryLoggerRepository) GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error) {
+	defer r.logSlow("GetDueWithinWindow", time.Now())
+	return r.inner.GetDueWithinWindow(from, to)
+}
+
+// CountByCompletion calls the wrapped repository's CountByCompletion
+func (r *SlowQueryLoggerRepository) CountByCompletion() (*models.TodoCount, error) {
+	defer r.logSlow("CountByCompletion", time.Now())
+	return r.inner.CountByCompletion()
+}
+
+// GetAllByCategory calls the wrapped repository's GetAllByCategory
+func (r *SlowQueryLoggerRepository) GetAllByCategory() ([]*models.Todo, error) {
+	defer r.logSlow("GetAllByCategory", time.Now())
+	return r.inner.GetAllByCategory()
+}
+
+// GetCategoryAncestors calls the wrapped repository's GetCategoryAncestors
+func (r *SlowQueryLoggerRepository) GetCategoryAncestors(id int64) ([]*models.Category, error) {
+	defer r.logSlow("GetCategoryAncestors", time.Now())
+	return r.inner.GetCategoryAncestors(id)
+}
+
+// ConvertToCategory calls the wrapped repository's ConvertToCategory
+func (r *SlowQueryLoggerRepository) ConvertToCategory(id int64) (*models.Category, error) {
+	defer r.logSlow("ConvertToCategory", time.Now())
+	return r.inner.ConvertToCategory(id)
+}
+
+// GetCompletedBetween calls the wrapped repository's GetCompletedBetween
+func (r *SlowQueryLoggerRepository) GetCompletedBetween(from, to time.Time) ([]*models.Todo, error) {
+	defer r.logSlow("GetCompletedBetween", time.Now())
+	return r.inner.GetCompletedBetween(from, to)
+}
+
+// GetDueBetween calls the wrapped repository's GetDueBetween
+func (r *SlowQueryLoggerRepository) GetDueBetween(from, to time.Time) ([]*models.Todo, error) {
+	defer r.logSlow("GetDueBetween", time.Now())
+	return r.inner.GetDueBetween(from, to)
+}
+
+// GetPreferences calls the wrapped repository's GetPreferences
+func (r *SlowQueryLoggerRepository) GetPreferences() (*models.Preferences, error) {
+	defer r.logSlow("GetPreferences", time.Now())
+	return r.inner.GetPreferences()
+}
+
+// SetPreferences calls the wrapped repository's SetPreferences
+func (r *SlowQueryLoggerRepository) SetPreferences(prefs *models.Preferences) error {
+	defer r.logSlow("SetPreferences", time.Now())
+	return r.inner.SetPreferences(prefs)
+}
+
+// VacuumCompleted calls the wrapped repository's VacuumCompleted
+func (r *SlowQueryLoggerRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	defer r.logSlow("VacuumCompleted", time.Now())
+	return r.inner.VacuumCompleted(olderThanDays)
+}
+
+// UpdateWith calls the wrapped repository's UpdateWith
+func (r *SlowQueryLoggerRepository) UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error) {
+	defer r.logSlow("UpdateWith", time.Now())
+	return r.inner.UpdateWith(id, apply)
+}
+
+// CompleteAllInCategory calls the wrapped repository's CompleteAllInCategory
+func (r *SlowQueryLoggerRepository) CompleteAllInCategory(categoryID int64) ([]int64, []int64, error) {
+	defer r.logSlow("CompleteAllInCategory", time.Now())
+	return r.inner.CompleteAllInCategory(categoryID)
+}
+
+// AddReaction calls the wrapped repository's AddReaction
+func (r *SlowQueryLoggerRepository) AddReaction(todoID int64, emoji string) error {
+	defer r.logSlow("AddReaction", time.Now())
+	return r.inner.AddReaction(todoID, emoji)
+}
+
+// RemoveReaction calls the wrapped repository's RemoveReaction
+func (r *SlowQueryLoggerRepository) RemoveReaction(todoID int64, emoji string) error {
+	defer r.logSlow("RemoveReaction", time.Now())
+	return r.inner.RemoveReaction(todoID, emoji)
+}
+
+// GetReactions calls the wrapped repository's GetReactions
+func (r *SlowQueryLoggerRepository) GetReactions(todoID int64) ([]models.Reaction, error) {
+	defer r.logSlow("GetReactions", time.Now())
+	return r.inner.GetReactions(todoID)
+}
+
+// SetArchived calls the wrapped repository's SetArchived
+func (r *SlowQueryLoggerRepository) SetArchived(ids []int64, archived bool) ([]int64, []int64, error) {
+	defer r.logSlow("SetArchived", time.Now())
+	return r.inner.SetArchived(ids, archived)
+}
+
+// ShiftDueDates calls the wrapped repository's ShiftDueDates
+func (r *SlowQueryLoggerRepository) ShiftDueDates(ids []int64, days int) ([]int64, []int64, error) {
+	defer r.logSlow("ShiftDueDates", time.Now())
+	return r.inner.ShiftDueDates(ids, days)
+}
+
+// AddDependency calls the wrapped repository's AddDependency
+func (r *SlowQueryLoggerRepository) AddDependency(todoID, blockingID int64) error {
+	defer r.logSlow("AddDependency", time.Now())
+	return r.inner.AddDependency(todoID, blockingID)
+}
+
+// RemoveDependency calls the wrapped repository's RemoveDependency
+func (r *SlowQueryLoggerRepository) RemoveDependency(todoID, blockingID int64) error {
+	defer r.logSlow("RemoveDependency", time.Now())
+	return r.inner.RemoveDependency(todoID, blockingID)
+}
+
+// GetBlockers calls the wrapped repository's GetBlockers
+func (r *SlowQueryLoggerRepository) GetBlockers(todoID int64) ([]models.Blocker, error) {
+	defer r.logSlow("GetBlockers", time.Now())
+	return r.inner.GetBlockers(todoID)
+}
+
+// GetActivity calls the wrapped repository's GetActivity
+func (r *SlowQueryLoggerRepository) GetActivity(before int64, limit int) ([]*models.ActivityEntry, error) {
+	defer r.logSlow("GetActivity", time.Now())
+	return r.inner.GetActivity(before, limit)
+}
@@ -0,0 +1,34 @@
+package repository_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+func TestSlowQueryLoggerLogsOnlySlowCalls(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	fast := repository.NewSlowQueryLoggerRepository(testhelpers.NewMemoryRepository(), time.Hour, logger)
+	if _, err := fast.GetAllCategories(); err != nil {
+		t.Fatalf("GetAllCategories returned error: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("fast call was logged: %s", buf.String())
+	}
+
+	// Every call exceeds a negative threshold
+	slow := repository.NewSlowQueryLoggerRepository(testhelpers.NewMemoryRepository(), -1, logger)
+	if _, err := slow.GetAllCategories(); err != nil {
+		t.Fatalf("GetAllCategories returned error: %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "level=WARN") || !strings.Contains(got, "operation=GetAllCategories") {
+		t.Errorf("log = %q, want a warning for GetAllCategories", got)
+	}
+}
@@ -1,67 +1,1931 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
+	"github.com/yourusername/todo-api/internal/db"
 	"github.com/yourusername/todo-api/internal/models"
 )
 
-// TodoRepository handles database operations for todos
-type TodoRepository struct {
-	db *sql.DB
+// MaxBreadcrumbDepth is the most categories GetCategoryAncestors follows
+// up from a todo's category, guarding against a cycle of parents
+const MaxBreadcrumbDepth = 10
+
+// MaxPinnedTodos is the maximum number of todos that can be pinned at once
+const MaxPinnedTodos = 10
+
+// ErrPinLimitReached is returned by SetPinned when MaxPinnedTodos are
+// already pinned
+var ErrPinLimitReached = errors.New("pinned todo limit reached")
+
+// MaxReactionsPerTodo is the maximum number of distinct emoji a todo can
+// be reacted to with
+const MaxReactionsPerTodo = 20
+
+// ErrReactionLimitReached is returned by AddReaction when a todo already
+// has MaxReactionsPerTodo distinct reactions
+var ErrReactionLimitReached = errors.New("reaction limit reached")
+
+// ErrBlocked is returned by SetCompleted when a todo cannot be completed
+// because a todo it depends on is not completed
+var ErrBlocked = errors.New("todo is blocked by incomplete todos")
+
+// ErrDependencyCycle is returned by AddDependency when the blocking todo
+// already depends on the dependent todo, directly or through others
+var ErrDependencyCycle = errors.New("dependency would create a cycle")
+
+// ErrTodoLimitExceeded is returned by Create, Upsert, BulkCreate and
+// DuplicateCategory when the todos they would add do not fit under the
+// configured maximum
+var ErrTodoLimitExceeded = errors.New("todo limit exceeded")
+
+// todoLimitLockQuery takes the transaction-scoped advisory lock that every
+// insert holds while a todo limit is configured. Without it two
+// transactions could both count the todos before either inserts, and
+// together go over the limit.
+const todoLimitLockQuery = `SELECT pg_advisory_xact_lock(hashtext('todos.limit'))`
+
+// countTodosQuery counts the todos for the limit, leaving out the todo
+// with external ID $1 when it is not null, since Upsert updates that todo
+// rather than adding another
+const countTodosQuery = `SELECT COUNT(*) FROM todos WHERE $1::text IS NULL OR external_id IS DISTINCT FROM $1`
+
+// ErrNotFound is returned by every method that reads or changes a single
+// todo when it does not exist, and by Reorder when one of the todos does not
+// exist
+var ErrNotFound = errors.New("todo not found")
+
+// ErrCategoryNotFound is returned when a category looked up, duplicated or
+// moved todos into does not exist
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrSavedSearchExists is returned by CreateSavedSearch when a saved search
+// with the same name already exists
+var ErrSavedSearchExists = errors.New("saved search already exists")
+
+// ErrSavedSearchNotFound is returned by GetSavedSearch, UpdateSavedSearch and
+// DeleteSavedSearch when there is no saved search with the name
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+
+// ErrCategoryTooDeep is returned by ConvertToCategory when the todo's
+// category is already nested models.MaxCategoryDepth deep, so the new
+// category would go past the limit
+var ErrCategoryTooDeep = errors.New("category nesting too deep")
+
+// TodoRepositoryInterface is the set of todo operations used by the handlers.
+// It is implemented by TodoRepository (database/sql with lib/pq) and
+// PgxTodoRepository (pgx with pgxpool).
+type TodoRepositoryInterface interface {
+	Create(todo *models.CreateTodoRequest) (*models.Todo, error)
+	Upsert(todo *models.CreateTodoRequest) (*models.Todo, bool, error)
+	BulkCreate(todos []*models.CreateTodoRequest) (int64, error)
+	ImportGroups(groups []models.ImportGroup) ([]string, int64, error)
+	GetAll(filter TodoFilter) ([]*models.Todo, int64, error)
+	GetHighlights(ids []int64, query string) (map[int64]map[string]string, error)
+	GetRelated(id int64, limit int) ([]*models.Todo, error)
+	GetTimeline(todoID int64) ([]*models.TimelineEvent, error)
+	GetActivity(before int64, limit int) ([]*models.ActivityEntry, error)
+	GetDeletedAt(id int64) (*time.Time, error)
+	LastModified() (time.Time, error)
+	StreamAll(ctx context.Context, filter TodoFilter, fn func(*models.Todo) error) error
+	GetAllByCategory() ([]*models.Todo, error)
+	StreamAllIDs(ctx context.Context, fn func(int64) error) error
+	GetByID(id int64) (*models.Todo, error)
+	Update(todo *models.Todo) (*models.Todo, error)
+	UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error)
+	SetCompleted(id int64, completed bool) (*models.Todo, error)
+	AppendNote(id int64, body string) (*models.Todo, error)
+	SetTags(id int64, tags []string) (*models.Todo, error)
+	ExistingTags(names []string) ([]string, error)
+	BatchUpdatePriority(ids []int64, priority int) ([]int64, error)
+	BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error)
+	SetPinned(id int64, pinned bool) (*models.Todo, error)
+	AddReaction(todoID int64, emoji string) error
+	RemoveReaction(todoID int64, emoji string) error
+	GetReactions(todoID int64) ([]models.Reaction, error)
+	AddDependency(todoID, blockingID int64) error
+	RemoveDependency(todoID, blockingID int64) error
+	GetBlockers(todoID int64) ([]models.Blocker, error)
+	Snooze(id int64, days int) (*models.Todo, error)
+	ShiftDueDates(ids []int64, days int) ([]int64, []int64, error)
+	Delete(id int64) (*models.Todo, error)
+	DeleteAllCompleted() (int64, error)
+	VacuumCompleted(olderThanDays int) (int64, error)
+	SetArchived(ids []int64, archived bool) ([]int64, []int64, error)
+	CreateCategory(name string, parentID *int64) (*models.Category, error)
+	GetAllCategories() ([]*models.Category, error)
+	GetCategoryByID(id int64) (*models.Category, error)
+	GetCategoryTree() ([]*models.Category, error)
+	GetCategoryDepth(id int64) (int, error)
+	GetCategoryAncestors(id int64) ([]*models.Category, error)
+	MoveTodos(ids []int64, categoryID *int64) (int64, error)
+	CompleteAllInCategory(categoryID int64) ([]int64, []int64, error)
+	Reorder(order []models.TodoPosition) (int64, error)
+	DuplicateCategory(id int64) (*models.CategoryWithCount, error)
+	ConvertToCategory(id int64) (*models.Category, error)
+	GetOverdueUnnotified() ([]*models.Todo, error)
+	MarkOverdueNotified(ids []int64) error
+	GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error)
+	ReleaseReminders(ids []int64) error
+	GetCompletedBetween(from, to time.Time) ([]*models.Todo, error)
+	GetDueBetween(from, to time.Time) ([]*models.Todo, error)
+	GetTimezone() (string, error)
+	SetTimezone(tz string) error
+	GetPreferences() (*models.Preferences, error)
+	SetPreferences(prefs *models.Preferences) error
+	GetCompletionTrend(period string, days int) ([]*models.TrendPoint, error)
+	GetCompletionStreak(timezone string) (*models.Streak, error)
+	CountByPriority() (map[int]int64, error)
+	CountByCompletion() (*models.TodoCount, error)
+	ListSavedSearches() ([]*models.SavedSearch, error)
+	GetSavedSearch(name string) (*models.SavedSearch, error)
+	CreateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error)
+	UpdateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error)
+	DeleteSavedSearch(name string) error
+}
+
+// Queries run on nearly every request, which NewTodoRepository can run as
+// prepared statements
+const (
+	createTodoQuery = `
+		INSERT INTO todos (title, description, priority, external_id, due_at, completed, completed_at, category_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $6::boolean THEN COALESCE($7::timestamp, NOW()) END, $8, NOW(), NOW())
+		RETURNING ` + todoColumns
+
+	getByIDQuery = `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE id = $1
+	`
+
+	updateTodoQuery = `
+		UPDATE todos
+		SET title = $1, description = $2, completed = $3, priority = $4,
+			due_at = $5,
+			overdue_notified_at = CASE WHEN due_at IS DISTINCT FROM $5 THEN NULL ELSE overdue_notified_at END,
+			reminded_at = CASE WHEN due_at IS DISTINCT FROM $5 THEN NULL ELSE reminded_at END
+		WHERE id = $6
+		RETURNING ` + todoColumns
+
+	deleteTodoQuery = `DELETE FROM todos WHERE id = $1 RETURNING ` + todoColumns
+
+	// lockTodoQuery reads a todo for UpdateWith, locking it until the end of
+	// the transaction. FOR NO KEY UPDATE blocks deletes and other updates
+	// of the row, like FOR SHARE, but two updaters queue up behind each
+	// other rather than both taking a share lock and deadlocking when they
+	// upgrade it to write.
+	lockTodoQuery = getByIDQuery + ` FOR NO KEY UPDATE`
+)
+
+// PreparedQueries returns the SQL of the queries worth preparing once with
+// db.Prepare. GetAll builds its query from the filter, so only the
+// unfiltered list, whole or paged, is included.
+func PreparedQueries() []string {
+	unpaged, _ := getAllQuery(TodoFilter{})
+	paged, _ := getAllQuery(TodoFilter{Limit: 1})
+	return []string{createTodoQuery, getByIDQuery, updateTodoQuery, deleteTodoQuery, unpaged, paged}
+}
+
+// reactionLockQuery takes the transaction-scoped advisory lock on todo $1's
+// reactions that AddReaction holds while it counts and inserts. Without it
+// two transactions could each count fewer than MaxReactionsPerTodo and
+// together go over.
+const reactionLockQuery = `SELECT pg_advisory_xact_lock(hashtext('todo_reactions'), $1::integer)`
+
+// addReactionQuery adds a reaction to a todo that exists and has fewer
+// than $3 reactions. It inserts nothing if the reaction is already there.
+const addReactionQuery = `
+	INSERT INTO todo_reactions (todo_id, emoji)
+	SELECT id, $2 FROM todos
+	WHERE id = $1 AND (SELECT COUNT(*) FROM todo_reactions WHERE todo_id = $1) < $3
+	ON CONFLICT DO NOTHING
+`
+
+// reactionExistsQuery reports whether a todo has a reaction
+const reactionExistsQuery = `SELECT EXISTS (SELECT 1 FROM todo_reactions WHERE todo_id = $1 AND emoji = $2)`
+
+// removeReactionQuery removes a reaction from a todo
+const removeReactionQuery = `DELETE FROM todo_reactions WHERE todo_id = $1 AND emoji = $2`
+
+// getReactionsQuery counts a todo's reactions by emoji, oldest first
+const getReactionsQuery = `
+	SELECT emoji, COUNT(*)
+	FROM todo_reactions
+	WHERE todo_id = $1
+	GROUP BY emoji
+	ORDER BY MIN(created_at), emoji
+`
+
+// setCompletedQuery sets whether a todo is completed. It updates nothing
+// when completing a todo that is blocked by one that is not completed yet.
+const setCompletedQuery = `
+	UPDATE todos
+	SET completed = $1
+	WHERE id = $2
+		AND (NOT $1::boolean OR completed OR NOT EXISTS (
+			SELECT 1 FROM todo_dependencies d
+			JOIN todos blocking ON blocking.id = d.blocking_todo_id
+			WHERE d.dependent_todo_id = $2 AND NOT blocking.completed AND blocking.archived_at IS NULL
+		))
+	RETURNING ` + todoColumns
+
+// addDependencyQuery makes todo $1 depend on todo $2 when both exist and $2
+// does not already depend on $1, directly or through others. It inserts
+// nothing if the dependency is already there.
+const addDependencyQuery = `
+	WITH RECURSIVE upstream AS (
+		SELECT blocking_todo_id AS id FROM todo_dependencies WHERE dependent_todo_id = $2
+		UNION
+		SELECT d.blocking_todo_id FROM todo_dependencies d JOIN upstream u ON d.dependent_todo_id = u.id
+	)
+	INSERT INTO todo_dependencies (dependent_todo_id, blocking_todo_id)
+	SELECT dependent.id, blocking.id
+	FROM todos dependent, todos blocking
+	WHERE dependent.id = $1 AND blocking.id = $2
+		AND NOT EXISTS (SELECT 1 FROM upstream WHERE id = $1)
+	ON CONFLICT DO NOTHING
+`
+
+// pinLockQuery takes the transaction-scoped advisory lock that SetPinned
+// holds while it counts and pins. Without it two transactions could each
+// count fewer than MaxPinnedTodos pinned and together go over.
+const pinLockQuery = `SELECT pg_advisory_xact_lock(hashtext('todos.pinned'))`
+
+// setPinnedQuery pins or unpins todo $2, pinning only while fewer than $3
+// unarchived todos are pinned
+const setPinnedQuery = `
+	UPDATE todos
+	SET pinned = $1
+	WHERE id = $2
+		AND (NOT $1::boolean OR pinned OR (SELECT COUNT(*) FROM todos WHERE pinned AND archived_at IS NULL) < $3)
+	RETURNING ` + todoColumns + `
+`
+
+// dependencyLockQuery takes the transaction-scoped advisory lock that
+// AddDependency holds while it inserts. Without it two transactions could
+// each check for a cycle before either inserts, and together close one.
+const dependencyLockQuery = `SELECT pg_advisory_xact_lock(hashtext('todo_dependencies'))`
+
+// blockedQuery reports whether todo $1 depends on a todo that is neither
+// completed nor archived
+const blockedQuery = `
+	SELECT EXISTS (
+		SELECT 1 FROM todo_dependencies d
+		JOIN todos blocking ON blocking.id = d.blocking_todo_id
+		WHERE d.dependent_todo_id = $1 AND NOT blocking.completed AND blocking.archived_at IS NULL
+	)
+`
+
+// dependencyExistsQuery reports whether todo $1 depends on todo $2
+const dependencyExistsQuery = `SELECT EXISTS (SELECT 1 FROM todo_dependencies WHERE dependent_todo_id = $1 AND blocking_todo_id = $2)`
+
+// removeDependencyQuery stops todo $1 depending on todo $2
+const removeDependencyQuery = `DELETE FROM todo_dependencies WHERE dependent_todo_id = $1 AND blocking_todo_id = $2`
+
+// getBlockersQuery lists the unarchived todos a todo depends on, oldest
+// dependency first
+const getBlockersQuery = `
+	SELECT t.id, t.title, t.completed
+	FROM todo_dependencies d
+	JOIN todos t ON t.id = d.blocking_todo_id
+	WHERE d.dependent_todo_id = $1 AND t.archived_at IS NULL
+	ORDER BY d.created_at, t.id
+`
+
+// getActivityQuery lists up to $2 activity log entries across all todos,
+// newest first, starting below ID $1 unless it is 0. Archived todos keep
+// their title in todos_archive.
+const getActivityQuery = `
+	SELECT ` + activityColumns + `
+	FROM activity_logs
+	LEFT JOIN todos ON todos.id = activity_logs.todo_id
+	LEFT JOIN todos_archive ON todos_archive.id = activity_logs.todo_id
+	WHERE $1::bigint = 0 OR activity_logs.id < $1
+	ORDER BY activity_logs.id DESC
+	LIMIT $2
+`
+
+// completeAllInCategoryQuery completes the incomplete, unarchived todos in a
+// category that are not blocked by an incomplete todo, like
+// setCompletedQuery. It returns the ID of every such todo with true if it
+// was completed and false if it is blocked.
+const completeAllInCategoryQuery = `
+	WITH completed AS (
+		UPDATE todos
+		SET completed = true
+		WHERE category_id = $1 AND NOT completed AND archived_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM todo_dependencies d
+			JOIN todos blocking ON blocking.id = d.blocking_todo_id
+			WHERE d.dependent_todo_id = todos.id AND NOT blocking.completed AND blocking.archived_at IS NULL
+		)
+		RETURNING id
+	)
+	SELECT id, true FROM completed
+	UNION ALL
+	SELECT id, false FROM todos
+	WHERE category_id = $1 AND NOT completed AND archived_at IS NULL AND id NOT IN (SELECT id FROM completed)
+	ORDER BY id
+`
+
+// categoryDepthQuery returns how deeply category $1 is nested, 1 for a
+// top-level category, or 0 if it does not exist
+const categoryDepthQuery = `
+	WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id, 1 AS depth
+		FROM categories
+		WHERE id = $1
+		UNION ALL
+		SELECT c.id, c.parent_id, ancestors.depth + 1
+		FROM categories c
+		JOIN ancestors ON c.id = ancestors.parent_id
+	)
+	SELECT COALESCE(MAX(depth), 0) FROM ancestors
+`
+
+// VacuumBatchSize is how many todos VacuumCompleted archives per statement
+const VacuumBatchSize = 1000
+
+// vacuumBatchQuery moves up to $2 todos completed more than $1 days ago,
+// with their tag names, into todos_archive and returns how many it moved.
+// Every CTE sees the rows as they were before the DELETE, so the tags are
+// read before they cascade away.
+const vacuumBatchQuery = `
+	WITH batch AS (
+		SELECT id FROM todos
+		WHERE completed = true AND completed_at < NOW() - make_interval(days => $1)
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	), moved AS (
+		DELETE FROM todos WHERE id IN (SELECT id FROM batch)
+		RETURNING *
+	), archived AS (
+		INSERT INTO todos_archive SELECT * FROM moved
+		RETURNING id
+	), archived_tags AS (
+		INSERT INTO todo_tags_archive (todo_id, name)
+		SELECT todo_tags.todo_id, tags.name
+		FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id
+		WHERE todo_tags.todo_id IN (SELECT id FROM archived)
+	)
+	SELECT COUNT(*) FROM archived
+`
+
+// archiveTodosQuery stamps archived_at on the todos in $1 that are not
+// archived yet. Their tags, reactions and dependencies stay in place. It
+// returns each requested todo with whether it was archived; the last SELECT
+// sees the rows as they were before the UPDATE, so it finds only todos
+// archived earlier.
+const archiveTodosQuery = `
+	WITH archived AS (
+		UPDATE todos SET archived_at = NOW()
+		WHERE id = ANY($1) AND archived_at IS NULL
+		RETURNING id
+	)
+	SELECT id, true FROM archived
+	UNION ALL
+	SELECT id, false FROM todos WHERE id = ANY($1) AND archived_at IS NOT NULL
+`
+
+// unarchiveTodosQuery clears archived_at on the todos in $1. Like
+// archiveTodosQuery it returns each requested todo with whether it changed.
+const unarchiveTodosQuery = `
+	WITH unarchived AS (
+		UPDATE todos SET archived_at = NULL
+		WHERE id = ANY($1) AND archived_at IS NOT NULL
+		RETURNING id
+	)
+	SELECT id, true FROM unarchived
+	UNION ALL
+	SELECT id, false FROM todos WHERE id = ANY($1) AND archived_at IS NULL
+`
+
+// shiftDueDatesQuery moves the due date of every todo in $2 that has one by
+// $1 days, clearing its reminders like a snooze, and returns each requested
+// todo with whether it was shifted
+const shiftDueDatesQuery = `
+	WITH shifted AS (
+		UPDATE todos
+		SET due_at = due_at + make_interval(days => $1),
+			overdue_notified_at = NULL,
+			reminded_at = NULL
+		WHERE id = ANY($2) AND due_at IS NOT NULL
+		RETURNING id
+	)
+	SELECT id, true FROM shifted
+	UNION ALL
+	SELECT id, false FROM todos WHERE id = ANY($2) AND due_at IS NULL
+`
+
+// setArchivedQuery returns the query SetArchived runs
+func setArchivedQuery(archived bool) string {
+	if archived {
+		return archiveTodosQuery
+	}
+	return unarchiveTodosQuery
+}
+
+// getAllQuery builds the GetAll query for filter and its arguments
+func getAllQuery(filter TodoFilter) (string, []interface{}) {
+	where, args := filter.where()
+	order, orderArgs := filter.orderBy(len(args))
+	page, pageArgs := filter.page(len(args) + len(orderArgs))
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		` + where + `
+		` + order + `
+		` + page
+
+	return query, append(append(args, orderArgs...), pageArgs...)
+}
+
+// TodoRepository handles database operations for todos
+type TodoRepository struct {
+	db       dbtx
+	conn     *sql.DB
+	tx       *sql.Tx
+	stmts    *db.PreparedStatements
+	maxTodos int
+}
+
+// NewTodoRepository creates a new TodoRepository. Nothing adds todos
+// beyond maxTodos; zero or less means no limit. Queries found in
+// stmts run as prepared statements; stmts may be nil.
+func NewTodoRepository(conn *sql.DB, maxTodos int, stmts *db.PreparedStatements) *TodoRepository {
+	r := &TodoRepository{
+		conn:     conn,
+		stmts:    stmts,
+		maxTodos: maxTodos,
+	}
+	r.db = r.withStatements(conn, nil)
+	return r
+}
+
+// Create adds a new todo to the database
+func (r *TodoRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+	query := createTodoQuery
+
+	if r.maxTodos <= 0 {
+		return scanTodo(r.db.QueryRow(query, todo.Title, todo.Description, todo.Priority, todo.ExternalID, todo.DueAt, todo.Completed, todo.CompletedAt, todo.CategoryID))
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := r.reserveTodos(tx, 1, nil); err != nil {
+		return nil, err
+	}
+
+	newTodo, err := scanTodo(tx.QueryRow(query, todo.Title, todo.Description, todo.Priority, todo.ExternalID, todo.DueAt, todo.Completed, todo.CompletedAt, todo.CategoryID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return newTodo, nil
+}
+
+// reserveTodos fails with ErrTodoLimitExceeded unless n more todos fit
+// under the limit, not counting the todo with externalID if it is not nil.
+// It holds todoLimitLockQuery until tx ends, so tx must insert the todos
+// before committing. It does nothing without a limit.
+func (r *TodoRepository) reserveTodos(tx dbtx, n int, externalID *string) error {
+	if r.maxTodos <= 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(todoLimitLockQuery); err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow(countTodosQuery, externalID).Scan(&count); err != nil {
+		return err
+	}
+	if count+n > r.maxTodos {
+		return ErrTodoLimitExceeded
+	}
+	return nil
+}
+
+// Upsert inserts a todo, or updates the todo with the same external ID if
+// one exists. The completion state and category are only taken from todo on
+// insert. The returned bool is true when a new row was inserted.
+func (r *TodoRepository) Upsert(todo *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	query := `
+		INSERT INTO todos (title, description, priority, external_id, due_at, completed, completed_at, category_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CASE WHEN $6::boolean THEN COALESCE($7::timestamp, NOW()) END, $8, NOW(), NOW())
+		ON CONFLICT (external_id) DO UPDATE
+		SET title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			priority = EXCLUDED.priority,
+			due_at = EXCLUDED.due_at,
+			overdue_notified_at = CASE
+				WHEN todos.due_at IS DISTINCT FROM EXCLUDED.due_at THEN NULL
+				ELSE todos.overdue_notified_at
+			END,
+			reminded_at = CASE
+				WHEN todos.due_at IS DISTINCT FROM EXCLUDED.due_at THEN NULL
+				ELSE todos.reminded_at
+			END
+		RETURNING ` + todoColumns + `, (xmax = 0) AS inserted
+	`
+
+	tx, err := r.begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	if err := r.reserveTodos(tx, 1, todo.ExternalID); err != nil {
+		return nil, false, err
+	}
+
+	var inserted bool
+	newTodo, err := scanTodo(
+		tx.QueryRow(query, todo.Title, todo.Description, todo.Priority, todo.ExternalID, todo.DueAt, todo.Completed, todo.CompletedAt, todo.CategoryID),
+		&inserted,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	return newTodo, inserted, nil
+}
+
+// BulkCreate inserts many todos at once and returns the number inserted.
+// Batches larger than copyThreshold are loaded with COPY FROM STDIN.
+func (r *TodoRepository) BulkCreate(todos []*models.CreateTodoRequest) (int64, error) {
+	if len(todos) == 0 {
+		return 0, nil
+	}
+
+	if len(todos) <= copyThreshold && r.maxTodos <= 0 {
+		query, args := multiRowInsert(todos)
+		result, err := r.db.Exec(query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	inserted, err := r.insertTodos(tx, todos)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
+// insertTodos reserves room for todos under the limit and inserts them on
+// tx, with COPY FROM STDIN for batches larger than copyThreshold. It
+// returns the number inserted.
+func (r *TodoRepository) insertTodos(tx txn, todos []*models.CreateTodoRequest) (int64, error) {
+	if len(todos) == 0 {
+		return 0, nil
+	}
+
+	if err := r.reserveTodos(tx, len(todos), nil); err != nil {
+		return 0, err
+	}
+
+	if len(todos) <= copyThreshold {
+		query, args := multiRowInsert(todos)
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+
+	var now time.Time
+	if err := tx.QueryRow(copyNowQuery).Scan(&now); err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("todos", copyColumns...))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, todo := range todos {
+		if _, err := stmt.Exec(copyRow(todo, now)...); err != nil {
+			stmt.Close()
+			return 0, err
+		}
+	}
+
+	// Flush the buffered rows
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return 0, err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return 0, err
+	}
+
+	return int64(len(todos)), nil
+}
+
+// importCategoryQuery returns the ID of the oldest top-level category named
+// $1, creating it if there is none, and whether it was created
+const importCategoryQuery = `
+	WITH found AS (
+		SELECT id FROM categories WHERE parent_id IS NULL AND name = $1
+		ORDER BY id
+		LIMIT 1
+	), inserted AS (
+		INSERT INTO categories (name, created_at)
+		SELECT $1, NOW()
+		WHERE NOT EXISTS (SELECT 1 FROM found)
+		RETURNING id
+	)
+	SELECT id, false FROM found
+	UNION ALL
+	SELECT id, true FROM inserted
+`
+
+// ImportGroups files the todos of each group under the top-level category
+// with the group's name, creating the categories that do not exist yet,
+// and inserts all the todos. Everything happens in one transaction, so a
+// failure leaves neither new categories nor todos behind. It returns the
+// names of the categories it created and the number of todos inserted.
+func (r *TodoRepository) ImportGroups(groups []models.ImportGroup) ([]string, int64, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	var created []string
+	var todos []*models.CreateTodoRequest
+	categoryIDs := make(map[string]int64)
+	for _, group := range groups {
+		if group.Category != "" && len(group.Todos) > 0 {
+			id, ok := categoryIDs[group.Category]
+			if !ok {
+				var isNew bool
+				if err := tx.QueryRow(importCategoryQuery, group.Category).Scan(&id, &isNew); err != nil {
+					return nil, 0, err
+				}
+				categoryIDs[group.Category] = id
+				if isNew {
+					created = append(created, group.Category)
+				}
+			}
+			fileUnder(group.Todos, id)
+		}
+		todos = append(todos, group.Todos...)
+	}
+
+	imported, err := r.insertTodos(tx, todos)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+
+	return created, imported, nil
+}
+
+// GetAll retrieves the page of todos matching filter, along with the total
+// number of matching todos across all pages. Pinned todos come first, then
+// the rest in filter.Sort order or, by default, todos never reordered
+// newest first followed by the others by position.
+func (r *TodoRepository) GetAll(filter TodoFilter) ([]*models.Todo, int64, error) {
+	where, args := filter.where()
+	query, queryArgs := getAllQuery(filter)
+
+	rows, err := r.db.Query(query, queryArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// An empty page encodes as [] rather than null
+	todos := []*models.Todo{}
+
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		todos = append(todos, todo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// Without paging the page is the whole result
+	if filter.Limit <= 0 && filter.Offset <= 0 {
+		return todos, int64(len(todos)), nil
+	}
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM todos ` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return todos, total, nil
+}
+
+// GetHighlights shows where query matched the title and description of each
+// todo in ids, keyed by todo ID. Fields the query does not match are empty.
+func (r *TodoRepository) GetHighlights(ids []int64, query string) (map[int64]map[string]string, error) {
+	rows, err := r.db.Query(`SELECT `+highlightColumns+` FROM todos WHERE id = ANY($2)`, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	highlights := make(map[int64]map[string]string, len(ids))
+	for rows.Next() {
+		var id int64
+		var title, description string
+		if err := rows.Scan(&id, &title, &description); err != nil {
+			return nil, err
+		}
+		highlights[id] = map[string]string{"title": highlighted(title), "description": highlighted(description)}
+	}
+
+	return highlights, rows.Err()
+}
+
+// GetRelated finds up to limit incomplete todos similar to todo id, ranked
+// by how well their text matches its title
+func (r *TodoRepository) GetRelated(id int64, limit int) ([]*models.Todo, error) {
+	rows, err := r.db.Query(relatedQuery, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetAllByCategory retrieves every unarchived todo ordered for grouping by
+// category: by category, uncategorized last, then by position and creation
+// time
+func (r *TodoRepository) GetAllByCategory() ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE archived_at IS NULL
+		ORDER BY category_id NULLS LAST, position, created_at, id
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetTimeline returns the recorded changes to a todo, oldest first
+func (r *TodoRepository) GetTimeline(todoID int64) ([]*models.TimelineEvent, error) {
+	query := `
+		SELECT ` + timelineColumns + `
+		FROM activity_logs
+		WHERE todo_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*models.TimelineEvent{}
+	for rows.Next() {
+		event, err := scanTimelineEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetActivity returns up to limit activity log entries across all todos,
+// newest first. A before of 0 starts from the newest entry; otherwise only
+// entries with a lower ID are returned.
+func (r *TodoRepository) GetActivity(before int64, limit int) ([]*models.ActivityEntry, error) {
+	rows, err := r.db.Query(getActivityQuery, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.ActivityEntry{}
+	for rows.Next() {
+		entry, err := scanActivityEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetDeletedAt returns when the todo with the given ID was deleted, from its
+// activity log, or nil if no such todo was ever deleted. The vacuum deletes
+// the todos it moves into todos_archive, so those are not counted as deleted.
+func (r *TodoRepository) GetDeletedAt(id int64) (*time.Time, error) {
+	query := `
+		SELECT created_at
+		FROM activity_logs
+		WHERE todo_id = $1 AND event = 'deleted'
+			AND NOT EXISTS (SELECT 1 FROM todos_archive WHERE id = $1)
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var deletedAt time.Time
+	if err := r.db.QueryRow(query, id).Scan(&deletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Todo never deleted
+		}
+		return nil, err
+	}
+
+	return &deletedAt, nil
+}
+
+// LastModified returns when any todo was last created, changed or deleted:
+// the later of the newest updated_at and the newest deletion in the
+// activity log. It is the zero time if there has been neither.
+func (r *TodoRepository) LastModified() (time.Time, error) {
+	query := `
+		SELECT GREATEST(
+			(SELECT MAX(updated_at) FROM todos),
+			(SELECT MAX(created_at) FROM activity_logs WHERE event = 'deleted')
+		)
+	`
+
+	var modified *time.Time
+	if err := r.db.QueryRow(query).Scan(&modified); err != nil || modified == nil {
+		return time.Time{}, err
+	}
+
+	return *modified, nil
+}
+
+// StreamAll calls fn for each todo matching filter, in the same order as
+// GetAll, without holding the full result set in memory. Iteration stops at
+// the first error returned by fn, which is returned to the caller.
+func (r *TodoRepository) StreamAll(ctx context.Context, filter TodoFilter, fn func(*models.Todo) error) error {
+	where, args := filter.where()
+	order, orderArgs := filter.orderBy(len(args))
+	args = append(args, orderArgs...)
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		` + where + `
+		` + order + `
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// StreamAllIDs calls fn with the ID of every todo in ascending order as the
+// rows arrive, without loading them all into memory. It stops at the first
+// error from fn and returns it.
+func (r *TodoRepository) StreamAllIDs(ctx context.Context, fn func(int64) error) error {
+	query := `SELECT id FROM todos WHERE archived_at IS NULL ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetByID retrieves a todo by ID
+func (r *TodoRepository) GetByID(id int64) (*models.Todo, error) {
+	todo, err := scanTodo(r.db.QueryRow(getByIDQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// Update writes the editable fields of a todo to the database. The
+// todos_set_timestamps trigger stamps updated_at and completed_at.
+func (r *TodoRepository) Update(todo *models.Todo) (*models.Todo, error) {
+	updatedTodo, err := scanTodo(r.db.QueryRow(
+		updateTodoQuery,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		todo.DueAt,
+		todo.ID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return updatedTodo, nil
+}
+
+// UpdateWith reads the todo with the given ID, passes it to apply and
+// saves the todo apply returns, in one transaction. The row is locked
+// between the read and the write, so a concurrent delete or update waits
+// instead of the write silently missing the row or overwriting a change
+// committed in between. It returns ErrNotFound if the todo does not exist,
+// and ErrBlocked if apply completes a todo that depends on an incomplete
+// one.
+func (r *TodoRepository) UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := scanTodo(tx.QueryRow(lockTodoQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	todo := apply(current)
+	if todo.Completed && !current.Completed {
+		var blocked bool
+		if err := tx.QueryRow(blockedQuery, id).Scan(&blocked); err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrBlocked
+		}
+	}
+
+	updated, err := scanTodo(tx.QueryRow(
+		updateTodoQuery,
+		todo.Title,
+		todo.Description,
+		todo.Completed,
+		todo.Priority,
+		todo.DueAt,
+		id,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// SetCompleted sets the completion state of a todo with a single targeted
+// UPDATE. Completing a todo fails with ErrBlocked while a todo it depends on
+// is not completed.
+func (r *TodoRepository) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	todo, err := scanTodo(r.db.QueryRow(setCompletedQuery, completed, id))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		// No row was updated: either the todo does not exist or it is
+		// blocked
+		if _, err := r.GetByID(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrBlocked
+	}
+
+	return todo, nil
+}
+
+// AppendNote appends a timestamped note to a todo's notes log
+func (r *TodoRepository) AppendNote(id int64, body string) (*models.Todo, error) {
+	query := `
+		UPDATE todos
+		SET notes = notes || jsonb_build_array(jsonb_build_object('body', $1::text, 'created_at', NOW()))
+		WHERE id = $2
+		RETURNING ` + todoColumns
+
+	todo, err := scanTodo(r.db.QueryRow(query, body, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// SetTags replaces the tags of a todo, creating tags that do not exist yet
+func (r *TodoRepository) SetTags(id int64, tags []string) (*models.Todo, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the todo so concurrent calls apply one after the other
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM todos WHERE id = $1 FOR UPDATE`, id).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM todo_tags WHERE todo_id = $1`, id); err != nil {
+		return nil, err
+	}
+
+	if len(tags) > 0 {
+		if _, err := tx.Exec(`INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, pq.Array(tags)); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO todo_tags (todo_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`, id, pq.Array(tags)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Touch the row so the timestamps trigger records the tag change
+	todo, err := scanTodo(tx.QueryRow(`UPDATE todos SET updated_at = NOW() WHERE id = $1 RETURNING `+todoColumns, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// ExistingTags returns which of names are tags that exist
+func (r *TodoRepository) ExistingTags(names []string) ([]string, error) {
+	query := `SELECT name FROM tags WHERE name = ANY($1) ORDER BY name`
+
+	rows, err := r.db.Query(query, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing = append(existing, name)
+	}
+
+	return existing, rows.Err()
+}
+
+// BatchUpdatePriority sets the priority of every unarchived todo in ids with a
+// single UPDATE and returns the IDs that were updated
+func (r *TodoRepository) BatchUpdatePriority(ids []int64, priority int) ([]int64, error) {
+	query := `
+		UPDATE todos
+		SET priority = $1
+		WHERE id = ANY($2) AND archived_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.db.Query(query, priority, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var updated []int64
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		updated = append(updated, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// BatchUpdateCategory files every unarchived todo in ids under categoryID with
+// a single UPDATE and returns the IDs that were updated
+func (r *TodoRepository) BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error) {
+	query := `
+		UPDATE todos
+		SET category_id = $1
+		WHERE id = ANY($2) AND archived_at IS NULL
+		RETURNING id
+	`
+
+	rows, err := r.db.Query(query, categoryID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var updated []int64
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		updated = append(updated, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// SetPinned pins or unpins a todo. Pinning fails with ErrPinLimitReached
+// when MaxPinnedTodos other unarchived todos are already pinned.
+func (r *TodoRepository) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(pinLockQuery); err != nil {
+		return nil, err
+	}
+
+	todo, err := scanTodo(tx.QueryRow(setPinnedQuery, pinned, id, MaxPinnedTodos))
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+
+		// No row was updated: either the todo does not exist or the
+		// pin limit prevented the update
+		if _, err := r.GetByID(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrPinLimitReached
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// AddReaction reacts to a todo with emoji. Reacting again with the same
+// emoji does nothing. It fails with ErrReactionLimitReached when the todo
+// already has MaxReactionsPerTodo other reactions, and with ErrNotFound if
+// the todo does not exist.
+func (r *TodoRepository) AddReaction(todoID int64, emoji string) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(reactionLockQuery, todoID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(addReactionQuery, todoID, emoji, MaxReactionsPerTodo)
+	if err != nil {
+		return err
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if added > 0 {
+		return nil
+	}
+
+	// Nothing was inserted: the todo does not exist, already has this
+	// reaction or is at the limit
+	if _, err := r.GetByID(todoID); err != nil {
+		return err
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(reactionExistsQuery, todoID, emoji).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrReactionLimitReached
+	}
+
+	return nil
+}
+
+// RemoveReaction takes back a reaction to a todo. Removing a reaction that
+// is not there does nothing. It returns ErrNotFound if the todo does not
+// exist.
+func (r *TodoRepository) RemoveReaction(todoID int64, emoji string) error {
+	result, err := r.db.Exec(removeReactionQuery, todoID, emoji)
+	if err != nil {
+		return err
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil || removed > 0 {
+		return err
+	}
+
+	_, err = r.GetByID(todoID)
+	return err
+}
+
+// GetReactions returns the reactions to a todo, one per emoji in the order
+// they were first added. Every reaction is the single user's, so each is
+// reacted_by_me.
+func (r *TodoRepository) GetReactions(todoID int64) ([]models.Reaction, error) {
+	rows, err := r.db.Query(getReactionsQuery, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := []models.Reaction{}
+	for rows.Next() {
+		reaction := models.Reaction{ReactedByMe: true}
+		if err := rows.Scan(&reaction.Emoji, &reaction.Count); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, rows.Err()
+}
+
+// AddDependency makes a todo depend on blockingID, so it cannot be
+// completed before blockingID is. Adding a dependency again does nothing.
+// It fails with ErrDependencyCycle when blockingID already depends on the
+// todo, and with ErrNotFound if either todo does not exist.
+func (r *TodoRepository) AddDependency(todoID, blockingID int64) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(dependencyLockQuery); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(addDependencyQuery, todoID, blockingID)
+	if err != nil {
+		return err
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if added > 0 {
+		return nil
+	}
+
+	// Nothing was inserted: a todo does not exist, the dependency is
+	// already there or it would close a cycle
+	for _, id := range []int64{todoID, blockingID} {
+		if _, err := r.GetByID(id); err != nil {
+			return err
+		}
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(dependencyExistsQuery, todoID, blockingID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrDependencyCycle
+	}
+
+	return nil
+}
+
+// RemoveDependency stops a todo depending on blockingID. Removing a
+// dependency that is not there does nothing. It returns ErrNotFound if the
+// todo does not exist.
+func (r *TodoRepository) RemoveDependency(todoID, blockingID int64) error {
+	result, err := r.db.Exec(removeDependencyQuery, todoID, blockingID)
+	if err != nil {
+		return err
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil || removed > 0 {
+		return err
+	}
+
+	_, err = r.GetByID(todoID)
+	return err
+}
+
+// GetBlockers returns the todos a todo depends on, in the order the
+// dependencies were added
+func (r *TodoRepository) GetBlockers(todoID int64) ([]models.Blocker, error) {
+	rows, err := r.db.Query(getBlockersQuery, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blockers := []models.Blocker{}
+	for rows.Next() {
+		var blocker models.Blocker
+		if err := rows.Scan(&blocker.ID, &blocker.Title, &blocker.Completed); err != nil {
+			return nil, err
+		}
+		blockers = append(blockers, blocker)
+	}
+
+	return blockers, rows.Err()
+}
+
+// Snooze pushes a todo's due date back by days, counting from now if it
+// has none, and re-arms its overdue reminder
+func (r *TodoRepository) Snooze(id int64, days int) (*models.Todo, error) {
+	query := `
+		UPDATE todos
+		SET due_at = COALESCE(due_at, NOW() AT TIME ZONE 'UTC') + make_interval(days => $1),
+			overdue_notified_at = NULL,
+			reminded_at = NULL,
+			snooze_count = snooze_count + 1
+		WHERE id = $2
+		RETURNING ` + todoColumns
+
+	todo, err := scanTodo(r.db.QueryRow(query, days, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// ShiftDueDates moves the due date of every todo in ids by days with a
+// single UPDATE. It returns the IDs it shifted and the IDs of todos without
+// a due date, which are left alone.
+func (r *TodoRepository) ShiftDueDates(ids []int64, days int) ([]int64, []int64, error) {
+	rows, err := r.db.Query(shiftDueDatesQuery, days, pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return splitIDs(rows)
+}
+
+// Delete removes a todo from the database and returns it as it was
+// before the delete
+func (r *TodoRepository) Delete(id int64) (*models.Todo, error) {
+	deleted, err := scanTodo(r.db.QueryRow(deleteTodoQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// DeleteAllCompleted removes every completed, unarchived todo and returns how
+// many were deleted
+func (r *TodoRepository) DeleteAllCompleted() (int64, error) {
+	query := `DELETE FROM todos WHERE completed = true AND archived_at IS NULL`
+
+	result, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// VacuumCompleted moves todos completed more than olderThanDays days ago to
+// todos_archive and returns how many it moved. Each batch of
+// VacuumBatchSize todos is moved in its own transaction, so locks are not
+// held for the whole run; a failure leaves earlier batches archived.
+func (r *TodoRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	var archived int64
+	for {
+		var n int64
+		if err := r.db.QueryRow(vacuumBatchQuery, olderThanDays, VacuumBatchSize).Scan(&n); err != nil {
+			return archived, err
+		}
+		archived += n
+
+		if n < VacuumBatchSize {
+			return archived, nil
+		}
+	}
+}
+
+// SetArchived stamps archived_at on the todos in ids with a single
+// statement, or clears it when archived is false. It returns the IDs it
+// changed and the IDs that were already archived, or already not archived;
+// IDs of todos that do not exist are in neither list.
+func (r *TodoRepository) SetArchived(ids []int64, archived bool) ([]int64, []int64, error) {
+	rows, err := r.db.Query(setArchivedQuery(archived), pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return splitIDs(rows)
+}
+
+// CreateCategory adds a new category to the database
+func (r *TodoRepository) CreateCategory(name string, parentID *int64) (*models.Category, error) {
+	query := `
+		INSERT INTO categories (name, parent_id, created_at)
+		VALUES ($1, $2, NOW())
+		RETURNING ` + categoryColumns
+
+	return scanCategory(r.db.QueryRow(query, name, parentID))
+}
+
+// GetAllCategories retrieves all categories ordered by name
+func (r *TodoRepository) GetAllCategories() ([]*models.Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM categories
+		ORDER BY name, id
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetCategoryByID retrieves a category by ID, or ErrCategoryNotFound if
+// there is none
+func (r *TodoRepository) GetCategoryByID(id int64) (*models.Category, error) {
+	query := `
+		SELECT ` + categoryColumns + `
+		FROM categories
+		WHERE id = $1
+	`
+
+	category, err := scanCategory(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetCategoryTree retrieves every category ordered so that each parent
+// comes before its children, and siblings are sorted by name
+func (r *TodoRepository) GetCategoryTree() ([]*models.Category, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT ` + categoryColumns + `, 1 AS depth
+			FROM categories
+			WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.id, c.name, c.parent_id, c.created_at, tree.depth + 1
+			FROM categories c
+			JOIN tree ON c.parent_id = tree.id
+		)
+		SELECT ` + categoryColumns + `
+		FROM tree
+		ORDER BY depth, name, id
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetCategoryDepth returns how deeply a category is nested, 1 for a
+// top-level category, or 0 if it does not exist
+func (r *TodoRepository) GetCategoryDepth(id int64) (int, error) {
+	var depth int
+	err := r.db.QueryRow(categoryDepthQuery, id).Scan(&depth)
+	return depth, err
+}
+
+// GetCategoryAncestors returns the category with the given ID and its
+// ancestors, root first, following at most MaxBreadcrumbDepth parents
+func (r *TodoRepository) GetCategoryAncestors(id int64) ([]*models.Category, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT ` + categoryColumns + `, 1 AS depth
+			FROM categories
+			WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.parent_id, c.created_at, ancestors.depth + 1
+			FROM categories c
+			JOIN ancestors ON c.id = ancestors.parent_id
+			WHERE ancestors.depth < $2
+		)
+		SELECT ` + categoryColumns + ` FROM ancestors
+		ORDER BY depth DESC
+	`
+
+	rows, err := r.db.Query(query, id, MaxBreadcrumbDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
 }
 
-// NewTodoRepository creates a new TodoRepository
-func NewTodoRepository(db *sql.DB) *TodoRepository {
-	return &TodoRepository{
-		db: db,
+// MoveTodos sets the category of every unarchived todo in ids with a single
+// UPDATE and returns how many were moved. A nil categoryID leaves them
+// uncategorized.
+func (r *TodoRepository) MoveTodos(ids []int64, categoryID *int64) (int64, error) {
+	query := `
+		UPDATE todos
+		SET category_id = $1
+		WHERE id = ANY($2) AND archived_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, categoryID, pq.Array(ids))
+	if err != nil {
+		return 0, err
 	}
+
+	return result.RowsAffected()
 }
 
-// Create adds a new todo to the database
-func (r *TodoRepository) Create(todo *models.CreateTodoRequest) (*models.Todo, error) {
+// CompleteAllInCategory marks every incomplete, unarchived todo directly in
+// categoryID as completed with a single UPDATE. It returns the IDs it
+// completed and the IDs of todos it left open because they are blocked by an
+// incomplete todo. The todos_set_timestamps trigger stamps completed_at and
+// updated_at.
+func (r *TodoRepository) CompleteAllInCategory(categoryID int64) ([]int64, []int64, error) {
+	rows, err := r.db.Query(completeAllInCategoryQuery, categoryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	return splitIDs(rows)
+}
+
+// Reorder sets the manual position of every todo in order with a single
+// UPDATE, in one transaction. It fails with ErrNotFound, changing
+// nothing, if any of the todos does not exist.
+func (r *TodoRepository) Reorder(order []models.TodoPosition) (int64, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, len(order))
+	for i, p := range order {
+		ids[i] = p.ID
+	}
+
+	var found int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM (SELECT id FROM todos WHERE id = ANY($1) FOR UPDATE) t
+	`, pq.Array(ids)).Scan(&found)
+	if err != nil {
+		return 0, err
+	}
+	if found != len(order) {
+		return 0, ErrNotFound
+	}
+
+	query, args := reorderQuery(order)
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// reorderQuery builds the UPDATE that applies order, joining the todos to a
+// VALUES list of new positions
+func reorderQuery(order []models.TodoPosition) (string, []interface{}) {
+	values := make([]string, len(order))
+	args := make([]interface{}, 0, 2*len(order))
+	for i, p := range order {
+		args = append(args, p.ID, p.Position)
+		values[i] = fmt.Sprintf("($%d::bigint, $%d::integer)", len(args)-1, len(args))
+	}
+
 	query := `
-		INSERT INTO todos (title, description, created_at, updated_at)
-		VALUES ($1, $2, NOW(), NOW())
-		RETURNING id, title, description, completed, created_at, updated_at, completed_at
+		WITH updates(id, position) AS (VALUES ` + strings.Join(values, ", ") + `)
+		UPDATE todos
+		SET position = u.position
+		FROM updates u
+		WHERE todos.id = u.id
 	`
 
-	var newTodo models.Todo
-	var completedAt sql.NullTime
+	return query, args
+}
 
-	err := r.db.QueryRow(
-		query,
-		todo.Title,
-		todo.Description,
-	).Scan(
-		&newTodo.ID,
-		&newTodo.Title,
-		&newTodo.Description,
-		&newTodo.Completed,
-		&newTodo.CreatedAt,
-		&newTodo.UpdatedAt,
-		&completedAt,
-	)
+// DuplicateCategory copies a category, named "Copy of <name>" under the same
+// parent, together with the unarchived todos directly in it, in one
+// transaction. The copied todos keep their title, description, priority and
+// tags but start incomplete, unpinned and without a due date. It returns
+// ErrCategoryNotFound if the category does not exist.
+func (r *TodoRepository) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	category, err := scanCategory(tx.QueryRow(`
+		INSERT INTO categories (name, parent_id, created_at)
+		SELECT LEFT('Copy of ' || name, 255), parent_id, NOW()
+		FROM categories
+		WHERE id = $1
+		RETURNING `+categoryColumns, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := tx.Query(`SELECT id FROM todos WHERE category_id = $1 AND archived_at IS NULL ORDER BY id FOR SHARE`, id)
+	if err != nil {
+		return nil, err
+	}
+	var sourceIDs []int64
+	for rows.Next() {
+		var sourceID int64
+		if err := rows.Scan(&sourceID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sourceIDs = append(sourceIDs, sourceID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(sourceIDs) > 0 {
+		if err := r.reserveTodos(tx, len(sourceIDs), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sourceID := range sourceIDs {
+		var copyID int64
+		err := tx.QueryRow(`
+			INSERT INTO todos (title, description, priority, category_id, created_at, updated_at)
+			SELECT title, description, priority, $2, NOW(), NOW()
+			FROM todos
+			WHERE id = $1
+			RETURNING id
+		`, sourceID, category.ID).Scan(&copyID)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO todo_tags (todo_id, tag_id) SELECT $1, tag_id FROM todo_tags WHERE todo_id = $2`, copyID, sourceID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.CategoryWithCount{Category: *category, TodoCount: int64(len(sourceIDs))}, nil
+}
+
+// ConvertToCategory archives the todo with the given ID and creates a
+// category named after its title, under the todo's own category,
+// in one transaction. It returns ErrNotFound if the todo does not exist,
+// and ErrCategoryTooDeep if the new category would be nested deeper than
+// models.MaxCategoryDepth.
+func (r *TodoRepository) ConvertToCategory(id int64) (*models.Category, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var title string
+	var parentID *int64
+	err = tx.QueryRow(`SELECT title, category_id FROM todos WHERE id = $1 FOR UPDATE`, id).Scan(&title, &parentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if parentID != nil {
+		var depth int
+		if err := tx.QueryRow(categoryDepthQuery, *parentID).Scan(&depth); err != nil {
+			return nil, err
+		}
+		if depth >= models.MaxCategoryDepth {
+			return nil, ErrCategoryTooDeep
+		}
+	}
 
+	if _, err := tx.Exec(archiveTodosQuery, pq.Array([]int64{id})); err != nil {
+		return nil, err
+	}
+
+	category, err := scanCategory(tx.QueryRow(`
+		INSERT INTO categories (name, parent_id, created_at)
+		VALUES ($1, $2, NOW())
+		RETURNING `+categoryColumns, title, parentID))
 	if err != nil {
 		return nil, err
 	}
 
-	if completedAt.Valid {
-		newTodo.CompletedAt = &completedAt.Time
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
-	return &newTodo, nil
+	return category, nil
 }
 
-// GetAll retrieves all todos from the database
-func (r *TodoRepository) GetAll() ([]*models.Todo, error) {
+// GetOverdueUnnotified retrieves incomplete todos whose due date has passed
+// and that have not had an overdue reminder sent, oldest due date first
+func (r *TodoRepository) GetOverdueUnnotified() ([]*models.Todo, error) {
 	query := `
-		SELECT id, title, description, completed, created_at, updated_at, completed_at
+		SELECT ` + todoColumns + `
 		FROM todos
-		ORDER BY created_at DESC
+		WHERE due_at < NOW() AND completed = false AND overdue_notified_at IS NULL AND archived_at IS NULL
+		ORDER BY due_at, id
 	`
 
 	rows, err := r.db.Query(query)
@@ -70,157 +1934,442 @@ func (r *TodoRepository) GetAll() ([]*models.Todo, error) {
 	}
 	defer rows.Close()
 
-	var todos []*models.Todo
-
+	todos := []*models.Todo{}
 	for rows.Next() {
-		var todo models.Todo
-		var completedAt sql.NullTime
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// MarkOverdueNotified records that the overdue reminder for each todo in ids
+// has been sent
+func (r *TodoRepository) MarkOverdueNotified(ids []int64) error {
+	query := `UPDATE todos SET overdue_notified_at = NOW() WHERE id = ANY($1)`
 
-		err := rows.Scan(
-			&todo.ID,
-			&todo.Title,
-			&todo.Description,
-			&todo.Completed,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-			&completedAt,
+	_, err := r.db.Exec(query, pq.Array(ids))
+	return err
+}
+
+// GetDueWithinWindow claims the incomplete todos due between from and to
+// whose due-soon reminder has not been sent, and returns them soonest
+// first. Claiming stamps reminded_at in the same statement, and rows locked
+// by another worker are skipped, so concurrent workers never return the
+// same todo. Moving a todo's due date re-arms its reminder.
+func (r *TodoRepository) GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error) {
+	// due_at is stored as UTC without a zone
+	query := `
+		WITH claimed AS (
+			UPDATE todos
+			SET reminded_at = NOW()
+			WHERE id IN (
+				SELECT id
+				FROM todos
+				WHERE due_at BETWEEN $1 AND $2 AND completed = false AND reminded_at IS NULL AND archived_at IS NULL
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + todoColumns + `
 		)
+		SELECT * FROM claimed
+		ORDER BY due_at, id
+	`
+
+	rows, err := r.db.Query(query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
 		if err != nil {
 			return nil, err
 		}
+		todos = append(todos, todo)
+	}
 
-		if completedAt.Valid {
-			todo.CompletedAt = &completedAt.Time
-		}
+	return todos, rows.Err()
+}
 
-		todos = append(todos, &todo)
-	}
+// releaseRemindersQuery clears the due-soon reminder claim of the todos in $1
+const releaseRemindersQuery = `UPDATE todos SET reminded_at = NULL WHERE id = ANY($1)`
 
-	if err = rows.Err(); err != nil {
+// ReleaseReminders gives up the due-soon reminder claims GetDueWithinWindow
+// made on the todos in ids, so the next call returns them again. The
+// reminder worker calls it when it could not send the reminder.
+func (r *TodoRepository) ReleaseReminders(ids []int64) error {
+	_, err := r.db.Exec(releaseRemindersQuery, pq.Array(ids))
+	return err
+}
+
+// GetCompletedBetween retrieves the unarchived todos completed from from up to
+// but not including to, in the order they were completed
+func (r *TodoRepository) GetCompletedBetween(from, to time.Time) ([]*models.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE completed = true AND completed_at >= $1 AND completed_at < $2 AND archived_at IS NULL
+		ORDER BY completed_at, id
+	`
+
+	rows, err := r.db.Query(query, from.UTC(), to.UTC())
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
 
-	return todos, nil
+	return todos, rows.Err()
 }
 
-// GetByID retrieves a todo by ID
-func (r *TodoRepository) GetByID(id int64) (*models.Todo, error) {
+// GetDueBetween retrieves the incomplete todos due from from up to but not
+// including to, soonest first. Unlike GetDueWithinWindow it does not claim
+// them for a reminder.
+func (r *TodoRepository) GetDueBetween(from, to time.Time) ([]*models.Todo, error) {
 	query := `
-		SELECT id, title, description, completed, created_at, updated_at, completed_at
+		SELECT ` + todoColumns + `
 		FROM todos
-		WHERE id = $1
+		WHERE completed = false AND due_at >= $1 AND due_at < $2 AND archived_at IS NULL
+		ORDER BY due_at, id
 	`
 
-	var todo models.Todo
-	var completedAt sql.NullTime
+	rows, err := r.db.Query(query, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	err := r.db.QueryRow(query, id).Scan(
-		&todo.ID,
-		&todo.Title,
-		&todo.Description,
-		&todo.Completed,
-		&todo.CreatedAt,
-		&todo.UpdatedAt,
-		&completedAt,
-	)
+	todos := []*models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// GetTimezone returns the user's IANA timezone name
+func (r *TodoRepository) GetTimezone() (string, error) {
+	query := `SELECT timezone FROM user_preferences WHERE id = 1`
+
+	var tz string
+	err := r.db.QueryRow(query).Scan(&tz)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "UTC", nil // Preferences not saved yet
+	}
+
+	return tz, err
+}
+
+// SetTimezone stores the user's IANA timezone name
+func (r *TodoRepository) SetTimezone(tz string) error {
+	query := `
+		INSERT INTO user_preferences (id, timezone)
+		VALUES (1, $1)
+		ON CONFLICT (id) DO UPDATE SET timezone = EXCLUDED.timezone
+	`
+
+	_, err := r.db.Exec(query, tz)
+	return err
+}
+
+// GetPreferences returns the user's UI preferences, or the defaults if none
+// have been saved
+func (r *TodoRepository) GetPreferences() (*models.Preferences, error) {
+	query := `
+		SELECT default_sort, default_filter, todos_per_page, theme, timezone
+		FROM user_preferences
+		WHERE id = 1
+	`
 
+	prefs := models.Preferences{DefaultFilter: models.SearchParams{}, TodosPerPage: models.DefaultTodosPerPage, Theme: models.ThemeSystem, Timezone: "UTC"}
+	var filter []byte
+	err := r.db.QueryRow(query).Scan(&prefs.DefaultSort, &filter, &prefs.TodosPerPage, &prefs.Theme, &prefs.Timezone)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil // Todo not found
+			return &prefs, nil // Preferences not saved yet
 		}
 		return nil, err
 	}
 
-	if completedAt.Valid {
-		todo.CompletedAt = &completedAt.Time
+	if err := json.Unmarshal(filter, &prefs.DefaultFilter); err != nil {
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+// SetPreferences replaces the user's UI preferences, timezone included
+func (r *TodoRepository) SetPreferences(prefs *models.Preferences) error {
+	filter, err := json.Marshal(prefs.DefaultFilter)
+	if err != nil {
+		return err
 	}
 
-	return &todo, nil
+	query := `
+		INSERT INTO user_preferences (id, default_sort, default_filter, todos_per_page, theme, timezone)
+		VALUES (1, $1, $2::jsonb, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			default_sort = EXCLUDED.default_sort,
+			default_filter = EXCLUDED.default_filter,
+			todos_per_page = EXCLUDED.todos_per_page,
+			theme = EXCLUDED.theme,
+			timezone = EXCLUDED.timezone
+	`
+
+	_, err = r.db.Exec(query, prefs.DefaultSort, string(filter), prefs.TodosPerPage, prefs.Theme, prefs.Timezone)
+	return err
 }
 
-// Update updates a todo in the database
-func (r *TodoRepository) Update(id int64, todo *models.UpdateTodoRequest) (*models.Todo, error) {
-	// First, get the current todo
-	currentTodo, err := r.GetByID(id)
+// GetCompletionTrend counts the todos created and completed in each period
+// ("day" or "week") covering the last days days, oldest first. Periods with
+// no activity are included with zero counts.
+func (r *TodoRepository) GetCompletionTrend(period string, days int) ([]*models.TrendPoint, error) {
+	query := `
+		WITH periods AS (
+			SELECT generate_series(
+				date_trunc($1::text, LOCALTIMESTAMP - make_interval(days => $2 - 1)),
+				date_trunc($1::text, LOCALTIMESTAMP),
+				('1 ' || $1::text)::interval
+			) AS period
+		),
+		created AS (
+			SELECT date_trunc($1::text, created_at) AS period, COUNT(*) AS n
+			FROM todos
+			WHERE created_at >= (SELECT MIN(period) FROM periods)
+			GROUP BY 1
+		),
+		completed AS (
+			SELECT date_trunc($1::text, completed_at) AS period, COUNT(*) AS n
+			FROM todos
+			WHERE completed_at >= (SELECT MIN(period) FROM periods)
+			GROUP BY 1
+		)
+		SELECT p.period, COALESCE(d.n, 0), COALESCE(c.n, 0)
+		FROM periods p
+		LEFT JOIN completed d ON d.period = p.period
+		LEFT JOIN created c ON c.period = p.period
+		ORDER BY p.period
+	`
+
+	rows, err := r.db.Query(query, period, days)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	points := []*models.TrendPoint{}
+	for rows.Next() {
+		var start time.Time
+		var point models.TrendPoint
+		if err := rows.Scan(&start, &point.Completed, &point.Created); err != nil {
+			return nil, err
+		}
+		point.Date = start.Format("2006-01-02")
+		points = append(points, &point)
+	}
+
+	return points, rows.Err()
+}
+
+// GetCompletionStreak finds the runs of consecutive days, in timezone, on
+// which at least one todo was completed. The current run is still going if
+// it reached today or yesterday.
+func (r *TodoRepository) GetCompletionStreak(timezone string) (*models.Streak, error) {
+	query := `
+		WITH days AS (
+			SELECT DISTINCT (completed_at AT TIME ZONE 'UTC' AT TIME ZONE $1)::date AS day
+			FROM todos
+			WHERE completed_at IS NOT NULL
+		),
+		runs AS (
+			-- A day minus its row number is the same for every day in a run
+			SELECT MAX(day) AS last_day, COUNT(*) AS length
+			FROM (
+				SELECT day, day - (ROW_NUMBER() OVER (ORDER BY day))::int AS run
+				FROM days
+			) numbered
+			GROUP BY run
+		)
+		SELECT
+			COALESCE(MAX(length) FILTER (WHERE last_day >= (NOW() AT TIME ZONE $1)::date - 1), 0),
+			COALESCE(MAX(length), 0),
+			MAX(last_day)
+		FROM runs
+	`
+
+	var streak models.Streak
+	var lastDay *time.Time
+	if err := r.db.QueryRow(query, timezone).Scan(&streak.Current, &streak.Longest, &lastDay); err != nil {
+		return nil, err
+	}
+
+	if lastDay != nil {
+		date := lastDay.Format("2006-01-02")
+		streak.LastCompletionDate = &date
+	}
+
+	return &streak, nil
+}
+
+// CountByPriority counts the incomplete, unarchived todos at each priority.
+// Priorities with no such todos are left out.
+func (r *TodoRepository) CountByPriority() (map[int]int64, error) {
+	query := `SELECT priority, COUNT(*) FROM todos WHERE completed = false AND archived_at IS NULL GROUP BY priority`
 
-	if currentTodo == nil {
-		return nil, nil // Todo not found
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Prepare update values
-	title := currentTodo.Title
-	if todo.Title != nil {
-		title = *todo.Title
+	counts := make(map[int]int64)
+	for rows.Next() {
+		var priority int
+		var count int64
+		if err := rows.Scan(&priority, &count); err != nil {
+			return nil, err
+		}
+		counts[priority] = count
 	}
 
-	description := currentTodo.Description
-	if todo.Description != nil {
-		description = *todo.Description
+	return counts, rows.Err()
+}
+
+// CountByCompletion counts the completed and pending todos, leaving out
+// archived ones like the lists do
+func (r *TodoRepository) CountByCompletion() (*models.TodoCount, error) {
+	query := `SELECT COUNT(*) FILTER (WHERE completed), COUNT(*) FILTER (WHERE NOT completed) FROM todos WHERE archived_at IS NULL`
+
+	var count models.TodoCount
+	if err := r.db.QueryRow(query).Scan(&count.Completed, &count.Pending); err != nil {
+		return nil, err
 	}
+	count.Total = count.Completed + count.Pending
+
+	return &count, nil
+}
+
+// ListSavedSearches returns all saved searches ordered by name
+func (r *TodoRepository) ListSavedSearches() ([]*models.SavedSearch, error) {
+	query := `
+		SELECT ` + savedSearchColumns + `
+		FROM saved_searches
+		ORDER BY name
+	`
 
-	completed := currentTodo.Completed
-	var completedAt *time.Time = currentTodo.CompletedAt
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if todo.Completed != nil && *todo.Completed != completed {
-		completed = *todo.Completed
-		if completed {
-			now := time.Now()
-			completedAt = &now
-		} else {
-			completedAt = nil
+	searches := []*models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
 		}
+		searches = append(searches, search)
 	}
 
-	// Update in database
+	return searches, rows.Err()
+}
+
+// GetSavedSearch retrieves a saved search by name. It returns
+// ErrSavedSearchNotFound if there is none.
+func (r *TodoRepository) GetSavedSearch(name string) (*models.SavedSearch, error) {
 	query := `
-		UPDATE todos
-		SET title = $1, description = $2, completed = $3, completed_at = $4, updated_at = NOW()
-		WHERE id = $5
-		RETURNING id, title, description, completed, created_at, updated_at, completed_at
+		SELECT ` + savedSearchColumns + `
+		FROM saved_searches
+		WHERE name = $1
 	`
 
-	var updatedTodo models.Todo
-	var nullCompletedAt sql.NullTime
-	if completedAt != nil {
-		nullCompletedAt = sql.NullTime{Time: *completedAt, Valid: true}
+	search, err := scanSavedSearch(r.db.QueryRow(query, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedSearchNotFound
 	}
 
-	err = r.db.QueryRow(
-		query,
-		title,
-		description,
-		completed,
-		nullCompletedAt,
-		id,
-	).Scan(
-		&updatedTodo.ID,
-		&updatedTodo.Title,
-		&updatedTodo.Description,
-		&updatedTodo.Completed,
-		&updatedTodo.CreatedAt,
-		&updatedTodo.UpdatedAt,
-		&nullCompletedAt,
-	)
+	return search, err
+}
+
+// CreateSavedSearch stores params under name. It returns
+// ErrSavedSearchExists if the name is taken.
+func (r *TodoRepository) CreateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
 
+	query := `
+		INSERT INTO saved_searches (name, params)
+		VALUES ($1, $2::jsonb)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING ` + savedSearchColumns
+
+	search, err := scanSavedSearch(r.db.QueryRow(query, name, string(encoded)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedSearchExists
+	}
+
+	return search, err
+}
+
+// UpdateSavedSearch replaces the params of the saved search called name. It
+// returns ErrSavedSearchNotFound if there is none.
+func (r *TodoRepository) UpdateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	encoded, err := json.Marshal(params)
 	if err != nil {
 		return nil, err
 	}
 
-	if nullCompletedAt.Valid {
-		updatedTodo.CompletedAt = &nullCompletedAt.Time
+	query := `
+		UPDATE saved_searches
+		SET params = $2::jsonb, updated_at = NOW()
+		WHERE name = $1
+		RETURNING ` + savedSearchColumns
+
+	search, err := scanSavedSearch(r.db.QueryRow(query, name, string(encoded)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSavedSearchNotFound
 	}
 
-	return &updatedTodo, nil
+	return search, err
 }
 
-// Delete removes a todo from the database
-func (r *TodoRepository) Delete(id int64) error {
-	query := `DELETE FROM todos WHERE id = $1`
+// DeleteSavedSearch removes the saved search called name. It returns
+// ErrSavedSearchNotFound if there is none.
+func (r *TodoRepository) DeleteSavedSearch(name string) error {
+	query := `DELETE FROM saved_searches WHERE name = $1`
 
-	_, err := r.db.Exec(query, id)
-	return err
+	result, err := r.db.Exec(query, name)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSavedSearchNotFound
+	}
+
+	return nil
 }
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/yourusername/todo-api/internal/db"
+)
+
+// dbtx is the part of *sql.DB and *sql.Tx used to run queries, so that a
+// TodoRepository can work on the connection pool or inside a transaction
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+// prepared runs the queries prepared in stmts as prepared statements and
+// every other query directly on the pool or transaction it wraps
+type prepared struct {
+	dbtx
+	stmts *db.PreparedStatements
+	// tx binds the prepared statements to a transaction, if there is one
+	tx *sql.Tx
+}
+
+// lookup returns the prepared statement for query, or nil
+func (p *prepared) lookup(query string) *sql.Stmt {
+	stmt := p.stmts.Lookup(query)
+	if stmt != nil && p.tx != nil {
+		return p.tx.Stmt(stmt)
+	}
+	return stmt
+}
+
+func (p *prepared) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if stmt := p.lookup(query); stmt != nil {
+		return stmt.Exec(args...)
+	}
+	return p.dbtx.Exec(query, args...)
+}
+
+func (p *prepared) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if stmt := p.lookup(query); stmt != nil {
+		return stmt.Query(args...)
+	}
+	return p.dbtx.Query(query, args...)
+}
+
+func (p *prepared) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if stmt := p.lookup(query); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return p.dbtx.QueryContext(ctx, query, args...)
+}
+
+func (p *prepared) QueryRow(query string, args ...interface{}) *sql.Row {
+	if stmt := p.lookup(query); stmt != nil {
+		return stmt.QueryRow(args...)
+	}
+	return p.dbtx.QueryRow(query, args...)
+}
+
+// preparedTx is a transaction that runs prepared queries through
+// *sql.Tx.Stmt
+type preparedTx struct {
+	*prepared
+}
+
+func (p *preparedTx) Commit() error   { return p.tx.Commit() }
+func (p *preparedTx) Rollback() error { return p.tx.Rollback() }
+
+// withStatements wraps queries so that those in r.stmts run prepared, on
+// tx if it is not nil. It returns queries unchanged without r.stmts.
+func (r *TodoRepository) withStatements(queries dbtx, tx *sql.Tx) dbtx {
+	if r.stmts == nil {
+		return queries
+	}
+	return &prepared{dbtx: queries, stmts: r.stmts, tx: tx}
+}
+
+// txn is a transaction started by TodoRepository.begin
+type txn interface {
+	dbtx
+	Commit() error
+	Rollback() error
+}
+
+// ErrNestedIsolation is returned by WithIsolation when called on the
+// repository passed to another WithIsolation callback. The isolation level
+// of a transaction cannot change once it has started.
+var ErrNestedIsolation = errors.New("repository: WithIsolation called inside a transaction")
+
+// WithTx calls fn with a repository whose methods all run in one
+// transaction at the database's default isolation level. The transaction
+// is committed if fn returns nil and rolled back otherwise. fn's error is
+// returned as is, so callers can match ErrNotFound with errors.Is.
+func (r *TodoRepository) WithTx(ctx context.Context, fn func(*TodoRepository) error) error {
+	return r.WithIsolation(ctx, sql.LevelDefault, fn)
+}
+
+// WithIsolation is WithTx at the given isolation level.
+//
+// Postgres defaults to sql.LevelReadCommitted, where each statement sees
+// the rows committed before it started. That suits single statements and
+// most reads, but a read-modify-write such as GetByID followed by Update
+// can overwrite a change committed in between. Under
+// sql.LevelRepeatableRead the transaction sees one snapshot, and writing a
+// row another transaction changed since fails with a serialization failure
+// (isRetryable reports true) instead of losing that change. Retry the whole
+// call when that happens. sql.LevelSerializable also fails transactions
+// whose reads another transaction invalidated, at the cost of more retries,
+// and is only needed when a decision depends on rows that were not written,
+// like a count.
+func (r *TodoRepository) WithIsolation(ctx context.Context, level sql.IsolationLevel, fn func(*TodoRepository) error) error {
+	if r.tx != nil {
+		return ErrNestedIsolation
+	}
+
+	tx, err := r.conn.BeginTx(ctx, &sql.TxOptions{Isolation: level})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	inTx := &TodoRepository{conn: r.conn, tx: tx, stmts: r.stmts, maxTodos: r.maxTodos}
+	inTx.db = r.withStatements(tx, tx)
+	if err := fn(inTx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// begin starts a transaction for a method that needs one. Inside WithTx it
+// is a savepoint of the enclosing transaction instead.
+func (r *TodoRepository) begin() (txn, error) {
+	if r.tx != nil {
+		if _, err := r.tx.Exec(`SAVEPOINT nested`); err != nil {
+			return nil, err
+		}
+		return &savepoint{Tx: r.tx}, nil
+	}
+
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if r.stmts != nil {
+		return &preparedTx{&prepared{dbtx: tx, stmts: r.stmts, tx: tx}}, nil
+	}
+	return tx, nil
+}
+
+// savepoint is a transaction nested in the one opened by WithTx. Like
+// *sql.Tx, it may only be committed or rolled back once, so a deferred
+// Rollback after Commit does nothing.
+type savepoint struct {
+	*sql.Tx
+	done bool
+}
+
+// Commit releases the savepoint, keeping its changes in the enclosing
+// transaction
+func (s *savepoint) Commit() error {
+	return s.end(`RELEASE SAVEPOINT nested`)
+}
+
+// Rollback undoes the changes made since the savepoint
+func (s *savepoint) Rollback() error {
+	return s.end(`ROLLBACK TO SAVEPOINT nested`)
+}
+
+// end runs the statement that finishes the savepoint, once
+func (s *savepoint) end(query string) error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+
+	_, err := s.Exec(query)
+	return err
+}
@@ -1,31 +1,227 @@
 package router
 
 import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"time"
+
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/yourusername/todo-api/internal/config"
 	"github.com/yourusername/todo-api/internal/handlers"
+	"github.com/yourusername/todo-api/internal/middleware"
+	"github.com/yourusername/todo-api/internal/notify"
 	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/service"
+	"github.com/yourusername/todo-api/internal/watch"
 )
 
-// SetupRouter configures the HTTP router
+// SetupRouter wires the application's dependencies from cfg and configures
+// the HTTP router
 func SetupRouter(cfg *config.Config) *mux.Router {
-	r := mux.NewRouter()
-
 	// Initialize repositories
-	todoRepo := repository.NewTodoRepository(cfg.DB)
+	var todoRepo repository.TodoRepositoryInterface
+	var searchRefresher *repository.SearchIndexRefresher
+	if cfg.Pool != nil {
+		todoRepo = repository.NewPgxTodoRepository(cfg.Pool, cfg.MaxTodosPerUser)
+		searchRefresher = repository.NewPgxSearchIndexRefresher(cfg.Pool)
+	} else {
+		todoRepo = repository.NewTodoRepository(cfg.DB, cfg.MaxTodosPerUser, cfg.Statements)
+		searchRefresher = repository.NewSQLSearchIndexRefresher(cfg.DB)
+	}
+
+	// Decorators, innermost first: the slow query logger wraps the concrete
+	// repository, so each retried attempt is timed on its own and neither the
+	// retry backoff nor the search refresh trigger counts toward a query's
+	// time. Search refreshing, retries and then metrics wrap it in turn.
+	if cfg.LogSlowQueries {
+		todoRepo = repository.NewSlowQueryLoggerRepository(todoRepo, cfg.SlowQueryThreshold, slog.Default())
+	}
+
+	// Refresh the search materialized view in the background at startup and
+	// after writes. Read-only mode writes nothing, not even the view.
+	go searchRefresher.Run(context.Background())
+	if !cfg.ReadOnly {
+		searchRefresher.Trigger()
+	}
+	todoRepo = repository.NewSearchRefreshingRepository(todoRepo, searchRefresher)
+	todoRepo = repository.NewRetryableRepository(todoRepo)
+	todoRepo = repository.NewInstrumentedTodoRepository(todoRepo, prometheus.DefaultRegisterer)
+
+	// Initialize services
+	todoService := service.NewTodoService(todoRepo)
+
+	// Start listening for todo changes from the database
+	broker := watch.NewBroker()
+	go func() {
+		var err error
+		if cfg.Pool != nil {
+			err = watch.ListenPgx(context.Background(), cfg.Pool, broker)
+		} else {
+			err = watch.ListenPQ(context.Background(), cfg.DBConfig.ConnString(), broker)
+		}
+		log.Printf("Todo change listener stopped: %v", err)
+	}()
+
+	// Reminders record which todos they were sent for, so read-only mode
+	// does not start them
+	if !cfg.ReadOnly && (cfg.Notify.Overdue || cfg.Notify.DueSoon) {
+		var notifiers []notify.Notifier
+		if cfg.Notify.EmailEnabled() {
+			mailer := notify.NewSMTPMailer(cfg.Notify.SMTPHost, cfg.Notify.SMTPPort, cfg.Notify.SMTPUser, cfg.Notify.SMTPPassword, cfg.Notify.From)
+			notifiers = append(notifiers, notify.NewEmailNotifier(mailer, cfg.Notify.To))
+		}
+		if cfg.Notify.SlackEnabled() {
+			notifiers = append(notifiers, notify.NewSlackNotifier(cfg.Notify.SlackWebhookURL, cfg.Notify.PublicURL))
+		}
+		if cfg.Notify.Overdue {
+			go notify.NewOverdueNotifier(todoRepo, notifiers, cfg.Notify.OverdueInterval).Run(context.Background())
+		}
+		if cfg.Notify.DueSoon {
+			go notify.NewDueSoonNotifier(todoRepo, notifiers, cfg.Notify.DueSoonInterval, cfg.Notify.DueSoonWindow).Run(context.Background())
+		}
+	}
+
+	r := NewRouter(todoService, broker)
+	r.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	// Registered first so it also catches panics in the other middleware
+	r.Use(middleware.Recover(slog.Default()))
+
+	if cfg.LogRequests {
+		r.Use(middleware.LogRequests(slog.Default()))
+	}
+
+	if cfg.ServerTiming {
+		r.Use(middleware.ServerTiming)
+	}
+
+	r.Use(middleware.CORS(cfg.CORSAllowedOrigins))
+
+	// Admin routes are off unless TODO_ADMIN_TOKEN is set, and then need it
+	r.Use(middleware.RequireToken(cfg.AdminToken, "/api/v1/admin/"))
+
+	// Parsing quick entry text only reads the timezone, so it keeps working
+	if cfg.ReadOnly {
+		r.Use(middleware.ReadOnly("/api/v1/todos/parse"))
+	}
+
+	// Replay responses to retried writes carrying an Idempotency-Key
+	var idempotencyStore repository.IdempotencyStore
+	if cfg.Pool != nil {
+		idempotencyStore = repository.NewPgxIdempotencyStore(cfg.Pool)
+	} else {
+		idempotencyStore = repository.NewSQLIdempotencyStore(cfg.DB)
+	}
+	r.Use(middleware.Idempotency(idempotencyStore))
+	if !cfg.ReadOnly {
+		go middleware.ExpireIdempotencyKeys(context.Background(), idempotencyStore, time.Hour)
+	}
+
+	return r
+}
+
+// NewRouter registers the application routes backed by the given service
+// and change broker
+func NewRouter(todoService *service.TodoService, broker *watch.Broker) *mux.Router {
+	r := mux.NewRouter()
 
 	// Initialize handlers
-	todoHandler := handlers.NewTodoHandler(todoRepo)
+	todoHandler := handlers.NewTodoHandler(todoService)
+	webHandler := handlers.NewWebHandler(todoService)
+	watchHandler := handlers.NewWatchHandler(todoService, broker)
+	categoryHandler := handlers.NewCategoryHandler(todoService)
+	savedSearchHandler := handlers.NewSavedSearchHandler(todoService)
 
 	// Define API routes
 	api := r.PathPrefix("/api/v1").Subrouter()
 
+	// Imports take CSV, iCalendar and Markdown bodies; everything else is JSON
+	api.Use(middleware.RequireJSON("/api/v1/todos/import"))
+
 	// Todo routes
 	api.HandleFunc("/todos", todoHandler.GetAllTodos).Methods("GET")
+	api.HandleFunc("/todos/ids", todoHandler.GetAllTodoIDs).Methods("GET")
+	api.HandleFunc("/todos/count", todoHandler.GetTodoCount).Methods("GET")
+	api.HandleFunc("/todos/grouped", todoHandler.GetGroupedTodos).Methods("GET")
+	api.HandleFunc("/todos/digest", todoHandler.GetDigest).Methods("GET")
 	api.HandleFunc("/todos/{id:[0-9]+}", todoHandler.GetTodo).Methods("GET")
 	api.HandleFunc("/todos", todoHandler.CreateTodo).Methods("POST")
+	api.HandleFunc("/todos", todoHandler.DeleteCompletedTodos).Methods("DELETE")
+	api.HandleFunc("/todos/parse", todoHandler.ParseTodo).Methods("POST")
+	api.HandleFunc("/todos/import", todoHandler.ImportTodos).Methods("POST")
+	api.HandleFunc("/todos/import/ical", todoHandler.ImportICalTodos).Methods("POST")
+	api.HandleFunc("/todos/import/markdown", todoHandler.ImportMarkdownTodos).Methods("POST")
+	api.HandleFunc("/todos/export", todoHandler.ExportTodos).Methods("GET")
+	api.HandleFunc("/todos/batch", todoHandler.BatchUpdateTodos).Methods("PATCH")
+	api.HandleFunc("/todos/bulk-archive", todoHandler.BulkArchiveTodos).Methods("POST")
+	api.HandleFunc("/todos/bulk-shift-due", todoHandler.ShiftDueDates).Methods("PATCH")
+	api.HandleFunc("/todos/reorder", todoHandler.ReorderTodos).Methods("PATCH")
 	api.HandleFunc("/todos/{id:[0-9]+}", todoHandler.UpdateTodo).Methods("PUT")
+	api.HandleFunc("/todos/{id:[0-9]+}", todoHandler.HandleMergePatch).Methods("PATCH")
+	api.HandleFunc("/todos/external/{external_id}", todoHandler.UpsertTodo).Methods("PUT")
 	api.HandleFunc("/todos/{id:[0-9]+}", todoHandler.DeleteTodo).Methods("DELETE")
+	api.HandleFunc("/todos/{id:[0-9]+}/complete", todoHandler.CompleteTodo).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/uncomplete", todoHandler.UncompleteTodo).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/pin", todoHandler.PinTodo).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/pin", todoHandler.UnpinTodo).Methods("DELETE")
+	api.HandleFunc("/todos/{id:[0-9]+}/tags", todoHandler.SetTodoTags).Methods("PUT")
+	api.HandleFunc("/todos/{id:[0-9]+}/snooze", todoHandler.SnoozeTodo).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/notes", todoHandler.AddNote).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/react", todoHandler.ReactToTodo).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/react", todoHandler.UnreactToTodo).Methods("DELETE")
+	api.HandleFunc("/todos/{id:[0-9]+}/dependencies", todoHandler.GetDependencies).Methods("GET")
+	api.HandleFunc("/todos/{id:[0-9]+}/dependencies", todoHandler.AddDependency).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/dependencies/{blocking_id:[0-9]+}", todoHandler.RemoveDependency).Methods("DELETE")
+	api.HandleFunc("/todos/{id:[0-9]+}/related", todoHandler.GetRelatedTodos).Methods("GET")
+	api.HandleFunc("/todos/{id:[0-9]+}/timeline", todoHandler.GetTodoTimeline).Methods("GET")
+	api.HandleFunc("/todos/{id:[0-9]+}/breadcrumbs", todoHandler.GetTodoBreadcrumbs).Methods("GET")
+	api.HandleFunc("/todos/{id:[0-9]+}/convert-to-project", todoHandler.ConvertToProject).Methods("POST")
+	api.HandleFunc("/todos/{id:[0-9]+}/watch", watchHandler.WatchTodo).Methods("GET")
+
+	// Activity routes
+	api.HandleFunc("/activity", todoHandler.GetActivity).Methods("GET")
+
+	// Stats routes
+	api.HandleFunc("/stats", todoHandler.GetStats).Methods("GET")
+	api.HandleFunc("/stats/trend", todoHandler.GetCompletionTrend).Methods("GET")
+	api.HandleFunc("/stats/streak", todoHandler.GetCompletionStreak).Methods("GET")
+
+	// Current user routes
+	api.HandleFunc("/me", todoHandler.GetProfile).Methods("GET")
+	api.HandleFunc("/me/timezone", todoHandler.SetTimezone).Methods("PUT")
+	api.HandleFunc("/me/preferences", todoHandler.GetPreferences).Methods("GET")
+	api.HandleFunc("/me/preferences", todoHandler.UpdatePreferences).Methods("PUT")
+	api.Handle("/me/export", middleware.OncePer(time.Hour)(http.HandlerFunc(todoHandler.ExportPersonalData))).Methods("GET")
+
+	// Category routes
+	api.HandleFunc("/categories", categoryHandler.GetAllCategories).Methods("GET")
+	api.HandleFunc("/categories", categoryHandler.CreateCategory).Methods("POST")
+	api.HandleFunc("/categories/tree", categoryHandler.GetCategoryTree).Methods("GET")
+	api.HandleFunc("/categories/{id:[0-9]+}/duplicate", categoryHandler.DuplicateCategory).Methods("POST")
+	api.HandleFunc("/categories/{id:[0-9]+}/todos/move", categoryHandler.MoveTodos).Methods("POST")
+	api.HandleFunc("/categories/{id:[0-9]+}/complete-all", categoryHandler.CompleteAllTodos).Methods("POST")
+	api.HandleFunc("/categories/uncategorized/todos/move", categoryHandler.UncategorizeTodos).Methods("POST")
+
+	// Admin routes
+	api.HandleFunc("/admin/vacuum", todoHandler.VacuumTodos).Methods("POST")
+
+	// Saved search routes
+	api.HandleFunc("/search/saved", savedSearchHandler.GetAllSavedSearches).Methods("GET")
+	api.HandleFunc("/search/saved", savedSearchHandler.CreateSavedSearch).Methods("POST")
+	api.HandleFunc("/search/saved/{name}", savedSearchHandler.GetSavedSearch).Methods("GET")
+	api.HandleFunc("/search/saved/{name}", savedSearchHandler.UpdateSavedSearch).Methods("PUT")
+	api.HandleFunc("/search/saved/{name}", savedSearchHandler.DeleteSavedSearch).Methods("DELETE")
+
+	// Answer CORS preflight requests for every API route
+	api.Methods(http.MethodOptions).HandlerFunc(preflightHandler(r))
+
+	// HTML routes
+	r.HandleFunc("/", webHandler.Index).Methods("GET")
+	r.HandleFunc("/todos/{id:[0-9]+}/toggle", webHandler.ToggleTodo).Methods("POST")
 
 	return r
 }
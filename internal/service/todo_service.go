@@ -0,0 +1,1199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"html"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/i18n"
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/validator"
+)
+
+// ValidationError reports a request that failed a business rule. Handlers
+// map it to 400 Bad Request.
+type ValidationError struct {
+	// Key identifies the message in the i18n catalogue
+	Key  string
+	Args []interface{}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Localize(i18n.DefaultLanguage)
+}
+
+// Localize returns the error message in lang, translating any wrapped
+// ValidationError arguments too
+func (e *ValidationError) Localize(lang string) string {
+	args := make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		if inner, ok := arg.(*ValidationError); ok {
+			arg = inner.Localize(lang)
+		}
+		args[i] = arg
+	}
+
+	return i18n.Message(lang, e.Key, args...)
+}
+
+// Warning reports something a request could only partly honour. Unlike a
+// ValidationError it does not fail the request; handlers send it alongside
+// the result.
+type Warning struct {
+	// Key identifies the message in the i18n catalogue
+	Key  string
+	Args []interface{}
+}
+
+// Localize returns the warning message in lang
+func (w Warning) Localize(lang string) string {
+	return i18n.Message(lang, w.Key, w.Args...)
+}
+
+// invalid creates a ValidationError for the message key
+func invalid(key string, args ...interface{}) error {
+	return &ValidationError{Key: key, Args: args}
+}
+
+// validateRequest checks req against its validate struct tags and reports
+// the first field that fails
+func validateRequest(req interface{}) error {
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return invalid(errs[0].Key, errs[0].Args...)
+	}
+	return nil
+}
+
+// TodoService holds the business rules for todos. Handlers translate HTTP
+// to service calls; the repository only stores and loads data.
+type TodoService struct {
+	repo repository.TodoRepositoryInterface
+}
+
+// NewTodoService creates a new TodoService
+func NewTodoService(repo repository.TodoRepositoryInterface) *TodoService {
+	return &TodoService{
+		repo: repo,
+	}
+}
+
+// List returns the page of todos matching filter and the total number of
+// matching todos
+func (s *TodoService) List(filter repository.TodoFilter) ([]*models.Todo, int64, error) {
+	if filter.Due == repository.DueToday && filter.Timezone == "" {
+		tz, err := s.repo.GetTimezone()
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.Timezone = tz
+	}
+
+	return s.repo.GetAll(filter)
+}
+
+// Highlight shows where query matched each todo, wrapping the matched words
+// of its title and description in <tag> elements. The rest of the text is
+// HTML-escaped, so the highlights are safe to render as markup.
+func (s *TodoService) Highlight(todos []*models.Todo, query, tag string) ([]*models.HighlightedTodo, error) {
+	ids := make([]int64, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	raw, err := s.repo.GetHighlights(ids, query)
+	if err != nil {
+		return nil, err
+	}
+
+	marks := strings.NewReplacer(
+		repository.HighlightStart, "<"+tag+">",
+		repository.HighlightStop, "</"+tag+">",
+	)
+
+	highlighted := make([]*models.HighlightedTodo, len(todos))
+	for i, todo := range todos {
+		fields := map[string]string{"title": "", "description": ""}
+		for name, text := range raw[todo.ID] {
+			fields[name] = marks.Replace(html.EscapeString(text))
+		}
+		highlighted[i] = &models.HighlightedTodo{Todo: todo, Highlight: fields}
+	}
+
+	return highlighted, nil
+}
+
+// Stream calls fn for each todo matching filter without loading them all
+func (s *TodoService) Stream(ctx context.Context, filter repository.TodoFilter, fn func(*models.Todo) error) error {
+	return s.repo.StreamAll(ctx, filter, fn)
+}
+
+// StreamIDs calls fn with the ID of every todo in ascending order, as they
+// are read
+func (s *TodoService) StreamIDs(ctx context.Context, fn func(int64) error) error {
+	return s.repo.StreamAllIDs(ctx, fn)
+}
+
+// Get returns the todo with the given ID, or repository.ErrNotFound if it
+// does not exist
+func (s *TodoService) Get(id int64) (*models.Todo, error) {
+	return s.repo.GetByID(id)
+}
+
+// Related suggests up to limit incomplete todos similar to todo id. It
+// returns repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) Related(id int64, limit int) ([]*models.Todo, error) {
+	if limit < 1 || limit > models.MaxRelatedTodos {
+		return nil, invalid("err.related.limit", models.MaxRelatedTodos)
+	}
+
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetRelated(id, limit)
+}
+
+// Breadcrumbs returns the path to todo id through its category and that
+// category's ancestors, root first and ending with the todo itself. It
+// returns repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) Breadcrumbs(id int64) ([]models.Breadcrumb, error) {
+	todo, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var crumbs []models.Breadcrumb
+	if todo.CategoryID != nil {
+		ancestors, err := s.repo.GetCategoryAncestors(*todo.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range ancestors {
+			crumbs = append(crumbs, models.Breadcrumb{ID: category.ID, Title: category.Name, Type: models.BreadcrumbCategory})
+		}
+	}
+
+	return append(crumbs, models.Breadcrumb{ID: todo.ID, Title: todo.Title, Type: models.BreadcrumbTodo}), nil
+}
+
+// GroupedByCategory returns every category with its todos, in category
+// creation order, followed by the uncategorized todos. Each group keeps the
+// todos' board order, by position and then creation time.
+func (s *TodoService) GroupedByCategory() ([]*models.CategoryGroup, error) {
+	// Read the todos first, so every category they are in is listed even if
+	// one is created in between
+	todos, err := s.repo.GetAllByCategory()
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.repo.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].ID < categories[j].ID
+	})
+
+	groups := make([]*models.CategoryGroup, 0, len(categories)+1)
+	byID := make(map[int64]*models.CategoryGroup, len(categories))
+	for _, category := range categories {
+		group := &models.CategoryGroup{
+			Category: &models.CategoryRef{ID: category.ID, Name: category.Name},
+			Todos:    []*models.Todo{},
+		}
+		groups = append(groups, group)
+		byID[category.ID] = group
+	}
+
+	uncategorized := &models.CategoryGroup{Todos: []*models.Todo{}}
+	groups = append(groups, uncategorized)
+
+	for _, todo := range todos {
+		group := uncategorized
+		if todo.CategoryID != nil {
+			if byID[*todo.CategoryID] != nil {
+				group = byID[*todo.CategoryID]
+			} else {
+				// The category went away after the todos were read, and
+				// took the todo's category with it
+				todo.CategoryID = nil
+			}
+		}
+		group.Todos = append(group.Todos, todo)
+	}
+
+	return groups, nil
+}
+
+// GroupedByPriority returns the todos at each priority, lowest first. Each
+// group keeps the same board order as GroupedByCategory.
+func (s *TodoService) GroupedByPriority() ([]*models.PriorityGroup, error) {
+	todos, err := s.repo.GetAllByCategory()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*models.PriorityGroup
+	for p := models.PriorityLow; p <= models.PriorityHigh; p++ {
+		groups = append(groups, &models.PriorityGroup{
+			Priority: p,
+			Label:    models.PriorityLabels[p],
+			Todos:    []*models.Todo{},
+		})
+	}
+
+	for _, todo := range todos {
+		group := groups[todo.Priority-models.PriorityLow]
+		group.Todos = append(group.Todos, todo)
+	}
+
+	return groups, nil
+}
+
+// Timeline returns how todo id changed over time, oldest event first. It
+// returns repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) Timeline(id int64) ([]*models.TimelineEvent, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTimeline(id)
+}
+
+// Activity returns up to limit changes across all todos, newest first,
+// starting below the entry with ID cursor unless it is 0
+func (s *TodoService) Activity(cursor int64, limit int) (*models.ActivityFeed, error) {
+	if limit < 1 || limit > models.MaxActivityLimit {
+		return nil, invalid("err.activity.limit", models.MaxActivityLimit)
+	}
+
+	// Read one entry more than asked for to learn whether older ones remain
+	entries, err := s.repo.GetActivity(cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &models.ActivityFeed{Data: entries}
+	if len(entries) > limit {
+		feed.Data = entries[:limit]
+		feed.NextCursor = strconv.FormatInt(feed.Data[limit-1].ID, 10)
+	}
+	return feed, nil
+}
+
+// DeletedAt returns when todo id was deleted, or nil if it never existed
+// or still does
+func (s *TodoService) DeletedAt(id int64) (*time.Time, error) {
+	return s.repo.GetDeletedAt(id)
+}
+
+// LastModified returns when any todo was last created, changed or deleted
+func (s *TodoService) LastModified() (time.Time, error) {
+	return s.repo.LastModified()
+}
+
+// Create validates and stores a new todo. The repository returns
+// repository.ErrTodoLimitExceeded once the configured maximum is reached.
+func (s *TodoService) Create(req *models.CreateTodoRequest) (*models.Todo, error) {
+	if err := validateCreate(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.requireCategory(req.CategoryID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(req)
+}
+
+// Upsert validates and stores a todo keyed by its external ID. The returned
+// bool is true when a new todo was created. Completed only applies to a new
+// todo, so an upsert cannot complete a todo its dependencies block.
+func (s *TodoService) Upsert(req *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	req.Normalize()
+	if req.ExternalID == nil || *req.ExternalID == "" {
+		return nil, false, invalid("err.external_id.required")
+	}
+
+	if err := validateCreate(req); err != nil {
+		return nil, false, err
+	}
+
+	if err := s.requireCategory(req.CategoryID); err != nil {
+		return nil, false, err
+	}
+
+	return s.repo.Upsert(req)
+}
+
+// requireCategory returns repository.ErrCategoryNotFound unless id is nil
+// or names an existing category
+func (s *TodoService) requireCategory(id *int64) error {
+	if id == nil {
+		return nil
+	}
+
+	_, err := s.repo.GetCategoryByID(*id)
+	return err
+}
+
+// Import validates and stores many todos at once, returning the number stored
+func (s *TodoService) Import(todos []*models.CreateTodoRequest) (int64, error) {
+	for i, todo := range todos {
+		if err := validateCreate(todo); err != nil {
+			return 0, invalid("err.import.row", i+1, err)
+		}
+	}
+
+	return s.repo.BulkCreate(todos)
+}
+
+// ImportReport is the outcome of an import that skips the records it cannot
+// use. Each skipped record has a ValidationError in Skipped.
+type ImportReport struct {
+	Imported          int64
+	Skipped           []error
+	CategoriesCreated []string
+}
+
+// ImportValid stores the todos that pass validation in one batch and skips
+// the rest. A nil todo is a record the caller could not parse, and failed
+// holds its error by index. Skipped records are reported in order as
+// err.import.item errors, numbered from 1.
+func (s *TodoService) ImportValid(todos []*models.CreateTodoRequest, failed map[int]error) (*ImportReport, error) {
+	var valid []*models.CreateTodoRequest
+	var skipped []error
+	for i, todo := range todos {
+		err := failed[i]
+		if err == nil {
+			err = validateCreate(todo)
+		}
+		if err != nil {
+			skipped = append(skipped, invalid("err.import.item", i+1, err))
+			continue
+		}
+		valid = append(valid, todo)
+	}
+
+	imported, err := s.repo.BulkCreate(valid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImportReport{Imported: imported, Skipped: skipped}, nil
+}
+
+// ImportGroups stores the todos of each group under the top-level category
+// with the group's name, creating categories that do not exist yet. Todos
+// that fail validation are skipped like in ImportValid, numbered from 1
+// across all groups. Every group is checked before anything is written,
+// and the categories and other todos are then stored in one transaction.
+func (s *TodoService) ImportGroups(groups []models.ImportGroup) (*ImportReport, error) {
+	var valid []models.ImportGroup
+	var skipped []error
+	n := 0
+	for _, group := range groups {
+		var todos []*models.CreateTodoRequest
+		for _, todo := range group.Todos {
+			n++
+			if err := validateCreate(todo); err != nil {
+				skipped = append(skipped, invalid("err.import.item", n, err))
+				continue
+			}
+			todos = append(todos, todo)
+		}
+
+		name := strings.TrimSpace(group.Category)
+		if name != "" && len(todos) > 0 {
+			if err := validateRequest(&models.CreateCategoryRequest{Name: name}); err != nil {
+				return nil, err
+			}
+		}
+		valid = append(valid, models.ImportGroup{Category: name, Todos: todos})
+	}
+
+	created, imported, err := s.repo.ImportGroups(valid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImportReport{Imported: imported, Skipped: skipped, CategoriesCreated: created}, nil
+}
+
+// Update applies a partial update to a todo, stamping completed_at when it
+// becomes completed. It returns repository.ErrNotFound if the todo does not
+// exist, and repository.ErrBlocked if it would complete a todo that depends
+// on an incomplete one.
+func (s *TodoService) Update(id int64, req *models.UpdateTodoRequest) (*models.Todo, error) {
+	req.Normalize()
+
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	// Read and write in one locked transaction, so a concurrent delete or
+	// update cannot slip in between, or a dependency be completed too early
+	return s.repo.UpdateWith(id, func(current *models.Todo) *models.Todo {
+		return applyUpdate(current, req)
+	})
+}
+
+// SetCompleted marks a todo as completed or not completed. The repository
+// returns repository.ErrBlocked when completing a todo that depends on an
+// incomplete one.
+func (s *TodoService) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	return s.repo.SetCompleted(id, completed)
+}
+
+// Toggle flips the completion state of a todo. It returns
+// repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) Toggle(id int64) (*models.Todo, error) {
+	todo, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.SetCompleted(id, !todo.Completed)
+}
+
+// SetPinned pins or unpins a todo. The repository returns
+// repository.ErrPinLimitReached when too many todos are pinned.
+func (s *TodoService) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	return s.repo.SetPinned(id, pinned)
+}
+
+// React adds a reaction to a todo and returns its reactions. It returns
+// repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) React(id int64, req *models.ReactionRequest) ([]models.Reaction, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddReaction(id, req.Emoji); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetReactions(id)
+}
+
+// Unreact removes a reaction from a todo and returns the reactions left.
+// It returns repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) Unreact(id int64, req *models.ReactionRequest) ([]models.Reaction, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RemoveReaction(id, req.Emoji); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetReactions(id)
+}
+
+// Reactions returns the reactions to a todo, one per emoji
+func (s *TodoService) Reactions(id int64) ([]models.Reaction, error) {
+	return s.repo.GetReactions(id)
+}
+
+// AddDependency makes a todo depend on another and returns the todos it now
+// depends on. It returns repository.ErrNotFound if either todo does not
+// exist and repository.ErrDependencyCycle if the other todo already depends
+// on it.
+func (s *TodoService) AddDependency(id int64, req *models.AddDependencyRequest) ([]models.Blocker, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	if req.BlockingTodoID == id {
+		return nil, invalid("err.dependency.self")
+	}
+
+	if err := s.repo.AddDependency(id, req.BlockingTodoID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetBlockers(id)
+}
+
+// RemoveDependency stops a todo depending on another. It returns
+// repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) RemoveDependency(id, blockingID int64) error {
+	return s.repo.RemoveDependency(id, blockingID)
+}
+
+// Dependencies returns the todos a todo depends on. It returns
+// repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) Dependencies(id int64) ([]models.Blocker, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBlockers(id)
+}
+
+// SetTags validates and replaces the tags of a todo. Tags are trimmed,
+// lowercased and deduplicated.
+func (s *TodoService) SetTags(id int64, req *models.SetTagsRequest) (*models.Todo, error) {
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.SetTags(id, tags)
+}
+
+// normalizeTags trims, lowercases and deduplicates tag names
+func normalizeTags(raw []string) ([]string, error) {
+	tags := []string{}
+	seen := make(map[string]bool, len(raw))
+
+	for _, tag := range raw {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return nil, invalid("err.tags.empty")
+		}
+		if len(tag) > models.MaxTagLength {
+			return nil, invalid("err.tags.too_long", models.MaxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// Snooze pushes a todo's due date back by days
+func (s *TodoService) Snooze(id int64, days int) (*models.Todo, error) {
+	if days < 1 || days > models.MaxSnoozeDays {
+		return nil, invalid("err.snooze.days", models.MaxSnoozeDays)
+	}
+
+	return s.repo.Snooze(id, days)
+}
+
+// AddNote appends a note to a todo's notes log
+func (s *TodoService) AddNote(id int64, req *models.AddNoteRequest) (*models.Todo, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	return s.repo.AppendNote(id, req.Body)
+}
+
+// BatchUpdate applies a single-field update to several todos
+func (s *TodoService) BatchUpdate(req *models.BatchUpdateRequest) (*models.BatchUpdateResult, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	if (req.Priority == nil) == (req.CategoryID == nil) {
+		return nil, invalid("err.batch.one_field")
+	}
+
+	var updated []int64
+	var err error
+	if req.Priority != nil {
+		updated, err = s.repo.BatchUpdatePriority(req.IDs, *req.Priority)
+	} else if err = s.requireCategory(req.CategoryID); err == nil {
+		updated, err = s.repo.BatchUpdateCategory(req.IDs, *req.CategoryID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BatchUpdateResult{
+		Updated:  len(updated),
+		NotFound: missingIDs(req.IDs, updated),
+	}, nil
+}
+
+// BulkArchive archives the requested todos, hiding them from lists, or
+// unarchives them when req.Action is models.BulkUnarchive
+func (s *TodoService) BulkArchive(req *models.BulkArchiveRequest) (*models.BulkArchiveResult, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	if len(req.IDs) > models.MaxBulkIDs {
+		return nil, invalid("err.ids.max", models.MaxBulkIDs)
+	}
+
+	archive := req.Action != models.BulkUnarchive
+	changed, unchanged, err := s.repo.SetArchived(req.IDs, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BulkArchiveResult{
+		Updated:  len(changed),
+		NotFound: missingIDs(req.IDs, append(append([]int64{}, changed...), unchanged...)),
+	}
+	if archive {
+		result.AlreadyArchived = unchanged
+	} else {
+		result.NotArchived = unchanged
+	}
+
+	return result, nil
+}
+
+// ShiftDueDates moves the due dates of the requested todos by
+// req.ShiftDays days. Todos without a due date are counted as skipped.
+func (s *TodoService) ShiftDueDates(req *models.ShiftDueRequest) (*models.ShiftDueResult, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	if len(req.IDs) > models.MaxBulkIDs {
+		return nil, invalid("err.ids.max", models.MaxBulkIDs)
+	}
+	if req.ShiftDays < -models.MaxShiftDays || req.ShiftDays > models.MaxShiftDays {
+		return nil, invalid("err.shift_days.range", models.MaxShiftDays, models.MaxShiftDays)
+	}
+
+	shifted, skipped, err := s.repo.ShiftDueDates(req.IDs, req.ShiftDays)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ShiftDueResult{
+		Updated:          len(shifted),
+		SkippedNoDueDate: len(skipped),
+		NotFound:         missingIDs(req.IDs, append(append([]int64{}, shifted...), skipped...)),
+	}, nil
+}
+
+// Delete removes a todo and returns it as it was before the delete. It
+// returns repository.ErrNotFound if the todo does not exist.
+func (s *TodoService) Delete(id int64) (*models.Todo, error) {
+	return s.repo.Delete(id)
+}
+
+// DeleteAllCompleted removes every completed, unarchived todo and returns how
+// many were deleted
+func (s *TodoService) DeleteAllCompleted() (int64, error) {
+	return s.repo.DeleteAllCompleted()
+}
+
+// CreateCategory validates and creates a new category
+func (s *TodoService) CreateCategory(req *models.CreateCategoryRequest) (*models.Category, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		depth, err := s.repo.GetCategoryDepth(*req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if depth == 0 {
+			return nil, invalid("err.category.parent_not_found")
+		}
+		if depth >= models.MaxCategoryDepth {
+			return nil, invalid("err.category.too_deep", models.MaxCategoryDepth)
+		}
+	}
+
+	return s.repo.CreateCategory(req.Name, req.ParentID)
+}
+
+// DuplicateCategory copies a category and its todos. It returns
+// repository.ErrCategoryNotFound if the category does not exist.
+func (s *TodoService) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	return s.repo.DuplicateCategory(id)
+}
+
+// VacuumCompleted archives todos completed more than olderThanDays days ago
+// and returns how many were archived
+func (s *TodoService) VacuumCompleted(olderThanDays int) (int64, error) {
+	if olderThanDays < 1 {
+		return 0, invalid("err.vacuum.days")
+	}
+
+	return s.repo.VacuumCompleted(olderThanDays)
+}
+
+// ConvertToProject replaces a todo with a category named after its title,
+// nested under the todo's category. The todo is archived, so it can be
+// restored later. It returns repository.ErrNotFound if the todo does not
+// exist.
+func (s *TodoService) ConvertToProject(id int64) (*models.ProjectConversion, error) {
+	category, err := s.repo.ConvertToCategory(id)
+	if errors.Is(err, repository.ErrCategoryTooDeep) {
+		return nil, invalid("err.category.too_deep", models.MaxCategoryDepth)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProjectConversion{Category: category}, nil
+}
+
+// CategoryTree returns all categories nested under their parents
+func (s *TodoService) CategoryTree() ([]*models.CategoryNode, error) {
+	categories, err := s.repo.GetCategoryTree()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parents are listed before their children, so each parent's node
+	// already exists when a child is reached
+	roots := []*models.CategoryNode{}
+	nodes := make(map[int64]*models.CategoryNode, len(categories))
+	for _, category := range categories {
+		node := &models.CategoryNode{ID: category.ID, Name: category.Name, Children: []*models.CategoryNode{}}
+		nodes[category.ID] = node
+
+		if category.ParentID == nil {
+			roots = append(roots, node)
+		} else if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	return roots, nil
+}
+
+// ListCategories returns all categories
+func (s *TodoService) ListCategories() ([]*models.Category, error) {
+	return s.repo.GetAllCategories()
+}
+
+// Reorder applies a drag-and-drop order. Every todo must exist, and no todo
+// or position may appear twice.
+func (s *TodoService) Reorder(req *models.ReorderRequest) (*models.ReorderResult, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[int64]bool, len(req.Order))
+	positions := make(map[int]bool, len(req.Order))
+	for _, p := range req.Order {
+		if ids[p.ID] {
+			return nil, invalid("err.reorder.duplicate_id", p.ID)
+		}
+		if positions[p.Position] {
+			return nil, invalid("err.reorder.duplicate_position", p.Position)
+		}
+		ids[p.ID] = true
+		positions[p.Position] = true
+	}
+
+	updated, err := s.repo.Reorder(req.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReorderResult{Updated: updated}, nil
+}
+
+// MoveTodos moves the requested todos into categoryID, or out of any
+// category when it is nil, and returns how many were moved
+func (s *TodoService) MoveTodos(categoryID *int64, req *models.MoveTodosRequest) (*models.MoveTodosResult, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.requireCategory(categoryID); err != nil {
+		return nil, err
+	}
+
+	moved, err := s.repo.MoveTodos(req.TodoIDs, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MoveTodosResult{Moved: moved}, nil
+}
+
+// CompleteAllInCategory completes every incomplete todo in category id,
+// closing out a project, and returns how many were completed. Todos
+// blocked by an incomplete todo stay open and are listed in the result.
+// Todos in its subcategories are left alone.
+func (s *TodoService) CompleteAllInCategory(id int64) (*models.CompleteAllResult, error) {
+	if err := s.requireCategory(&id); err != nil {
+		return nil, err
+	}
+
+	completed, blocked, err := s.repo.CompleteAllInCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CompleteAllResult{Completed: len(completed), Blocked: append([]int64{}, blocked...)}, nil
+}
+
+// savedSearchName is the form of a saved search name: lowercase letters and
+// digits in words joined by hyphens, so it reads well in ?saved_search=
+var savedSearchName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ListSavedSearches returns all saved searches
+func (s *TodoService) ListSavedSearches() ([]*models.SavedSearch, error) {
+	return s.repo.ListSavedSearches()
+}
+
+// GetSavedSearch returns the saved search called name, or
+// repository.ErrSavedSearchNotFound if there is none
+func (s *TodoService) GetSavedSearch(name string) (*models.SavedSearch, error) {
+	return s.repo.GetSavedSearch(name)
+}
+
+// CreateSavedSearch validates and stores a named search. The caller checks
+// the params against the GET /todos query rules.
+func (s *TodoService) CreateSavedSearch(req *models.CreateSavedSearchRequest) (*models.SavedSearch, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if !savedSearchName.MatchString(req.Name) {
+		return nil, invalid("err.saved_search.name")
+	}
+
+	params := req.Params
+	if params == nil {
+		params = models.SearchParams{}
+	}
+
+	return s.repo.CreateSavedSearch(req.Name, params)
+}
+
+// UpdateSavedSearch replaces the params of the saved search called name. It
+// returns repository.ErrSavedSearchNotFound if there is no such search.
+func (s *TodoService) UpdateSavedSearch(name string, req *models.UpdateSavedSearchRequest) (*models.SavedSearch, error) {
+	params := req.Params
+	if params == nil {
+		params = models.SearchParams{}
+	}
+
+	return s.repo.UpdateSavedSearch(name, params)
+}
+
+// DeleteSavedSearch removes the saved search called name
+func (s *TodoService) DeleteSavedSearch(name string) error {
+	return s.repo.DeleteSavedSearch(name)
+}
+
+// DropMissingReferences removes the category and tags from filter that no
+// longer exist, so a saved search outliving them still lists what it can.
+// Each one removed is reported as a Warning.
+func (s *TodoService) DropMissingReferences(filter *repository.TodoFilter) ([]Warning, error) {
+	var warnings []Warning
+
+	if filter.CategoryID != nil {
+		_, err := s.repo.GetCategoryByID(*filter.CategoryID)
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			warnings = append(warnings, Warning{Key: "warn.saved_search.category_missing", Args: []interface{}{*filter.CategoryID}})
+			filter.CategoryID = nil
+			filter.IncludeDescendants = false
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(filter.Tags) > 0 {
+		existing, err := s.repo.ExistingTags(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+
+		var kept []string
+		for _, tag := range filter.Tags {
+			if slices.Contains(existing, tag) {
+				kept = append(kept, tag)
+				continue
+			}
+			warnings = append(warnings, Warning{Key: "warn.saved_search.tag_missing", Args: []interface{}{tag}})
+		}
+		filter.Tags = kept
+	}
+
+	return warnings, nil
+}
+
+// Timezone returns the user's timezone
+func (s *TodoService) Timezone() (*time.Location, error) {
+	tz, err := s.repo.GetTimezone()
+	if err != nil {
+		return nil, err
+	}
+
+	return time.LoadLocation(tz)
+}
+
+// Profile returns the user's stored settings
+func (s *TodoService) Profile() (*models.Profile, error) {
+	prefs, err := s.repo.GetPreferences()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Profile{Timezone: prefs.Timezone, Preferences: prefs}, nil
+}
+
+// SetTimezone validates and stores the user's IANA timezone name
+func (s *TodoService) SetTimezone(tz string) error {
+	if err := validateTimezone(tz); err != nil {
+		return err
+	}
+
+	return s.repo.SetTimezone(tz)
+}
+
+// Preferences returns the user's UI preferences
+func (s *TodoService) Preferences() (*models.Preferences, error) {
+	return s.repo.GetPreferences()
+}
+
+// SetPreferences validates and replaces the user's UI preferences. The
+// caller checks that the default sort and filter are valid list parameters.
+func (s *TodoService) SetPreferences(req *models.UpdatePreferencesRequest) (*models.Preferences, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	if err := validateTimezone(req.Timezone); err != nil {
+		return nil, err
+	}
+
+	prefs := &models.Preferences{
+		DefaultSort:   req.DefaultSort,
+		DefaultFilter: req.DefaultFilter,
+		TodosPerPage:  req.TodosPerPage,
+		Theme:         req.Theme,
+		Timezone:      req.Timezone,
+	}
+	if prefs.DefaultFilter == nil {
+		prefs.DefaultFilter = models.SearchParams{}
+	}
+
+	if err := s.repo.SetPreferences(prefs); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// validateTimezone checks that tz is an IANA timezone name
+func validateTimezone(tz string) error {
+	// LoadLocation accepts "" and "Local", which are not portable names
+	if tz == "" || tz == "Local" {
+		return invalid("err.timezone.required")
+	}
+
+	if _, err := time.LoadLocation(tz); err != nil {
+		return invalid("err.timezone.unknown", tz)
+	}
+
+	return nil
+}
+
+// CompletionTrend counts todos created and completed per period over the
+// last days days
+func (s *TodoService) CompletionTrend(period string, days int) ([]*models.TrendPoint, error) {
+	if period != models.PeriodDay && period != models.PeriodWeek {
+		return nil, invalid("err.trend.period", models.PeriodDay, models.PeriodWeek)
+	}
+
+	if days < 1 || days > models.MaxTrendDays {
+		return nil, invalid("err.trend.days", models.MaxTrendDays)
+	}
+
+	return s.repo.GetCompletionTrend(period, days)
+}
+
+// CompletionStreak reports the current and longest daily completion streaks,
+// counting days in the user's timezone
+func (s *TodoService) CompletionStreak() (*models.Streak, error) {
+	tz, err := s.repo.GetTimezone()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetCompletionStreak(tz)
+}
+
+// Digest gathers the todos for a daily or weekly digest as of now, with days
+// and weeks (starting Monday) in the user's timezone. Due lists the
+// incomplete todos due from now until the end of today, or during next week;
+// anything already past due is in Overdue instead.
+func (s *TodoService) Digest(period string, now time.Time) (*models.Digest, error) {
+	if period != models.DigestDaily && period != models.DigestWeekly {
+		return nil, invalid("err.digest.period", models.DigestDaily, models.DigestWeekly)
+	}
+
+	loc, err := s.Timezone()
+	if err != nil {
+		return nil, err
+	}
+
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var completedFrom, completedTo, dueFrom, dueTo time.Time
+	if period == models.DigestDaily {
+		completedFrom, completedTo = today.AddDate(0, 0, -1), today
+		dueFrom, dueTo = now, today.AddDate(0, 0, 1)
+	} else {
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		completedFrom, completedTo = monday, now
+		dueFrom, dueTo = monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 14)
+	}
+
+	completed, err := s.repo.GetCompletedBetween(completedFrom, completedTo)
+	if err != nil {
+		return nil, err
+	}
+
+	due, err := s.repo.GetDueBetween(dueFrom, dueTo)
+	if err != nil {
+		return nil, err
+	}
+
+	overdue, _, err := s.repo.GetAll(repository.TodoFilter{
+		Due:  repository.DueOverdue,
+		Sort: []repository.SortParam{{Field: "due_at"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Digest{
+		Period:    period,
+		Timezone:  loc.String(),
+		Completed: completed,
+		Due:       due,
+		Overdue:   overdue,
+	}, nil
+}
+
+// Stats summarises the todo list. Every priority is included in the open
+// counts, with zero when no incomplete todos have it.
+func (s *TodoService) Stats() (*models.Stats, error) {
+	counts, err := s.repo.CountByPriority()
+	if err != nil {
+		return nil, err
+	}
+
+	for p := models.PriorityLow; p <= models.PriorityHigh; p++ {
+		if _, ok := counts[p]; !ok {
+			counts[p] = 0
+		}
+	}
+
+	return &models.Stats{OpenByPriority: counts}, nil
+}
+
+// Count returns how many todos there are, completed and pending
+func (s *TodoService) Count() (*models.TodoCount, error) {
+	return s.repo.CountByCompletion()
+}
+
+// CollectionVersion returns a short hash of a page of the todo list, so a
+// client can tell whether its copy of that page is stale. It covers the ID
+// and updated_at of each todo on the page, the total and page number in
+// meta, the saved search the filter came from, and loc, the timezone the
+// timestamps are shown in, or nil for UTC. Deleting a todo on another page
+// changes the total, and updating a saved search moves its updated_at.
+func (s *TodoService) CollectionVersion(todos []*models.Todo, meta models.ListMeta, saved *models.SavedSearch, loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d:%d", meta.Total, meta.Page)
+	for _, todo := range todos {
+		fmt.Fprintf(&b, ";%d:%d", todo.ID, todo.UpdatedAt.UnixNano())
+	}
+	if saved != nil {
+		fmt.Fprintf(&b, ";saved:%d:%d", saved.ID, saved.UpdatedAt.UnixNano())
+	}
+	if loc != nil {
+		fmt.Fprintf(&b, ";tz:%s", loc)
+	}
+
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(b.String())))
+}
+
+// validateCreate checks a create request and fills in defaults
+func validateCreate(req *models.CreateTodoRequest) error {
+	req.Normalize()
+
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	// due_at is stored without a zone, so always store it as UTC
+	if req.DueAt != nil {
+		dueAt := req.DueAt.UTC()
+		req.DueAt = &dueAt
+	}
+
+	// So is completed_at, which only an already completed todo has
+	if !req.Completed {
+		req.CompletedAt = nil
+	} else if req.CompletedAt != nil {
+		completedAt := req.CompletedAt.UTC()
+		req.CompletedAt = &completedAt
+	}
+
+	if req.Priority == 0 {
+		req.Priority = models.PriorityMedium
+	}
+
+	return nil
+}
+
+// applyUpdate applies the fields set in an update request to a copy of the
+// current todo and returns the result
+func applyUpdate(current *models.Todo, req *models.UpdateTodoRequest) *models.Todo {
+	updated := *current
+
+	if req.Title != nil {
+		updated.Title = *req.Title
+	}
+
+	if req.Description != nil {
+		updated.Description = *req.Description
+	}
+
+	if req.Priority != nil {
+		updated.Priority = *req.Priority
+	}
+
+	if req.DueAt != nil {
+		dueAt := req.DueAt.UTC()
+		updated.DueAt = &dueAt
+	} else if req.ClearDueAt {
+		updated.DueAt = nil
+	}
+
+	// The database stamps completed_at when completed changes
+	if req.Completed != nil {
+		updated.Completed = *req.Completed
+	}
+
+	return &updated
+}
+
+// missingIDs returns the IDs in requested that are not in found
+func missingIDs(requested, found []int64) []int64 {
+	seen := make(map[int64]bool, len(found))
+	for _, id := range found {
+		seen[id] = true
+	}
+
+	missing := []int64{}
+	for _, id := range requested {
+		if !seen[id] {
+			missing = append(missing, id)
+			seen[id] = true
+		}
+	}
+
+	return missing
+}
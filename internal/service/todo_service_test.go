@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/service"
+	"github.com/yourusername/todo-api/internal/testhelpers"
+)
+
+// assertInvalid fails the test unless err is a ValidationError for key
+func assertInvalid(t *testing.T, err error, key string) {
+	t.Helper()
+
+	var validationErr *service.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want a ValidationError for %s", err, key)
+	}
+	if validationErr.Key != key {
+		t.Errorf("error key = %q, want %q", validationErr.Key, key)
+	}
+}
+
+func TestCreateNormalizesAndDefaults(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	dueAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+	todo, err := svc.Create(&models.CreateTodoRequest{Title: "  Buy   milk ", DueAt: &dueAt})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	if todo.Title != "Buy milk" {
+		t.Errorf("Title = %q, want %q", todo.Title, "Buy milk")
+	}
+	if todo.Priority != models.PriorityMedium {
+		t.Errorf("Priority = %d, want %d", todo.Priority, models.PriorityMedium)
+	}
+	if todo.DueAt == nil || todo.DueAt.Location() != time.UTC || !todo.DueAt.Equal(dueAt) {
+		t.Errorf("DueAt = %v, want %v in UTC", todo.DueAt, dueAt)
+	}
+}
+
+func TestCreateRejectsInvalidRequests(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	_, err := svc.Create(&models.CreateTodoRequest{Title: "   "})
+	assertInvalid(t, err, "err.title.required")
+
+	missing := int64(42)
+	if _, err := svc.Create(&models.CreateTodoRequest{Title: "Buy milk", CategoryID: &missing}); !errors.Is(err, repository.ErrCategoryNotFound) {
+		t.Errorf("Create with a missing category error = %v, want ErrCategoryNotFound", err)
+	}
+}
+
+func TestCreateIgnoresCompletedAtOfOpenTodo(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	completedAt := time.Now().Add(-time.Hour)
+	todo, err := svc.Create(&models.CreateTodoRequest{Title: "Buy milk", CompletedAt: &completedAt})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if todo.Completed || todo.CompletedAt != nil {
+		t.Errorf("Completed = %v, CompletedAt = %v, want an open todo", todo.Completed, todo.CompletedAt)
+	}
+}
+
+func TestUpsertRequiresExternalID(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	_, _, err := svc.Upsert(&models.CreateTodoRequest{Title: "Buy milk"})
+	assertInvalid(t, err, "err.external_id.required")
+}
+
+func TestUpdateStampsCompletion(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithTitle("Buy milk"))
+
+	completed := true
+	updated, err := svc.Update(todo.ID, &models.UpdateTodoRequest{Completed: &completed})
+	if err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+
+	if !updated.Completed || updated.CompletedAt == nil {
+		t.Errorf("Completed = %v, CompletedAt = %v, want a completion time", updated.Completed, updated.CompletedAt)
+	}
+	if updated.Title != "Buy milk" {
+		t.Errorf("Title = %q, want it left alone", updated.Title)
+	}
+}
+
+func TestUpdateRejectsCompletingBlockedTodo(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	todo := testhelpers.InsertTodo(t, repo)
+	blocker := testhelpers.InsertTodo(t, repo)
+
+	if _, err := svc.AddDependency(todo.ID, &models.AddDependencyRequest{BlockingTodoID: blocker.ID}); err != nil {
+		t.Fatalf("AddDependency returned error: %v", err)
+	}
+
+	completed := true
+	if _, err := svc.Update(todo.ID, &models.UpdateTodoRequest{Completed: &completed}); !errors.Is(err, repository.ErrBlocked) {
+		t.Errorf("Update error = %v, want ErrBlocked", err)
+	}
+}
+
+func TestUpdateMissingTodo(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	title := "Buy milk"
+	if _, err := svc.Update(42, &models.UpdateTodoRequest{Title: &title}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Update error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestToggleFlipsCompletion(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	todo := testhelpers.InsertTodo(t, repo, testhelpers.WithCompleted(true))
+
+	toggled, err := svc.Toggle(todo.ID)
+	if err != nil {
+		t.Fatalf("Toggle returned error: %v", err)
+	}
+	if toggled.Completed || toggled.CompletedAt != nil {
+		t.Errorf("Completed = %v, CompletedAt = %v, want an open todo", toggled.Completed, toggled.CompletedAt)
+	}
+
+	if _, err := svc.Toggle(42); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Toggle of a missing todo error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSnoozeRejectsDaysOutOfRange(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	for _, days := range []int{0, models.MaxSnoozeDays + 1} {
+		_, err := svc.Snooze(todo.ID, days)
+		assertInvalid(t, err, "err.snooze.days")
+	}
+}
+
+func TestBulkArchiveReportsEachID(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	todo := testhelpers.InsertTodo(t, repo)
+	archived := testhelpers.InsertTodo(t, repo)
+
+	if _, err := svc.BulkArchive(&models.BulkArchiveRequest{IDs: []int64{archived.ID}}); err != nil {
+		t.Fatalf("BulkArchive returned error: %v", err)
+	}
+
+	result, err := svc.BulkArchive(&models.BulkArchiveRequest{IDs: []int64{todo.ID, archived.ID, 42}})
+	if err != nil {
+		t.Fatalf("BulkArchive returned error: %v", err)
+	}
+	if result.Updated != 1 || len(result.AlreadyArchived) != 1 || result.AlreadyArchived[0] != archived.ID || len(result.NotFound) != 1 || result.NotFound[0] != 42 {
+		t.Errorf("BulkArchive = %+v, want 1 updated, %d already archived and 42 not found", result, archived.ID)
+	}
+}
+
+func TestBulkArchiveRejectsTooManyIDs(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	ids := make([]int64, models.MaxBulkIDs+1)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	_, err := svc.BulkArchive(&models.BulkArchiveRequest{IDs: ids})
+	assertInvalid(t, err, "err.ids.max")
+}
+
+func TestBatchUpdateSetsCategory(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	category, err := svc.CreateCategory(&models.CreateCategoryRequest{Name: "Work"})
+	if err != nil {
+		t.Fatalf("CreateCategory returned error: %v", err)
+	}
+
+	result, err := svc.BatchUpdate(&models.BatchUpdateRequest{IDs: []int64{todo.ID, 42}, CategoryID: &category.ID})
+	if err != nil {
+		t.Fatalf("BatchUpdate returned error: %v", err)
+	}
+	if result.Updated != 1 || len(result.NotFound) != 1 || result.NotFound[0] != 42 {
+		t.Errorf("BatchUpdate = %+v, want 1 updated and 42 not found", result)
+	}
+
+	missing := int64(42)
+	if _, err := svc.BatchUpdate(&models.BatchUpdateRequest{IDs: []int64{todo.ID}, CategoryID: &missing}); !errors.Is(err, repository.ErrCategoryNotFound) {
+		t.Errorf("BatchUpdate with a missing category error = %v, want ErrCategoryNotFound", err)
+	}
+}
+
+func TestBatchUpdateRequiresExactlyOneField(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	todo := testhelpers.InsertTodo(t, repo)
+
+	_, err := svc.BatchUpdate(&models.BatchUpdateRequest{IDs: []int64{todo.ID}})
+	assertInvalid(t, err, "err.batch.one_field")
+
+	priority, categoryID := models.PriorityHigh, int64(1)
+	_, err = svc.BatchUpdate(&models.BatchUpdateRequest{IDs: []int64{todo.ID}, Priority: &priority, CategoryID: &categoryID})
+	assertInvalid(t, err, "err.batch.one_field")
+}
+
+func TestShiftDueDatesSkipsTodosWithoutDueDate(t *testing.T) {
+	repo := testhelpers.NewMemoryRepository()
+	svc := service.NewTodoService(repo)
+	dueAt := time.Now().UTC().Truncate(time.Second)
+	due := testhelpers.InsertTodo(t, repo, testhelpers.WithDueAt(dueAt))
+	undated := testhelpers.InsertTodo(t, repo)
+
+	result, err := svc.ShiftDueDates(&models.ShiftDueRequest{IDs: []int64{due.ID, undated.ID, 42}, ShiftDays: 2})
+	if err != nil {
+		t.Fatalf("ShiftDueDates returned error: %v", err)
+	}
+	if result.Updated != 1 || result.SkippedNoDueDate != 1 || len(result.NotFound) != 1 || result.NotFound[0] != 42 {
+		t.Errorf("ShiftDueDates = %+v, want 1 updated, 1 skipped and 42 not found", result)
+	}
+
+	shifted, err := svc.Get(due.ID)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if want := dueAt.AddDate(0, 0, 2); shifted.DueAt == nil || !shifted.DueAt.Equal(want) {
+		t.Errorf("DueAt = %v, want %v", shifted.DueAt, want)
+	}
+}
+
+func TestShiftDueDatesRejectsOutOfRange(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	ids := make([]int64, models.MaxBulkIDs+1)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	_, err := svc.ShiftDueDates(&models.ShiftDueRequest{IDs: ids, ShiftDays: 1})
+	assertInvalid(t, err, "err.ids.max")
+
+	for _, days := range []int{-models.MaxShiftDays - 1, models.MaxShiftDays + 1} {
+		_, err := svc.ShiftDueDates(&models.ShiftDueRequest{IDs: []int64{1}, ShiftDays: days})
+		assertInvalid(t, err, "err.shift_days.range")
+	}
+}
+
+func TestCreateCategoryRejectsTooDeep(t *testing.T) {
+	svc := service.NewTodoService(testhelpers.NewMemoryRepository())
+
+	var parentID *int64
+	for i := 0; i < models.MaxCategoryDepth; i++ {
+		category, err := svc.CreateCategory(&models.CreateCategoryRequest{Name: "Level", ParentID: parentID})
+		if err != nil {
+			t.Fatalf("CreateCategory at depth %d returned error: %v", i+1, err)
+		}
+		parentID = &category.ID
+	}
+
+	_, err := svc.CreateCategory(&models.CreateCategoryRequest{Name: "Too deep", ParentID: parentID})
+	assertInvalid(t, err, "err.category.too_deep")
+}
@@ -0,0 +1,123 @@
+package testhelpers
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// todoCounter numbers the default titles given by NewTodo
+var todoCounter int64
+
+// TodoOption overrides a field of a todo built by NewTodo
+type TodoOption func(*models.Todo)
+
+// NewTodo builds a todo with a unique title, an empty description, medium
+// priority and current timestamps, then applies overrides to it
+func NewTodo(overrides ...TodoOption) *models.Todo {
+	now := time.Now()
+	todo := &models.Todo{
+		Title:     fmt.Sprintf("Todo %d", atomic.AddInt64(&todoCounter, 1)),
+		Priority:  models.PriorityMedium,
+		Notes:     []models.Note{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	for _, override := range overrides {
+		override(todo)
+	}
+
+	return todo
+}
+
+// WithTitle sets the todo title
+func WithTitle(title string) TodoOption {
+	return func(todo *models.Todo) {
+		todo.Title = title
+	}
+}
+
+// WithDescription sets the todo description
+func WithDescription(description string) TodoOption {
+	return func(todo *models.Todo) {
+		todo.Description = description
+	}
+}
+
+// WithPriority sets the todo priority
+func WithPriority(priority int) TodoOption {
+	return func(todo *models.Todo) {
+		todo.Priority = priority
+	}
+}
+
+// WithCompleted sets whether the todo is completed, stamping completed_at
+func WithCompleted(completed bool) TodoOption {
+	return func(todo *models.Todo) {
+		todo.Completed = completed
+		todo.CompletedAt = nil
+		if completed {
+			now := time.Now()
+			todo.CompletedAt = &now
+		}
+	}
+}
+
+// WithPinned sets whether the todo is pinned
+func WithPinned(pinned bool) TodoOption {
+	return func(todo *models.Todo) {
+		todo.Pinned = pinned
+	}
+}
+
+// WithExternalID sets the todo external ID
+func WithExternalID(externalID string) TodoOption {
+	return func(todo *models.Todo) {
+		todo.ExternalID = &externalID
+	}
+}
+
+// WithDueAt sets the todo due date
+func WithDueAt(dueAt time.Time) TodoOption {
+	return func(todo *models.Todo) {
+		todo.DueAt = &dueAt
+	}
+}
+
+// InsertTodo stores a todo built by NewTodo in repo and returns it as
+// stored. The test fails if any repository call returns an error.
+func InsertTodo(t *testing.T, repo repository.TodoRepositoryInterface, overrides ...TodoOption) *models.Todo {
+	t.Helper()
+
+	want := NewTodo(overrides...)
+
+	todo, err := repo.Create(&models.CreateTodoRequest{
+		Title:       want.Title,
+		Description: want.Description,
+		Priority:    want.Priority,
+		ExternalID:  want.ExternalID,
+		DueAt:       want.DueAt,
+	})
+	if err != nil {
+		t.Fatalf("Create(%q) returned error: %v", want.Title, err)
+	}
+
+	if want.Completed {
+		if todo, err = repo.SetCompleted(todo.ID, true); err != nil {
+			t.Fatalf("SetCompleted(%d) returned error: %v", todo.ID, err)
+		}
+	}
+
+	if want.Pinned {
+		if todo, err = repo.SetPinned(todo.ID, true); err != nil {
+			t.Fatalf("SetPinned(%d) returned error: %v", todo.ID, err)
+		}
+	}
+
+	return todo
+}
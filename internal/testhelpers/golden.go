@@ -0,0 +1,73 @@
+package testhelpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// goldenDir is where golden files live, relative to the test's package
+var goldenDir = filepath.Join("testdata", "golden")
+
+// timestampPattern matches RFC 3339 timestamps as encoded by encoding/json
+var timestampPattern = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})"`)
+
+// fixedTimestamp replaces every timestamp before comparing with a golden file
+const fixedTimestamp = `"2000-01-01T00:00:00Z"`
+
+// versionPattern matches a list's collection_version, which is derived from
+// timestamps
+var versionPattern = regexp.MustCompile(`"collection_version":\s*"[0-9a-f]+"`)
+
+// fixedVersion replaces the collection_version before comparing
+const fixedVersion = `"collection_version":"00000000"`
+
+// AssertGolden compares the JSON in got with testdata/golden/{name}.json,
+// ignoring timestamp and collection version values and formatting. Run the tests with
+// UPDATE_GOLDEN=true to write got to the golden file instead.
+func AssertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	normalized := normalizeJSON(t, got)
+	path := filepath.Join(goldenDir, name+".json")
+
+	if os.Getenv("UPDATE_GOLDEN") == "true" {
+		if err := os.MkdirAll(goldenDir, 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", goldenDir, err)
+		}
+		if err := os.WriteFile(path, normalized, 0o644); err != nil {
+			t.Fatalf("Failed to write golden file %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %s (run with UPDATE_GOLDEN=true to create it): %v", path, err)
+	}
+
+	if !bytes.Equal(normalized, want) {
+		t.Errorf("response does not match %s\n--- got\n%s\n--- want\n%s", path, normalized, want)
+	}
+}
+
+// normalizeJSON replaces timestamps with fixedTimestamp, and collection
+// versions with fixedVersion, and re-indents the document so golden files
+// diff cleanly
+func normalizeJSON(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	data = timestampPattern.ReplaceAll(data, []byte(fixedTimestamp))
+	data = versionPattern.ReplaceAll(data, []byte(fixedVersion))
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(data), "", "  "); err != nil {
+		t.Fatalf("Failed to indent JSON: %v\n%s", err, data)
+	}
+	buf.WriteByte('\n')
+
+	return buf.Bytes()
+}
@@ -0,0 +1,84 @@
+package testhelpers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// MemoryIdempotencyStore is an in-memory repository.IdempotencyStore
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*models.IdempotentResponse
+}
+
+var _ repository.IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+// NewMemoryIdempotencyStore creates an empty MemoryIdempotencyStore
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		responses: make(map[string]*models.IdempotentResponse),
+	}
+}
+
+// Reserve claims key for a request, or returns what is stored for it
+func (s *MemoryIdempotencyStore) Reserve(key, method, path string, ttl, pendingTTL time.Duration) (*models.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resp, ok := s.responses[key]; ok {
+		age := time.Since(resp.CreatedAt)
+		if age < ttl && (!resp.Pending() || age < pendingTTL) {
+			c := *resp
+			return &c, nil
+		}
+	}
+
+	s.responses[key] = &models.IdempotentResponse{Key: key, Method: method, Path: path, CreatedAt: time.Now()}
+	return nil, nil
+}
+
+// Save stores the response to the request that reserved its key
+func (s *MemoryIdempotencyStore) Save(resp *models.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reserved, ok := s.responses[resp.Key]
+	if !ok || !reserved.Pending() {
+		return nil
+	}
+
+	reserved.Status = resp.Status
+	reserved.Header = resp.Header.Clone()
+	reserved.Body = append([]byte(nil), resp.Body...)
+	return nil
+}
+
+// Release frees a key whose request is still pending
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resp, ok := s.responses[key]; ok && resp.Pending() {
+		delete(s.responses, key)
+	}
+	return nil
+}
+
+// DeleteExpired removes responses older than ttl
+func (s *MemoryIdempotencyStore) DeleteExpired(ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, resp := range s.responses {
+		if time.Since(resp.CreatedAt) >= ttl {
+			delete(s.responses, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,1908 @@
+package testhelpers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/todo-api/internal/models"
+	"github.com/yourusername/todo-api/internal/repository"
+)
+
+// MemoryRepository is an in-memory repository.TodoRepositoryInterface for
+// handler and service tests that do not need a database
+type MemoryRepository struct {
+	mu             sync.Mutex
+	todos          map[int64]*models.Todo
+	nextID         int64
+	categories     map[int64]*models.Category
+	nextCategoryID int64
+
+	// notified holds the IDs whose overdue reminder has been sent
+	notified map[int64]bool
+	// reminded holds the IDs whose due-soon reminder has been claimed
+	reminded map[int64]bool
+	// archived holds the todos moved out by VacuumCompleted
+	archived map[int64]*models.Todo
+	// reactions holds each todo's reaction emoji in the order they were added
+	reactions map[int64][]string
+	// dependencies holds the IDs each todo depends on in the order they were
+	// added
+	dependencies map[int64][]int64
+
+	timezone    string
+	preferences models.Preferences
+
+	savedSearches map[string]*models.SavedSearch
+	nextSearchID  int64
+
+	activity []activity
+}
+
+// activity is a recorded change to a todo, like a row of activity_logs
+type activity struct {
+	todoID int64
+	event  models.TimelineEvent
+}
+
+var _ repository.TodoRepositoryInterface = (*MemoryRepository)(nil)
+
+// NewMemoryRepository creates an empty MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		todos:          make(map[int64]*models.Todo),
+		nextID:         1,
+		categories:     make(map[int64]*models.Category),
+		nextCategoryID: 1,
+		notified:       make(map[int64]bool),
+		reminded:       make(map[int64]bool),
+		archived:       make(map[int64]*models.Todo),
+		reactions:      make(map[int64][]string),
+		dependencies:   make(map[int64][]int64),
+		timezone:       "UTC",
+		preferences:    models.Preferences{DefaultFilter: models.SearchParams{}, TodosPerPage: models.DefaultTodosPerPage, Theme: models.ThemeSystem},
+		savedSearches:  make(map[string]*models.SavedSearch),
+		nextSearchID:   1,
+	}
+}
+
+// insert stores a new todo built from req. The caller must hold r.mu.
+func (r *MemoryRepository) insert(req *models.CreateTodoRequest) *models.Todo {
+	now := time.Now()
+	todo := &models.Todo{
+		ID:          r.nextID,
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    req.Priority,
+		ExternalID:  req.ExternalID,
+		DueAt:       req.DueAt,
+		CategoryID:  req.CategoryID,
+		Notes:       []models.Note{},
+		Tags:        []string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.Completed {
+		todo.Completed = true
+		todo.CompletedAt = req.CompletedAt
+		if todo.CompletedAt == nil {
+			todo.CompletedAt = &now
+		}
+	}
+	r.nextID++
+	r.todos[todo.ID] = todo
+	r.logChanges(nil, todo)
+	return todo
+}
+
+// logChanges records how a todo changed from before to after, like the
+// todos_log_activity trigger. before is nil for a new todo and after is nil
+// for a deleted one. The caller must hold r.mu.
+func (r *MemoryRepository) logChanges(before, after *models.Todo) {
+	now := time.Now()
+	add := func(id int64, event string, change ...interface{}) {
+		e := models.TimelineEvent{Timestamp: now, Event: event}
+		if len(change) == 2 {
+			e.From, _ = json.Marshal(change[0])
+			e.To, _ = json.Marshal(change[1])
+		}
+		r.activity = append(r.activity, activity{todoID: id, event: e})
+	}
+
+	if before == nil {
+		add(after.ID, models.EventCreated)
+		if after.Completed {
+			add(after.ID, models.EventCompleted)
+		}
+		return
+	}
+	if after == nil {
+		add(before.ID, models.EventDeleted)
+		return
+	}
+
+	id := after.ID
+	if before.Completed != after.Completed {
+		if after.Completed {
+			add(id, models.EventCompleted)
+		} else {
+			add(id, models.EventUncompleted)
+		}
+	}
+	if before.Title != after.Title {
+		add(id, models.EventTitleChanged, before.Title, after.Title)
+	}
+	if before.Description != after.Description {
+		add(id, models.EventDescriptionChanged, before.Description, after.Description)
+	}
+	if before.Priority != after.Priority {
+		add(id, models.EventPriorityChanged, before.Priority, after.Priority)
+	}
+	if (before.DueAt == nil) != (after.DueAt == nil) || (before.DueAt != nil && !before.DueAt.Equal(*after.DueAt)) {
+		add(id, models.EventDueChanged, before.DueAt, after.DueAt)
+	}
+	if before.Pinned != after.Pinned {
+		if after.Pinned {
+			add(id, models.EventPinned)
+		} else {
+			add(id, models.EventUnpinned)
+		}
+	}
+	if (before.CategoryID == nil) != (after.CategoryID == nil) || (before.CategoryID != nil && *before.CategoryID != *after.CategoryID) {
+		add(id, models.EventCategoryChanged, before.CategoryID, after.CategoryID)
+	}
+	if (before.ArchivedAt == nil) != (after.ArchivedAt == nil) {
+		if after.ArchivedAt != nil {
+			add(id, models.EventArchived)
+		} else {
+			add(id, models.EventUnarchived)
+		}
+	}
+}
+
+// setDueAt changes the due date of todo, clearing its reminders if the
+// date moved. The caller must hold r.mu.
+func (r *MemoryRepository) setDueAt(todo *models.Todo, dueAt *time.Time) {
+	changed := (todo.DueAt == nil) != (dueAt == nil) ||
+		(dueAt != nil && !todo.DueAt.Equal(*dueAt))
+	if changed {
+		delete(r.notified, todo.ID)
+		delete(r.reminded, todo.ID)
+	}
+	todo.DueAt = dueAt
+}
+
+// setCompleted changes the completion state of todo, stamping completed_at
+// like the todos_set_timestamps trigger. The caller must hold r.mu.
+func (r *MemoryRepository) setCompleted(todo *models.Todo, completed bool) {
+	if completed && !todo.Completed {
+		now := time.Now()
+		todo.CompletedAt = &now
+	} else if !completed {
+		todo.CompletedAt = nil
+	}
+	todo.Completed = completed
+}
+
+// copyTodo returns a copy of todo so callers cannot mutate stored state
+func copyTodo(todo *models.Todo) *models.Todo {
+	c := *todo
+	c.Notes = append([]models.Note{}, todo.Notes...)
+	c.Tags = append([]string{}, todo.Tags...)
+	if todo.CategoryID != nil {
+		categoryID := *todo.CategoryID
+		c.CategoryID = &categoryID
+	}
+	if todo.DueAt != nil {
+		dueAt := *todo.DueAt
+		c.DueAt = &dueAt
+	}
+	if todo.Position != nil {
+		position := *todo.Position
+		c.Position = &position
+	}
+	if todo.ArchivedAt != nil {
+		archivedAt := *todo.ArchivedAt
+		c.ArchivedAt = &archivedAt
+	}
+	return &c
+}
+
+// Create adds a new todo
+func (r *MemoryRepository) Create(req *models.CreateTodoRequest) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return copyTodo(r.insert(req)), nil
+}
+
+// Upsert inserts a todo or updates the one with the same external ID
+func (r *MemoryRepository) Upsert(req *models.CreateTodoRequest) (*models.Todo, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, todo := range r.todos {
+		if todo.ExternalID != nil && req.ExternalID != nil && *todo.ExternalID == *req.ExternalID {
+			before := copyTodo(todo)
+			todo.Title = req.Title
+			todo.Description = req.Description
+			todo.Priority = req.Priority
+			r.setDueAt(todo, req.DueAt)
+			todo.UpdatedAt = time.Now()
+			r.logChanges(before, todo)
+			return copyTodo(todo), false, nil
+		}
+	}
+
+	return copyTodo(r.insert(req)), true, nil
+}
+
+// BulkCreate adds many todos at once
+func (r *MemoryRepository) BulkCreate(reqs []*models.CreateTodoRequest) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, req := range reqs {
+		r.insert(req)
+	}
+
+	return int64(len(reqs)), nil
+}
+
+// ImportGroups files the todos of each group under the oldest top-level
+// category with the group's name, creating the categories that do not
+// exist yet, and adds all the todos. It returns the names of the categories
+// it created and the number of todos added.
+func (r *MemoryRepository) ImportGroups(groups []models.ImportGroup) ([]string, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var created []string
+	var imported int64
+	for _, group := range groups {
+		if group.Category != "" && len(group.Todos) > 0 {
+			category := r.topLevelCategory(group.Category)
+			if category == nil {
+				category = &models.Category{ID: r.nextCategoryID, Name: group.Category, CreatedAt: time.Now()}
+				r.nextCategoryID++
+				r.categories[category.ID] = category
+				created = append(created, category.Name)
+			}
+			for _, todo := range group.Todos {
+				id := category.ID
+				todo.CategoryID = &id
+			}
+		}
+
+		for _, todo := range group.Todos {
+			r.insert(todo)
+			imported++
+		}
+	}
+
+	return created, imported, nil
+}
+
+// topLevelCategory returns the oldest category without a parent called
+// name, or nil if there is none. The caller must hold r.mu.
+func (r *MemoryRepository) topLevelCategory(name string) *models.Category {
+	var found *models.Category
+	for _, category := range r.categories {
+		if category.ParentID == nil && category.Name == name && (found == nil || category.ID < found.ID) {
+			found = category
+		}
+	}
+	return found
+}
+
+// GetAll returns the page of todos matching filter, pinned first, newest
+// first, and the total number of matches
+func (r *MemoryRepository) GetAll(filter repository.TodoFilter) ([]*models.Todo, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := []*models.Todo{}
+	for _, todo := range r.todos {
+		if todo.ArchivedAt != nil {
+			continue
+		}
+		if filter.Pinned != nil && todo.Pinned != *filter.Pinned {
+			continue
+		}
+		if !matchesDue(todo, filter) || !matchesSearch(todo, filter) || !r.matchesCategory(todo, filter) {
+			continue
+		}
+		todos = append(todos, copyTodo(todo))
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].Pinned != todos[j].Pinned {
+			return todos[i].Pinned
+		}
+		if filter.SortByRelevance && filter.SearchQuery != nil {
+			ri, rj := searchRank(todos[i], *filter.SearchQuery), searchRank(todos[j], *filter.SearchQuery)
+			if ri != rj {
+				return ri > rj
+			}
+			return todos[i].ID > todos[j].ID
+		}
+		if len(filter.Sort) > 0 {
+			return lessBySort(todos[i], todos[j], filter.Sort)
+		}
+		// Like position NULLS FIRST
+		pi, pj := todos[i].Position, todos[j].Position
+		if (pi == nil) != (pj == nil) {
+			return pi == nil
+		}
+		if pi != nil && *pi != *pj {
+			return *pi < *pj
+		}
+		if !todos[i].CreatedAt.Equal(todos[j].CreatedAt) {
+			return todos[i].CreatedAt.After(todos[j].CreatedAt)
+		}
+		return todos[i].ID > todos[j].ID
+	})
+
+	total := int64(len(todos))
+	todos = todos[min(filter.Offset, len(todos)):]
+	if filter.Limit > 0 && filter.Limit < len(todos) {
+		todos = todos[:filter.Limit]
+	}
+
+	return todos, total, nil
+}
+
+// lessBySort orders a before b by the sort fields, with missing values
+// last, then newest ID first, like TodoFilter's ORDER BY
+func lessBySort(a, b *models.Todo, params []repository.SortParam) bool {
+	for _, p := range params {
+		c := compareField(a, b, p.Field)
+		if c == 0 {
+			continue
+		}
+		if c == nullsLast || c == -nullsLast {
+			return c < 0
+		}
+		if p.Descending {
+			return c > 0
+		}
+		return c < 0
+	}
+	return a.ID > b.ID
+}
+
+// nullsLast is returned by compareField when exactly one of the todos has
+// no value for the field; its sign puts that todo last in either direction
+const nullsLast = 2
+
+// compareField returns -1, 0 or 1 as a's field is less than, equal to or
+// greater than b's, or ±nullsLast if only one of them is set
+func compareField(a, b *models.Todo, field string) int {
+	compareTimes := func(x, y *time.Time) int {
+		switch {
+		case x == nil && y == nil:
+			return 0
+		case x == nil:
+			return nullsLast
+		case y == nil:
+			return -nullsLast
+		}
+		return x.Compare(*y)
+	}
+
+	switch field {
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case "due_at":
+		return compareTimes(a.DueAt, b.DueAt)
+	case "completed_at":
+		return compareTimes(a.CompletedAt, b.CompletedAt)
+	case "priority":
+		return cmp.Compare(a.Priority, b.Priority)
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	}
+	return 0
+}
+
+// GetHighlights wraps each word of query found in the title and description
+// of the todos in ids, ignoring case but not stemming
+func (r *MemoryRepository) GetHighlights(ids []int64, query string) (map[int64]map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	words := strings.Fields(strings.ToLower(query))
+	mark := func(text string) string {
+		var out strings.Builder
+		found := false
+		for i, word := range strings.Split(text, " ") {
+			if i > 0 {
+				out.WriteByte(' ')
+			}
+			if slices.Contains(words, strings.ToLower(word)) {
+				out.WriteString(repository.HighlightStart + word + repository.HighlightStop)
+				found = true
+			} else {
+				out.WriteString(word)
+			}
+		}
+		if !found {
+			return ""
+		}
+		return out.String()
+	}
+
+	highlights := make(map[int64]map[string]string, len(ids))
+	for _, id := range ids {
+		if todo, ok := r.todos[id]; ok {
+			highlights[id] = map[string]string{"title": mark(todo.Title), "description": mark(todo.Description)}
+		}
+	}
+
+	return highlights, nil
+}
+
+// GetRelated ranks the incomplete todos other than id by how many words of
+// its title their text contains, ignoring case but not stemming
+func (r *MemoryRepository) GetRelated(id int64, limit int) ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, ok := r.todos[id]
+	if !ok {
+		return []*models.Todo{}, nil
+	}
+	words := strings.Fields(strings.ToLower(source.Title))
+
+	scores := make(map[int64]int)
+	related := []*models.Todo{}
+	for _, todo := range r.todos {
+		if todo.ID == id || todo.Completed || todo.ArchivedAt != nil {
+			continue
+		}
+		text := strings.Fields(strings.ToLower(todo.Title + " " + todo.Description))
+		for _, word := range words {
+			if slices.Contains(text, word) {
+				scores[todo.ID]++
+			}
+		}
+		if scores[todo.ID] > 0 {
+			related = append(related, copyTodo(todo))
+		}
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		if scores[related[i].ID] != scores[related[j].ID] {
+			return scores[related[i].ID] > scores[related[j].ID]
+		}
+		return related[i].ID > related[j].ID
+	})
+
+	return related[:min(limit, len(related))], nil
+}
+
+// StreamAll calls fn for each todo matching filter, in GetAll order
+func (r *MemoryRepository) StreamAll(ctx context.Context, filter repository.TodoFilter, fn func(*models.Todo) error) error {
+	todos, _, _ := r.GetAll(filter)
+
+	for _, todo := range todos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchesDue applies filter.Due the way TodoFilter's SQL does
+func matchesDue(todo *models.Todo, filter repository.TodoFilter) bool {
+	switch filter.Due {
+	case repository.DueToday:
+		if todo.DueAt == nil {
+			return false
+		}
+		loc, err := time.LoadLocation(filter.Timezone)
+		if err != nil {
+			return false
+		}
+		y1, m1, d1 := todo.DueAt.In(loc).Date()
+		y2, m2, d2 := time.Now().In(loc).Date()
+		return y1 == y2 && m1 == m2 && d1 == d2
+	case repository.DueOverdue:
+		return todo.DueAt != nil && todo.DueAt.Before(time.Now()) && !todo.Completed
+	}
+	return true
+}
+
+// matchesSearch applies filter.SearchQuery, filter.Tags and filter.Tagged.
+// Full-text search is approximated by requiring every word to appear in the
+// title or description.
+func matchesSearch(todo *models.Todo, filter repository.TodoFilter) bool {
+	if filter.SearchQuery != nil {
+		text := strings.ToLower(todo.Title + " " + todo.Description)
+		for _, word := range strings.Fields(strings.ToLower(*filter.SearchQuery)) {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+	}
+
+	for _, tag := range filter.Tags {
+		if !slices.Contains(todo.Tags, tag) {
+			return false
+		}
+	}
+
+	if filter.Tagged != nil && (len(todo.Tags) > 0) != *filter.Tagged {
+		return false
+	}
+
+	return true
+}
+
+// searchRank approximates ts_rank by counting how often the words of query
+// appear in the todo's title and description
+func searchRank(todo *models.Todo, query string) int {
+	text := strings.ToLower(todo.Title + " " + todo.Description)
+	rank := 0
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		rank += strings.Count(text, word)
+	}
+	return rank
+}
+
+// matchesCategory applies filter.CategoryID and filter.IncludeDescendants.
+// The caller must hold r.mu.
+func (r *MemoryRepository) matchesCategory(todo *models.Todo, filter repository.TodoFilter) bool {
+	if filter.CategoryID == nil {
+		return true
+	}
+
+	for id := todo.CategoryID; id != nil; {
+		if *id == *filter.CategoryID {
+			return true
+		}
+		if !filter.IncludeDescendants {
+			return false
+		}
+
+		category, ok := r.categories[*id]
+		if !ok {
+			return false
+		}
+		id = category.ParentID
+	}
+
+	return false
+}
+
+// StreamAllIDs calls fn with the IDs of all unarchived todos in ascending
+// order
+func (r *MemoryRepository) StreamAllIDs(ctx context.Context, fn func(int64) error) error {
+	r.mu.Lock()
+	var ids []int64
+	for id, todo := range r.todos {
+		if todo.ArchivedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	r.mu.Unlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetByID returns the todo with the given ID, or repository.ErrNotFound if
+// it does not exist
+func (r *MemoryRepository) GetByID(id int64) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return copyTodo(todo), nil
+}
+
+// Update writes the editable fields of a todo
+func (r *MemoryRepository) Update(todo *models.Todo) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[todo.ID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	r.update(stored, todo)
+	return copyTodo(stored), nil
+}
+
+// UpdateWith applies apply to the stored todo and saves the result, all
+// under the lock. Completing a blocked todo fails with ErrBlocked.
+func (r *MemoryRepository) UpdateWith(id int64, apply func(*models.Todo) *models.Todo) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	todo := apply(copyTodo(stored))
+	if todo.Completed && !stored.Completed && r.blocked(id) {
+		return nil, repository.ErrBlocked
+	}
+
+	r.update(stored, todo)
+	return copyTodo(stored), nil
+}
+
+// update copies the editable fields of todo onto stored. The caller must
+// hold r.mu.
+func (r *MemoryRepository) update(stored, todo *models.Todo) {
+	before := copyTodo(stored)
+	stored.Title = todo.Title
+	stored.Description = todo.Description
+	stored.Priority = todo.Priority
+	r.setDueAt(stored, todo.DueAt)
+	r.setCompleted(stored, todo.Completed)
+	stored.UpdatedAt = time.Now()
+	r.logChanges(before, stored)
+}
+
+// SetCompleted sets the completion state of a todo
+func (r *MemoryRepository) SetCompleted(id int64, completed bool) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	if completed && !todo.Completed && r.blocked(id) {
+		return nil, repository.ErrBlocked
+	}
+
+	before := copyTodo(todo)
+	r.setCompleted(todo, completed)
+	todo.UpdatedAt = time.Now()
+	r.logChanges(before, todo)
+
+	return copyTodo(todo), nil
+}
+
+// AppendNote appends a note to a todo's notes log
+func (r *MemoryRepository) AppendNote(id int64, body string) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	now := time.Now()
+	todo.Notes = append(todo.Notes, models.Note{Body: body, CreatedAt: now})
+	todo.UpdatedAt = now
+
+	return copyTodo(todo), nil
+}
+
+// SetTags replaces the tags of a todo
+func (r *MemoryRepository) SetTags(id int64, tags []string) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	todo.Tags = append([]string{}, tags...)
+	sort.Strings(todo.Tags)
+	todo.UpdatedAt = time.Now()
+
+	return copyTodo(todo), nil
+}
+
+// ExistingTags returns which of names are on at least one todo. There is no
+// separate tag table in memory, so a tag exists while something uses it.
+func (r *MemoryRepository) ExistingTags(names []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := []string{}
+	for _, name := range names {
+		for _, todo := range r.todos {
+			if slices.Contains(todo.Tags, name) {
+				existing = append(existing, name)
+				break
+			}
+		}
+	}
+	sort.Strings(existing)
+
+	return existing, nil
+}
+
+// BatchUpdatePriority sets the priority of every unarchived todo in ids and
+// returns the IDs that were updated
+func (r *MemoryRepository) BatchUpdatePriority(ids []int64, priority int) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var updated []int64
+	for _, id := range ids {
+		if todo, ok := r.todos[id]; ok && todo.ArchivedAt == nil {
+			before := copyTodo(todo)
+			todo.Priority = priority
+			todo.UpdatedAt = time.Now()
+			r.logChanges(before, todo)
+			updated = append(updated, id)
+		}
+	}
+
+	return updated, nil
+}
+
+// BatchUpdateCategory files every unarchived todo in ids that exists under
+// categoryID and returns the IDs that were updated
+func (r *MemoryRepository) BatchUpdateCategory(ids []int64, categoryID int64) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Like id = ANY($2), a repeated ID only counts once
+	seen := make(map[int64]bool, len(ids))
+
+	var updated []int64
+	for _, id := range ids {
+		todo, ok := r.todos[id]
+		if !ok || todo.ArchivedAt != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		before := copyTodo(todo)
+		c := categoryID
+		todo.CategoryID = &c
+		todo.UpdatedAt = time.Now()
+		r.logChanges(before, todo)
+		updated = append(updated, id)
+	}
+
+	return updated, nil
+}
+
+// SetPinned pins or unpins a todo, enforcing repository.MaxPinnedTodos
+// over the unarchived todos
+func (r *MemoryRepository) SetPinned(id int64, pinned bool) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	if pinned && !todo.Pinned {
+		count := 0
+		for _, other := range r.todos {
+			if other.Pinned && other.ArchivedAt == nil {
+				count++
+			}
+		}
+		if count >= repository.MaxPinnedTodos {
+			return nil, repository.ErrPinLimitReached
+		}
+	}
+
+	before := copyTodo(todo)
+	todo.Pinned = pinned
+	todo.UpdatedAt = time.Now()
+	r.logChanges(before, todo)
+
+	return copyTodo(todo), nil
+}
+
+// AddReaction reacts to a todo with emoji, at most MaxReactionsPerTodo
+// distinct emoji per todo
+func (r *MemoryRepository) AddReaction(todoID int64, emoji string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[todoID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	for _, e := range r.reactions[todoID] {
+		if e == emoji {
+			return nil
+		}
+	}
+	if len(r.reactions[todoID]) >= repository.MaxReactionsPerTodo {
+		return repository.ErrReactionLimitReached
+	}
+
+	r.reactions[todoID] = append(r.reactions[todoID], emoji)
+	return nil
+}
+
+// RemoveReaction takes back a reaction to a todo
+func (r *MemoryRepository) RemoveReaction(todoID int64, emoji string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[todoID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	kept := r.reactions[todoID][:0]
+	for _, e := range r.reactions[todoID] {
+		if e != emoji {
+			kept = append(kept, e)
+		}
+	}
+	r.reactions[todoID] = kept
+	return nil
+}
+
+// GetReactions returns the reactions to a todo in the order they were added
+func (r *MemoryRepository) GetReactions(todoID int64) ([]models.Reaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reactions := []models.Reaction{}
+	for _, emoji := range r.reactions[todoID] {
+		reactions = append(reactions, models.Reaction{Emoji: emoji, Count: 1, ReactedByMe: true})
+	}
+	return reactions, nil
+}
+
+// AddDependency makes a todo depend on blockingID, refusing a dependency
+// that would close a cycle
+func (r *MemoryRepository) AddDependency(todoID, blockingID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[todoID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := r.todos[blockingID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	if slices.Contains(r.dependencies[todoID], blockingID) {
+		return nil
+	}
+	if r.dependsOn(blockingID, todoID) {
+		return repository.ErrDependencyCycle
+	}
+
+	r.dependencies[todoID] = append(r.dependencies[todoID], blockingID)
+	return nil
+}
+
+// dependsOn reports whether todo id depends on target, directly or through
+// others. The caller must hold r.mu.
+func (r *MemoryRepository) dependsOn(id, target int64) bool {
+	seen := map[int64]bool{}
+	pending := []int64{id}
+	for len(pending) > 0 {
+		next := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if next == target {
+			return true
+		}
+		if !seen[next] {
+			seen[next] = true
+			pending = append(pending, r.dependencies[next]...)
+		}
+	}
+	return false
+}
+
+// blocked reports whether the todo with id depends on a todo that is
+// neither completed nor archived
+func (r *MemoryRepository) blocked(id int64) bool {
+	for _, blockingID := range r.dependencies[id] {
+		if blocking := r.todos[blockingID]; !blocking.Completed && blocking.ArchivedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveDependency stops a todo depending on blockingID
+func (r *MemoryRepository) RemoveDependency(todoID, blockingID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[todoID]; !ok {
+		return repository.ErrNotFound
+	}
+
+	r.dependencies[todoID] = slices.DeleteFunc(r.dependencies[todoID], func(id int64) bool { return id == blockingID })
+	return nil
+}
+
+// GetBlockers returns the unarchived todos a todo depends on in the order
+// the dependencies were added
+func (r *MemoryRepository) GetBlockers(todoID int64) ([]models.Blocker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blockers := []models.Blocker{}
+	for _, id := range r.dependencies[todoID] {
+		todo := r.todos[id]
+		if todo.ArchivedAt != nil {
+			continue
+		}
+		blockers = append(blockers, models.Blocker{ID: todo.ID, Title: todo.Title, Completed: todo.Completed})
+	}
+	return blockers, nil
+}
+
+// forget drops the reactions and dependencies of a todo that was removed,
+// as the database cascades them. The caller must hold r.mu.
+func (r *MemoryRepository) forget(id int64) {
+	delete(r.reactions, id)
+	delete(r.dependencies, id)
+	for todoID, blockers := range r.dependencies {
+		r.dependencies[todoID] = slices.DeleteFunc(blockers, func(blockingID int64) bool { return blockingID == id })
+	}
+}
+
+// Snooze pushes a todo's due date back by days and re-arms its reminder
+func (r *MemoryRepository) Snooze(id int64, days int) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	dueAt := time.Now().UTC()
+	if todo.DueAt != nil {
+		dueAt = *todo.DueAt
+	}
+	dueAt = dueAt.AddDate(0, 0, days)
+
+	before := copyTodo(todo)
+	todo.DueAt = &dueAt
+	delete(r.notified, id)
+	delete(r.reminded, id)
+	todo.SnoozeCount++
+	todo.Flagged = todo.SnoozeCount >= models.SnoozeFlagThreshold
+	todo.UpdatedAt = time.Now()
+	r.logChanges(before, todo)
+
+	return copyTodo(todo), nil
+}
+
+// ShiftDueDates moves the due date of every todo in ids by days and returns
+// the IDs it shifted and the IDs of todos without a due date
+func (r *MemoryRepository) ShiftDueDates(ids []int64, days int) ([]int64, []int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shifted, skipped []int64
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		todo, ok := r.todos[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if todo.DueAt == nil {
+			skipped = append(skipped, id)
+			continue
+		}
+
+		before := copyTodo(todo)
+		dueAt := todo.DueAt.AddDate(0, 0, days)
+		todo.DueAt = &dueAt
+		delete(r.notified, id)
+		delete(r.reminded, id)
+		todo.UpdatedAt = time.Now()
+		r.logChanges(before, todo)
+		shifted = append(shifted, id)
+	}
+
+	return shifted, skipped, nil
+}
+
+// Delete removes a todo and returns it
+func (r *MemoryRepository) Delete(id int64) (*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	r.logChanges(todo, nil)
+	delete(r.todos, id)
+	r.forget(id)
+	return copyTodo(todo), nil
+}
+
+// DeleteAllCompleted removes every completed, unarchived todo and returns how
+// many were deleted
+func (r *MemoryRepository) DeleteAllCompleted() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, todo := range r.todos {
+		if todo.Completed && todo.ArchivedAt == nil {
+			r.logChanges(todo, nil)
+			delete(r.todos, id)
+			r.forget(id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// VacuumCompleted moves todos completed more than olderThanDays days ago to
+// the archive
+func (r *MemoryRepository) VacuumCompleted(olderThanDays int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().AddDate(0, 0, -olderThanDays)
+
+	var archived int64
+	for id, todo := range r.todos {
+		if todo.Completed && todo.CompletedAt != nil && todo.CompletedAt.Before(cutoff) {
+			r.logChanges(todo, nil)
+			r.archived[id] = todo
+			delete(r.todos, id)
+			r.forget(id)
+			archived++
+		}
+	}
+
+	return archived, nil
+}
+
+// SetArchived stamps archived_at on the todos in ids, or clears it when
+// archived is false, and returns the IDs it changed and the IDs that were
+// already in the state they were asked to be in
+func (r *MemoryRepository) SetArchived(ids []int64, archived bool) ([]int64, []int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changed, unchanged []int64
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		todo, ok := r.todos[id]
+		if !ok {
+			continue
+		}
+		if (todo.ArchivedAt != nil) == archived {
+			unchanged = append(unchanged, id)
+			continue
+		}
+
+		before := copyTodo(todo)
+		now := time.Now()
+		if archived {
+			todo.ArchivedAt = &now
+		} else {
+			todo.ArchivedAt = nil
+		}
+		todo.UpdatedAt = now
+		r.logChanges(before, todo)
+		changed = append(changed, id)
+	}
+
+	return changed, unchanged, nil
+}
+
+// Archived returns the todo with the given ID if VacuumCompleted moved it to
+// the archive
+func (r *MemoryRepository) Archived(id int64) *models.Todo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if todo, ok := r.archived[id]; ok {
+		return copyTodo(todo)
+	}
+	return nil
+}
+
+// CreateCategory adds a new category
+func (r *MemoryRepository) CreateCategory(name string, parentID *int64) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category := &models.Category{ID: r.nextCategoryID, Name: name, ParentID: parentID, CreatedAt: time.Now()}
+	r.nextCategoryID++
+	r.categories[category.ID] = category
+
+	c := *category
+	return &c, nil
+}
+
+// GetAllCategories returns all categories ordered by name
+func (r *MemoryRepository) GetAllCategories() ([]*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	categories := make([]*models.Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		c := *category
+		categories = append(categories, &c)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID < categories[j].ID
+	})
+
+	return categories, nil
+}
+
+// GetCategoryByID returns the category with id, or
+// repository.ErrCategoryNotFound if there is none
+func (r *MemoryRepository) GetCategoryByID(id int64) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, repository.ErrCategoryNotFound
+	}
+
+	c := *category
+	return &c, nil
+}
+
+// GetCategoryTree returns every category with parents before their
+// children and siblings sorted by name
+func (r *MemoryRepository) GetCategoryTree() ([]*models.Category, error) {
+	all, _ := r.GetAllCategories()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	depth := make(map[int64]int, len(all))
+	for _, category := range all {
+		depth[category.ID] = r.depth(category.ID)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return depth[all[i].ID] < depth[all[j].ID]
+	})
+
+	return all, nil
+}
+
+// GetCategoryDepth returns how deeply a category is nested, or 0 if it
+// does not exist
+func (r *MemoryRepository) GetCategoryDepth(id int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.depth(id), nil
+}
+
+// depth counts the categories from id up to the root. The caller must hold r.mu.
+func (r *MemoryRepository) depth(id int64) int {
+	depth := 0
+	for category, ok := r.categories[id]; ok; category, ok = r.categories[id] {
+		depth++
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return depth
+}
+
+// GetCategoryAncestors returns the category and its ancestors, root first
+func (r *MemoryRepository) GetCategoryAncestors(id int64) ([]*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	categories := []*models.Category{}
+	for category, ok := r.categories[id]; ok && len(categories) < repository.MaxBreadcrumbDepth; category, ok = r.categories[id] {
+		c := *category
+		categories = append([]*models.Category{&c}, categories...)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+
+	return categories, nil
+}
+
+// DuplicateCategory copies a category and the unarchived todos directly in it
+func (r *MemoryRepository) DuplicateCategory(id int64) (*models.CategoryWithCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source, ok := r.categories[id]
+	if !ok {
+		return nil, repository.ErrCategoryNotFound
+	}
+
+	category := &models.Category{ID: r.nextCategoryID, Name: "Copy of " + source.Name, ParentID: source.ParentID, CreatedAt: time.Now()}
+	r.nextCategoryID++
+	r.categories[category.ID] = category
+
+	var sources []*models.Todo
+	for _, todo := range r.todos {
+		if todo.CategoryID != nil && *todo.CategoryID == id && todo.ArchivedAt == nil {
+			sources = append(sources, todo)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].ID < sources[j].ID })
+
+	for _, todo := range sources {
+		copied := r.insert(&models.CreateTodoRequest{Title: todo.Title, Description: todo.Description, Priority: todo.Priority})
+		categoryID := category.ID
+		copied.CategoryID = &categoryID
+		copied.Tags = append([]string{}, todo.Tags...)
+	}
+
+	return &models.CategoryWithCount{Category: *category, TodoCount: int64(len(sources))}, nil
+}
+
+// ConvertToCategory archives a todo and creates a category named after it
+// under the todo's own category, unless that would nest the category
+// deeper than models.MaxCategoryDepth
+func (r *MemoryRepository) ConvertToCategory(id int64) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if todo.CategoryID != nil && r.depth(*todo.CategoryID) >= models.MaxCategoryDepth {
+		return nil, repository.ErrCategoryTooDeep
+	}
+	before := copyTodo(todo)
+	now := time.Now()
+	todo.ArchivedAt = &now
+	todo.UpdatedAt = now
+	r.logChanges(before, todo)
+
+	category := &models.Category{ID: r.nextCategoryID, Name: todo.Title, ParentID: todo.CategoryID, CreatedAt: time.Now()}
+	r.nextCategoryID++
+	r.categories[category.ID] = category
+
+	c := *category
+	return &c, nil
+}
+
+// MoveTodos sets the category of every unarchived todo in ids and returns how
+// many were moved
+func (r *MemoryRepository) MoveTodos(ids []int64, categoryID *int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Like id = ANY($2), a repeated ID only counts once
+	seen := make(map[int64]bool, len(ids))
+
+	var moved int64
+	for _, id := range ids {
+		todo, ok := r.todos[id]
+		if !ok || todo.ArchivedAt != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		before := copyTodo(todo)
+		todo.CategoryID = nil
+		if categoryID != nil {
+			c := *categoryID
+			todo.CategoryID = &c
+		}
+		todo.UpdatedAt = time.Now()
+		r.logChanges(before, todo)
+		moved++
+	}
+
+	return moved, nil
+}
+
+// CompleteAllInCategory marks every incomplete, unarchived todo directly in
+// categoryID as completed, unless it is blocked by an incomplete todo. It
+// returns the IDs it completed and the IDs of the blocked todos, in ID order.
+func (r *MemoryRepository) CompleteAllInCategory(categoryID int64) ([]int64, []int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var open []*models.Todo
+	for _, todo := range r.todos {
+		if !todo.Completed && todo.ArchivedAt == nil && todo.CategoryID != nil && *todo.CategoryID == categoryID {
+			open = append(open, todo)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].ID < open[j].ID })
+
+	// Decide from the state before the update, as a single UPDATE would
+	var completed, blocked []int64
+	for _, todo := range open {
+		if r.blocked(todo.ID) {
+			blocked = append(blocked, todo.ID)
+		} else {
+			completed = append(completed, todo.ID)
+		}
+	}
+
+	for _, id := range completed {
+		todo := r.todos[id]
+		before := copyTodo(todo)
+		r.setCompleted(todo, true)
+		todo.UpdatedAt = time.Now()
+		r.logChanges(before, todo)
+	}
+
+	return completed, blocked, nil
+}
+
+// Reorder sets the manual position of every todo in order, or of none if one
+// of them does not exist
+func (r *MemoryRepository) Reorder(order []models.TodoPosition) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range order {
+		if _, ok := r.todos[p.ID]; !ok {
+			return 0, repository.ErrNotFound
+		}
+	}
+
+	for _, p := range order {
+		todo := r.todos[p.ID]
+		position := p.Position
+		todo.Position = &position
+		todo.UpdatedAt = time.Now()
+	}
+
+	return int64(len(order)), nil
+}
+
+// GetOverdueUnnotified returns incomplete todos past their due date that
+// have not been marked notified, oldest due date first
+func (r *MemoryRepository) GetOverdueUnnotified() ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	todos := []*models.Todo{}
+	for _, todo := range r.todos {
+		if todo.DueAt != nil && todo.DueAt.Before(now) && !todo.Completed && todo.ArchivedAt == nil && !r.notified[todo.ID] {
+			todos = append(todos, copyTodo(todo))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		return todos[i].DueAt.Before(*todos[j].DueAt)
+	})
+
+	return todos, nil
+}
+
+// MarkOverdueNotified records that the overdue reminder for each todo in ids
+// has been sent
+func (r *MemoryRepository) MarkOverdueNotified(ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := r.todos[id]; ok {
+			r.notified[id] = true
+		}
+	}
+
+	return nil
+}
+
+// GetDueWithinWindow claims the incomplete todos due between from and to
+// whose due-soon reminder has not been claimed, soonest first
+func (r *MemoryRepository) GetDueWithinWindow(from, to time.Time) ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := []*models.Todo{}
+	for _, todo := range r.todos {
+		if todo.DueAt == nil || todo.Completed || todo.ArchivedAt != nil || r.reminded[todo.ID] {
+			continue
+		}
+		if todo.DueAt.Before(from) || todo.DueAt.After(to) {
+			continue
+		}
+		r.reminded[todo.ID] = true
+		todos = append(todos, copyTodo(todo))
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DueAt.Equal(*todos[j].DueAt) {
+			return todos[i].DueAt.Before(*todos[j].DueAt)
+		}
+		return todos[i].ID < todos[j].ID
+	})
+
+	return todos, nil
+}
+
+// ReleaseReminders clears the due-soon reminder claims on the todos in ids
+func (r *MemoryRepository) ReleaseReminders(ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		delete(r.reminded, id)
+	}
+
+	return nil
+}
+
+// GetCompletedBetween returns the unarchived todos completed in [from, to), in
+// the order they were completed
+func (r *MemoryRepository) GetCompletedBetween(from, to time.Time) ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := []*models.Todo{}
+	for _, todo := range r.todos {
+		if todo.Completed && todo.ArchivedAt == nil && todo.CompletedAt != nil && !todo.CompletedAt.Before(from) && todo.CompletedAt.Before(to) {
+			todos = append(todos, copyTodo(todo))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].CompletedAt.Equal(*todos[j].CompletedAt) {
+			return todos[i].CompletedAt.Before(*todos[j].CompletedAt)
+		}
+		return todos[i].ID < todos[j].ID
+	})
+
+	return todos, nil
+}
+
+// GetDueBetween returns the incomplete todos due in [from, to), soonest first
+func (r *MemoryRepository) GetDueBetween(from, to time.Time) ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := []*models.Todo{}
+	for _, todo := range r.todos {
+		if !todo.Completed && todo.ArchivedAt == nil && todo.DueAt != nil && !todo.DueAt.Before(from) && todo.DueAt.Before(to) {
+			todos = append(todos, copyTodo(todo))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if !todos[i].DueAt.Equal(*todos[j].DueAt) {
+			return todos[i].DueAt.Before(*todos[j].DueAt)
+		}
+		return todos[i].ID < todos[j].ID
+	})
+
+	return todos, nil
+}
+
+// GetTimezone returns the stored timezone
+func (r *MemoryRepository) GetTimezone() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.timezone, nil
+}
+
+// SetTimezone stores the timezone
+func (r *MemoryRepository) SetTimezone(tz string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timezone = tz
+	return nil
+}
+
+// GetPreferences returns the stored preferences, with the stored timezone
+func (r *MemoryRepository) GetPreferences() (*models.Preferences, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prefs := r.preferences
+	prefs.DefaultFilter = maps.Clone(prefs.DefaultFilter)
+	prefs.Timezone = r.timezone
+	return &prefs, nil
+}
+
+// SetPreferences stores the preferences and their timezone
+func (r *MemoryRepository) SetPreferences(prefs *models.Preferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.preferences = *prefs
+	r.preferences.DefaultFilter = maps.Clone(prefs.DefaultFilter)
+	r.timezone = prefs.Timezone
+	return nil
+}
+
+// GetCompletionTrend counts the todos created and completed in each period
+// covering the last days days, oldest first
+func (r *MemoryRepository) GetCompletionTrend(period string, days int) ([]*models.TrendPoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	first := truncatePeriod(now.AddDate(0, 0, -(days-1)), period)
+	last := truncatePeriod(now, period)
+
+	var points []*models.TrendPoint
+	index := make(map[string]*models.TrendPoint)
+	for start := first; !start.After(last); start = nextPeriod(start, period) {
+		point := &models.TrendPoint{Date: start.Format("2006-01-02")}
+		points = append(points, point)
+		index[point.Date] = point
+	}
+
+	for _, todo := range r.todos {
+		if point, ok := index[truncatePeriod(todo.CreatedAt, period).Format("2006-01-02")]; ok {
+			point.Created++
+		}
+		if todo.CompletedAt != nil {
+			if point, ok := index[truncatePeriod(*todo.CompletedAt, period).Format("2006-01-02")]; ok {
+				point.Completed++
+			}
+		}
+	}
+
+	return points, nil
+}
+
+// GetCompletionStreak finds the runs of consecutive days, in timezone, on
+// which at least one todo was completed
+func (r *MemoryRepository) GetCompletionStreak(timezone string) (*models.Streak, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]bool)
+	for _, todo := range r.todos {
+		if todo.CompletedAt != nil {
+			days[todo.CompletedAt.In(loc).Format("2006-01-02")] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(days))
+	for day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Strings(sorted)
+
+	streak := &models.Streak{}
+	run := 0
+	var prev time.Time
+	for _, raw := range sorted {
+		day, _ := time.Parse("2006-01-02", raw)
+		if run > 0 && day.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = day
+		streak.Longest = max(streak.Longest, run)
+	}
+
+	if len(sorted) > 0 {
+		last := sorted[len(sorted)-1]
+		streak.LastCompletionDate = &last
+
+		yesterday := time.Now().In(loc).AddDate(0, 0, -1).Format("2006-01-02")
+		if last >= yesterday {
+			streak.Current = run
+		}
+	}
+
+	return streak, nil
+}
+
+// CountByPriority counts the incomplete, unarchived todos at each priority
+func (r *MemoryRepository) CountByPriority() (map[int]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[int]int64)
+	for _, todo := range r.todos {
+		if !todo.Completed && todo.ArchivedAt == nil {
+			counts[todo.Priority]++
+		}
+	}
+
+	return counts, nil
+}
+
+// CountByCompletion counts the completed and pending unarchived todos
+func (r *MemoryRepository) CountByCompletion() (*models.TodoCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count models.TodoCount
+	for _, todo := range r.todos {
+		if todo.ArchivedAt != nil {
+			continue
+		}
+		if todo.Completed {
+			count.Completed++
+		} else {
+			count.Pending++
+		}
+	}
+	count.Total = count.Completed + count.Pending
+
+	return &count, nil
+}
+
+// truncatePeriod returns the start of the day or ISO week containing t, like
+// Postgres date_trunc
+func truncatePeriod(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == models.PeriodWeek {
+		offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// nextPeriod returns the start of the period after start
+func nextPeriod(start time.Time, period string) time.Time {
+	if period == models.PeriodWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// ListSavedSearches returns all saved searches ordered by name
+func (r *MemoryRepository) ListSavedSearches() ([]*models.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	searches := make([]*models.SavedSearch, 0, len(r.savedSearches))
+	for _, search := range r.savedSearches {
+		s := *search
+		searches = append(searches, &s)
+	}
+	sort.Slice(searches, func(i, j int) bool {
+		return searches[i].Name < searches[j].Name
+	})
+
+	return searches, nil
+}
+
+// GetSavedSearch retrieves a saved search by name
+func (r *MemoryRepository) GetSavedSearch(name string) (*models.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	search, ok := r.savedSearches[name]
+	if !ok {
+		return nil, repository.ErrSavedSearchNotFound
+	}
+
+	s := *search
+	return &s, nil
+}
+
+// CreateSavedSearch stores params under name
+func (r *MemoryRepository) CreateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.savedSearches[name]; ok {
+		return nil, repository.ErrSavedSearchExists
+	}
+
+	now := time.Now()
+	search := &models.SavedSearch{
+		ID:        r.nextSearchID,
+		Name:      name,
+		Params:    params,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.nextSearchID++
+	r.savedSearches[name] = search
+
+	s := *search
+	return &s, nil
+}
+
+// UpdateSavedSearch replaces the params of the saved search called name
+func (r *MemoryRepository) UpdateSavedSearch(name string, params models.SearchParams) (*models.SavedSearch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	search, ok := r.savedSearches[name]
+	if !ok {
+		return nil, repository.ErrSavedSearchNotFound
+	}
+
+	search.Params = params
+	search.UpdatedAt = time.Now()
+
+	s := *search
+	return &s, nil
+}
+
+// DeleteSavedSearch removes the saved search called name
+func (r *MemoryRepository) DeleteSavedSearch(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.savedSearches[name]; !ok {
+		return repository.ErrSavedSearchNotFound
+	}
+
+	delete(r.savedSearches, name)
+	return nil
+}
+
+// GetAllByCategory returns every unarchived todo by category,
+// uncategorized last, then by position and creation time
+func (r *MemoryRepository) GetAllByCategory() ([]*models.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos := make([]*models.Todo, 0, len(r.todos))
+	for _, todo := range r.todos {
+		if todo.ArchivedAt == nil {
+			todos = append(todos, copyTodo(todo))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		a, b := todos[i], todos[j]
+		if (a.CategoryID == nil) != (b.CategoryID == nil) {
+			return b.CategoryID == nil
+		}
+		if a.CategoryID != nil && *a.CategoryID != *b.CategoryID {
+			return *a.CategoryID < *b.CategoryID
+		}
+		if (a.Position == nil) != (b.Position == nil) {
+			return b.Position == nil
+		}
+		if a.Position != nil && *a.Position != *b.Position {
+			return *a.Position < *b.Position
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	return todos, nil
+}
+
+// GetTimeline returns the recorded changes to a todo, oldest first
+func (r *MemoryRepository) GetTimeline(todoID int64) ([]*models.TimelineEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []*models.TimelineEvent{}
+	for _, a := range r.activity {
+		if a.todoID == todoID {
+			e := a.event
+			events = append(events, &e)
+		}
+	}
+
+	return events, nil
+}
+
+// GetActivity returns up to limit recorded changes across all todos,
+// newest first, below ID before unless it is 0. An entry's ID is its
+// position in the log, counting from 1.
+func (r *MemoryRepository) GetActivity(before int64, limit int) ([]*models.ActivityEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := []*models.ActivityEntry{}
+	for i := len(r.activity) - 1; i >= 0 && len(entries) < limit; i-- {
+		id := int64(i + 1)
+		if before != 0 && id >= before {
+			continue
+		}
+
+		a := r.activity[i]
+		entry := &models.ActivityEntry{ID: id, TodoID: a.todoID, TimelineEvent: a.event}
+		if todo, ok := r.todos[a.todoID]; ok {
+			entry.TodoTitle = &todo.Title
+		} else if todo, ok := r.archived[a.todoID]; ok {
+			entry.TodoTitle = &todo.Title
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetDeletedAt returns when the todo with the given ID was deleted, or nil if
+// it never was or was only moved to the archive by VacuumCompleted
+func (r *MemoryRepository) GetDeletedAt(id int64) (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.archived[id]; ok {
+		return nil, nil
+	}
+
+	for i := len(r.activity) - 1; i >= 0; i-- {
+		a := r.activity[i]
+		if a.todoID == id && a.event.Event == models.EventDeleted {
+			deletedAt := a.event.Timestamp
+			return &deletedAt, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// LastModified returns the later of the newest UpdatedAt and the newest
+// deletion in the activity log, or the zero time if there is neither
+func (r *MemoryRepository) LastModified() (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest time.Time
+	for _, todo := range r.todos {
+		if todo.UpdatedAt.After(latest) {
+			latest = todo.UpdatedAt
+		}
+	}
+	for _, a := range r.activity {
+		if a.event.Event == models.EventDeleted && a.event.Timestamp.After(latest) {
+			latest = a.event.Timestamp
+		}
+	}
+
+	return latest, nil
+}
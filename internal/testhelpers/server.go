@@ -0,0 +1,102 @@
+package testhelpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/repository"
+	"github.com/yourusername/todo-api/internal/router"
+	"github.com/yourusername/todo-api/internal/service"
+	"github.com/yourusername/todo-api/internal/watch"
+)
+
+// NewTestServer starts an httptest.Server serving the application routes
+// backed by repo. The server is closed when the test finishes.
+func NewTestServer(t *testing.T, repo repository.TodoRepositoryInterface) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(router.NewRouter(service.NewTodoService(repo), watch.NewBroker()))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// MustGet sends a GET request to path on srv
+func MustGet(t *testing.T, srv *httptest.Server, path string) *http.Response {
+	t.Helper()
+	return MustDo(t, srv, http.MethodGet, path, nil)
+}
+
+// MustPost sends a POST request to path on srv with body encoded as JSON
+func MustPost(t *testing.T, srv *httptest.Server, path string, body interface{}) *http.Response {
+	t.Helper()
+	return MustDo(t, srv, http.MethodPost, path, body)
+}
+
+// MustPut sends a PUT request to path on srv with body encoded as JSON
+func MustPut(t *testing.T, srv *httptest.Server, path string, body interface{}) *http.Response {
+	t.Helper()
+	return MustDo(t, srv, http.MethodPut, path, body)
+}
+
+// MustDelete sends a DELETE request to path on srv
+func MustDelete(t *testing.T, srv *httptest.Server, path string) *http.Response {
+	t.Helper()
+	return MustDo(t, srv, http.MethodDelete, path, nil)
+}
+
+// MustDo sends a request to path on srv, encoding body as JSON unless it is
+// nil. The test fails if the request cannot be sent. The response body is
+// closed when the test finishes.
+func MustDo(t *testing.T, srv *httptest.Server, method, path string, body interface{}) *http.Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, srv.URL+path, reader)
+	if err != nil {
+		t.Fatalf("Failed to build %s %s: %v", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+// DecodeJSON decodes the JSON body of resp into v, failing the test on error
+func DecodeJSON(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+}
+
+// AssertStatus fails the test if resp does not have the wanted status code
+func AssertStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+
+	if resp.StatusCode != want {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s %s: status = %d, want %d (body: %s)",
+			resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, want, bytes.TrimSpace(body))
+	}
+}
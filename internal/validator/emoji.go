@@ -0,0 +1,95 @@
+package validator
+
+// Code points that join or modify the emoji before them
+const (
+	zeroWidthJoiner   = '\u200D'
+	variationSelector = '\uFE0F'
+	combiningKeycap   = '\u20E3'
+	cancelTag         = '\U000E007F'
+)
+
+// isSingleEmoji reports whether s is exactly one emoji as people type it:
+// a pictograph with an optional presentation selector and skin tone, a
+// zero-width-joiner sequence of those such as the woman technologist, a
+// flag or a keycap.
+func isSingleEmoji(s string) bool {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return false
+	}
+
+	// Flags are a pair of regional indicators
+	if isRegionalIndicator(runes[0]) {
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	}
+
+	// Keycaps: a digit, # or *, then the combining keycap
+	if isKeycapBase(runes[0]) {
+		rest := runes[1:]
+		if len(rest) > 0 && rest[0] == variationSelector {
+			rest = rest[1:]
+		}
+		return len(rest) == 1 && rest[0] == combiningKeycap
+	}
+
+	for i := 0; i < len(runes); {
+		if !isPictograph(runes[i]) {
+			return false
+		}
+		i++
+
+		if i < len(runes) && runes[i] == variationSelector {
+			i++
+		}
+		if i < len(runes) && isSkinTone(runes[i]) {
+			i++
+		}
+
+		// Subdivision flags such as Scotland's spell their region in tag characters
+		if i < len(runes) && isTag(runes[i]) {
+			for i < len(runes) && isTag(runes[i]) {
+				i++
+			}
+			if i >= len(runes) || runes[i] != cancelTag {
+				return false
+			}
+			i++
+		}
+
+		if i == len(runes) {
+			return true
+		}
+		if runes[i] != zeroWidthJoiner || i+1 == len(runes) {
+			return false
+		}
+		i++
+	}
+
+	return false
+}
+
+// isPictograph reports whether r is drawn as an emoji on its own
+func isPictograph(r rune) bool {
+	switch {
+	case isRegionalIndicator(r), isSkinTone(r):
+		return false
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2300 && r <= 0x23FF, r >= 0x2600 && r <= 0x27BF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	}
+
+	switch r {
+	case 0x00A9, 0x00AE, 0x203C, 0x2049, 0x2122, 0x2139, 0x3030, 0x303D, 0x3297, 0x3299:
+		return true
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool { return r >= 0x1F1E6 && r <= 0x1F1FF }
+
+func isSkinTone(r rune) bool { return r >= 0x1F3FB && r <= 0x1F3FF }
+
+func isTag(r rune) bool { return r >= 0xE0020 && r <= 0xE007E }
+
+func isKeycapBase(r rune) bool { return r == '#' || r == '*' || (r >= '0' && r <= '9') }
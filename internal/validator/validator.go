@@ -0,0 +1,79 @@
+// Package validator checks request structs against the rules in their
+// validate struct tags, using github.com/go-playground/validator.
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	playground "github.com/go-playground/validator/v10"
+	"github.com/yourusername/todo-api/internal/i18n"
+)
+
+// validate caches struct metadata, so it is shared by every call
+var validate = newValidate()
+
+func newValidate() *playground.Validate {
+	v := playground.New(playground.WithRequiredStructEnabled())
+
+	// Report fields by the name clients send them under
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("emoji", func(fl playground.FieldLevel) bool {
+		return isSingleEmoji(fl.Field().String())
+	})
+
+	return v
+}
+
+// ValidationError describes one field that broke a rule
+type ValidationError struct {
+	// Field is the JSON name of the field
+	Field   string `json:"field"`
+	Message string `json:"message"`
+
+	// Key identifies the message in the i18n catalogue, as
+	// "err.<field>.<rule>", and Args are its arguments
+	Key  string        `json:"-"`
+	Args []interface{} `json:"-"`
+}
+
+// Validate checks v, a struct or pointer to one, against its validate tags.
+// It returns one error per failing field, or nil if v is valid.
+func Validate(v interface{}) []ValidationError {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs playground.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		// Only reachable if v is not a struct, which is a programming error
+		panic(err)
+	}
+
+	errs := make([]ValidationError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		var args []interface{}
+		if fe.Param() != "" {
+			args = append(args, fe.Param())
+		}
+
+		key := "err." + fe.Field() + "." + fe.Tag()
+		errs = append(errs, ValidationError{
+			Field:   fe.Field(),
+			Message: i18n.Message(i18n.DefaultLanguage, key, args...),
+			Key:     key,
+			Args:    args,
+		})
+	}
+
+	return errs
+}
@@ -0,0 +1,81 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/todo-api/internal/models"
+)
+
+func TestValidateAcceptsValidRequest(t *testing.T) {
+	req := &models.CreateTodoRequest{Title: "Buy milk", Priority: models.PriorityHigh}
+
+	if errs := Validate(req); errs != nil {
+		t.Errorf("Validate = %v, want no errors", errs)
+	}
+}
+
+func TestValidateReportsEveryFailingField(t *testing.T) {
+	req := &models.CreateTodoRequest{
+		Title:       strings.Repeat("a", 256),
+		Description: strings.Repeat("a", 2001),
+		Priority:    4,
+	}
+
+	errs := Validate(req)
+
+	want := []ValidationError{
+		{Field: "title", Message: "Title must be at most 255 characters"},
+		{Field: "description", Message: "Description must be at most 2000 characters"},
+		{Field: "priority", Message: "Priority must be at most 3"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i := range want {
+		if errs[i].Field != want[i].Field || errs[i].Message != want[i].Message {
+			t.Errorf("errs[%d] = {%q, %q}, want {%q, %q}", i, errs[i].Field, errs[i].Message, want[i].Field, want[i].Message)
+		}
+	}
+}
+
+func TestValidateSkipsUnsetUpdateFields(t *testing.T) {
+	if errs := Validate(&models.UpdateTodoRequest{}); errs != nil {
+		t.Errorf("Validate = %v, want no errors", errs)
+	}
+
+	title := ""
+	errs := Validate(&models.UpdateTodoRequest{Title: &title})
+	if len(errs) != 1 || errs[0].Key != "err.title.min" {
+		t.Errorf("Validate = %v, want a single err.title.min error", errs)
+	}
+}
+
+func TestIsSingleEmoji(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"\U0001F44D", true},                 // thumbs up
+		{"\U0001F44D\U0001F3FD", true},       // with a skin tone
+		{"\u2764\uFE0F", true},               // red heart with its presentation selector
+		{"\U0001F469\u200D\U0001F4BB", true}, // woman technologist
+		{"\U0001F1F3\U0001F1F1", true},       // flag
+		{"1\uFE0F\u20E3", true},              // keycap
+		{"\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", true}, // Scotland
+		{"", false},
+		{"a", false},
+		{"1", false},
+		{"\U0001F44D\U0001F44D", false},
+		{"\U0001F44D ", false},
+		{"\U0001F469\u200D", false},
+		{"\U0001F1F3", false},
+		{"\U0001F3FD", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSingleEmoji(tt.s); got != tt.want {
+			t.Errorf("isSingleEmoji(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Channel is the Postgres NOTIFY channel that carries changed todo IDs
+const Channel = "todo_changes"
+
+// Broker fans out todo change notifications to subscribers watching a
+// particular todo
+type Broker struct {
+	mu   sync.Mutex
+	subs map[int64]map[chan struct{}]struct{}
+}
+
+// NewBroker creates a new Broker
+func NewBroker() *Broker {
+	return &Broker{
+		subs: make(map[int64]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe returns a channel that receives a value the next time the todo
+// with the given ID changes, and a function that must be called to cancel
+// the subscription
+func (b *Broker) Subscribe(id int64) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	b.mu.Lock()
+	if b.subs[id] == nil {
+		b.subs[id] = make(map[chan struct{}]struct{})
+	}
+	b.subs[id][ch] = struct{}{}
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		delete(b.subs[id], ch)
+		if len(b.subs[id]) == 0 {
+			delete(b.subs, id)
+		}
+		b.mu.Unlock()
+	}
+
+	return ch, cancel
+}
+
+// Publish notifies every subscriber watching the todo with the given ID
+func (b *Broker) Publish(id int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[id] {
+		select {
+		case ch <- struct{}{}:
+		default: // Already notified
+		}
+	}
+}
+
+// publishPayload publishes a notification payload holding a todo ID
+func (b *Broker) publishPayload(payload string) {
+	id, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		return
+	}
+	b.Publish(id)
+}
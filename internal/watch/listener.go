@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lib/pq"
+)
+
+// listenRetryDelay is the wait before re-establishing a dropped listener
+const listenRetryDelay = 5 * time.Second
+
+// ListenPQ listens for todo change notifications on a dedicated lib/pq
+// connection and publishes them to broker until ctx is done
+func ListenPQ(ctx context.Context, connString string, broker *Broker) error {
+	listener := pq.NewListener(connString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Todo change listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(Channel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-listener.Notify:
+			// A nil notification signals a reconnect
+			if n != nil {
+				broker.publishPayload(n.Extra)
+			}
+		case <-time.After(90 * time.Second):
+			// Check the connection is still alive
+			go listener.Ping()
+		}
+	}
+}
+
+// ListenPgx listens for todo change notifications on a connection held from
+// pool and publishes them to broker until ctx is done
+func ListenPgx(ctx context.Context, pool *pgxpool.Pool, broker *Broker) error {
+	for {
+		err := listenPgxConn(ctx, pool, broker)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		log.Printf("Todo change listener: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// listenPgxConn runs LISTEN on a single pooled connection until it fails
+func listenPgxConn(ctx context.Context, pool *pgxpool.Pool, broker *Broker) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		broker.publishPayload(n.Payload)
+	}
+}
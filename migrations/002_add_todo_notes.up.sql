@@ -0,0 +1,3 @@
+-- Append-only log of timestamped notes, stored as a JSON array of
+-- {"body": ..., "created_at": ...} objects
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS notes JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+-- Priority: 1 = low, 2 = medium, 3 = high
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 2;
+ALTER TABLE todos ADD CONSTRAINT todos_priority_check CHECK (priority BETWEEN 1 AND 3);
@@ -0,0 +1 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- Identifier assigned by a sync client, used to upsert todos
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+ALTER TABLE todos ADD CONSTRAINT todos_external_id_key UNIQUE (external_id);
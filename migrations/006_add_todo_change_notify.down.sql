@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS todos_notify_change ON todos;
+DROP FUNCTION IF EXISTS notify_todo_change();
@@ -0,0 +1,13 @@
+-- Publish the ID of every updated todo on the todo_changes channel
+CREATE OR REPLACE FUNCTION notify_todo_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('todo_changes', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todos_notify_change ON todos;
+CREATE TRIGGER todos_notify_change
+    AFTER UPDATE ON todos
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_todo_change();
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS todos_overdue_idx;
+ALTER TABLE todos DROP COLUMN IF EXISTS overdue_notified_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS due_at;
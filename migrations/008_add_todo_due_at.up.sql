@@ -0,0 +1,7 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_at TIMESTAMP;
+
+-- Set once the overdue reminder for the current due_at has been sent
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS overdue_notified_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS todos_overdue_idx ON todos (due_at)
+    WHERE completed = false AND overdue_notified_at IS NULL;
@@ -0,0 +1,14 @@
+-- Responses to mutating requests, replayed when a client retries with the
+-- same Idempotency-Key header. A row with response_status 0 is a
+-- reservation for a request still being handled.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    response_status INTEGER NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    response_body BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
@@ -0,0 +1,7 @@
+-- Settings for the API's single implicit user, kept in one row
+CREATE TABLE IF NOT EXISTS user_preferences (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC'
+);
+
+INSERT INTO user_preferences (id) VALUES (1) ON CONFLICT DO NOTHING;
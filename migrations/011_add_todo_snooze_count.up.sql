@@ -0,0 +1 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS snooze_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS todos_search_idx;
+DROP TABLE IF EXISTS todo_tags;
+DROP TABLE IF EXISTS tags;
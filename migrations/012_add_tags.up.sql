@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS todo_tags_tag_id_idx ON todo_tags (tag_id);
+
+-- Full-text search over title and description (?q=)
+CREATE INDEX IF NOT EXISTS todos_search_idx ON todos
+    USING GIN (to_tsvector('english', title || ' ' || COALESCE(description, '')));
@@ -0,0 +1,3 @@
+-- Nested categories, at most models.MaxCategoryDepth levels deep
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES categories(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
@@ -0,0 +1 @@
+DROP MATERIALIZED VIEW IF EXISTS todo_search_mv;
@@ -0,0 +1,8 @@
+-- Precomputed search documents, refreshed in the background after writes
+CREATE MATERIALIZED VIEW IF NOT EXISTS todo_search_mv AS
+    SELECT id, to_tsvector('english', title || ' ' || COALESCE(description, '')) AS document
+    FROM todos;
+
+-- REFRESH ... CONCURRENTLY requires a unique index
+CREATE UNIQUE INDEX IF NOT EXISTS todo_search_mv_id_idx ON todo_search_mv (id);
+CREATE INDEX IF NOT EXISTS todo_search_mv_document_idx ON todo_search_mv USING GIN (document);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS todos_set_timestamps ON todos;
+DROP FUNCTION IF EXISTS set_todo_timestamps();
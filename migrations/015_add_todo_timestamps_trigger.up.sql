@@ -0,0 +1,26 @@
+-- Stamp updated_at on every change, and completed_at when completed flips.
+-- Recording an overdue reminder alone is bookkeeping, not a change to the todo.
+CREATE OR REPLACE FUNCTION set_todo_timestamps() RETURNS trigger AS $$
+BEGIN
+    IF NEW.overdue_notified_at IS DISTINCT FROM OLD.overdue_notified_at
+        AND to_jsonb(NEW) - 'overdue_notified_at' = to_jsonb(OLD) - 'overdue_notified_at' THEN
+        RETURN NEW;
+    END IF;
+
+    NEW.updated_at := NOW();
+
+    IF NEW.completed AND NOT OLD.completed THEN
+        NEW.completed_at := NOW();
+    ELSIF NOT NEW.completed THEN
+        NEW.completed_at := NULL;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todos_set_timestamps ON todos;
+CREATE TRIGGER todos_set_timestamps
+    BEFORE UPDATE ON todos
+    FOR EACH ROW
+    EXECUTE FUNCTION set_todo_timestamps();
@@ -0,0 +1,2 @@
+-- Manual drag-and-drop order. Todos never reordered have no position.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS position INTEGER;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS todos_log_activity ON todos;
+DROP FUNCTION IF EXISTS log_todo_activity();
+DROP TABLE IF EXISTS activity_logs;
@@ -0,0 +1,82 @@
+-- History of changes to todos, written by a trigger so every write path is
+-- recorded. Rows are kept after their todo is deleted, so there is no
+-- foreign key.
+CREATE TABLE IF NOT EXISTS activity_logs (
+    id BIGSERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    -- {"from": ..., "to": ...} for events that change a value
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS activity_logs_todo_id_idx ON activity_logs (todo_id, id);
+
+CREATE OR REPLACE FUNCTION log_todo_activity() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO activity_logs (todo_id, event) VALUES (OLD.id, 'deleted');
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO activity_logs (todo_id, event) VALUES (NEW.id, 'created');
+        IF NEW.completed THEN
+            INSERT INTO activity_logs (todo_id, event) VALUES (NEW.id, 'completed');
+        END IF;
+        RETURN NULL;
+    END IF;
+
+    IF NEW.completed IS DISTINCT FROM OLD.completed THEN
+        INSERT INTO activity_logs (todo_id, event)
+        VALUES (NEW.id, CASE WHEN NEW.completed THEN 'completed' ELSE 'uncompleted' END);
+    END IF;
+
+    IF NEW.title IS DISTINCT FROM OLD.title THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'title_changed', jsonb_build_object('from', OLD.title, 'to', NEW.title));
+    END IF;
+
+    IF NEW.description IS DISTINCT FROM OLD.description THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'description_changed', jsonb_build_object('from', OLD.description, 'to', NEW.description));
+    END IF;
+
+    IF NEW.priority IS DISTINCT FROM OLD.priority THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'priority_changed', jsonb_build_object('from', OLD.priority, 'to', NEW.priority));
+    END IF;
+
+    IF NEW.due_at IS DISTINCT FROM OLD.due_at THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'due_changed', jsonb_build_object(
+            'from', OLD.due_at AT TIME ZONE 'UTC',
+            'to', NEW.due_at AT TIME ZONE 'UTC'));
+    END IF;
+
+    IF NEW.pinned IS DISTINCT FROM OLD.pinned THEN
+        INSERT INTO activity_logs (todo_id, event)
+        VALUES (NEW.id, CASE WHEN NEW.pinned THEN 'pinned' ELSE 'unpinned' END);
+    END IF;
+
+    IF NEW.category_id IS DISTINCT FROM OLD.category_id THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'category_changed', jsonb_build_object('from', OLD.category_id, 'to', NEW.category_id));
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todos_log_activity ON todos;
+CREATE TRIGGER todos_log_activity
+    AFTER INSERT OR UPDATE OR DELETE ON todos
+    FOR EACH ROW
+    EXECUTE FUNCTION log_todo_activity();
+
+-- Start the history of existing todos from what their row still shows
+INSERT INTO activity_logs (todo_id, event, created_at)
+SELECT id, 'created', created_at FROM todos;
+
+INSERT INTO activity_logs (todo_id, event, created_at)
+SELECT id, 'completed', completed_at FROM todos WHERE completed AND completed_at IS NOT NULL;
@@ -0,0 +1,21 @@
+CREATE OR REPLACE FUNCTION set_todo_timestamps() RETURNS trigger AS $$
+BEGIN
+    IF NEW.overdue_notified_at IS DISTINCT FROM OLD.overdue_notified_at
+        AND to_jsonb(NEW) - 'overdue_notified_at' = to_jsonb(OLD) - 'overdue_notified_at' THEN
+        RETURN NEW;
+    END IF;
+
+    NEW.updated_at := NOW();
+
+    IF NEW.completed AND NOT OLD.completed THEN
+        NEW.completed_at := NOW();
+    ELSIF NOT NEW.completed THEN
+        NEW.completed_at := NULL;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS todos_remind_idx;
+ALTER TABLE todos DROP COLUMN IF EXISTS reminded_at;
@@ -0,0 +1,28 @@
+-- Set once the due-soon reminder for the current due_at has been claimed
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS todos_remind_idx ON todos (due_at)
+    WHERE completed = false AND reminded_at IS NULL;
+
+-- Recording a reminder of either kind alone is bookkeeping, not a change to
+-- the todo
+CREATE OR REPLACE FUNCTION set_todo_timestamps() RETURNS trigger AS $$
+BEGIN
+    IF (NEW.overdue_notified_at IS DISTINCT FROM OLD.overdue_notified_at
+            OR NEW.reminded_at IS DISTINCT FROM OLD.reminded_at)
+        AND to_jsonb(NEW) - 'overdue_notified_at' - 'reminded_at'
+            = to_jsonb(OLD) - 'overdue_notified_at' - 'reminded_at' THEN
+        RETURN NEW;
+    END IF;
+
+    NEW.updated_at := NOW();
+
+    IF NEW.completed AND NOT OLD.completed THEN
+        NEW.completed_at := NOW();
+    ELSIF NOT NEW.completed THEN
+        NEW.completed_at := NULL;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
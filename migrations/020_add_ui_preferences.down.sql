@@ -0,0 +1,5 @@
+ALTER TABLE user_preferences
+    DROP COLUMN IF EXISTS theme,
+    DROP COLUMN IF EXISTS todos_per_page,
+    DROP COLUMN IF EXISTS default_filter,
+    DROP COLUMN IF EXISTS default_sort;
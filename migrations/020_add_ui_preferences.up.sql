@@ -0,0 +1,8 @@
+-- UI settings the frontend starts from, alongside the timezone
+ALTER TABLE user_preferences
+    ADD COLUMN IF NOT EXISTS default_sort VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS default_filter JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS todos_per_page INTEGER NOT NULL DEFAULT 50
+        CHECK (todos_per_page BETWEEN 10 AND 200),
+    ADD COLUMN IF NOT EXISTS theme VARCHAR(10) NOT NULL DEFAULT 'system'
+        CHECK (theme IN ('light', 'dark', 'system'));
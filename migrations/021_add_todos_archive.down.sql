@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS todos_vacuum_idx;
+DROP TABLE IF EXISTS todo_tags_archive;
+DROP TABLE IF EXISTS todos_archive;
@@ -0,0 +1,13 @@
+-- Old completed todos moved out of todos by POST /admin/vacuum. The columns
+-- match todos, so a column added to todos must be added here too.
+CREATE TABLE IF NOT EXISTS todos_archive (LIKE todos);
+ALTER TABLE todos_archive ADD PRIMARY KEY (id);
+
+-- Tag names of archived todos, kept by name since tags may be removed later
+CREATE TABLE IF NOT EXISTS todo_tags_archive (
+    todo_id INTEGER NOT NULL REFERENCES todos_archive(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    PRIMARY KEY (todo_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS todos_vacuum_idx ON todos (completed_at) WHERE completed;
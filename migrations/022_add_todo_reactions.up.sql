@@ -0,0 +1,7 @@
+-- Emoji reactions on todos, at most one of each emoji per todo
+CREATE TABLE IF NOT EXISTS todo_reactions (
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    emoji VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (todo_id, emoji)
+);
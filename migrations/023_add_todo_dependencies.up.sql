@@ -0,0 +1,10 @@
+-- Todos that must be completed before another todo can be
+CREATE TABLE IF NOT EXISTS todo_dependencies (
+    dependent_todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    blocking_todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (dependent_todo_id, blocking_todo_id),
+    CHECK (dependent_todo_id <> blocking_todo_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_dependencies_blocking ON todo_dependencies(blocking_todo_id);
@@ -0,0 +1,59 @@
+CREATE OR REPLACE FUNCTION log_todo_activity() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO activity_logs (todo_id, event) VALUES (OLD.id, 'deleted');
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO activity_logs (todo_id, event) VALUES (NEW.id, 'created');
+        IF NEW.completed THEN
+            INSERT INTO activity_logs (todo_id, event) VALUES (NEW.id, 'completed');
+        END IF;
+        RETURN NULL;
+    END IF;
+
+    IF NEW.completed IS DISTINCT FROM OLD.completed THEN
+        INSERT INTO activity_logs (todo_id, event)
+        VALUES (NEW.id, CASE WHEN NEW.completed THEN 'completed' ELSE 'uncompleted' END);
+    END IF;
+
+    IF NEW.title IS DISTINCT FROM OLD.title THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'title_changed', jsonb_build_object('from', OLD.title, 'to', NEW.title));
+    END IF;
+
+    IF NEW.description IS DISTINCT FROM OLD.description THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'description_changed', jsonb_build_object('from', OLD.description, 'to', NEW.description));
+    END IF;
+
+    IF NEW.priority IS DISTINCT FROM OLD.priority THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'priority_changed', jsonb_build_object('from', OLD.priority, 'to', NEW.priority));
+    END IF;
+
+    IF NEW.due_at IS DISTINCT FROM OLD.due_at THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'due_changed', jsonb_build_object(
+            'from', OLD.due_at AT TIME ZONE 'UTC',
+            'to', NEW.due_at AT TIME ZONE 'UTC'));
+    END IF;
+
+    IF NEW.pinned IS DISTINCT FROM OLD.pinned THEN
+        INSERT INTO activity_logs (todo_id, event)
+        VALUES (NEW.id, CASE WHEN NEW.pinned THEN 'pinned' ELSE 'unpinned' END);
+    END IF;
+
+    IF NEW.category_id IS DISTINCT FROM OLD.category_id THEN
+        INSERT INTO activity_logs (todo_id, event, details)
+        VALUES (NEW.id, 'category_changed', jsonb_build_object('from', OLD.category_id, 'to', NEW.category_id));
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS todos_unarchived_idx;
+ALTER TABLE todos_archive DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS archived_at;